@@ -1,220 +1,210 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/THEGunDevil/GoForBackend/internal/blob"
 	"github.com/THEGunDevil/GoForBackend/internal/config"
 	"github.com/THEGunDevil/GoForBackend/internal/db"
 	"github.com/THEGunDevil/GoForBackend/internal/handlers"
-	"github.com/THEGunDevil/GoForBackend/internal/middleware"
+	"github.com/THEGunDevil/GoForBackend/internal/logging"
+	"github.com/THEGunDevil/GoForBackend/internal/mailer"
+	"github.com/THEGunDevil/GoForBackend/internal/server"
 	"github.com/THEGunDevil/GoForBackend/internal/service"
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
+	"github.com/THEGunDevil/GoForBackend/internal/telemetry"
 	"github.com/joho/godotenv"
 )
 
 func main() {
-	// Load env — fail hard in development if .env is missing/corrupted
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, skipping...")
-	}
-	cloudName := os.Getenv("CLOUDINARY_CLOUD_NAME")
-	apiKey := os.Getenv("CLOUDINARY_API_KEY")
-	apiSecret := os.Getenv("CLOUDINARY_API_SECRET")
-
-	// Init Cloudinary
-	cldURL := fmt.Sprintf("cloudinary://%s:%s@%s", apiKey, apiSecret, cloudName)
-	service.InitCloudinary(cldURL)
-
-	// Load config & connect DB
-	cfg := config.LoadConfig()
-
-	db.Connect(cfg)
-	fmt.Println("DEBUG DBURL:", cfg.DBURL)
-
-	// fmt.Println("DEBUG LOCAL DB:", cfg.LOCALDBURL)
-	// db.LocalConnect(cfg)
-	defer db.Close()
-
-	r := gin.New() // instead of gin.Default() if you want full control
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"https://himel-s-library.vercel.app", "http://localhost:3000"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}))
-	r.Use(gin.Logger())
-	r.Use(gin.Recovery())
-	// r.Use(middleware.RateLimiter())
-
-	// Health check
-	r.GET("/", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	// .env is optional; real environment variables win over it
+	envErr := godotenv.Load()
+
+	// Load and validate the config: profile defaults, CONFIG_FILE, then env
+	cfg, err := config.Load()
+	if err != nil {
+		// Before the logger exists; one problem per line reads best
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// Structured logs; secrets are redacted by the handler
+	logger, err := logging.New(os.Stdout, logging.Config{Level: cfg.Observability.LogLevel, Format: cfg.Observability.LogFormat})
+	if err != nil {
+		fatal("logger setup failed", err)
+	}
+	slog.SetDefault(logger)
+	if envErr != nil {
+		logger.Info("no .env file found, skipping")
+	}
+	logger.Info("config loaded", "env", cfg.Env)
+
+	if cfg.Cloudinary.CloudName != "" {
+		service.InitCloudinary(cfg.Cloudinary.URL())
+	} else {
+		logger.Warn("cloudinary not configured; image uploads will fail")
+	}
+
+	service.JwtAccessSecret = []byte(cfg.Auth.JWTAccessSecret)
+	service.JwtRefreshSecret = []byte(cfg.Auth.JWTRefreshSecret)
+	handlers.SecureCookies = cfg.Server.SecureCookies
+
+	// Tracing first so the connection check is traced too
+	shutdownTracing, err := telemetry.InitTracing(context.Background(), telemetry.TracingConfig{
+		Exporter:    cfg.Observability.TracingExporter,
+		ServiceName: "book-library-api",
+		SampleRatio: cfg.Observability.TracingSampleRatio,
+	})
+	if err != nil {
+		fatal("tracing setup failed", err)
+	}
+	defer shutdownTracing(context.Background())
+	service.ConfigureStripe(cfg.Stripe, &http.Client{Timeout: 80 * time.Second, Transport: telemetry.Transport("stripe", nil)})
+	if !cfg.Stripe.Enabled() {
+		logger.Warn("stripe not configured; checkout is disabled")
+	} else if cfg.Stripe.APIURL != "" {
+		logger.Warn("stripe calls go to a stand-in, not stripe", "url", cfg.Stripe.APIURL)
+	}
+
+	pool, err := db.Connect(context.Background(), cfg.DB.URL)
+	if err != nil {
+		fatal("database connection failed", err)
+	}
+	if err := telemetry.RegisterPool(pool.Stat); err != nil {
+		fatal("pool metrics setup failed", err)
+	}
+
+	// Refuse to serve against a schema older than the code
+	if cfg.DB.AutoMigrate {
+		results, err := db.Migrate(context.Background(), pool)
+		if err != nil {
+			fatal("auto-migrate failed", err)
+		}
+		for _, r := range results {
+			logger.Info("migration applied", "file", r.Source.Path, "duration", r.Duration)
+		}
+	}
+	version, latest, err := db.CheckSchema(context.Background(), pool)
+	if err != nil {
+		fatal("database schema check failed; run go run ./cmd/migrate up (or baseline for a hand-made schema)", err)
+	}
+	if version > latest {
+		logger.Warn("database schema is newer than this build", "version", version, "latest", latest)
+	}
+	logger.Info("database schema up to date", "version", version)
+
+	// Email provider for the outbox worker
+	mail, err := mailer.New(mailer.Config{
+		Provider:     cfg.Mail.Provider,
+		From:         cfg.Mail.From,
+		ReplyTo:      cfg.Mail.ReplyTo,
+		ResendAPIKey: cfg.Mail.ResendAPIKey,
+		SMTPHost:     cfg.Mail.SMTPHost,
+		SMTPPort:     cfg.Mail.SMTPPort,
+		SMTPUsername: cfg.Mail.SMTPUsername,
+		SMTPPassword: cfg.Mail.SMTPPassword,
+		DumpDir:      cfg.Mail.DumpDir,
 	})
+	if err != nil {
+		fatal("mail setup failed", err)
+	}
+	logger.Info("mail provider configured", "provider", cfg.Mail.Provider)
+
+	// Storage and signed links for background exports
+	blobs, err := blob.New(blob.Config{Provider: cfg.Exports.BlobStore, Dir: cfg.Exports.BlobDir})
+	if err != nil {
+		fatal("blob store setup failed", err)
+	}
+	exports := service.ExportConfig{
+		Blobs:     blobs,
+		Retention: cfg.Exports.Retention(),
+		PublicURL: cfg.Exports.PublicURL,
+	}
+	if cfg.Exports.SigningKey != "" {
+		exports.Signer = blob.Signer{Key: []byte(cfg.Exports.SigningKey)}
+	} else {
+		logger.Warn("EXPORT_SIGNING_KEY not set; export download links stop working on restart")
+	}
+
+	// Services share one store; handlers get them through handlers.New
+	store := db.NewStore(pool)
+	svc := server.NewServices(pool, store, server.ServiceConfig{
+		Mail:         mail,
+		SupportEmail: cfg.Mail.SupportEmail,
+		Exports:      exports,
+	})
+	h := handlers.New(store, svc)
+
+	// Background work: LISTEN/NOTIFY fan-out to SSE streams, the email
+	// outbox worker, the reminder/digest jobs and report exports
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	service.StartWorker(bgCtx, service.NewNotificationListener(pool, store).Run)
+	service.StartWorker(bgCtx, svc.EmailOutbox.Run)
+	service.StartWorker(bgCtx, service.NewNotificationJobs(store, svc.Notifications, svc.Announcements).Run)
+	service.StartWorker(bgCtx, svc.Exports.Run)
+
+	r := server.NewRouter(server.Deps{
+		Handler:      h,
+		Users:        store,
+		Logger:       logger,
+		CORSOrigins:  cfg.Server.CORSOrigins,
+		MetricsToken: cfg.Observability.MetricsToken,
+	})
+
+	port := cfg.Server.Port
+	srv := &http.Server{
+		Addr:              ":" + port,
+		Handler:           r,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      60 * time.Second, // lifted by middleware.NoWriteTimeout on streaming routes
+		IdleTimeout:       120 * time.Second,
+	}
+	// SSE streams never go idle, so Shutdown would wait on them forever
+	srv.RegisterOnShutdown(service.CloseNotificationStreams)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.ListenAndServe() }()
+	logger.Info("server running", "port", port)
+
+	select {
+	case err := <-serveErr:
+		fatal("server stopped", err)
+	case <-ctx.Done():
+	}
+	stop() // a second signal kills the process right away
+
+	// Fail readiness and give load balancers the drain delay to notice, stop
+	// accepting connections and let in-flight requests finish, then stop the
+	// workers and wait for them and for notifications still being sent
+	// before closing the pool
+	logger.Info("shutting down", "drain_delay", cfg.Server.ShutdownDrainDelay, "timeout", cfg.Server.ShutdownTimeout)
+	service.StartDraining()
+	time.Sleep(cfg.Server.ShutdownDrainDelay)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error("http server shutdown incomplete", "error", err)
+	}
+	stopBackground()
+	if err := service.WaitBackground(shutdownCtx); err != nil {
+		logger.Error("background work still running at shutdown", "error", err)
+	}
+	pool.Close()
+	logger.Info("shutdown complete")
+}
 
-	// r.GET("/download/books", middleware.AuthMiddleware(), middleware.AdminOnly(), handlers.DownloadSearchBooksHandler)
-	// r.GET("/download/users", middleware.AuthMiddleware(), middleware.AdminOnly(), handlers.DownloadUsersHandler)
-	// r.GET("/download/borrows", middleware.AuthMiddleware(), middleware.AdminOnly(), handlers.DownloadBorrowsHandler)
-	r.GET("/overview", middleware.AuthMiddleware(), middleware.AdminOnly(), handlers.OverviewHandler)
-	r.POST("/stripe/webhook", handlers.StripeWebhookHandler)
-	r.GET("/stripe/success", handlers.StripeSuccessHandler)
-	r.GET("/stripe/cancel", handlers.StripeCancelHandler)
-
-	// Auth routes (public)
-	authGroup := r.Group("/auth")
-	{
-		authGroup.POST("/register", handlers.RegisterHandler)
-		authGroup.POST("/login", handlers.LoginHandler)
-		authGroup.POST("/refresh", handlers.RefreshHandler) // ✅ add this
-		authGroup.POST("/logout", handlers.LogoutHandler)
-	}
-
-	// User routes (protected)
-	userGroup := r.Group("/users")
-	userGroup.Use(middleware.AuthMiddleware())
-	{
-		userGroup.GET("/", middleware.SkipRateLimit(), middleware.AdminOnly(), handlers.GetUsersHandler)
-		userGroup.GET("/user/email", middleware.AdminOnly(), middleware.SkipRateLimit(), handlers.SearchUsersPaginatedHandler)
-		userGroup.GET("/user/:id", handlers.GetUserByIDHandler)
-		userGroup.GET("/user/profile/:id", handlers.GetProfileDataByIDHandler)
-		userGroup.PATCH("/user/:id", handlers.UpdateUserByIDHandler)
-		userGroup.PATCH("/user/ban/:id", middleware.AdminOnly(), handlers.BanUserByIDHandler)
-		userGroup.DELETE("/user/:id", handlers.DeleteProfileImage)
-	}
-	bannedUserGroup := r.Group("/banned-users")
-	bannedUserGroup.Use(middleware.AuthMiddleware())
-	{
-		bannedUserGroup.GET("/", middleware.AdminOnly(), handlers.GetUsersHandler)
-	}
-	// Book routes
-	bookGroup := r.Group("/books")
-	{
-		// Public
-		bookGroup.GET("/", middleware.SkipRateLimit(), handlers.GetBooksHandler)
-		bookGroup.GET("/search", middleware.SkipRateLimit(), handlers.SearchBooksPaginatedHandler)
-		bookGroup.GET("/genres", handlers.ListGenresHandler)
-		bookGroup.GET("/genre/:genre", handlers.ListBooksByGenreHandler)
-		bookGroup.GET("/:id", handlers.GetBookByIDHandler)
-
-		bookGroup.POST("/", middleware.AuthMiddleware(), middleware.AdminOnly(), handlers.CreateBookHandler)
-		bookGroup.PATCH("/:id", middleware.AuthMiddleware(), middleware.AdminOnly(), handlers.UpdateBookByIDHandler)
-		bookGroup.DELETE("/:id", middleware.AuthMiddleware(), middleware.AdminOnly(), handlers.DeleteBookHandler)
-	}
-
-	// Reservation routes (protected)
-	reservationGroup := r.Group("/reservations")
-	reservationGroup.Use(middleware.AuthMiddleware())
-	{
-		reservationGroup.GET("/", handlers.GetReservationsHandler)
-		reservationGroup.GET("/book/:id", handlers.GetReservationsByBookIDHandler)
-		reservationGroup.GET("/book/:id/user", handlers.GetReservationsByBookIDAndUserIDHandler)
-		reservationGroup.GET("/reservation/:id", handlers.GetReservationsByReservationID)
-		reservationGroup.GET("/next/:id", middleware.AdminOnly(), handlers.GetNextReservationHandler)
-		reservationGroup.POST("/", handlers.CreateReservationHandler)
-		reservationGroup.PATCH("/:id/status", middleware.AdminOnly(), handlers.UpdateReservationStatusHandler)
-	}
-
-	// Borrow routes (protected)
-	borrowGroup := r.Group("/borrows")
-	borrowGroup.Use(middleware.AuthMiddleware())
-	{
-		borrowGroup.GET("/", middleware.AdminOnly(), middleware.SkipRateLimit(), handlers.GetAllBorrowsHandlers)
-		borrowGroup.GET("/user/:id", handlers.GetBorrowsByUserIDHandler)
-		borrowGroup.GET("/book/:id", handlers.GetBorrowsByBookIDHandler)
-		borrowGroup.GET("/borrow/book/:id", handlers.GetBorrowByBookAndUserIDHandler)
-		borrowGroup.POST("/borrow", handlers.BorrowBookHandler)
-		borrowGroup.PATCH("/borrow/:id/return", handlers.ReturnBookHandler)
-	}
-
-	// Review routes (protected)
-	reviewGroup := r.Group("/reviews")
-	reviewGroup.Use(middleware.AuthMiddleware())
-	{
-		reviewGroup.GET("/book/:id", handlers.GetReviewsByBookIDHandler)
-		reviewGroup.GET("/user/:id", handlers.GetReviewsByUserIDHandler)
-		reviewGroup.GET("/review/:id", handlers.GetReviewsByReviewIDHandler)
-		reviewGroup.POST("/review", handlers.CreateReviewHandler)
-		reviewGroup.PATCH("/review/:id", handlers.UpdateReviewByIDHandler)
-		reviewGroup.DELETE("/review/:id", handlers.DeleteReviewsByIDHandler)
-	}
-
-	// Contact routes (protected)
-	contactGroup := r.Group("/contact")
-	contactGroup.Use(middleware.AuthMiddleware())
-	{
-		contactGroup.POST("/send", handlers.ContactHandler)
-	}
-
-	// Notification routes (protected)
-	notificationGroup := r.Group("/notifications")
-	notificationGroup.Use(middleware.AuthMiddleware())
-	{
-		notificationGroup.GET("/get", handlers.GetUserNotificationsByUserIDHandler)
-		notificationGroup.PATCH("/mark-read", handlers.MarkAllNotificationsAsReadHandler)
-	}
-
-	// Subscription Plan routes (protected)
-	subscriptionPlanGroup := r.Group("/subscription-plan")
-	subscriptionPlanGroup.Use(middleware.AuthMiddleware())
-	{
-		subscriptionPlanGroup.GET("/", handlers.GetSubscriptionsPlanHandler)
-		subscriptionPlanGroup.GET("/:id", handlers.GetSubscriptionPlanByIDHandler)
-		subscriptionPlanGroup.POST("/", middleware.AdminOnly(), handlers.CreateSubscriptionPlanHandler)
-		subscriptionPlanGroup.DELETE("/:id", middleware.AdminOnly(), handlers.DeleteSubscriptionPlanByID)
-	}
-
-	// Subscription routes (protected)
-	subscriptionGroup := r.Group("/subscription")
-	subscriptionGroup.Use(middleware.AuthMiddleware())
-	{
-		subscriptionGroup.GET("/", middleware.AdminOnly(), handlers.ListSubscriptionsHandler)
-		subscriptionGroup.GET("/:user_id", handlers.GetSubscriptionByUserIDHandler)
-		subscriptionGroup.GET("/user/:user_id", middleware.AdminOnly(), handlers.ListSubscriptionsByUserHandler)
-		subscriptionGroup.POST("/", handlers.CreateSubscriptionHandler)
-		subscriptionGroup.DELETE("/:id", middleware.AdminOnly(), handlers.DeleteSubscriptionByIDHandler)
-	}
-
-	// Payment routes (protected)
-	paymentGroup := r.Group("/payments")
-	paymentGroup.Use(middleware.AuthMiddleware())
-	{
-		paymentGroup.GET("/search-payments", middleware.AdminOnly(), handlers.SearchPaymentsPaginatedHandler)
-		paymentGroup.GET("/all-payments", middleware.AdminOnly(), handlers.ListAllPaymentsHandler)
-		paymentGroup.GET("/:id", handlers.GetPaymentHandler)
-		paymentGroup.POST("/payment", handlers.CreatePaymentHandler)
-		paymentGroup.PATCH("/payment/:id/status", handlers.UpdatePaymentStatusHandler)
-		paymentGroup.DELETE("/payment/:id", middleware.AdminOnly(), handlers.DeletePaymentByIDHandler)
-	}
-
-	// Refund routes (protected)
-	refundGroup := r.Group("/refunds")
-	refundGroup.Use(middleware.AuthMiddleware())
-	{
-		refundGroup.GET("/:id", handlers.GetRefundHandler)
-		refundGroup.GET("/payment/:payment_id", middleware.AdminOnly(), handlers.ListRefundsByPaymentHandler)
-		refundGroup.GET("/status", middleware.AdminOnly(), handlers.ListRefundsByStatusHandler)
-		refundGroup.POST("/", handlers.CreateRefundHandler)
-		refundGroup.PATCH("/:id/status", handlers.UpdateRefundStatusHandler)
-		refundGroup.DELETE("/:id", middleware.AdminOnly(), handlers.DeleteRefundHandler)
-	}
-
-	// List routes (protected, admin only)
-	listGroup := r.Group("/list")
-	listGroup.Use(middleware.AuthMiddleware(), middleware.AdminOnly()) // ← Auth MUST come before Admin
-	{
-		listGroup.GET("/data-paginated", handlers.ListDataByStatusHandler)
-	}
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-	log.Printf("Server running on port %s", port)
-	r.Run(":" + port)
+// fatal logs err and exits; deferred cleanup does not run
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
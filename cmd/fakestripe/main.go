@@ -0,0 +1,51 @@
+// Command fakestripe serves a local stand-in for the Stripe API so checkout
+// can be run without network access or a Stripe account. Start it, then
+// run the API with STRIPE_API_URL pointing at it:
+//
+//	go run ./cmd/fakestripe
+//	STRIPE_API_URL=http://localhost:12111 go run ./cmd/api
+//
+// The checkout URL the API hands out opens a page with Pay and Cancel
+// buttons. Paying sends a signed checkout.session.completed event to the
+// API's /stripe/webhook before redirecting to the success URL.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/fakestripe"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// Same .env as the API, so the keys and webhook secret match it
+	_ = godotenv.Load()
+	addr := flag.String("addr", "localhost:12111", "address to listen on")
+	webhookURL := flag.String("webhook-url", "http://localhost:8080/stripe/webhook", "where to deliver webhook events; empty sends none")
+	webhookSecret := flag.String("webhook-secret", os.Getenv("STRIPE_WEBHOOK_SECRET"), "secret events are signed with")
+	apiKey := flag.String("api-key", os.Getenv("STRIPE_SECRET_KEY"), "secret key clients must send; empty accepts any")
+	flag.Parse()
+
+	if *webhookURL != "" && *webhookSecret == "" {
+		fmt.Fprintln(os.Stderr, "fakestripe: -webhook-secret or STRIPE_WEBHOOK_SECRET is required to sign events")
+		os.Exit(2)
+	}
+
+	fake := fakestripe.New(fakestripe.Config{
+		APIKey:        *apiKey,
+		WebhookURL:    *webhookURL,
+		WebhookSecret: *webhookSecret,
+		Client:        &http.Client{Timeout: 30 * time.Second},
+	})
+	srv := &http.Server{Addr: *addr, Handler: fake, ReadHeaderTimeout: 5 * time.Second}
+	slog.Info("fake stripe listening", "addr", *addr, "webhook_url", *webhookURL)
+	if err := srv.ListenAndServe(); err != nil {
+		slog.Error("fake stripe stopped", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,213 @@
+// Command migrate applies and inspects the database migrations embedded in
+// the API binary. It reads the database settings the same way the API does.
+//
+//	go run ./cmd/migrate up
+//	go run ./cmd/migrate create add_fines
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/THEGunDevil/GoForBackend/internal/config"
+	"github.com/THEGunDevil/GoForBackend/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/joho/godotenv"
+	"github.com/pressly/goose/v3"
+)
+
+const usage = `usage: migrate [-dir DIR] COMMAND [ARG]
+
+commands:
+  up                apply every pending migration
+  down              roll back the latest migration
+  redo              roll back and re-apply the latest migration
+  status            list migrations and whether each is applied
+  create NAME       write a new, empty migration to DIR
+  baseline VERSION  mark migrations up to VERSION applied without running
+                    them, for a database whose schema was created by hand
+`
+
+func main() {
+	dir := flag.String("dir", db.MigrationsDir, "where create writes new migrations")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(args[0], args[1:], *dir); err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		os.Exit(1)
+	}
+}
+
+func run(command string, args []string, dir string) error {
+	if command == "create" {
+		if len(args) != 1 {
+			return errors.New("create needs a NAME")
+		}
+		path, err := create(dir, args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println("created", path)
+		return nil
+	}
+
+	_ = godotenv.Load()
+	cfg, err := config.LoadDatabase()
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	sqlDB, err := open(cfg.URL)
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	migrator, err := db.NewMigrator(sqlDB)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		results, err := migrator.Up(ctx)
+		printResults(results)
+		if err == nil && len(results) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return err
+	case "down":
+		result, err := migrator.Down(ctx)
+		printResults([]*goose.MigrationResult{result})
+		return err
+	case "redo":
+		down, err := migrator.Down(ctx)
+		printResults([]*goose.MigrationResult{down})
+		if err != nil {
+			return err
+		}
+		up, err := migrator.UpByOne(ctx)
+		printResults([]*goose.MigrationResult{up})
+		return err
+	case "status":
+		return status(ctx, migrator)
+	case "baseline":
+		if len(args) != 1 {
+			return errors.New("baseline needs a VERSION")
+		}
+		version, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[0])
+		}
+		if err := db.Baseline(ctx, sqlDB, version); err != nil {
+			return err
+		}
+		fmt.Printf("marked migrations up to %d as applied\n", version)
+		return nil
+	}
+	return fmt.Errorf("unknown command %q\n\n%s", command, usage)
+}
+
+// open connects with the same simple protocol the API's pool uses, so it
+// works through PgBouncer too
+func open(url string) (*sql.DB, error) {
+	connConfig, err := pgx.ParseConfig(url)
+	if err != nil {
+		return nil, fmt.Errorf("parse database URL: %w", err)
+	}
+	connConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
+	return stdlib.OpenDB(*connConfig), nil
+}
+
+func printResults(results []*goose.MigrationResult) {
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		if r.Error != nil {
+			fmt.Printf("FAILED %s %s: %v\n", r.Direction, filepath.Base(r.Source.Path), r.Error)
+			continue
+		}
+		fmt.Println(r)
+	}
+}
+
+func status(ctx context.Context, migrator *goose.Provider) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATE\tAPPLIED AT\tFILE")
+	for _, s := range statuses {
+		applied := "-"
+		if s.State == goose.StateApplied {
+			applied = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Source.Version, s.State, applied, filepath.Base(s.Source.Path))
+	}
+	return w.Flush()
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+const migrationTemplate = `-- +goose Up
+
+-- +goose Down
+`
+
+// create writes the next sequential migration, numbered after the highest
+// file already in dir, e.g. 0014_add_fines.sql
+func create(dir, name string) (string, error) {
+	name = strings.Trim(unsafeNameChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", errors.New("migration name must contain letters or digits")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var latest int64
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		version, err := db.MigrationVersion(entry.Name())
+		if err != nil {
+			return "", err
+		}
+		latest = max(latest, version)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%04d_%s.sql", latest+1, name))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(migrationTemplate); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
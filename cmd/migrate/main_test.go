@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"0001_initial.sql", "0009_invoices.sql", "README.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path, err := create(dir, "Add Fines!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "0010_add_fines.sql"); path != want {
+		t.Errorf("create() = %s, want %s", path, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != migrationTemplate {
+		t.Errorf("unexpected contents:\n%s", data)
+	}
+
+	if _, err := create(dir, "!!"); err == nil {
+		t.Error("create accepted a name without letters or digits")
+	}
+}
@@ -0,0 +1,143 @@
+// Package apierror is the API's error model. Handlers and services return
+// or record an *Error; middleware.Errors turns whatever error a request
+// ended with into an RFC 7807 application/problem+json response, so every
+// failure carries a status, a stable machine-readable code and a detail
+// that is safe to show a client.
+package apierror
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Error is a failure with the response it should produce. Err, when set, is
+// the underlying cause; it is logged but never sent to the client.
+type Error struct {
+	Status     int
+	Code       string
+	Detail     string
+	Fields     []FieldError
+	Extensions map[string]any // extra members of the problem, e.g. ban_until
+	Err        error
+}
+
+// FieldError is one invalid field of a request
+type FieldError struct {
+	Field   string `json:"field" binding:"required"`
+	Code    string `json:"code" binding:"required"`
+	Message string `json:"message" binding:"required"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Detail + ": " + e.Err.Error()
+	}
+	return e.Detail
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Is matches errors with the same status and code, so a sentinel matches
+// the copies made from it with WithDetail or With
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Status == e.Status && t.Code == e.Code
+}
+
+// WithDetail returns a copy of e with a different detail
+func (e *Error) WithDetail(detail string) *Error {
+	out := *e
+	out.Detail = detail
+	return &out
+}
+
+// With returns a copy of e with an extra member in its problem. key must
+// not be one of the standard members, which take precedence.
+func (e *Error) With(key string, value any) *Error {
+	out := *e
+	out.Extensions = maps.Clone(e.Extensions)
+	if out.Extensions == nil {
+		out.Extensions = map[string]any{}
+	}
+	out.Extensions[key] = value
+	return &out
+}
+
+// Wrap returns a copy of e that records cause
+func (e *Error) Wrap(cause error) *Error {
+	out := *e
+	out.Err = cause
+	return &out
+}
+
+// New returns an error with the given status, code and detail
+func New(status int, code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+// Constructors for the common statuses; each uses a code named after its
+// status, so define an error with New when clients need to tell it apart
+
+func BadRequest(detail string) *Error { return New(http.StatusBadRequest, "bad_request", detail) }
+
+func Unauthorized(detail string) *Error { return New(http.StatusUnauthorized, "unauthorized", detail) }
+
+func Forbidden(detail string) *Error { return New(http.StatusForbidden, "forbidden", detail) }
+
+func NotFound(detail string) *Error { return New(http.StatusNotFound, "not_found", detail) }
+
+func Conflict(detail string) *Error { return New(http.StatusConflict, "conflict", detail) }
+
+func Gone(detail string) *Error { return New(http.StatusGone, "gone", detail) }
+
+func TooLarge(detail string) *Error {
+	return New(http.StatusRequestEntityTooLarge, "too_large", detail)
+}
+
+func TooManyRequests(detail string) *Error {
+	return New(http.StatusTooManyRequests, "too_many_requests", detail)
+}
+
+func Unavailable(detail string) *Error {
+	return New(http.StatusServiceUnavailable, "unavailable", detail)
+}
+
+func BadGateway(detail string) *Error { return New(http.StatusBadGateway, "bad_gateway", detail) }
+
+// Internal is a server-side failure. detail says what the server was doing;
+// cause is logged and kept out of the response.
+func Internal(detail string, cause error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: "internal", Detail: detail, Err: cause}
+}
+
+// From maps any error to an *Error. An *Error anywhere in the chain is used,
+// and when it is a client error wrapped with more context, as in
+// fmt.Errorf("%w: %v", ErrInvalidRange, err), that context becomes the
+// detail. A missing row is a 404 and a unique violation a 409; anything else
+// is an internal error that keeps err as its cause.
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		if err != error(apiErr) && apiErr.Status < http.StatusInternalServerError {
+			return apiErr.WithDetail(err.Error())
+		}
+		return apiErr
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &Error{Status: http.StatusNotFound, Code: "not_found", Detail: "resource not found", Err: err}
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505": // unique_violation
+			return &Error{Status: http.StatusConflict, Code: "conflict", Detail: "resource already exists", Err: err}
+		case "23503": // foreign_key_violation
+			return &Error{Status: http.StatusConflict, Code: "conflict", Detail: "resource is still referenced", Err: err}
+		}
+	}
+	return Internal("something went wrong", err)
+}
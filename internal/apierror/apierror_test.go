@@ -0,0 +1,119 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var errTooFar = New(http.StatusBadRequest, "too_far", "range is too long")
+
+func TestFrom(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{"api error", Conflict("book has copies on loan"), http.StatusConflict, "conflict", "book has copies on loan"},
+		{"wrapped sentinel", fmt.Errorf("%w: at most 400 days", errTooFar), http.StatusBadRequest, "too_far", "range is too long: at most 400 days"},
+		{"no rows", fmt.Errorf("get book: %w", pgx.ErrNoRows), http.StatusNotFound, "not_found", "resource not found"},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, http.StatusConflict, "conflict", "resource already exists"},
+		{"anything else", errors.New(`relation "books" does not exist`), http.StatusInternalServerError, "internal", "something went wrong"},
+		{"wrapped internal", fmt.Errorf("commit: %w", Internal("failed to save", errors.New("conn reset"))), http.StatusInternalServerError, "internal", "failed to save"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := From(tt.err)
+			if got.Status != tt.wantStatus || got.Code != tt.wantCode || got.Detail != tt.wantDetail {
+				t.Errorf("From = %d %s %q, want %d %s %q", got.Status, got.Code, got.Detail, tt.wantStatus, tt.wantCode, tt.wantDetail)
+			}
+		})
+	}
+
+	if !errors.Is(fmt.Errorf("x: %w", errTooFar.WithDetail("other")), errTooFar) {
+		t.Error("a copy of a sentinel does not match it")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/exports/1/download", nil)
+
+	// The status extension clashes with the standard member and loses
+	Write(c, Gone("export file is no longer available").With("job_status", "expired").With("status", "expired"))
+
+	if w.Code != http.StatusGone || w.Header().Get("Content-Type") != ContentType {
+		t.Fatalf("got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"type": "/problems/gone", "title": "Gone", "status": float64(410), "code": "gone",
+		"detail": "export file is no longer available", "instance": "/exports/1/download",
+		"job_status": "expired",
+	}
+	for k, v := range want {
+		if body[k] != v {
+			t.Errorf("%s = %v, want %v", k, body[k], v)
+		}
+	}
+}
+
+func TestWriteHidesCause(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	Write(c, errors.New(`pq: duplicate key value violates unique constraint "users_email_key"`))
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "users_email_key") {
+		t.Errorf("got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	type request struct {
+		BookID string `json:"book_id" binding:"required"`
+		Rating int    `json:"rating" binding:"min=1,max=5"`
+		Name   string `form:"name" binding:"required"`
+	}
+	var req request
+	err := binding.JSON.BindBody([]byte(`{"rating": 9}`), &req)
+	got := Invalid(err)
+	if got.Status != http.StatusBadRequest || got.Code != "validation_failed" {
+		t.Fatalf("Invalid = %+v", got)
+	}
+	fields := map[string]string{}
+	for _, f := range got.Fields {
+		fields[f.Field] = f.Code
+	}
+	if fields["book_id"] != "required" || fields["rating"] != "max" || fields["name"] != "required" || len(fields) != 3 {
+		t.Errorf("fields = %+v", got.Fields)
+	}
+
+	err = binding.JSON.BindBody([]byte(`{"book_id": 7}`), &req)
+	got = Invalid(err)
+	if got.Code != "malformed_body" || len(got.Fields) != 1 || got.Fields[0].Field != "book_id" {
+		t.Errorf("Invalid(type error) = %+v", got)
+	}
+
+	got = Invalid(binding.JSON.BindBody([]byte(`{`), &req))
+	if got.Code != "malformed_body" || got.Detail != "request body could not be read" {
+		t.Errorf("Invalid(syntax error) = %+v", got)
+	}
+}
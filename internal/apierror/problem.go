@@ -0,0 +1,67 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType is the media type problems are sent as
+const ContentType = "application/problem+json"
+
+// Problem is the RFC 7807 body for an error
+type Problem struct {
+	Type     string       `json:"type" binding:"required"`
+	Title    string       `json:"title" binding:"required"`
+	Status   int          `json:"status" binding:"required"`
+	Code     string       `json:"code" binding:"required"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+
+	Extensions map[string]any `json:"-"`
+}
+
+// MarshalJSON writes the extension members alongside the standard ones
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type plain Problem
+	raw, err := json.Marshal(plain(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return raw, err
+	}
+	members := map[string]any{}
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+	if err := json.Unmarshal(raw, &members); err != nil {
+		return nil, err
+	}
+	return json.Marshal(members)
+}
+
+// Problem returns the body for e, answering a request for instance
+func (e *Error) Problem(instance string) Problem {
+	return Problem{
+		Type:       "/problems/" + e.Code,
+		Title:      http.StatusText(e.Status),
+		Status:     e.Status,
+		Code:       e.Code,
+		Detail:     e.Detail,
+		Instance:   instance,
+		Errors:     e.Fields,
+		Extensions: e.Extensions,
+	}
+}
+
+// Write sends err as a problem. Most handlers record errors with c.Error and
+// leave the reply to middleware.Errors; Write is for code outside that chain.
+func Write(c *gin.Context, err error) {
+	apiErr := From(err)
+	body, marshalErr := json.Marshal(apiErr.Problem(c.Request.URL.Path))
+	if marshalErr != nil {
+		apiErr = Internal("something went wrong", marshalErr)
+		body, _ = json.Marshal(apiErr.Problem(c.Request.URL.Path))
+	}
+	c.Data(apiErr.Status, ContentType, body)
+}
@@ -0,0 +1,83 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Name fields in validation errors the way clients send them
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(wireName)
+	}
+}
+
+// wireName is a field's JSON name, or its form name for fields only bound
+// from multipart forms
+func wireName(f reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(f.Tag.Get(key), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// Invalid maps an error from ShouldBind and friends to a 400: failed
+// validation rules become field errors, anything else a malformed body
+func Invalid(err error) *Error {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		e := New(http.StatusBadRequest, "validation_failed", "request failed validation")
+		for _, fe := range verrs {
+			e.Fields = append(e.Fields, FieldError{Field: fe.Field(), Code: fe.Tag(), Message: fieldMessage(fe)})
+		}
+		return e.Wrap(err)
+	}
+
+	e := New(http.StatusBadRequest, "malformed_body", "request body could not be read")
+	var typeErr *json.UnmarshalTypeError
+	var numErr *strconv.NumError
+	switch {
+	case errors.As(err, &typeErr):
+		e.Detail = fmt.Sprintf("%s must be %s", typeErr.Field, typeErr.Type)
+		e.Fields = []FieldError{{Field: typeErr.Field, Code: "type", Message: "must be " + typeErr.Type.String()}}
+	case errors.As(err, &numErr):
+		e.Detail = "a field that must be a number is not one"
+	}
+	return e.Wrap(err)
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be an email address"
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "min":
+		if fe.Kind() == reflect.String {
+			return "must be at least " + fe.Param() + " characters"
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return "must be at most " + fe.Param() + " characters"
+		}
+		return "must be at most " + fe.Param()
+	}
+	return "failed the " + fe.Tag() + " rule"
+}
@@ -0,0 +1,362 @@
+package apitest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	"github.com/THEGunDevil/GoForBackend/internal/apitest"
+	"github.com/THEGunDevil/GoForBackend/internal/db"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/THEGunDevil/GoForBackend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestMain(m *testing.M) { os.Exit(apitest.Main(m)) }
+
+func TestRegisterLoginRefresh(t *testing.T) {
+	env := apitest.New(t)
+
+	register := gin.H{
+		"first_name":       "Ayesha",
+		"last_name":        "Rahman",
+		"email":            "ayesha@example.com",
+		"phone_number":     "+8801711111111",
+		"password":         apitest.Password,
+		"confirm_password": apitest.Password,
+	}
+	var user models.UserResponse
+	apitest.Decode(t, env.Do(t, http.MethodPost, "/auth/register", "", register), http.StatusCreated, &user)
+	if user.Email != "ayesha@example.com" || user.Role != "member" {
+		t.Errorf("registered %+v", user)
+	}
+	apitest.Decode(t, env.Do(t, http.MethodPost, "/auth/register", "", register), http.StatusConflict, nil)
+
+	w := env.Do(t, http.MethodPost, "/auth/login", "", gin.H{"email": "ayesha@example.com", "password": "wrong"})
+	apitest.Decode(t, w, http.StatusUnauthorized, nil)
+
+	var login struct {
+		AccessToken string `json:"access_token"`
+		Role        string `json:"role"`
+	}
+	w = env.Do(t, http.MethodPost, "/auth/login", "", gin.H{"email": "ayesha@example.com", "password": apitest.Password})
+	apitest.Decode(t, w, http.StatusOK, &login)
+	refresh := apitest.Cookie(t, w, "refresh_token")
+	if login.AccessToken == "" || login.Role != "member" {
+		t.Fatalf("login = %+v", login)
+	}
+	apitest.Decode(t, env.Do(t, http.MethodGet, "/notifications/unread-count", login.AccessToken, nil), http.StatusOK, nil)
+
+	var refreshed struct {
+		AccessToken string `json:"access_token"`
+	}
+	w = env.Do(t, http.MethodPost, "/auth/refresh", "", nil, refresh)
+	apitest.Decode(t, w, http.StatusOK, &refreshed)
+	if refreshed.AccessToken == "" {
+		t.Fatal("refresh returned no access token")
+	}
+	refresh = apitest.Cookie(t, w, "refresh_token")
+
+	// Logging out revokes every token issued so far
+	apitest.Decode(t, env.Do(t, http.MethodPost, "/auth/logout", "", nil, refresh), http.StatusOK, nil)
+	apitest.Decode(t, env.Do(t, http.MethodPost, "/auth/refresh", "", nil, refresh), http.StatusUnauthorized, nil)
+	apitest.Decode(t, env.Do(t, http.MethodGet, "/notifications/unread-count", refreshed.AccessToken, nil), http.StatusUnauthorized, nil)
+}
+
+func TestBorrowAndReturn(t *testing.T) {
+	env := apitest.New(t)
+	member := env.Member(t)
+	admin := env.Admin(t)
+	book := env.Book(t, 2)
+	bookID := uuid.UUID(book.ID.Bytes)
+
+	borrowReq := gin.H{"book_id": bookID, "due_date": time.Now().Add(14 * 24 * time.Hour).Format(time.RFC3339)}
+	apitest.Decode(t, env.Do(t, http.MethodPost, "/borrows/borrow", "", borrowReq), http.StatusUnauthorized, nil)
+
+	var borrow models.BorrowResponse
+	apitest.Decode(t, env.Do(t, http.MethodPost, "/borrows/borrow", member.Token, borrowReq), http.StatusCreated, &borrow)
+	if borrow.UserID != member.ID || borrow.BookID != bookID || borrow.ReturnedAt != nil {
+		t.Errorf("borrow = %+v", borrow)
+	}
+	if got := availableCopies(t, env, book.ID); got != 1 {
+		t.Errorf("available copies after borrow = %d, want 1", got)
+	}
+	var problem apierror.Problem
+	apitest.Decode(t, env.Do(t, http.MethodPost, "/borrows/borrow", member.Token, borrowReq), http.StatusConflict, &problem)
+	if problem.Code != "already_borrowed" {
+		t.Errorf("second borrow = %+v", problem)
+	}
+
+	// Only admins list every borrow
+	apitest.Decode(t, env.Do(t, http.MethodGet, "/borrows/", member.Token, nil), http.StatusForbidden, nil)
+	apitest.Decode(t, env.Do(t, http.MethodGet, "/borrows/", admin.Token, nil), http.StatusOK, nil)
+
+	path := "/borrows/borrow/" + borrow.ID.String() + "/return"
+	apitest.Decode(t, env.Do(t, http.MethodPatch, path, member.Token, gin.H{"book_id": bookID}), http.StatusOK, nil)
+	if got := availableCopies(t, env, book.ID); got != 2 {
+		t.Errorf("available copies after return = %d, want 2", got)
+	}
+}
+
+// A delete racing a borrow must not leave a loan on a deleted book: either
+// the borrow lands first and the delete is refused, or the delete wins and
+// the borrow finds no book.
+func TestDeleteBookRacingBorrow(t *testing.T) {
+	env := apitest.New(t)
+	admin := env.Admin(t)
+	for i := 0; i < 20; i++ {
+		member := env.Member(t)
+		book := env.Book(t, 1)
+		bookID := uuid.UUID(book.ID.Bytes)
+		borrowReq := gin.H{"book_id": bookID, "due_date": time.Now().Add(7 * 24 * time.Hour).Format(time.RFC3339)}
+
+		var borrowed, deleted int
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			borrowed = env.Do(t, http.MethodPost, "/borrows/borrow", member.Token, borrowReq).Code
+		}()
+		go func() {
+			defer wg.Done()
+			deleted = env.Do(t, http.MethodDelete, "/books/"+bookID.String(), admin.Token, nil).Code
+		}()
+		wg.Wait()
+
+		switch {
+		case borrowed == http.StatusCreated && deleted == http.StatusConflict:
+		case borrowed == http.StatusNotFound && deleted == http.StatusOK:
+			if got := availableCopies(t, env, book.ID); got != 1 {
+				t.Errorf("available copies after refused borrow = %d, want 1", got)
+			}
+		default:
+			t.Fatalf("borrow = %d, delete = %d", borrowed, deleted)
+		}
+	}
+}
+
+func TestReservation(t *testing.T) {
+	env := apitest.New(t)
+	borrower := env.Member(t)
+	member := env.Member(t)
+	book := env.Book(t, 1)
+	bookID := uuid.UUID(book.ID.Bytes)
+
+	// An available book is borrowed, not reserved
+	reserve := gin.H{"book_id": bookID}
+	apitest.Decode(t, env.Do(t, http.MethodPost, "/reservations/", member.Token, reserve), http.StatusBadRequest, nil)
+
+	borrowReq := gin.H{"book_id": bookID, "due_date": time.Now().Add(7 * 24 * time.Hour).Format(time.RFC3339)}
+	apitest.Decode(t, env.Do(t, http.MethodPost, "/borrows/borrow", borrower.Token, borrowReq), http.StatusCreated, nil)
+
+	var reservation models.ReservationResponse
+	apitest.Decode(t, env.Do(t, http.MethodPost, "/reservations/", member.Token, reserve), http.StatusCreated, &reservation)
+	if reservation.UserID != member.ID || reservation.BookID != bookID {
+		t.Errorf("reservation = %+v", reservation)
+	}
+	apitest.Decode(t, env.Do(t, http.MethodPost, "/reservations/", member.Token, reserve), http.StatusConflict, nil)
+	apitest.Decode(t, env.Do(t, http.MethodPost, "/reservations/", member.Token, gin.H{"book_id": uuid.New()}), http.StatusNotFound, nil)
+}
+
+func TestReview(t *testing.T) {
+	env := apitest.New(t)
+	member := env.Member(t)
+	book := env.Book(t, 1)
+	bookID := uuid.UUID(book.ID.Bytes)
+
+	apitest.Decode(t, env.Do(t, http.MethodPost, "/reviews/review", member.Token, gin.H{"bookId": bookID, "rating": 6}), http.StatusBadRequest, nil)
+	apitest.Decode(t, env.Do(t, http.MethodPost, "/reviews/review", member.Token, gin.H{"bookId": bookID}), http.StatusBadRequest, nil)
+	apitest.Decode(t, env.Do(t, http.MethodPost, "/reviews/review", member.Token, gin.H{"bookId": uuid.New(), "rating": 4}), http.StatusNotFound, nil)
+
+	review := gin.H{"bookId": bookID, "rating": 5, "comment": "Could not put it down"}
+	apitest.Decode(t, env.Do(t, http.MethodPost, "/reviews/review", member.Token, review), http.StatusCreated, nil)
+
+	var reviews []models.ReviewResponse
+	apitest.Decode(t, env.Do(t, http.MethodGet, "/reviews/book/"+bookID.String(), member.Token, nil), http.StatusOK, &reviews)
+	if len(reviews) != 1 {
+		t.Fatalf("got %d reviews, want 1", len(reviews))
+	}
+	if r := reviews[0]; r.UserID != member.ID || r.Rating != 5 || r.Comment != "Could not put it down" || r.BookTitle != book.Title {
+		t.Errorf("review = %+v", r)
+	}
+}
+
+func TestStripeWebhook(t *testing.T) {
+	env := apitest.New(t)
+	member := env.Member(t)
+	plan := env.Plan(t)
+	payment := env.Payment(t, member, plan)
+
+	event := apitest.StripeEvent(t, "checkout.session.completed", gin.H{
+		"id":       "cs_test_apitest",
+		"object":   "checkout.session",
+		"metadata": gin.H{"transaction_id": uuid.UUID(payment.TransactionID.Bytes).String()},
+	})
+
+	apitest.Decode(t, env.Webhook(t, event, "whsec_someone_else"), http.StatusBadRequest, nil)
+	if got := reloadPayment(t, env, payment.TransactionID); got.Status != "pending" {
+		t.Fatalf("payment %s after a forged webhook", got.Status)
+	}
+
+	if w := env.Webhook(t, event, apitest.WebhookSecret); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	paid := reloadPayment(t, env, payment.TransactionID)
+	if paid.Status != "paid" || !paid.SubscriptionID.Valid {
+		t.Fatalf("payment after webhook = %s, subscription %v", paid.Status, paid.SubscriptionID)
+	}
+	sub, err := env.Store.GetSubscriptionByID(context.Background(), paid.SubscriptionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.UserID.Bytes != member.ID || sub.PlanID != plan.ID || sub.Status != "active" {
+		t.Errorf("subscription = %+v", sub)
+	}
+
+	// Stripe retries deliveries; a repeat must not subscribe twice
+	if w := env.Webhook(t, event, apitest.WebhookSecret); w.Code != http.StatusOK {
+		t.Fatalf("redelivery status %d: %s", w.Code, w.Body.String())
+	}
+	if again := reloadPayment(t, env, payment.TransactionID); again.SubscriptionID != paid.SubscriptionID {
+		t.Errorf("redelivery relinked the payment to %v", again.SubscriptionID)
+	}
+}
+
+// Receipt numbers are taken in the issuing transaction: a rollback hands
+// the number back and reissuing returns the same receipt
+func TestReceiptNumbersHaveNoGaps(t *testing.T) {
+	env := apitest.New(t)
+	ctx := context.Background()
+	member := env.Member(t)
+	plan := env.Plan(t)
+	first := env.Payment(t, member, plan)
+	second := env.Payment(t, member, plan)
+
+	rollback := errors.New("rollback")
+	err := env.Store.WithTx(ctx, func(tx db.Store) error {
+		if _, err := service.IssueReceipt(ctx, tx, first); err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("rolled back issue: %v", err)
+	}
+
+	for _, tc := range []struct {
+		payment gen.Payment
+		want    string
+	}{
+		{second, "INV-000001"},
+		{first, "INV-000002"},
+		{second, "INV-000001"},
+	} {
+		receipt, err := service.IssueReceipt(ctx, env.Store, tc.payment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if receipt.Number != tc.want {
+			t.Errorf("receipt number = %s, want %s", receipt.Number, tc.want)
+		}
+	}
+}
+
+func availableCopies(t *testing.T, env *apitest.Env, id pgtype.UUID) int32 {
+	t.Helper()
+	book, err := env.Store.GetBookByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return book.AvailableCopies.Int32
+}
+
+func reloadPayment(t *testing.T, env *apitest.Env, transactionID pgtype.UUID) gen.Payment {
+	t.Helper()
+	payment, err := env.Store.GetPaymentByTransactionID(context.Background(), transactionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payment
+}
+
+func TestCheckout(t *testing.T) {
+	env := apitest.New(t)
+	checkout := env.Stripe(t)
+	member := env.Member(t)
+	plan := env.Plan(t)
+
+	var created struct {
+		TransactionID uuid.UUID `json:"transaction_id"`
+		RedirectURL   string    `json:"redirect_url"`
+	}
+	body := gin.H{"user_id": member.ID, "plan_id": uuid.UUID(plan.ID.Bytes)}
+	apitest.Decode(t, env.Do(t, http.MethodPost, "/payments/payment", member.Token, body), http.StatusOK, &created)
+
+	// Paying on the hosted page delivers the webhook, then sends the
+	// browser to the API's success URL
+	success := follow(t, checkout, http.MethodPost, created.RedirectURL+"/pay")
+	transactionID := pgtype.UUID{Bytes: created.TransactionID, Valid: true}
+	if got := reloadPayment(t, env, transactionID); got.Status != "paid" {
+		t.Fatalf("payment %s after the webhook", got.Status)
+	}
+
+	// which confirms the session and sends it on to the web app
+	if got, want := follow(t, checkout, http.MethodGet, success), apitest.SuccessRedirect+"?tran_id="+created.TransactionID.String(); got != want {
+		t.Errorf("success redirected to %q, want %q", got, want)
+	}
+	var subscriptions int
+	if err := env.Pool.QueryRow(context.Background(), "SELECT count(*) FROM subscriptions WHERE user_id = $1", member.ID).Scan(&subscriptions); err != nil {
+		t.Fatal(err)
+	}
+	if subscriptions != 1 {
+		t.Errorf("%d subscriptions, want 1 from the webhook and success redirect together", subscriptions)
+	}
+}
+
+func TestCheckoutCancelled(t *testing.T) {
+	env := apitest.New(t)
+	checkout := env.Stripe(t)
+	member := env.Member(t)
+	plan := env.Plan(t)
+
+	var created struct {
+		TransactionID uuid.UUID `json:"transaction_id"`
+		RedirectURL   string    `json:"redirect_url"`
+	}
+	body := gin.H{"user_id": member.ID, "plan_id": uuid.UUID(plan.ID.Bytes)}
+	apitest.Decode(t, env.Do(t, http.MethodPost, "/payments/payment", member.Token, body), http.StatusOK, &created)
+
+	cancel := follow(t, checkout, http.MethodPost, created.RedirectURL+"/cancel")
+	if got := follow(t, checkout, http.MethodGet, cancel); got != apitest.CancelRedirect {
+		t.Errorf("cancel redirected to %q", got)
+	}
+	if got := reloadPayment(t, env, pgtype.UUID{Bytes: created.TransactionID, Valid: true}); got.Status != "cancelled" {
+		t.Errorf("payment %s after cancelling", got.Status)
+	}
+}
+
+// follow makes one request of the checkout and returns where it redirects
+func follow(t *testing.T, checkout *apitest.Checkout, method, url string) string {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := checkout.Client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("%s %s: %s, want a redirect", method, url, resp.Status)
+	}
+	return resp.Header.Get("Location")
+}
@@ -0,0 +1,58 @@
+package apitest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/THEGunDevil/GoForBackend/internal/config"
+	"github.com/THEGunDevil/GoForBackend/internal/fakestripe"
+	"github.com/THEGunDevil/GoForBackend/internal/service"
+)
+
+// Where checkout sends the browser once the API is done with it
+const (
+	SuccessRedirect = "http://app.test/payment/success"
+	CancelRedirect  = "http://app.test/payment/cancelled"
+)
+
+// Checkout is the API served over HTTP with a fake Stripe in front of it
+type Checkout struct {
+	*fakestripe.Server
+	APIURL string // the API, which the fake's redirects and webhooks reach
+	Client *http.Client
+}
+
+// Stripe serves the API on a local port and points checkout at a fake
+// Stripe that delivers its webhooks there. Client does not follow
+// redirects, so each hop of the checkout can be checked.
+func (e *Env) Stripe(t *testing.T) *Checkout {
+	t.Helper()
+	api := httptest.NewServer(e.Router)
+	t.Cleanup(api.Close)
+
+	fake := fakestripe.New(fakestripe.Config{
+		APIKey:        "sk_test_apitest",
+		WebhookURL:    api.URL + "/stripe/webhook",
+		WebhookSecret: WebhookSecret,
+	})
+	stripeSrv := httptest.NewServer(fake)
+	t.Cleanup(stripeSrv.Close)
+
+	service.ConfigureStripe(config.StripeConfig{
+		SecretKey:       "sk_test_apitest",
+		WebhookSecret:   WebhookSecret,
+		APIURL:          stripeSrv.URL,
+		SuccessURL:      api.URL + "/stripe/success",
+		CancelURL:       api.URL + "/stripe/cancel",
+		SuccessRedirect: SuccessRedirect,
+		CancelRedirect:  CancelRedirect,
+	}, stripeSrv.Client())
+	t.Cleanup(configureService)
+
+	return &Checkout{
+		Server: fake,
+		APIURL: api.URL,
+		Client: &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }},
+	}
+}
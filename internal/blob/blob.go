@@ -0,0 +1,48 @@
+// Package blob stores generated files such as report exports, and signs the
+// expiring URLs they are downloaded from.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNotFound is returned by Open for a key that holds nothing
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps blobs by key. Keys are slash-separated paths such as
+// "exports/<id>/borrows.csv".
+type Store interface {
+	// Put stores everything read from r under key, replacing any blob
+	// already there, and returns the number of bytes written. If r fails
+	// nothing is stored.
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Open returns the blob's contents, or ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Checker is implemented by stores that can tell whether they are usable
+// right now; readiness probes call it
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// Config selects and configures a Store
+type Config struct {
+	Provider string // local (the default)
+	Dir      string // root directory for the local store
+}
+
+// New returns the Store named by cfg.Provider
+func New(cfg Config) (Store, error) {
+	switch cfg.Provider {
+	case "", "local":
+		return NewLocal(cfg.Dir), nil
+	default:
+		return nil, fmt.Errorf("unknown blob store %q", cfg.Provider)
+	}
+}
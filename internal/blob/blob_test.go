@@ -0,0 +1,97 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocal(t.TempDir())
+
+	n, err := store.Put(ctx, "exports/1/report.csv", strings.NewReader("a,b\n1,2\n"))
+	if err != nil || n != 8 {
+		t.Fatalf("Put = %d, %v", n, err)
+	}
+	r, err := store.Open(ctx, "exports/1/report.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "a,b\n1,2\n" {
+		t.Errorf("read %q", data)
+	}
+
+	if err := store.Delete(ctx, "exports/1/report.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Open(ctx, "exports/1/report.csv"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open after Delete = %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, "exports/1/report.csv"); err != nil {
+		t.Errorf("second Delete = %v", err)
+	}
+}
+
+func TestLocalPutKeepsNothingOnError(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocal(t.TempDir())
+	r := io.MultiReader(strings.NewReader("partial"), errReader{})
+	if _, err := store.Put(ctx, "x.csv", r); err == nil {
+		t.Fatal("Put succeeded with a failing reader")
+	}
+	if _, err := store.Open(ctx, "x.csv"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalRejectsEscapingKeys(t *testing.T) {
+	store := NewLocal(t.TempDir())
+	for _, key := range []string{"", "../x", "a/../../x", "/etc/passwd"} {
+		if _, err := store.Put(context.Background(), key, strings.NewReader("x")); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+	}
+}
+
+func TestLocalCheck(t *testing.T) {
+	store := NewLocal(t.TempDir() + "/nested")
+	if err := store.Check(context.Background()); err != nil {
+		t.Fatalf("Check = %v, want nil for a creatable dir", err)
+	}
+	entries, _ := os.ReadDir(store.Dir)
+	if len(entries) != 0 {
+		t.Errorf("Check left %d files behind", len(entries))
+	}
+}
+
+func TestSigner(t *testing.T) {
+	s := Signer{Key: []byte("secret")}
+	now := time.Unix(1_700_000_000, 0)
+	expires := now.Add(time.Minute)
+	sig := s.Sign("exports/1", expires)
+	exp := "1700000060"
+
+	if err := s.Verify("exports/1", exp, sig, now); err != nil {
+		t.Errorf("valid link: %v", err)
+	}
+	if err := s.Verify("exports/2", exp, sig, now); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("other resource: %v", err)
+	}
+	if err := s.Verify("exports/1", "1700000999", sig, now); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("extended expiry: %v", err)
+	}
+	if err := s.Verify("exports/1", exp, sig, expires); !errors.Is(err, ErrURLExpired) {
+		t.Errorf("expired link: %v", err)
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("boom") }
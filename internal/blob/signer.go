@@ -0,0 +1,44 @@
+package blob
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrBadSignature = errors.New("invalid signature")
+	ErrURLExpired   = errors.New("link has expired")
+)
+
+// Signer signs download links so they can be used without logging in, but
+// only until they expire.
+type Signer struct {
+	Key []byte
+}
+
+// Sign returns the signature for resource valid until expires
+func (s Signer) Sign(resource string, expires time.Time) string {
+	mac := hmac.New(sha256.New, s.Key)
+	mac.Write([]byte(resource + "\n" + strconv.FormatInt(expires.Unix(), 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature and expiry (Unix seconds) taken from a link
+func (s Signer) Verify(resource, expires, signature string, now time.Time) error {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrBadSignature
+	}
+	want := s.Sign(resource, time.Unix(unix, 0))
+	if !hmac.Equal([]byte(want), []byte(signature)) {
+		return ErrBadSignature
+	}
+	if now.Unix() >= unix {
+		return ErrURLExpired
+	}
+	return nil
+}
@@ -1,51 +1,244 @@
+// Package config loads the service's settings. Values come, in increasing
+// order of precedence, from the profile's defaults, an optional YAML file and
+// environment variables; Load validates the result and reports every problem
+// at once.
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
+// Config is every setting the API reads at startup
 type Config struct {
-	DBHost     string
-	DBHostAddr string
-	DBPort     string
-	DBUser     string
-	DBPassword string
-	DBName     string
-	DBSSLMode  string
-	DBURL      string
-	LOCALDBURL string
+	Env string `yaml:"env" env:"APP_ENV"` // development, test or production (the default)
+
+	Server        ServerConfig        `yaml:"server"`
+	DB            DBConfig            `yaml:"db"`
+	Auth          AuthConfig          `yaml:"auth"`
+	Mail          MailConfig          `yaml:"mail"`
+	Stripe        StripeConfig        `yaml:"stripe"`
+	Cloudinary    CloudinaryConfig    `yaml:"cloudinary"`
+	Exports       ExportsConfig       `yaml:"exports"`
+	Observability ObservabilityConfig `yaml:"observability"`
+}
+
+// ServerConfig covers the HTTP listener and what browsers may do with it
+type ServerConfig struct {
+	Port            string        `yaml:"port" env:"PORT"`
+	CORSOrigins     []string      `yaml:"cors_origins" env:"CORS_ORIGINS"`
+	SecureCookies   bool          `yaml:"secure_cookies" env:"SECURE_COOKIES"` // Secure and SameSite=None on the refresh cookie
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	// ShutdownDrainDelay is how long /readyz fails before the listener
+	// closes, so load balancers stop routing here first
+	ShutdownDrainDelay time.Duration `yaml:"shutdown_drain_delay" env:"SHUTDOWN_DRAIN_DELAY"`
+}
+
+// DBConfig is either a full URL or the parts to build one from
+type DBConfig struct {
+	URL      string `yaml:"url" env:"DB_URL"`
+	Host     string `yaml:"host" env:"DB_HOST"`
+	Port     string `yaml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" env:"DB_USER"`
+	Password string `yaml:"password" env:"DB_PASSWORD"`
+	Name     string `yaml:"name" env:"DB_NAME"`
+	SSLMode  string `yaml:"sslmode" env:"DB_SSLMODE"`
+	LocalURL string `yaml:"local_url" env:"LOCAL_DB_URL"`
+
+	AutoMigrate bool `yaml:"auto_migrate" env:"DB_AUTO_MIGRATE"` // apply pending migrations on boot
+}
+
+// AuthConfig holds the keys that sign access and refresh tokens
+type AuthConfig struct {
+	JWTAccessSecret  string `yaml:"jwt_access_secret" env:"JWT_ACCESS_SECRET"`
+	JWTRefreshSecret string `yaml:"jwt_refresh_secret" env:"JWT_REFRESH_SECRET"`
 }
 
-func LoadConfig() Config {
+// MailConfig picks and configures the email provider
+type MailConfig struct {
+	Provider     string `yaml:"provider" env:"MAIL_PROVIDER"` // resend, smtp, file or log
+	From         string `yaml:"from" env:"MAIL_FROM"`
+	ReplyTo      string `yaml:"reply_to" env:"MAIL_REPLY_TO"`
+	SupportEmail string `yaml:"support_email" env:"SUPPORT_EMAIL"` // where contact form messages go
+	ResendAPIKey string `yaml:"resend_api_key" env:"RESEND_API_KEY"`
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     int    `yaml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD"`
+	DumpDir      string `yaml:"dump_dir" env:"MAIL_DUMP_DIR"`
+}
+
+// StripeConfig holds the API keys and the URLs checkout sends users to.
+// SuccessURL and CancelURL point at this API; the redirects at the web app.
+type StripeConfig struct {
+	SecretKey       string `yaml:"secret_key" env:"STRIPE_SECRET_KEY"`
+	WebhookSecret   string `yaml:"webhook_secret" env:"STRIPE_WEBHOOK_SECRET"`
+	APIURL          string `yaml:"api_url" env:"STRIPE_API_URL"` // a stand-in such as cmd/fakestripe; api.stripe.com when empty
+	SuccessURL      string `yaml:"success_url" env:"PAYMENT_SUCCESS_URL"`
+	CancelURL       string `yaml:"cancel_url" env:"PAYMENT_CANCEL_URL"`
+	SuccessRedirect string `yaml:"success_redirect" env:"PAYMENT_SUCCESS_REDIRECT"`
+	CancelRedirect  string `yaml:"cancel_redirect" env:"PAYMENT_CANCEL_REDIRECT"`
+}
 
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbName := os.Getenv("DB_NAME")
-	sslMode := os.Getenv("DB_SSLMODE")
-	localDbURL := os.Getenv("LOCAL_DB_URL")
+// Enabled reports whether checkout sessions can be created
+func (s StripeConfig) Enabled() bool {
+	return s.SecretKey != ""
+}
+
+// CloudinaryConfig is the account book covers are uploaded to
+type CloudinaryConfig struct {
+	CloudName string `yaml:"cloud_name" env:"CLOUDINARY_CLOUD_NAME"`
+	APIKey    string `yaml:"api_key" env:"CLOUDINARY_API_KEY"`
+	APISecret string `yaml:"api_secret" env:"CLOUDINARY_API_SECRET"`
+}
+
+// URL is the cloudinary:// URL the SDK is configured from
+func (c CloudinaryConfig) URL() string {
+	return fmt.Sprintf("cloudinary://%s:%s@%s", c.APIKey, c.APISecret, c.CloudName)
+}
+
+// ExportsConfig covers background exports and their download links
+type ExportsConfig struct {
+	PublicURL      string `yaml:"public_url" env:"PUBLIC_URL"` // base URL for links the API hands out, e.g. https://api.example.com
+	BlobStore      string `yaml:"blob_store" env:"BLOB_STORE"` // local (the default)
+	BlobDir        string `yaml:"blob_dir" env:"BLOB_DIR"`
+	SigningKey     string `yaml:"signing_key" env:"EXPORT_SIGNING_KEY"`
+	RetentionHours int    `yaml:"retention_hours" env:"EXPORT_RETENTION_HOURS"`
+}
+
+// Retention is how long finished exports are kept
+func (e ExportsConfig) Retention() time.Duration {
+	return time.Duration(e.RetentionHours) * time.Hour
+}
+
+// ObservabilityConfig covers metrics, tracing and logs
+type ObservabilityConfig struct {
+	MetricsToken       string  `yaml:"metrics_token" env:"METRICS_TOKEN"`       // bearer token for /metrics; open when empty, required in production
+	TracingExporter    string  `yaml:"tracing_exporter" env:"TRACING_EXPORTER"` // none, stdout or otlp
+	TracingSampleRatio float64 `yaml:"tracing_sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+	LogLevel           string  `yaml:"log_level" env:"LOG_LEVEL"`   // debug, info, warn or error
+	LogFormat          string  `yaml:"log_format" env:"LOG_FORMAT"` // json or text
+}
 
-	if sslMode == "" {
-		sslMode = "require"
+// FileEnv names the variable holding the optional YAML config file's path
+const FileEnv = "CONFIG_FILE"
+
+// Load reads the config from the profile named by APP_ENV (or the file's
+// env), the YAML file named by CONFIG_FILE if any, and the environment, and
+// validates it
+func Load() (Config, error) {
+	return load(os.LookupEnv)
+}
+
+// LoadDatabase reads the config the same way as Load but only validates the
+// database settings, for tools such as cmd/migrate that need nothing else
+func LoadDatabase() (DBConfig, error) {
+	cfg, problems, err := build(os.LookupEnv)
+	if err != nil {
+		return DBConfig{}, err
+	}
+	var v validator
+	cfg.validateDB(&v)
+	if problems = append(problems, v.problems...); len(problems) > 0 {
+		return cfg.DB, fmt.Errorf("invalid config:\n%w", errors.Join(problems...))
+	}
+	return cfg.DB, nil
+}
+
+func load(lookup func(string) (string, bool)) (Config, error) {
+	cfg, problems, err := build(lookup)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		problems = append(problems, err)
+	}
+	if len(problems) > 0 {
+		return cfg, fmt.Errorf("invalid config:\n%w", errors.Join(problems...))
+	}
+	return cfg, nil
+}
+
+// build layers the profile's defaults, the YAML file and the environment.
+// Values that do not parse come back as problems; err is for an unreadable
+// file or unknown profile, where nothing else can be trusted.
+func build(lookup func(string) (string, bool)) (cfg Config, problems []error, err error) {
+	var file []byte
+	if path, ok := lookup(FileEnv); ok && path != "" {
+		var err error
+		if file, err = os.ReadFile(path); err != nil {
+			return Config{}, nil, fmt.Errorf("read config file: %w", err)
+		}
 	}
 
-	// Use host for SSL verification, hostaddr forces IPv4
-	dbURL := fmt.Sprintf(
-		"postgresql://%s:%s@%s:%s/%s?sslmode=%s",
-		user, password, host, port, dbName, sslMode,
-	)
+	// The profile decides the defaults, so find it before anything else
+	env, _ := lookup("APP_ENV")
+	if env == "" && file != nil {
+		var head struct {
+			Env string `yaml:"env"`
+		}
+		if err := yaml.Unmarshal(file, &head); err != nil {
+			return Config{}, nil, fmt.Errorf("parse config file: %w", err)
+		}
+		env = head.Env
+	}
+	profile, err := ParseProfile(env)
+	if err != nil {
+		return Config{}, nil, err
+	}
 
-	return Config{
-		DBHost:     host,
-		DBPort:     port,
-		DBUser:     user,
-		DBPassword: password,
-		DBName:     dbName,
-		DBSSLMode:  sslMode,
-		DBURL:      dbURL,
-		LOCALDBURL: localDbURL,
+	cfg = Defaults(profile)
+	if file != nil {
+		// Unknown keys are errors so a typo does not silently fall back
+		// to a default
+		dec := yaml.NewDecoder(bytes.NewReader(file))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return Config{}, nil, fmt.Errorf("parse config file: %w", err)
+		}
 	}
+	problems = applyEnv(&cfg, lookup)
+	cfg.Env = string(profile)
+	cfg.finish()
+	return cfg, problems, nil
 }
 
+// finish fills in values derived from others
+func (c *Config) finish() {
+	if c.Mail.Provider == "" {
+		c.Mail.Provider = "log"
+		if c.Mail.ResendAPIKey != "" {
+			c.Mail.Provider = "resend"
+		}
+	}
+	if c.DB.URL == "" && c.DB.Host != "" {
+		c.DB.URL = (&url.URL{
+			Scheme:   "postgresql",
+			User:     url.UserPassword(c.DB.User, c.DB.Password),
+			Host:     hostPort(c.DB.Host, c.DB.Port),
+			Path:     "/" + c.DB.Name,
+			RawQuery: "sslmode=" + url.QueryEscape(c.DB.SSLMode),
+		}).String()
+	}
+	c.Exports.PublicURL = strings.TrimRight(c.Exports.PublicURL, "/")
+}
+
+func hostPort(host, port string) string {
+	if port == "" {
+		return host
+	}
+	return host + ":" + port
+}
+
+// Production reports whether the production profile is active
+func (c Config) Production() bool {
+	return Profile(c.Env) == Production
+}
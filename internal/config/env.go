@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// applyEnv sets every field tagged `env:"NAME"` whose variable is set and
+// not empty, walking nested structs. Values that do not parse are returned
+// as problems and leave the field as it was.
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) []error {
+	return applyEnvStruct(reflect.ValueOf(cfg).Elem(), lookup)
+}
+
+func applyEnvStruct(v reflect.Value, lookup func(string) (string, bool)) []error {
+	var problems []error
+	t := v.Type()
+	for i := range t.NumField() {
+		field := v.Field(i)
+		name, tagged := t.Field(i).Tag.Lookup("env")
+		if !tagged {
+			if field.Kind() == reflect.Struct {
+				problems = append(problems, applyEnvStruct(field, lookup)...)
+			}
+			continue
+		}
+		raw, ok := lookup(name)
+		if !ok || strings.TrimSpace(raw) == "" {
+			continue
+		}
+		if err := setField(field, strings.TrimSpace(raw)); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return problems
+}
+
+func setField(field reflect.Value, raw string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		// Comma-separated list
+		var items []string
+		for item := range strings.SplitSeq(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
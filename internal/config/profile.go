@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Profile is a named set of defaults
+type Profile string
+
+const (
+	Development Profile = "development"
+	Test        Profile = "test"
+	Production  Profile = "production"
+)
+
+// ParseProfile reads a profile name; dev and prod are accepted as short
+// forms. An empty name is production, so a deployment that never set APP_ENV
+// keeps secure cookies, TLS to Postgres and its own secrets; development has
+// to be asked for.
+func ParseProfile(name string) (Profile, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "dev", "development":
+		return Development, nil
+	case "test":
+		return Test, nil
+	case "", "prod", "production":
+		return Production, nil
+	}
+	return "", fmt.Errorf("unknown APP_ENV %q (use development, test or production)", name)
+}
+
+// Development-only JWT keys so a fresh checkout runs without setup.
+// Validate rejects them in production.
+const (
+	devJWTAccessSecret  = "super-secret-access-key"
+	devJWTRefreshSecret = "super-secret-refresh-key"
+)
+
+// Defaults returns the settings a profile starts from before the YAML file
+// and environment are applied
+func Defaults(p Profile) Config {
+	cfg := Config{
+		Env: string(p),
+		Server: ServerConfig{
+			Port:            "8080",
+			CORSOrigins:     []string{"http://localhost:3000"},
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Mail: MailConfig{
+			SMTPPort: 587,
+			DumpDir:  "tmp/mail",
+		},
+		Exports: ExportsConfig{
+			BlobStore:      "local",
+			BlobDir:        "tmp/exports",
+			RetentionHours: 7 * 24,
+		},
+		Observability: ObservabilityConfig{
+			TracingExporter:    "none",
+			TracingSampleRatio: 1,
+			LogLevel:           "info",
+			LogFormat:          "text",
+		},
+	}
+
+	switch p {
+	case Development:
+		cfg.DB.SSLMode = "prefer"
+		cfg.Auth = AuthConfig{JWTAccessSecret: devJWTAccessSecret, JWTRefreshSecret: devJWTRefreshSecret}
+	case Test:
+		cfg.DB.SSLMode = "disable"
+		cfg.Auth = AuthConfig{JWTAccessSecret: "test-access-secret", JWTRefreshSecret: "test-refresh-secret"}
+		cfg.Mail.Provider = "log"
+		cfg.Observability.LogLevel = "warn"
+	case Production:
+		// Secrets have no defaults here: Validate insists they are set
+		cfg.DB.SSLMode = "require"
+		cfg.Server.CORSOrigins = []string{"https://himel-s-library.vercel.app"}
+		cfg.Server.SecureCookies = true
+		cfg.Server.ShutdownDrainDelay = 10 * time.Second
+		cfg.Observability.LogFormat = "json"
+	}
+	return cfg
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// minSecretLength is the shortest JWT key accepted in production
+const minSecretLength = 32
+
+// Validate checks the config and returns every problem found, joined, or
+// nil. Problems are named after the environment variable that fixes them.
+func (c Config) Validate() error {
+	var v validator
+	prod := c.Production()
+
+	// Server
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		v.addf("PORT: invalid port %q", c.Server.Port)
+	}
+	if len(c.Server.CORSOrigins) == 0 {
+		v.addf("CORS_ORIGINS: at least one origin is required")
+	}
+	for _, origin := range c.Server.CORSOrigins {
+		if err := checkOrigin(origin); err != nil {
+			v.addf("CORS_ORIGINS: %v", err)
+		}
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		v.addf("SHUTDOWN_TIMEOUT: must be positive")
+	}
+	if c.Server.ShutdownDrainDelay < 0 {
+		v.addf("SHUTDOWN_DRAIN_DELAY: must not be negative")
+	}
+	if prod && !c.Server.SecureCookies {
+		v.addf("SECURE_COOKIES: must be true in production")
+	}
+
+	c.validateDB(&v)
+
+	// Auth
+	v.required("JWT_ACCESS_SECRET", c.Auth.JWTAccessSecret)
+	v.required("JWT_REFRESH_SECRET", c.Auth.JWTRefreshSecret)
+	if c.Auth.JWTAccessSecret != "" && c.Auth.JWTAccessSecret == c.Auth.JWTRefreshSecret {
+		v.addf("JWT_REFRESH_SECRET: must differ from JWT_ACCESS_SECRET")
+	}
+	if prod {
+		for _, s := range []struct{ name, secret string }{
+			{"JWT_ACCESS_SECRET", c.Auth.JWTAccessSecret},
+			{"JWT_REFRESH_SECRET", c.Auth.JWTRefreshSecret},
+		} {
+			if s.secret == devJWTAccessSecret || s.secret == devJWTRefreshSecret {
+				v.addf("%s: the development default cannot be used in production", s.name)
+			} else if s.secret != "" && len(s.secret) < minSecretLength {
+				v.addf("%s: must be at least %d characters in production", s.name, minSecretLength)
+			}
+		}
+	}
+
+	// Mail
+	switch strings.ToLower(c.Mail.Provider) {
+	case "resend":
+		v.required("RESEND_API_KEY", c.Mail.ResendAPIKey)
+	case "smtp":
+		v.required("SMTP_HOST", c.Mail.SMTPHost)
+		if c.Mail.SMTPPort < 1 || c.Mail.SMTPPort > 65535 {
+			v.addf("SMTP_PORT: invalid port %d", c.Mail.SMTPPort)
+		}
+	case "file", "log":
+		if prod {
+			v.addf("MAIL_PROVIDER: %q does not deliver email; use resend or smtp in production", c.Mail.Provider)
+		}
+	default:
+		v.addf("MAIL_PROVIDER: unknown provider %q (use resend, smtp, file or log)", c.Mail.Provider)
+	}
+
+	// Stripe: optional outside production, but all or nothing
+	if prod || c.Stripe.Enabled() {
+		v.required("STRIPE_SECRET_KEY", c.Stripe.SecretKey)
+		v.required("STRIPE_WEBHOOK_SECRET", c.Stripe.WebhookSecret)
+		v.url("PAYMENT_SUCCESS_URL", c.Stripe.SuccessURL, true)
+		v.url("PAYMENT_CANCEL_URL", c.Stripe.CancelURL, true)
+		v.url("PAYMENT_SUCCESS_REDIRECT", c.Stripe.SuccessRedirect, true)
+		v.url("PAYMENT_CANCEL_REDIRECT", c.Stripe.CancelRedirect, true)
+	}
+	if key := c.Stripe.SecretKey; key != "" && !strings.HasPrefix(key, "sk_") && !strings.HasPrefix(key, "rk_") {
+		v.addf("STRIPE_SECRET_KEY: expected an sk_ or rk_ key")
+	}
+	if prod && strings.Contains(c.Stripe.SecretKey, "_test_") {
+		v.addf("STRIPE_SECRET_KEY: a test mode key cannot be used in production")
+	}
+	v.url("STRIPE_API_URL", c.Stripe.APIURL, false)
+	if prod && c.Stripe.APIURL != "" {
+		v.addf("STRIPE_API_URL: production must talk to Stripe itself")
+	}
+
+	// Cloudinary: optional outside production, but all or nothing
+	cld := c.Cloudinary
+	if prod || cld.CloudName != "" || cld.APIKey != "" || cld.APISecret != "" {
+		v.required("CLOUDINARY_CLOUD_NAME", cld.CloudName)
+		v.required("CLOUDINARY_API_KEY", cld.APIKey)
+		v.required("CLOUDINARY_API_SECRET", cld.APISecret)
+	}
+
+	// Exports
+	v.url("PUBLIC_URL", c.Exports.PublicURL, prod)
+	if c.Exports.BlobStore != "local" {
+		v.addf("BLOB_STORE: unknown blob store %q (use local)", c.Exports.BlobStore)
+	}
+	v.required("BLOB_DIR", c.Exports.BlobDir)
+	if c.Exports.RetentionHours <= 0 {
+		v.addf("EXPORT_RETENTION_HOURS: must be positive")
+	}
+	if prod {
+		// Without it links are signed with a per-process key and break on
+		// every restart or across replicas
+		v.required("EXPORT_SIGNING_KEY", c.Exports.SigningKey)
+	}
+
+	// Observability
+	obs := c.Observability
+	if prod {
+		// The router serves /metrics to anyone when no token is set
+		v.required("METRICS_TOKEN", obs.MetricsToken)
+	}
+	if !slices.Contains([]string{"none", "stdout", "otlp"}, obs.TracingExporter) {
+		v.addf("TRACING_EXPORTER: unknown exporter %q (use none, stdout or otlp)", obs.TracingExporter)
+	}
+	if obs.TracingSampleRatio < 0 || obs.TracingSampleRatio > 1 {
+		v.addf("TRACING_SAMPLE_RATIO: must be between 0 and 1")
+	}
+	if !slices.Contains([]string{"debug", "info", "warn", "error"}, strings.ToLower(obs.LogLevel)) {
+		v.addf("LOG_LEVEL: unknown level %q (use debug, info, warn or error)", obs.LogLevel)
+	}
+	if !slices.Contains([]string{"json", "text"}, strings.ToLower(obs.LogFormat)) {
+		v.addf("LOG_FORMAT: unknown format %q (use json or text)", obs.LogFormat)
+	}
+
+	return errors.Join(v.problems...)
+}
+
+// validateDB checks the database settings; LoadDatabase runs only this
+func (c Config) validateDB(v *validator) {
+	if c.DB.URL == "" {
+		v.addf("DB_URL or DB_HOST: a database is required")
+	} else if u, err := url.Parse(c.DB.URL); err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		v.addf("DB_URL: not a postgres:// URL")
+	} else if c.Production() {
+		switch mode := u.Query().Get("sslmode"); mode {
+		case "", "disable", "allow", "prefer":
+			v.addf("DB_SSLMODE: %q does not enforce TLS; use require or verify-full in production", mode)
+		}
+	}
+}
+
+// validator collects problems instead of stopping at the first
+type validator struct {
+	problems []error
+}
+
+func (v *validator) addf(format string, args ...any) {
+	v.problems = append(v.problems, fmt.Errorf(format, args...))
+}
+
+func (v *validator) required(name, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.addf("%s: required", name)
+	}
+}
+
+// url checks value is an absolute http(s) URL; empty is fine unless required
+func (v *validator) url(name, value string, required bool) {
+	if value == "" {
+		if required {
+			v.addf("%s: required", name)
+		}
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.addf("%s: %q is not an absolute http(s) URL", name, value)
+	}
+}
+
+// checkOrigin accepts scheme://host[:port] and nothing else: the browser's
+// Origin header never has a path or trailing slash, so those never match,
+// and "*" cannot be combined with credentials
+func checkOrigin(origin string) error {
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("%q is not an origin like https://example.com", origin)
+	}
+	return nil
+}
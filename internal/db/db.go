@@ -2,24 +2,20 @@ package db
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
 
-	"github.com/THEGunDevil/GoForBackend/internal/config"
-	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/telemetry"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-var (
-	DB  *pgxpool.Pool
-	Ctx = context.Background()
-	Q   *gen.Queries
-)
-
-func Connect(cfg config.Config) {
-	poolConfig, err := pgxpool.ParseConfig(cfg.DBURL)
+// Connect opens a pool on url and checks it with a ping. Wrap the pool with
+// NewStore for queries; close it on shutdown.
+func Connect(ctx context.Context, url string) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(url)
 	if err != nil {
-		log.Fatalf("❌ Failed to parse DB config: %v", err)
+		return nil, fmt.Errorf("failed to parse DB config: %w", err)
 	}
 
 	// 🚫 Disable prepared statement caching
@@ -28,48 +24,19 @@ func Connect(cfg config.Config) {
 	// 🧩 Force simple protocol (no prepared statements)
 	poolConfig.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
 
-	pool, err := pgxpool.NewWithConfig(Ctx, poolConfig)
-	if err != nil {
-		log.Fatalf("❌ Unable to connect to database: %v", err)
-	}
-
-	if err := pool.Ping(Ctx); err != nil {
-		log.Fatalf("❌ Could not ping database: %v", err)
-	}
+	// 🔭 A span per query, named after the sqlc query
+	poolConfig.ConnConfig.Tracer = telemetry.QueryTracer{}
 
-	DB = pool
-	Q = gen.New(pool)
-	log.Println("✅ Connected to Postgres successfully (no prepared statement caching)")
-}
-func LocalConnect(cfg config.Config) {
-	poolConfig, err := pgxpool.ParseConfig(cfg.LOCALDBURL)
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
-		log.Fatalf("❌ Failed to parse DB config: %v", err)
+		return nil, fmt.Errorf("unable to connect to database: %w", err)
 	}
 
-	// 🚫 Disable prepared statement caching
-	poolConfig.ConnConfig.StatementCacheCapacity = 0
-
-	// 🧩 Force simple protocol (no prepared statements)
-	poolConfig.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
-
-	pool, err := pgxpool.NewWithConfig(Ctx, poolConfig)
-	if err != nil {
-		log.Fatalf("❌ Unable to connect to database: %v", err)
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("could not ping database: %w", err)
 	}
 
-	if err := pool.Ping(Ctx); err != nil {
-		log.Fatalf("❌ Could not ping database: %v", err)
-	}
-
-	DB = pool
-	Q = gen.New(pool)
-	log.Println("✅ Connected to Postgres successfully (no prepared statement caching)")
-}
-
-func Close() {
-	if DB != nil {
-		DB.Close()
-		log.Println("🛑 Database connection closed")
-	}
+	slog.Info("connected to postgres", "statement_cache", false)
+	return pool, nil
 }
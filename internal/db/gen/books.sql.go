@@ -13,10 +13,11 @@ import (
 
 const countBooks = `-- name: CountBooks :one
 SELECT COUNT(*) FROM books
+WHERE ($1::boolean OR deleted_at IS NULL)
 `
 
-func (q *Queries) CountBooks(ctx context.Context) (int64, error) {
-	row := q.db.QueryRow(ctx, countBooks)
+func (q *Queries) CountBooks(ctx context.Context, dollar_1 bool) (int64, error) {
+	row := q.db.QueryRow(ctx, countBooks, dollar_1)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -24,10 +25,21 @@ func (q *Queries) CountBooks(ctx context.Context) (int64, error) {
 
 const countBooksByGenre = `-- name: CountBooksByGenre :one
 SELECT COUNT(*) FROM books
-WHERE ($1 = 'all' OR genre = $1)
+WHERE (
+    $1::text = 'all'
+    OR EXISTS (
+        SELECT 1
+        FROM book_genres bg
+        JOIN genres g ON g.id = bg.genre_id
+        LEFT JOIN genres p ON p.id = g.parent_id
+        WHERE bg.book_id = books.id
+          AND (g.slug = $1::text OR p.slug = $1::text)
+    )
+)
+AND deleted_at IS NULL
 `
 
-func (q *Queries) CountBooksByGenre(ctx context.Context, dollar_1 interface{}) (int64, error) {
+func (q *Queries) CountBooksByGenre(ctx context.Context, dollar_1 string) (int64, error) {
 	row := q.db.QueryRow(ctx, countBooksByGenre, dollar_1)
 	var count int64
 	err := row.Scan(&count)
@@ -41,14 +53,33 @@ WHERE
     (
         $1::text = ''
         OR $1::text = 'all'
-        OR genre ILIKE '%' || $1 || '%'
+        OR EXISTS (
+            SELECT 1
+            FROM book_genres bg
+            JOIN genres g ON g.id = bg.genre_id
+            LEFT JOIN genres p ON p.id = g.parent_id
+            WHERE bg.book_id = books.id
+              AND (g.slug = $1::text OR p.slug = $1::text)
+        )
     )
     AND (
         $2::text = ''
         OR title ILIKE '%' || $2 || '%'
-        OR author ILIKE '%' || $2 || '%'
         OR description ILIKE '%' || $2 || '%'
+        OR EXISTS (
+            SELECT 1
+            FROM book_authors ba
+            JOIN authors a ON a.id = ba.author_id
+            WHERE ba.book_id = books.id AND a.name ILIKE '%' || $2 || '%'
+        )
+        OR EXISTS (
+            SELECT 1
+            FROM book_series bs
+            JOIN series s ON s.id = bs.series_id
+            WHERE bs.book_id = books.id AND s.name ILIKE '%' || $2 || '%'
+        )
     )
+    AND deleted_at IS NULL
 `
 
 type CountSearchBooksParams struct {
@@ -66,7 +97,7 @@ func (q *Queries) CountSearchBooks(ctx context.Context, arg CountSearchBooksPara
 const createBook = `-- name: CreateBook :one
 INSERT INTO books (title, author, published_year, isbn, total_copies, image_url, genre, description)
 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, title, author, description, genre, published_year, isbn, total_copies, available_copies, created_at, updated_at, image_url
+RETURNING id, title, author, description, genre, published_year, isbn, total_copies, available_copies, created_at, updated_at, image_url, deleted_at
 `
 
 type CreateBookParams struct {
@@ -105,6 +136,7 @@ func (q *Queries) CreateBook(ctx context.Context, arg CreateBookParams) (Book, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.ImageUrl,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -112,7 +144,7 @@ func (q *Queries) CreateBook(ctx context.Context, arg CreateBookParams) (Book, e
 const decrementAvailableCopiesByID = `-- name: DecrementAvailableCopiesByID :one
 UPDATE books
 SET available_copies = available_copies - 1
-WHERE id = $1 AND available_copies > 0
+WHERE id = $1 AND available_copies > 0 AND deleted_at IS NULL
 RETURNING available_copies
 `
 
@@ -123,45 +155,31 @@ func (q *Queries) DecrementAvailableCopiesByID(ctx context.Context, id pgtype.UU
 	return available_copies, err
 }
 
-const deleteBookByID = `-- name: DeleteBookByID :one
-DELETE FROM books
-WHERE id = $1
-RETURNING id, title, author, description, genre, published_year, isbn, total_copies, available_copies, created_at, updated_at, image_url
-`
-
-func (q *Queries) DeleteBookByID(ctx context.Context, id pgtype.UUID) (Book, error) {
-	row := q.db.QueryRow(ctx, deleteBookByID, id)
-	var i Book
-	err := row.Scan(
-		&i.ID,
-		&i.Title,
-		&i.Author,
-		&i.Description,
-		&i.Genre,
-		&i.PublishedYear,
-		&i.Isbn,
-		&i.TotalCopies,
-		&i.AvailableCopies,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.ImageUrl,
-	)
-	return i, err
-}
-
 const filterBooksByGenre = `-- name: FilterBooksByGenre :many
-SELECT id, title, author, description, genre, published_year, isbn, total_copies, available_copies, created_at, updated_at, image_url FROM books
-WHERE ($1 = 'all' OR genre = $1)
+SELECT id, title, author, description, genre, published_year, isbn, total_copies, available_copies, created_at, updated_at, image_url, deleted_at FROM books
+WHERE (
+    $1::text = 'all'
+    OR EXISTS (
+        SELECT 1
+        FROM book_genres bg
+        JOIN genres g ON g.id = bg.genre_id
+        LEFT JOIN genres p ON p.id = g.parent_id
+        WHERE bg.book_id = books.id
+          AND (g.slug = $1::text OR p.slug = $1::text)
+    )
+)
+AND deleted_at IS NULL
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3
 `
 
 type FilterBooksByGenreParams struct {
-	Column1 interface{} `json:"column_1"`
-	Limit   int32       `json:"limit"`
-	Offset  int32       `json:"offset"`
+	Column1 string `json:"column_1"`
+	Limit   int32  `json:"limit"`
+	Offset  int32  `json:"offset"`
 }
 
+// $1 is a genre slug; books filed under a child genre match its parent too.
 func (q *Queries) FilterBooksByGenre(ctx context.Context, arg FilterBooksByGenreParams) ([]Book, error) {
 	rows, err := q.db.Query(ctx, filterBooksByGenre, arg.Column1, arg.Limit, arg.Offset)
 	if err != nil {
@@ -184,6 +202,7 @@ func (q *Queries) FilterBooksByGenre(ctx context.Context, arg FilterBooksByGenre
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.ImageUrl,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -196,7 +215,7 @@ func (q *Queries) FilterBooksByGenre(ctx context.Context, arg FilterBooksByGenre
 }
 
 const getBookByID = `-- name: GetBookByID :one
-SELECT id, title, author, description, genre, published_year, isbn, total_copies, available_copies, created_at, updated_at, image_url FROM books
+SELECT id, title, author, description, genre, published_year, isbn, total_copies, available_copies, created_at, updated_at, image_url, deleted_at FROM books
 WHERE id = $1
 `
 
@@ -216,6 +235,61 @@ func (q *Queries) GetBookByID(ctx context.Context, id pgtype.UUID) (Book, error)
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.ImageUrl,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getBookByIDForUpdate = `-- name: GetBookByIDForUpdate :one
+SELECT id, title, author, description, genre, published_year, isbn, total_copies, available_copies, created_at, updated_at, image_url, deleted_at FROM books
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE
+`
+
+// Locks a book that is not deleted until the transaction ends.
+func (q *Queries) GetBookByIDForUpdate(ctx context.Context, id pgtype.UUID) (Book, error) {
+	row := q.db.QueryRow(ctx, getBookByIDForUpdate, id)
+	var i Book
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Author,
+		&i.Description,
+		&i.Genre,
+		&i.PublishedYear,
+		&i.Isbn,
+		&i.TotalCopies,
+		&i.AvailableCopies,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ImageUrl,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getBookByISBN = `-- name: GetBookByISBN :one
+SELECT id, title, author, description, genre, published_year, isbn, total_copies, available_copies, created_at, updated_at, image_url, deleted_at FROM books
+WHERE isbn = $1
+`
+
+func (q *Queries) GetBookByISBN(ctx context.Context, isbn pgtype.Text) (Book, error) {
+	row := q.db.QueryRow(ctx, getBookByISBN, isbn)
+	var i Book
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Author,
+		&i.Description,
+		&i.Genre,
+		&i.PublishedYear,
+		&i.Isbn,
+		&i.TotalCopies,
+		&i.AvailableCopies,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ImageUrl,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -234,20 +308,72 @@ func (q *Queries) IncrementAvailableCopiesByID(ctx context.Context, id pgtype.UU
 	return available_copies, err
 }
 
+const listAllBooks = `-- name: ListAllBooks :many
+SELECT id, title, author, description, genre, published_year, isbn, total_copies, available_copies, created_at, updated_at, image_url, deleted_at FROM books
+WHERE deleted_at IS NULL
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListAllBooks(ctx context.Context) ([]Book, error) {
+	rows, err := q.db.Query(ctx, listAllBooks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Book
+	for rows.Next() {
+		var i Book
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Author,
+			&i.Description,
+			&i.Genre,
+			&i.PublishedYear,
+			&i.Isbn,
+			&i.TotalCopies,
+			&i.AvailableCopies,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ImageUrl,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listBooksPaginated = `-- name: ListBooksPaginated :many
-SELECT id, title, author, description, genre, published_year, isbn, total_copies, available_copies, created_at, updated_at, image_url
+SELECT id, title, author, description, genre, published_year, isbn, total_copies, available_copies, created_at, updated_at, image_url, deleted_at
 FROM books
+WHERE ($3::boolean OR deleted_at IS NULL)
 ORDER BY created_at DESC
-LIMIT $1 OFFSET $2
+LIMIT $1 OFFSET $2;
+
+-- ListBooksPaginatedFiltered :many
+SELECT id, title, author, description, genre, published_year, isbn, total_copies, available_copies, created_at, updated_at, image_url, deleted_at
+FROM books
+WHERE
+    ($1::text = '' OR title ILIKE '%' || $1 || '%')
+AND
+    ($2::text = 'all' OR genre = $2)
+ORDER BY created_at DESC
+LIMIT $3 OFFSET $4
 `
 
 type ListBooksPaginatedParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	Limit   int32 `json:"limit"`
+	Offset  int32 `json:"offset"`
+	Column3 bool  `json:"column_3"`
 }
 
 func (q *Queries) ListBooksPaginated(ctx context.Context, arg ListBooksPaginatedParams) ([]Book, error) {
-	rows, err := q.db.Query(ctx, listBooksPaginated, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listBooksPaginated, arg.Limit, arg.Offset, arg.Column3)
 	if err != nil {
 		return nil, err
 	}
@@ -268,6 +394,7 @@ func (q *Queries) ListBooksPaginated(ctx context.Context, arg ListBooksPaginated
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.ImageUrl,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -280,10 +407,10 @@ func (q *Queries) ListBooksPaginated(ctx context.Context, arg ListBooksPaginated
 }
 
 const listGenres = `-- name: ListGenres :many
-SELECT DISTINCT genre
-FROM books
-WHERE genre IS NOT NULL AND genre <> ''
-ORDER BY genre
+SELECT g.name
+FROM genres g
+WHERE EXISTS (SELECT 1 FROM book_genres bg WHERE bg.genre_id = g.id)
+ORDER BY g.name
 `
 
 func (q *Queries) ListGenres(ctx context.Context) ([]string, error) {
@@ -306,6 +433,63 @@ func (q *Queries) ListGenres(ctx context.Context) ([]string, error) {
 	return items, nil
 }
 
+const purgeBookByID = `-- name: PurgeBookByID :one
+DELETE FROM books
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, title, author, description, genre, published_year, isbn, total_copies, available_copies, created_at, updated_at, image_url, deleted_at
+`
+
+// Permanently removes a soft-deleted book together with its history.
+func (q *Queries) PurgeBookByID(ctx context.Context, id pgtype.UUID) (Book, error) {
+	row := q.db.QueryRow(ctx, purgeBookByID, id)
+	var i Book
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Author,
+		&i.Description,
+		&i.Genre,
+		&i.PublishedYear,
+		&i.Isbn,
+		&i.TotalCopies,
+		&i.AvailableCopies,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ImageUrl,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const restoreBookByID = `-- name: RestoreBookByID :one
+UPDATE books
+SET deleted_at = NULL,
+    updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, title, author, description, genre, published_year, isbn, total_copies, available_copies, created_at, updated_at, image_url, deleted_at
+`
+
+func (q *Queries) RestoreBookByID(ctx context.Context, id pgtype.UUID) (Book, error) {
+	row := q.db.QueryRow(ctx, restoreBookByID, id)
+	var i Book
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Author,
+		&i.Description,
+		&i.Genre,
+		&i.PublishedYear,
+		&i.Isbn,
+		&i.TotalCopies,
+		&i.AvailableCopies,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ImageUrl,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
 const searchBooks = `-- name: SearchBooks :many
 SELECT
     id,
@@ -324,6 +508,7 @@ FROM books
 WHERE
     ($1::text IS NULL OR genre ILIKE '%' || $1 || '%')
     AND ($2::text IS NULL OR title ILIKE '%' || $2 || '%' OR author ILIKE '%' || $2 || '%')
+    AND deleted_at IS NULL
 ORDER BY title
 LIMIT $3
 OFFSET $4
@@ -408,14 +593,33 @@ WHERE
     (
         $1::text = ''
         OR $1::text = 'all'
-        OR genre ILIKE '%' || $1 || '%'
+        OR EXISTS (
+            SELECT 1
+            FROM book_genres bg
+            JOIN genres g ON g.id = bg.genre_id
+            LEFT JOIN genres p ON p.id = g.parent_id
+            WHERE bg.book_id = books.id
+              AND (g.slug = $1::text OR p.slug = $1::text)
+        )
     )
     AND (
         $2::text = ''
         OR title ILIKE '%' || $2 || '%'
-        OR author ILIKE '%' || $2 || '%'
         OR description ILIKE '%' || $2 || '%'
+        OR EXISTS (
+            SELECT 1
+            FROM book_authors ba
+            JOIN authors a ON a.id = ba.author_id
+            WHERE ba.book_id = books.id AND a.name ILIKE '%' || $2 || '%'
+        )
+        OR EXISTS (
+            SELECT 1
+            FROM book_series bs
+            JOIN series s ON s.id = bs.series_id
+            WHERE bs.book_id = books.id AND s.name ILIKE '%' || $2 || '%'
+        )
     )
+    AND deleted_at IS NULL
 ORDER BY title
 LIMIT $3
 OFFSET $4
@@ -481,6 +685,35 @@ func (q *Queries) SearchBooksWithPagination(ctx context.Context, arg SearchBooks
 	return items, nil
 }
 
+const softDeleteBookByID = `-- name: SoftDeleteBookByID :one
+UPDATE books
+SET deleted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, title, author, description, genre, published_year, isbn, total_copies, available_copies, created_at, updated_at, image_url, deleted_at
+`
+
+func (q *Queries) SoftDeleteBookByID(ctx context.Context, id pgtype.UUID) (Book, error) {
+	row := q.db.QueryRow(ctx, softDeleteBookByID, id)
+	var i Book
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Author,
+		&i.Description,
+		&i.Genre,
+		&i.PublishedYear,
+		&i.Isbn,
+		&i.TotalCopies,
+		&i.AvailableCopies,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ImageUrl,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
 const updateBookByID = `-- name: UpdateBookByID :one
 UPDATE books
 SET
@@ -495,7 +728,7 @@ SET
     image_url = COALESCE($9, image_url),
     updated_at = NOW()
 WHERE id = $10
-RETURNING id, title, author, description, genre, published_year, isbn, total_copies, available_copies, created_at, updated_at, image_url
+RETURNING id, title, author, description, genre, published_year, isbn, total_copies, available_copies, created_at, updated_at, image_url, deleted_at
 `
 
 type UpdateBookByIDParams struct {
@@ -538,6 +771,7 @@ func (q *Queries) UpdateBookByID(ctx context.Context, arg UpdateBookByIDParams)
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.ImageUrl,
+		&i.DeletedAt,
 	)
 	return i, err
 }
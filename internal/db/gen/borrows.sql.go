@@ -11,6 +11,50 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const claimBorrowDueReminder = `-- name: ClaimBorrowDueReminder :one
+INSERT INTO borrow_due_reminders (borrow_id)
+VALUES ($1)
+ON CONFLICT (borrow_id) DO NOTHING
+RETURNING borrow_id
+`
+
+// Records the reminder for a borrow; no row comes back when it already has one.
+func (q *Queries) ClaimBorrowDueReminder(ctx context.Context, borrowID pgtype.UUID) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, claimBorrowDueReminder, borrowID)
+	var borrow_id pgtype.UUID
+	err := row.Scan(&borrow_id)
+	return borrow_id, err
+}
+
+const claimBorrowOverdueNotice = `-- name: ClaimBorrowOverdueNotice :one
+INSERT INTO borrow_overdue_notices (borrow_id)
+VALUES ($1)
+ON CONFLICT (borrow_id) DO NOTHING
+RETURNING borrow_id
+`
+
+// Records the overdue notice for a borrow; no row comes back when it already has one.
+func (q *Queries) ClaimBorrowOverdueNotice(ctx context.Context, borrowID pgtype.UUID) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, claimBorrowOverdueNotice, borrowID)
+	var borrow_id pgtype.UUID
+	err := row.Scan(&borrow_id)
+	return borrow_id, err
+}
+
+const countActiveBorrowsByBookID = `-- name: CountActiveBorrowsByBookID :one
+SELECT COUNT(*)
+FROM borrows
+WHERE book_id = $1
+AND returned_at IS NULL
+`
+
+func (q *Queries) CountActiveBorrowsByBookID(ctx context.Context, bookID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countActiveBorrowsByBookID, bookID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countActiveBorrowsByUserID = `-- name: CountActiveBorrowsByUserID :one
 SELECT COUNT(*)
 FROM borrows
@@ -536,6 +580,111 @@ func (q *Queries) ListBorrowPaginatedByReturnedAt(ctx context.Context, arg ListB
 	return items, nil
 }
 
+const listBorrowsDueSoon = `-- name: ListBorrowsDueSoon :many
+SELECT
+    b.id,
+    b.user_id,
+    b.book_id,
+    b.due_date,
+    bk.title
+FROM borrows b
+JOIN books bk ON bk.id = b.book_id
+LEFT JOIN borrow_due_reminders r ON r.borrow_id = b.id
+WHERE b.returned_at IS NULL
+  AND b.due_date IS NOT NULL
+  AND b.due_date > NOW()
+  AND b.due_date <= $1::timestamp
+  AND r.borrow_id IS NULL
+ORDER BY b.due_date
+`
+
+type ListBorrowsDueSoonRow struct {
+	ID      pgtype.UUID      `json:"id"`
+	UserID  pgtype.UUID      `json:"user_id"`
+	BookID  pgtype.UUID      `json:"book_id"`
+	DueDate pgtype.Timestamp `json:"due_date"`
+	Title   string           `json:"title"`
+}
+
+// Open borrows due before the cutoff that have not been reminded yet.
+func (q *Queries) ListBorrowsDueSoon(ctx context.Context, dueBefore pgtype.Timestamp) ([]ListBorrowsDueSoonRow, error) {
+	rows, err := q.db.Query(ctx, listBorrowsDueSoon, dueBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBorrowsDueSoonRow
+	for rows.Next() {
+		var i ListBorrowsDueSoonRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.BookID,
+			&i.DueDate,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOverdueBorrowsToNotify = `-- name: ListOverdueBorrowsToNotify :many
+SELECT
+    b.id,
+    b.user_id,
+    b.book_id,
+    b.due_date,
+    bk.title
+FROM borrows b
+JOIN books bk ON bk.id = b.book_id
+LEFT JOIN borrow_overdue_notices n ON n.borrow_id = b.id
+WHERE b.returned_at IS NULL
+  AND b.due_date IS NOT NULL
+  AND b.due_date <= NOW()
+  AND n.borrow_id IS NULL
+ORDER BY b.due_date
+`
+
+type ListOverdueBorrowsToNotifyRow struct {
+	ID      pgtype.UUID      `json:"id"`
+	UserID  pgtype.UUID      `json:"user_id"`
+	BookID  pgtype.UUID      `json:"book_id"`
+	DueDate pgtype.Timestamp `json:"due_date"`
+	Title   string           `json:"title"`
+}
+
+// Open borrows past their due date that have not had an overdue notice yet.
+func (q *Queries) ListOverdueBorrowsToNotify(ctx context.Context) ([]ListOverdueBorrowsToNotifyRow, error) {
+	rows, err := q.db.Query(ctx, listOverdueBorrowsToNotify)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOverdueBorrowsToNotifyRow
+	for rows.Next() {
+		var i ListOverdueBorrowsToNotifyRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.BookID,
+			&i.DueDate,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const searchBorrowsWithPagination = `-- name: SearchBorrowsWithPagination :many
 SELECT 
     b.id, 
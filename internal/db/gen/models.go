@@ -8,6 +8,46 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type Announcement struct {
+	ID             pgtype.UUID      `json:"id"`
+	Title          string           `json:"title"`
+	Message        string           `json:"message"`
+	Audience       string           `json:"audience"`
+	AudienceRole   pgtype.Text      `json:"audience_role"`
+	UserIds        []pgtype.UUID    `json:"user_ids"`
+	Priority       string           `json:"priority"`
+	PublishAt      pgtype.Timestamp `json:"publish_at"`
+	ExpiresAt      pgtype.Timestamp `json:"expires_at"`
+	Status         string           `json:"status"`
+	PublishedAt    pgtype.Timestamp `json:"published_at"`
+	RecipientCount int32            `json:"recipient_count"`
+	CreatedBy      pgtype.UUID      `json:"created_by"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+	UpdatedAt      pgtype.Timestamp `json:"updated_at"`
+}
+
+type AuditLog struct {
+	ID         pgtype.UUID      `json:"id"`
+	ActorID    pgtype.UUID      `json:"actor_id"`
+	Action     string           `json:"action"`
+	TargetType string           `json:"target_type"`
+	TargetID   string           `json:"target_id"`
+	Before     []byte           `json:"before"`
+	After      []byte           `json:"after"`
+	Ip         pgtype.Text      `json:"ip"`
+	RequestID  pgtype.Text      `json:"request_id"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
+type Author struct {
+	ID        pgtype.UUID      `json:"id"`
+	Name      string           `json:"name"`
+	SortName  string           `json:"sort_name"`
+	Bio       string           `json:"bio"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+	UpdatedAt pgtype.Timestamp `json:"updated_at"`
+}
+
 type Book struct {
 	ID              pgtype.UUID      `json:"id"`
 	Title           string           `json:"title"`
@@ -21,6 +61,25 @@ type Book struct {
 	CreatedAt       pgtype.Timestamp `json:"created_at"`
 	UpdatedAt       pgtype.Timestamp `json:"updated_at"`
 	ImageUrl        string           `json:"image_url"`
+	DeletedAt       pgtype.Timestamp `json:"deleted_at"`
+}
+
+type BookAuthor struct {
+	BookID   pgtype.UUID `json:"book_id"`
+	AuthorID pgtype.UUID `json:"author_id"`
+	Role     string      `json:"role"`
+	Position int32       `json:"position"`
+}
+
+type BookGenre struct {
+	BookID  pgtype.UUID `json:"book_id"`
+	GenreID pgtype.UUID `json:"genre_id"`
+}
+
+type BookSeries struct {
+	BookID   pgtype.UUID   `json:"book_id"`
+	SeriesID pgtype.UUID   `json:"series_id"`
+	Position pgtype.Float8 `json:"position"`
 }
 
 type Borrow struct {
@@ -32,6 +91,35 @@ type Borrow struct {
 	ReturnedAt pgtype.Timestamp `json:"returned_at"`
 }
 
+type BorrowDueReminder struct {
+	BorrowID pgtype.UUID      `json:"borrow_id"`
+	SentAt   pgtype.Timestamp `json:"sent_at"`
+}
+
+type BorrowOverdueNotice struct {
+	BorrowID pgtype.UUID      `json:"borrow_id"`
+	SentAt   pgtype.Timestamp `json:"sent_at"`
+}
+
+type EmailOutbox struct {
+	ID            pgtype.UUID      `json:"id"`
+	Template      string           `json:"template"`
+	ToAddresses   []string         `json:"to_addresses"`
+	ReplyTo       pgtype.Text      `json:"reply_to"`
+	Subject       string           `json:"subject"`
+	TextBody      string           `json:"text_body"`
+	HtmlBody      pgtype.Text      `json:"html_body"`
+	Status        string           `json:"status"`
+	Attempts      int32            `json:"attempts"`
+	MaxAttempts   int32            `json:"max_attempts"`
+	NextAttemptAt pgtype.Timestamp `json:"next_attempt_at"`
+	LockedAt      pgtype.Timestamp `json:"locked_at"`
+	LastError     pgtype.Text      `json:"last_error"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+	SentAt        pgtype.Timestamp `json:"sent_at"`
+	Attachments   []byte           `json:"attachments"`
+}
+
 type Event struct {
 	ID          pgtype.UUID      `json:"id"`
 	ObjectID    pgtype.UUID      `json:"object_id"`
@@ -41,6 +129,74 @@ type Event struct {
 	Message     string           `json:"message"`
 	Metadata    []byte           `json:"metadata"`
 	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	UserID      pgtype.UUID      `json:"user_id"`
+	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
+}
+
+type ExportJob struct {
+	ID          pgtype.UUID      `json:"id"`
+	UserID      pgtype.UUID      `json:"user_id"`
+	Report      string           `json:"report"`
+	Format      string           `json:"format"`
+	Filters     []byte           `json:"filters"`
+	Status      string           `json:"status"`
+	BlobKey     pgtype.Text      `json:"blob_key"`
+	Filename    pgtype.Text      `json:"filename"`
+	SizeBytes   pgtype.Int8      `json:"size_bytes"`
+	Error       pgtype.Text      `json:"error"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	StartedAt   pgtype.Timestamp `json:"started_at"`
+	FinishedAt  pgtype.Timestamp `json:"finished_at"`
+	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
+	HeartbeatAt pgtype.Timestamp `json:"heartbeat_at"`
+}
+
+type Genre struct {
+	ID          pgtype.UUID      `json:"id"`
+	Name        string           `json:"name"`
+	Slug        string           `json:"slug"`
+	ParentID    pgtype.UUID      `json:"parent_id"`
+	Description string           `json:"description"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
+}
+
+type Invoice struct {
+	ID        pgtype.UUID      `json:"id"`
+	Kind      string           `json:"kind"`
+	Number    string           `json:"number"`
+	PaymentID pgtype.UUID      `json:"payment_id"`
+	RefundID  pgtype.UUID      `json:"refund_id"`
+	IssuedAt  pgtype.Timestamp `json:"issued_at"`
+}
+
+type InvoiceCounter struct {
+	Kind       string `json:"kind"`
+	LastNumber int64  `json:"last_number"`
+}
+
+type JobRun struct {
+	Name      string           `json:"name"`
+	LastRunAt pgtype.Timestamp `json:"last_run_at"`
+}
+
+type NotificationDigestItem struct {
+	ID        pgtype.UUID      `json:"id"`
+	UserID    pgtype.UUID      `json:"user_id"`
+	Type      string           `json:"type"`
+	Title     string           `json:"title"`
+	Message   string           `json:"message"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+	SentAt    pgtype.Timestamp `json:"sent_at"`
+}
+
+type NotificationPreference struct {
+	UserID    pgtype.UUID      `json:"user_id"`
+	Type      string           `json:"type"`
+	InApp     bool             `json:"in_app"`
+	Email     bool             `json:"email"`
+	Digest    bool             `json:"digest"`
+	UpdatedAt pgtype.Timestamp `json:"updated_at"`
 }
 
 type Payment struct {
@@ -91,6 +247,14 @@ type Review struct {
 	UpdatedAt pgtype.Timestamp `json:"updated_at"`
 }
 
+type Series struct {
+	ID          pgtype.UUID      `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
+}
+
 type Subscription struct {
 	ID        pgtype.UUID      `json:"id"`
 	UserID    pgtype.UUID      `json:"user_id"`
@@ -114,6 +278,31 @@ type SubscriptionPlan struct {
 	UpdatedAt    pgtype.Timestamp `json:"updated_at"`
 }
 
+type SupportTicket struct {
+	ID            pgtype.UUID      `json:"id"`
+	UserID        pgtype.UUID      `json:"user_id"`
+	Name          string           `json:"name"`
+	Email         string           `json:"email"`
+	Subject       string           `json:"subject"`
+	Category      string           `json:"category"`
+	Status        string           `json:"status"`
+	Priority      string           `json:"priority"`
+	AssignedTo    pgtype.UUID      `json:"assigned_to"`
+	LastMessageAt pgtype.Timestamp `json:"last_message_at"`
+	ClosedAt      pgtype.Timestamp `json:"closed_at"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+	UpdatedAt     pgtype.Timestamp `json:"updated_at"`
+}
+
+type SupportTicketMessage struct {
+	ID        pgtype.UUID      `json:"id"`
+	TicketID  pgtype.UUID      `json:"ticket_id"`
+	AuthorID  pgtype.UUID      `json:"author_id"`
+	IsStaff   bool             `json:"is_staff"`
+	Body      string           `json:"body"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
 type User struct {
 	ID                 pgtype.UUID      `json:"id"`
 	FirstName          string           `json:"first_name"`
@@ -132,13 +321,15 @@ type User struct {
 	BanReason          pgtype.Text      `json:"ban_reason"`
 	BanUntil           pgtype.Timestamp `json:"ban_until"`
 	IsPermanentBan     pgtype.Bool      `json:"is_permanent_ban"`
+	DeletedAt          pgtype.Timestamp `json:"deleted_at"`
 }
 
 type UserNotificationStatus struct {
-	ID        pgtype.UUID      `json:"id"`
-	UserID    pgtype.UUID      `json:"user_id"`
-	EventID   pgtype.UUID      `json:"event_id"`
-	IsRead    pgtype.Bool      `json:"is_read"`
-	ReadAt    pgtype.Timestamp `json:"read_at"`
-	CreatedAt pgtype.Timestamp `json:"created_at"`
+	ID          pgtype.UUID      `json:"id"`
+	UserID      pgtype.UUID      `json:"user_id"`
+	EventID     pgtype.UUID      `json:"event_id"`
+	IsRead      pgtype.Bool      `json:"is_read"`
+	ReadAt      pgtype.Timestamp `json:"read_at"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	DismissedAt pgtype.Timestamp `json:"dismissed_at"`
 }
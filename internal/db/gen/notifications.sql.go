@@ -31,6 +31,26 @@ func (q *Queries) AssignNotificationToUser(ctx context.Context, arg AssignNotifi
 	return err
 }
 
+const countUnreadNotifications = `-- name: CountUnreadNotifications :one
+SELECT COUNT(*)
+FROM events e
+JOIN users u ON u.id = $1
+LEFT JOIN user_notification_status uns 
+    ON e.id = uns.event_id AND uns.user_id = $1
+WHERE 
+    (e.user_id = $1 OR (e.user_id IS NULL AND e.created_at >= u.created_at))
+    AND uns.dismissed_at IS NULL
+    AND (e.expires_at IS NULL OR e.expires_at > NOW())
+    AND COALESCE(uns.is_read, false) = false
+`
+
+func (q *Queries) CountUnreadNotifications(ctx context.Context, id pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countUnreadNotifications, id)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createEvent = `-- name: CreateEvent :one
 INSERT INTO events (
     object_id,
@@ -39,20 +59,24 @@ INSERT INTO events (
     title,
     message,
     metadata,
+    user_id,
+    expires_at,
     created_at
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, NOW()
+    $1, $2, $3, $4, $5, $6, $7, $8, NOW()
 )
-RETURNING id, object_id, object_title, type, title, message, metadata, created_at
+RETURNING id, object_id, object_title, type, title, message, metadata, created_at, user_id, expires_at
 `
 
 type CreateEventParams struct {
-	ObjectID    pgtype.UUID `json:"object_id"`
-	ObjectTitle pgtype.Text `json:"object_title"`
-	Type        string      `json:"type"`
-	Title       string      `json:"title"`
-	Message     string      `json:"message"`
-	Metadata    []byte      `json:"metadata"`
+	ObjectID    pgtype.UUID      `json:"object_id"`
+	ObjectTitle pgtype.Text      `json:"object_title"`
+	Type        string           `json:"type"`
+	Title       string           `json:"title"`
+	Message     string           `json:"message"`
+	Metadata    []byte           `json:"metadata"`
+	UserID      pgtype.UUID      `json:"user_id"`
+	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
 }
 
 func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event, error) {
@@ -63,6 +87,8 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event
 		arg.Title,
 		arg.Message,
 		arg.Metadata,
+		arg.UserID,
+		arg.ExpiresAt,
 	)
 	var i Event
 	err := row.Scan(
@@ -74,6 +100,109 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event
 		&i.Message,
 		&i.Metadata,
 		&i.CreatedAt,
+		&i.UserID,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const dismissNotification = `-- name: DismissNotification :exec
+INSERT INTO user_notification_status (user_id, event_id, is_read, dismissed_at, created_at)
+VALUES ($1, $2, false, NOW(), NOW())
+ON CONFLICT (user_id, event_id)
+DO UPDATE SET dismissed_at = NOW()
+`
+
+type DismissNotificationParams struct {
+	UserID  pgtype.UUID `json:"user_id"`
+	EventID pgtype.UUID `json:"event_id"`
+}
+
+// Hides an item for one user; creates the status row for broadcasts.
+func (q *Queries) DismissNotification(ctx context.Context, arg DismissNotificationParams) error {
+	_, err := q.db.Exec(ctx, dismissNotification, arg.UserID, arg.EventID)
+	return err
+}
+
+const getEventByID = `-- name: GetEventByID :one
+SELECT id, object_id, object_title, type, title, message, metadata, created_at, user_id, expires_at FROM events
+WHERE id = $1
+`
+
+func (q *Queries) GetEventByID(ctx context.Context, id pgtype.UUID) (Event, error) {
+	row := q.db.QueryRow(ctx, getEventByID, id)
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.ObjectID,
+		&i.ObjectTitle,
+		&i.Type,
+		&i.Title,
+		&i.Message,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getUserNotification = `-- name: GetUserNotification :one
+SELECT 
+    e.id AS event_id,
+    e.object_id,
+    e.object_title,
+    e.type,
+    e.title AS notification_title,
+    e.message,
+    e.metadata,
+    e.created_at,
+    COALESCE(uns.is_read, false) AS is_read,
+    uns.read_at
+FROM events e
+JOIN users u ON u.id = $1
+LEFT JOIN user_notification_status uns 
+    ON e.id = uns.event_id AND uns.user_id = $1
+WHERE 
+    (e.user_id = $1 OR (e.user_id IS NULL AND e.created_at >= u.created_at))
+    AND uns.dismissed_at IS NULL
+    AND (e.expires_at IS NULL OR e.expires_at > NOW())
+    AND e.id = $2
+`
+
+type GetUserNotificationParams struct {
+	UserID  pgtype.UUID `json:"user_id"`
+	EventID pgtype.UUID `json:"event_id"`
+}
+
+type GetUserNotificationRow struct {
+	EventID           pgtype.UUID      `json:"event_id"`
+	ObjectID          pgtype.UUID      `json:"object_id"`
+	ObjectTitle       pgtype.Text      `json:"object_title"`
+	Type              string           `json:"type"`
+	NotificationTitle string           `json:"notification_title"`
+	Message           string           `json:"message"`
+	Metadata          []byte           `json:"metadata"`
+	CreatedAt         pgtype.Timestamp `json:"created_at"`
+	IsRead            bool             `json:"is_read"`
+	ReadAt            pgtype.Timestamp `json:"read_at"`
+}
+
+// A single inbox item, if the event is visible to the user and not dismissed.
+func (q *Queries) GetUserNotification(ctx context.Context, arg GetUserNotificationParams) (GetUserNotificationRow, error) {
+	row := q.db.QueryRow(ctx, getUserNotification, arg.UserID, arg.EventID)
+	var i GetUserNotificationRow
+	err := row.Scan(
+		&i.EventID,
+		&i.ObjectID,
+		&i.ObjectTitle,
+		&i.Type,
+		&i.NotificationTitle,
+		&i.Message,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.IsRead,
+		&i.ReadAt,
 	)
 	return i, err
 }
@@ -95,8 +224,9 @@ JOIN users u ON u.id = $1
 LEFT JOIN user_notification_status uns 
     ON e.id = uns.event_id AND uns.user_id = $1
 WHERE 
-    e.created_at >= u.created_at
-    OR uns.user_id IS NOT NULL
+    (e.user_id = $1 OR (e.user_id IS NULL AND e.created_at >= u.created_at))
+    AND uns.dismissed_at IS NULL
+    AND (e.expires_at IS NULL OR e.expires_at > NOW())
 ORDER BY e.created_at DESC
 LIMIT $2 OFFSET $3
 `
@@ -151,6 +281,172 @@ func (q *Queries) GetUserNotificationsByUserID(ctx context.Context, arg GetUserN
 	return items, nil
 }
 
+const listUserNotifications = `-- name: ListUserNotifications :many
+SELECT 
+    e.id AS event_id,
+    e.object_id,
+    e.object_title,
+    e.type,
+    e.title AS notification_title,
+    e.message,
+    e.metadata,
+    e.created_at,
+    COALESCE(uns.is_read, false) AS is_read,
+    uns.read_at
+FROM events e
+JOIN users u ON u.id = $1
+LEFT JOIN user_notification_status uns 
+    ON e.id = uns.event_id AND uns.user_id = $1
+WHERE 
+    (e.user_id = $1 OR (e.user_id IS NULL AND e.created_at >= u.created_at))
+    AND uns.dismissed_at IS NULL
+    AND (e.expires_at IS NULL OR e.expires_at > NOW())
+    AND ($2::text IS NULL OR e.type = $2)
+    AND ($3::boolean IS NULL OR COALESCE(uns.is_read, false) = $3)
+    AND ($4::timestamp IS NULL
+        OR (e.created_at, e.id) < ($4, $5::uuid))
+ORDER BY e.created_at DESC, e.id DESC
+LIMIT $6
+`
+
+type ListUserNotificationsParams struct {
+	UserID          pgtype.UUID      `json:"user_id"`
+	Type            pgtype.Text      `json:"type"`
+	IsRead          pgtype.Bool      `json:"is_read"`
+	CursorCreatedAt pgtype.Timestamp `json:"cursor_created_at"`
+	CursorID        pgtype.UUID      `json:"cursor_id"`
+	RowLimit        int32            `json:"row_limit"`
+}
+
+type ListUserNotificationsRow struct {
+	EventID           pgtype.UUID      `json:"event_id"`
+	ObjectID          pgtype.UUID      `json:"object_id"`
+	ObjectTitle       pgtype.Text      `json:"object_title"`
+	Type              string           `json:"type"`
+	NotificationTitle string           `json:"notification_title"`
+	Message           string           `json:"message"`
+	Metadata          []byte           `json:"metadata"`
+	CreatedAt         pgtype.Timestamp `json:"created_at"`
+	IsRead            bool             `json:"is_read"`
+	ReadAt            pgtype.Timestamp `json:"read_at"`
+}
+
+// Inbox page, newest first. Pass the last row's created_at and event_id as the cursor.
+func (q *Queries) ListUserNotifications(ctx context.Context, arg ListUserNotificationsParams) ([]ListUserNotificationsRow, error) {
+	rows, err := q.db.Query(ctx, listUserNotifications,
+		arg.UserID,
+		arg.Type,
+		arg.IsRead,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserNotificationsRow
+	for rows.Next() {
+		var i ListUserNotificationsRow
+		if err := rows.Scan(
+			&i.EventID,
+			&i.ObjectID,
+			&i.ObjectTitle,
+			&i.Type,
+			&i.NotificationTitle,
+			&i.Message,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.IsRead,
+			&i.ReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserNotificationsAfter = `-- name: ListUserNotificationsAfter :many
+SELECT 
+    e.id AS event_id,
+    e.object_id,
+    e.object_title,
+    e.type,
+    e.title AS notification_title,
+    e.message,
+    e.metadata,
+    e.created_at,
+    COALESCE(uns.is_read, false) AS is_read,
+    uns.read_at
+FROM events e
+JOIN users u ON u.id = $1
+JOIN events last ON last.id = $2
+LEFT JOIN user_notification_status uns 
+    ON e.id = uns.event_id AND uns.user_id = $1
+WHERE 
+    (e.user_id = $1 OR (e.user_id IS NULL AND e.created_at >= u.created_at))
+    AND uns.dismissed_at IS NULL
+    AND (e.expires_at IS NULL OR e.expires_at > NOW())
+    AND (e.created_at, e.id) > (last.created_at, last.id)
+ORDER BY e.created_at ASC, e.id ASC
+LIMIT $3
+`
+
+type ListUserNotificationsAfterParams struct {
+	UserID       pgtype.UUID `json:"user_id"`
+	AfterEventID pgtype.UUID `json:"after_event_id"`
+	RowLimit     int32       `json:"row_limit"`
+}
+
+type ListUserNotificationsAfterRow struct {
+	EventID           pgtype.UUID      `json:"event_id"`
+	ObjectID          pgtype.UUID      `json:"object_id"`
+	ObjectTitle       pgtype.Text      `json:"object_title"`
+	Type              string           `json:"type"`
+	NotificationTitle string           `json:"notification_title"`
+	Message           string           `json:"message"`
+	Metadata          []byte           `json:"metadata"`
+	CreatedAt         pgtype.Timestamp `json:"created_at"`
+	IsRead            bool             `json:"is_read"`
+	ReadAt            pgtype.Timestamp `json:"read_at"`
+}
+
+// Replays what a user missed after the given event (SSE Last-Event-ID), oldest first.
+func (q *Queries) ListUserNotificationsAfter(ctx context.Context, arg ListUserNotificationsAfterParams) ([]ListUserNotificationsAfterRow, error) {
+	rows, err := q.db.Query(ctx, listUserNotificationsAfter, arg.UserID, arg.AfterEventID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserNotificationsAfterRow
+	for rows.Next() {
+		var i ListUserNotificationsAfterRow
+		if err := rows.Scan(
+			&i.EventID,
+			&i.ObjectID,
+			&i.ObjectTitle,
+			&i.Type,
+			&i.NotificationTitle,
+			&i.Message,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.IsRead,
+			&i.ReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const markAllNotificationsAsRead = `-- name: MarkAllNotificationsAsRead :exec
 INSERT INTO user_notification_status (user_id, event_id, is_read, read_at, created_at)
 SELECT 
@@ -159,7 +455,7 @@ FROM events e
 JOIN users u ON u.id = $1
 LEFT JOIN user_notification_status uns ON e.id = uns.event_id AND uns.user_id = $1
 WHERE 
-    (e.created_at >= u.created_at OR uns.user_id IS NOT NULL)
+    (e.user_id = $1 OR (e.user_id IS NULL AND e.created_at >= u.created_at))
     AND COALESCE(uns.is_read, false) = false
 ON CONFLICT (user_id, event_id) 
 DO UPDATE SET is_read = true, read_at = NOW()
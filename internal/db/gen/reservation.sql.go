@@ -11,6 +11,32 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const cancelOpenReservationsByBookID = `-- name: CancelOpenReservationsByBookID :exec
+UPDATE reservations
+SET status = 'cancelled',
+    cancelled_at = now(),
+    updated_at = now()
+WHERE book_id = $1 AND status IN ('pending', 'notified')
+`
+
+func (q *Queries) CancelOpenReservationsByBookID(ctx context.Context, bookID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, cancelOpenReservationsByBookID, bookID)
+	return err
+}
+
+const cancelOpenReservationsByUserID = `-- name: CancelOpenReservationsByUserID :exec
+UPDATE reservations
+SET status = 'cancelled',
+    cancelled_at = now(),
+    updated_at = now()
+WHERE user_id = $1 AND status IN ('pending', 'notified')
+`
+
+func (q *Queries) CancelOpenReservationsByUserID(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, cancelOpenReservationsByUserID, userID)
+	return err
+}
+
 const checkExistingReservation = `-- name: CheckExistingReservation :one
 SELECT COUNT(*) as count
 FROM reservations
@@ -13,10 +13,11 @@ import (
 
 const countUsers = `-- name: CountUsers :one
 SELECT COUNT(*) FROM users
+WHERE ($1::boolean OR deleted_at IS NULL)
 `
 
-func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
-	row := q.db.QueryRow(ctx, countUsers)
+func (q *Queries) CountUsers(ctx context.Context, dollar_1 bool) (int64, error) {
+	row := q.db.QueryRow(ctx, countUsers, dollar_1)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -30,6 +31,7 @@ WHERE
         WHEN $1 = '' THEN TRUE
         ELSE email ILIKE '%' || $1 || '%'
     END)
+    AND deleted_at IS NULL
 `
 
 func (q *Queries) CountUsersByEmail(ctx context.Context, dollar_1 interface{}) (int64, error) {
@@ -42,7 +44,7 @@ func (q *Queries) CountUsersByEmail(ctx context.Context, dollar_1 interface{}) (
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (first_name, last_name, email, password_hash, phone_number, token_version)
 VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, first_name, last_name, bio, phone_number, email, password_hash, profile_img, profile_img_public_id, role, created_at, updated_at, token_version, is_banned, ban_reason, ban_until, is_permanent_ban
+RETURNING id, first_name, last_name, bio, phone_number, email, password_hash, profile_img, profile_img_public_id, role, created_at, updated_at, token_version, is_banned, ban_reason, ban_until, is_permanent_ban, deleted_at
 `
 
 type CreateUserParams struct {
@@ -82,12 +84,14 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.BanReason,
 		&i.BanUntil,
 		&i.IsPermanentBan,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const getAllUser = `-- name: GetAllUser :many
-SELECT id, first_name, last_name, bio, phone_number, email, password_hash, profile_img, profile_img_public_id, role, created_at, updated_at, token_version, is_banned, ban_reason, ban_until, is_permanent_ban FROM users
+SELECT id, first_name, last_name, bio, phone_number, email, password_hash, profile_img, profile_img_public_id, role, created_at, updated_at, token_version, is_banned, ban_reason, ban_until, is_permanent_ban, deleted_at FROM users
+WHERE deleted_at IS NULL
 `
 
 func (q *Queries) GetAllUser(ctx context.Context) ([]User, error) {
@@ -117,6 +121,7 @@ func (q *Queries) GetAllUser(ctx context.Context) ([]User, error) {
 			&i.BanReason,
 			&i.BanUntil,
 			&i.IsPermanentBan,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -129,7 +134,7 @@ func (q *Queries) GetAllUser(ctx context.Context) ([]User, error) {
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, first_name, last_name, bio, phone_number, email, password_hash, profile_img, profile_img_public_id, role, created_at, updated_at, token_version, is_banned, ban_reason, ban_until, is_permanent_ban FROM users
+SELECT id, first_name, last_name, bio, phone_number, email, password_hash, profile_img, profile_img_public_id, role, created_at, updated_at, token_version, is_banned, ban_reason, ban_until, is_permanent_ban, deleted_at FROM users
 WHERE email = $1
 `
 
@@ -154,12 +159,13 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.BanReason,
 		&i.BanUntil,
 		&i.IsPermanentBan,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, first_name, last_name, bio, phone_number, email, password_hash, profile_img, profile_img_public_id, role, created_at, updated_at, token_version, is_banned, ban_reason, ban_until, is_permanent_ban FROM users
+SELECT id, first_name, last_name, bio, phone_number, email, password_hash, profile_img, profile_img_public_id, role, created_at, updated_at, token_version, is_banned, ban_reason, ban_until, is_permanent_ban, deleted_at FROM users
 WHERE id = $1
 `
 
@@ -184,6 +190,7 @@ func (q *Queries) GetUserByID(ctx context.Context, id pgtype.UUID) (User, error)
 		&i.BanReason,
 		&i.BanUntil,
 		&i.IsPermanentBan,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -250,18 +257,20 @@ func (q *Queries) IncrementTokenVersion(ctx context.Context, id pgtype.UUID) err
 }
 
 const listUsersPaginated = `-- name: ListUsersPaginated :many
-SELECT id, first_name, last_name, bio, phone_number, email, password_hash, profile_img, profile_img_public_id, role, created_at, updated_at, token_version, is_banned, ban_reason, ban_until, is_permanent_ban FROM users
+SELECT id, first_name, last_name, bio, phone_number, email, password_hash, profile_img, profile_img_public_id, role, created_at, updated_at, token_version, is_banned, ban_reason, ban_until, is_permanent_ban, deleted_at FROM users
+WHERE ($3::boolean OR deleted_at IS NULL)
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2
 `
 
 type ListUsersPaginatedParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	Limit   int32 `json:"limit"`
+	Offset  int32 `json:"offset"`
+	Column3 bool  `json:"column_3"`
 }
 
 func (q *Queries) ListUsersPaginated(ctx context.Context, arg ListUsersPaginatedParams) ([]User, error) {
-	rows, err := q.db.Query(ctx, listUsersPaginated, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listUsersPaginated, arg.Limit, arg.Offset, arg.Column3)
 	if err != nil {
 		return nil, err
 	}
@@ -287,6 +296,7 @@ func (q *Queries) ListUsersPaginated(ctx context.Context, arg ListUsersPaginated
 			&i.BanReason,
 			&i.BanUntil,
 			&i.IsPermanentBan,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -298,6 +308,73 @@ func (q *Queries) ListUsersPaginated(ctx context.Context, arg ListUsersPaginated
 	return items, nil
 }
 
+const purgeUserByID = `-- name: PurgeUserByID :one
+DELETE FROM users
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, first_name, last_name, bio, phone_number, email, password_hash, profile_img, profile_img_public_id, role, created_at, updated_at, token_version, is_banned, ban_reason, ban_until, is_permanent_ban, deleted_at
+`
+
+// Permanently removes a soft-deleted user together with their history.
+func (q *Queries) PurgeUserByID(ctx context.Context, id pgtype.UUID) (User, error) {
+	row := q.db.QueryRow(ctx, purgeUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.FirstName,
+		&i.LastName,
+		&i.Bio,
+		&i.PhoneNumber,
+		&i.Email,
+		&i.PasswordHash,
+		&i.ProfileImg,
+		&i.ProfileImgPublicID,
+		&i.Role,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TokenVersion,
+		&i.IsBanned,
+		&i.BanReason,
+		&i.BanUntil,
+		&i.IsPermanentBan,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const restoreUserByID = `-- name: RestoreUserByID :one
+UPDATE users
+SET deleted_at = NULL,
+    updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, first_name, last_name, bio, phone_number, email, password_hash, profile_img, profile_img_public_id, role, created_at, updated_at, token_version, is_banned, ban_reason, ban_until, is_permanent_ban, deleted_at
+`
+
+func (q *Queries) RestoreUserByID(ctx context.Context, id pgtype.UUID) (User, error) {
+	row := q.db.QueryRow(ctx, restoreUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.FirstName,
+		&i.LastName,
+		&i.Bio,
+		&i.PhoneNumber,
+		&i.Email,
+		&i.PasswordHash,
+		&i.ProfileImg,
+		&i.ProfileImgPublicID,
+		&i.Role,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TokenVersion,
+		&i.IsBanned,
+		&i.BanReason,
+		&i.BanUntil,
+		&i.IsPermanentBan,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
 const searchUsersByEmailWithPagination = `-- name: SearchUsersByEmailWithPagination :many
 SELECT
     id,
@@ -313,6 +390,7 @@ WHERE
         WHEN $1 = '' THEN TRUE
         ELSE email ILIKE '%' || $1 || '%'
     END)
+    AND deleted_at IS NULL
 ORDER BY email
 LIMIT $2
 OFFSET $3
@@ -362,6 +440,42 @@ func (q *Queries) SearchUsersByEmailWithPagination(ctx context.Context, arg Sear
 	return items, nil
 }
 
+const softDeleteUserByID = `-- name: SoftDeleteUserByID :one
+UPDATE users
+SET deleted_at = NOW(),
+    token_version = token_version + 1,
+    updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, first_name, last_name, bio, phone_number, email, password_hash, profile_img, profile_img_public_id, role, created_at, updated_at, token_version, is_banned, ban_reason, ban_until, is_permanent_ban, deleted_at
+`
+
+// Also bumps token_version so every outstanding token is revoked.
+func (q *Queries) SoftDeleteUserByID(ctx context.Context, id pgtype.UUID) (User, error) {
+	row := q.db.QueryRow(ctx, softDeleteUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.FirstName,
+		&i.LastName,
+		&i.Bio,
+		&i.PhoneNumber,
+		&i.Email,
+		&i.PasswordHash,
+		&i.ProfileImg,
+		&i.ProfileImgPublicID,
+		&i.Role,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TokenVersion,
+		&i.IsBanned,
+		&i.BanReason,
+		&i.BanUntil,
+		&i.IsPermanentBan,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
 const updateUserBanByUserID = `-- name: UpdateUserBanByUserID :one
 UPDATE users
 SET is_banned = COALESCE($1, is_banned),
@@ -369,7 +483,7 @@ SET is_banned = COALESCE($1, is_banned),
     ban_until = $3,
     is_permanent_ban = COALESCE($4, is_permanent_ban)
 WHERE id = $5
-RETURNING id, first_name, last_name, bio, phone_number, email, password_hash, profile_img, profile_img_public_id, role, created_at, updated_at, token_version, is_banned, ban_reason, ban_until, is_permanent_ban
+RETURNING id, first_name, last_name, bio, phone_number, email, password_hash, profile_img, profile_img_public_id, role, created_at, updated_at, token_version, is_banned, ban_reason, ban_until, is_permanent_ban, deleted_at
 `
 
 type UpdateUserBanByUserIDParams struct {
@@ -407,6 +521,7 @@ func (q *Queries) UpdateUserBanByUserID(ctx context.Context, arg UpdateUserBanBy
 		&i.BanReason,
 		&i.BanUntil,
 		&i.IsPermanentBan,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -422,7 +537,7 @@ SET
   profile_img_public_id = COALESCE($6, profile_img_public_id),
   updated_at            = NOW()
 WHERE id = $7
-RETURNING id, first_name, last_name, bio, phone_number, email, password_hash, profile_img, profile_img_public_id, role, created_at, updated_at, token_version, is_banned, ban_reason, ban_until, is_permanent_ban
+RETURNING id, first_name, last_name, bio, phone_number, email, password_hash, profile_img, profile_img_public_id, role, created_at, updated_at, token_version, is_banned, ban_reason, ban_until, is_permanent_ban, deleted_at
 `
 
 type UpdateUserByIDParams struct {
@@ -464,6 +579,7 @@ func (q *Queries) UpdateUserByID(ctx context.Context, arg UpdateUserByIDParams)
 		&i.BanReason,
 		&i.BanUntil,
 		&i.IsPermanentBan,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,187 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// Migrations holds the goose migrations the binary was built with
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// MigrationsDir is where migrations live in the source tree, relative to the
+// module root; cmd/migrate create writes new files there
+const MigrationsDir = "internal/db/migrations"
+
+var (
+	// ErrSchemaBehind means the database is missing migrations the binary needs
+	ErrSchemaBehind = errors.New("database schema is behind")
+	// ErrSchemaUnversioned means the database has tables but no goose
+	// version table: the schema was applied by hand before migrations ran
+	ErrSchemaUnversioned = errors.New("database schema is not versioned")
+)
+
+// MigrationVersion reads the version from a migration file name, e.g. 12
+// for 0012_export_jobs.sql
+func MigrationVersion(name string) (int64, error) {
+	prefix, _, _ := strings.Cut(name, "_")
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("migration %s has no version prefix", name)
+	}
+	return version, nil
+}
+
+// LatestMigration is the highest version among the embedded migrations
+func LatestMigration() (int64, error) {
+	names, err := fs.Glob(Migrations, "migrations/*.sql")
+	if err != nil {
+		return 0, err
+	}
+	var latest int64
+	for _, name := range names {
+		version, err := MigrationVersion(strings.TrimPrefix(name, "migrations/"))
+		if err != nil {
+			return 0, err
+		}
+		latest = max(latest, version)
+	}
+	return latest, nil
+}
+
+// NewMigrator returns a goose provider for the embedded migrations. A
+// Postgres advisory lock keeps two instances from migrating at once, so it
+// needs a session-level connection, not a transaction-mode pooler.
+func NewMigrator(sqlDB *sql.DB) (*goose.Provider, error) {
+	fsys, err := fs.Sub(Migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider(goose.DialectPostgres, sqlDB, fsys, goose.WithSessionLocker(locker))
+}
+
+// Migrate applies pending migrations through pool. It refuses to run on an
+// unversioned schema, where the first migration would fail halfway; run
+// cmd/migrate baseline on those first.
+func Migrate(ctx context.Context, pool *pgxpool.Pool) ([]*goose.MigrationResult, error) {
+	_, versioned, err := SchemaVersion(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+	if !versioned {
+		var hasTables bool
+		if err := pool.QueryRow(ctx, `SELECT to_regclass('users') IS NOT NULL`).Scan(&hasTables); err != nil {
+			return nil, err
+		}
+		if hasTables {
+			return nil, fmt.Errorf("%w; run the migrate baseline command first", ErrSchemaUnversioned)
+		}
+	}
+
+	sqlDB := stdlib.OpenDBFromPool(pool)
+	defer sqlDB.Close()
+	migrator, err := NewMigrator(sqlDB)
+	if err != nil {
+		return nil, err
+	}
+	return migrator.Up(ctx)
+}
+
+// Baseline records every migration up to version as applied without running
+// it, for databases whose schema was created by hand
+func Baseline(ctx context.Context, sqlDB *sql.DB, version int64) error {
+	latest, err := LatestMigration()
+	if err != nil {
+		return err
+	}
+	if version < 1 || version > latest {
+		return fmt.Errorf("baseline version must be between 1 and %d", latest)
+	}
+	store, err := database.NewStore(goose.DialectPostgres, goose.DefaultTablename)
+	if err != nil {
+		return err
+	}
+
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, store.Tablename()).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("database is already versioned; use up instead")
+	}
+	if err := store.CreateVersionTable(ctx, tx); err != nil {
+		return err
+	}
+	// Version 0 marks the table as initialised, as goose itself does
+	for v := int64(0); v <= version; v++ {
+		if err := store.Insert(ctx, tx, database.InsertRequest{Version: v}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SchemaVersion is the highest migration goose has applied and not rolled
+// back. versioned is false when the database has no goose_db_version table.
+func SchemaVersion(ctx context.Context, conn gen.DBTX) (version int64, versioned bool, err error) {
+	if err := conn.QueryRow(ctx, `SELECT to_regclass('goose_db_version') IS NOT NULL`).Scan(&versioned); err != nil {
+		return 0, false, err
+	}
+	if !versioned {
+		return 0, false, nil
+	}
+	// The newest row per version says whether it is currently applied
+	err = conn.QueryRow(ctx, `
+		SELECT COALESCE(MAX(version_id), 0)
+		FROM (
+			SELECT DISTINCT ON (version_id) version_id, is_applied
+			FROM goose_db_version
+			ORDER BY version_id, id DESC
+		) v
+		WHERE is_applied`).Scan(&version)
+	return version, true, err
+}
+
+// CheckSchema compares the database's schema version with the newest
+// embedded migration. It fails with ErrSchemaBehind or ErrSchemaUnversioned;
+// a schema ahead of the binary (after a rollback) is not an error.
+func CheckSchema(ctx context.Context, conn gen.DBTX) (version, latest int64, err error) {
+	if latest, err = LatestMigration(); err != nil {
+		return 0, 0, err
+	}
+	version, versioned, err := SchemaVersion(ctx, conn)
+	if err != nil {
+		return 0, latest, err
+	}
+	if !versioned {
+		return 0, latest, ErrSchemaUnversioned
+	}
+	if version < latest {
+		return version, latest, fmt.Errorf("%w: at version %d, binary needs %d", ErrSchemaBehind, version, latest)
+	}
+	return version, latest, nil
+}
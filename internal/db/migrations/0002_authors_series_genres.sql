@@ -0,0 +1,104 @@
+-- +goose Up
+-- Authors, series and a controlled genre taxonomy. books.author and
+-- books.genre stay as denormalized display strings; the link tables below
+-- are the source of truth for search, filtering and reporting.
+
+-- Authors table
+CREATE TABLE authors (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    sort_name VARCHAR(255) NOT NULL DEFAULT '',   -- e.g. "Austen, Jane"
+    bio TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+CREATE UNIQUE INDEX authors_name_lower_key ON authors (LOWER(name));
+
+-- Book <-> author links, one row per contribution
+CREATE TABLE book_authors (
+    book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    author_id UUID NOT NULL REFERENCES authors(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'author' CHECK (role IN ('author', 'editor', 'translator', 'illustrator', 'contributor')),
+    position INT NOT NULL DEFAULT 0,              -- order on the title page
+    PRIMARY KEY (book_id, author_id, role)
+);
+CREATE INDEX book_authors_author_id_idx ON book_authors (author_id);
+
+-- Series table
+CREATE TABLE series (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+CREATE UNIQUE INDEX series_name_lower_key ON series (LOWER(name));
+
+-- A book belongs to at most one series; position allows 1, 2, 2.5 ...
+CREATE TABLE book_series (
+    book_id UUID PRIMARY KEY REFERENCES books(id) ON DELETE CASCADE,
+    series_id UUID NOT NULL REFERENCES series(id) ON DELETE CASCADE,
+    position FLOAT8
+);
+CREATE INDEX book_series_series_id_idx ON book_series (series_id);
+
+-- Controlled genre taxonomy (two levels is enough: "Fiction" > "Fantasy")
+CREATE TABLE genres (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    slug VARCHAR(100) NOT NULL UNIQUE,            -- lower-case, hyphenated: "sci-fi"
+    parent_id UUID REFERENCES genres(id) ON DELETE SET NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE book_genres (
+    book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    genre_id UUID NOT NULL REFERENCES genres(id) ON DELETE CASCADE,
+    PRIMARY KEY (book_id, genre_id)
+);
+CREATE INDEX book_genres_genre_id_idx ON book_genres (genre_id);
+
+-- Backfill authors from books.author, splitting co-authors on ";" and "&"
+INSERT INTO authors (name, sort_name)
+SELECT DISTINCT ON (LOWER(a.name)) a.name, a.name
+FROM (
+    SELECT TRIM(part) AS name
+    FROM books, regexp_split_to_table(books.author, '\s*[;&]\s*') AS part
+) a
+WHERE a.name <> ''
+ORDER BY LOWER(a.name), a.name;
+
+INSERT INTO book_authors (book_id, author_id, role, position)
+SELECT b.id, au.id, 'author', MIN(p.ord) - 1
+FROM books b
+CROSS JOIN LATERAL regexp_split_to_table(b.author, '\s*[;&]\s*') WITH ORDINALITY AS p(name, ord)
+JOIN authors au ON LOWER(au.name) = LOWER(TRIM(p.name))
+GROUP BY b.id, au.id;
+
+-- Backfill genres from books.genre, splitting on "," ";" and "/". Spelling
+-- variants ("Sci-Fi", "sci-fi") collapse onto one slug.
+INSERT INTO genres (name, slug)
+SELECT DISTINCT ON (g.slug) g.name, g.slug
+FROM (
+    SELECT TRIM(part) AS name,
+           TRIM(BOTH '-' FROM regexp_replace(LOWER(TRIM(part)), '[^a-z0-9]+', '-', 'g')) AS slug
+    FROM books, regexp_split_to_table(books.genre, '\s*[,;/]\s*') AS part
+) g
+WHERE g.slug <> ''
+ORDER BY g.slug, g.name;
+
+INSERT INTO book_genres (book_id, genre_id)
+SELECT DISTINCT b.id, g.id
+FROM books b
+CROSS JOIN LATERAL regexp_split_to_table(b.genre, '\s*[,;/]\s*') AS part
+JOIN genres g ON g.slug = TRIM(BOTH '-' FROM regexp_replace(LOWER(TRIM(part)), '[^a-z0-9]+', '-', 'g'));
+
+-- +goose Down
+DROP TABLE IF EXISTS book_genres;
+DROP TABLE IF EXISTS genres;
+DROP TABLE IF EXISTS book_series;
+DROP TABLE IF EXISTS series;
+DROP TABLE IF EXISTS book_authors;
+DROP TABLE IF EXISTS authors;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Soft deletion for books and users. Rows with deleted_at set are hidden from
+-- the default listings but keep their borrow, review, reservation and payment
+-- history until an admin purges them explicitly.
+ALTER TABLE books ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX books_deleted_at_idx ON books (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX users_deleted_at_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS users_deleted_at_idx;
+DROP INDEX IF EXISTS books_deleted_at_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE books DROP COLUMN IF EXISTS deleted_at;
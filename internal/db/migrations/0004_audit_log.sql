@@ -0,0 +1,36 @@
+-- +goose Up
+-- Append-only trail of administrative and financial actions. actor_id has
+-- no foreign key on purpose: entries must outlive the users they mention.
+CREATE TABLE audit_log (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    actor_id UUID,                        -- NULL for system actions (webhooks, jobs)
+    action VARCHAR(100) NOT NULL,         -- e.g. "user.ban", "payment.status_update"
+    target_type VARCHAR(50) NOT NULL,     -- e.g. "user", "book", "payment"
+    target_id TEXT NOT NULL DEFAULT '',
+    before JSONB,
+    after JSONB,
+    ip TEXT,
+    request_id TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at DESC);
+CREATE INDEX audit_log_target_idx ON audit_log (target_type, target_id);
+CREATE INDEX audit_log_actor_id_idx ON audit_log (actor_id);
+CREATE INDEX audit_log_action_idx ON audit_log (action);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_log_no_update
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS audit_log_no_update ON audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,36 @@
+-- +goose Up
+-- Recipient of a targeted notification; NULL marks a broadcast. The stream
+-- needs this to route events without guessing from user_notification_status,
+-- which also gains rows when users mark broadcasts as read.
+ALTER TABLE events ADD COLUMN user_id UUID;
+
+-- Targeted assignments are created unread, "mark as read" rows for
+-- broadcasts are created already read, so that tells the two apart.
+UPDATE events e
+SET user_id = uns.user_id
+FROM user_notification_status uns
+WHERE uns.event_id = e.id
+  AND (uns.read_at IS NULL OR uns.read_at > uns.created_at);
+
+CREATE INDEX events_user_id_created_at_idx ON events (user_id, created_at);
+
+-- Wake up every API instance listening on "notifications". The payload only
+-- carries IDs; NOTIFY payloads are capped at 8000 bytes.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_event_created() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('notifications', json_build_object('id', NEW.id, 'user_id', NEW.user_id)::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER events_notify_created
+    AFTER INSERT ON events
+    FOR EACH ROW EXECUTE FUNCTION notify_event_created();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS events_notify_created ON events;
+DROP FUNCTION IF EXISTS notify_event_created();
+DROP INDEX IF EXISTS events_user_id_created_at_idx;
+ALTER TABLE events DROP COLUMN IF EXISTS user_id;
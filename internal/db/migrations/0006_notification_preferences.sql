@@ -0,0 +1,35 @@
+-- +goose Up
+-- Per-user, per-type delivery channels. A missing row means the defaults for
+-- that type apply (see service.DefaultChannels).
+CREATE TABLE notification_preferences (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,            -- e.g. 'BOOK_AVAILABLE', 'DUE_SOON'
+    in_app BOOLEAN NOT NULL DEFAULT true,
+    email BOOLEAN NOT NULL DEFAULT false,
+    digest BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, type)
+);
+
+-- Notifications waiting for the next digest email
+CREATE TABLE notification_digest_items (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    title TEXT NOT NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMP
+);
+CREATE INDEX notification_digest_items_pending_idx ON notification_digest_items (user_id, created_at) WHERE sent_at IS NULL;
+
+-- One due-soon reminder per borrow
+CREATE TABLE borrow_due_reminders (
+    borrow_id UUID PRIMARY KEY REFERENCES borrows(id) ON DELETE CASCADE,
+    sent_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS borrow_due_reminders;
+DROP TABLE IF EXISTS notification_digest_items;
+DROP TABLE IF EXISTS notification_preferences;
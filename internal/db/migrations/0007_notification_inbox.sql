@@ -0,0 +1,10 @@
+-- +goose Up
+-- Per-user hide. Broadcasts get a status row the first time a user reads or
+-- dismisses them.
+ALTER TABLE user_notification_status ADD COLUMN dismissed_at TIMESTAMP;
+
+CREATE INDEX events_created_at_id_idx ON events (created_at DESC, id DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS events_created_at_id_idx;
+ALTER TABLE user_notification_status DROP COLUMN IF EXISTS dismissed_at;
@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE announcements (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    title TEXT NOT NULL,
+    message TEXT NOT NULL,
+    audience VARCHAR(20) NOT NULL CHECK (audience IN ('all', 'role', 'active_plan', 'overdue', 'users')),
+    audience_role TEXT,                   -- for audience = 'role'
+    user_ids UUID[] NOT NULL DEFAULT '{}', -- for audience = 'users'
+    priority VARCHAR(10) NOT NULL DEFAULT 'normal' CHECK (priority IN ('low', 'normal', 'high', 'urgent')),
+    publish_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP,
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'published', 'cancelled')),
+    published_at TIMESTAMP,
+    recipient_count INT NOT NULL DEFAULT 0,
+    created_by UUID,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX announcements_due_idx ON announcements (publish_at) WHERE status = 'scheduled';
+
+-- Expired notifications drop out of the inbox
+ALTER TABLE events ADD COLUMN expires_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE events DROP COLUMN IF EXISTS expires_at;
+DROP TABLE IF EXISTS announcements;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- Transactional outbox for email. Rows are written in the same transaction
+-- as the change that triggers them and drained by service.EmailOutbox.Run,
+-- which retries failures with exponential backoff.
+CREATE TABLE email_outbox (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    template VARCHAR(50) NOT NULL,        -- e.g. 'welcome', 'receipt'
+    to_addresses TEXT[] NOT NULL,
+    reply_to TEXT,
+    subject TEXT NOT NULL,
+    text_body TEXT NOT NULL,
+    html_body TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'sending', 'sent', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 8,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_at TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMP
+);
+CREATE INDEX email_outbox_due_idx ON email_outbox (next_attempt_at) WHERE status IN ('pending', 'sending');
+
+-- One overdue notice per borrow
+CREATE TABLE borrow_overdue_notices (
+    borrow_id UUID PRIMARY KEY REFERENCES borrows(id) ON DELETE CASCADE,
+    sent_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS borrow_overdue_notices;
+DROP TABLE IF EXISTS email_outbox;
@@ -0,0 +1,37 @@
+-- +goose Up
+-- Support tickets opened through the contact form, with a message thread
+-- shared between the member and staff.
+CREATE TABLE support_tickets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,                  -- reply-to address given on the form
+    subject TEXT NOT NULL,
+    category VARCHAR(20) NOT NULL DEFAULT 'general'
+        CHECK (category IN ('general', 'ban_appeal', 'billing', 'catalog')),
+    status VARCHAR(20) NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'pending', 'resolved', 'closed')),
+    priority VARCHAR(10) NOT NULL DEFAULT 'normal'
+        CHECK (priority IN ('low', 'normal', 'high', 'urgent')),
+    assigned_to UUID REFERENCES users(id) ON DELETE SET NULL,
+    last_message_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    closed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX support_tickets_user_idx ON support_tickets (user_id, created_at DESC);
+CREATE INDEX support_tickets_queue_idx ON support_tickets (status, last_message_at DESC);
+
+CREATE TABLE support_ticket_messages (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ticket_id UUID NOT NULL REFERENCES support_tickets(id) ON DELETE CASCADE,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    is_staff BOOLEAN NOT NULL DEFAULT false,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX support_ticket_messages_ticket_idx ON support_ticket_messages (ticket_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS support_ticket_messages;
+DROP TABLE IF EXISTS support_tickets;
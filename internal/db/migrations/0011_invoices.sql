@@ -0,0 +1,33 @@
+-- +goose Up
+-- Finance staff can see every payment and download its documents
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('member', 'admin', 'finance'));
+
+-- Receipts and credit notes each get a number from their own series. A
+-- document's number is assigned once and never changes.
+CREATE SEQUENCE receipt_number_seq;
+CREATE SEQUENCE credit_note_number_seq;
+
+CREATE TABLE invoices (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('receipt', 'credit_note')),
+    number TEXT NOT NULL UNIQUE,          -- e.g. 'INV-000042', 'CN-000007'
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    refund_id UUID REFERENCES refunds(id) ON DELETE CASCADE,
+    issued_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK ((kind = 'credit_note') = (refund_id IS NOT NULL))
+);
+CREATE UNIQUE INDEX invoices_receipt_payment_idx ON invoices (payment_id) WHERE kind = 'receipt';
+CREATE UNIQUE INDEX invoices_credit_note_refund_idx ON invoices (refund_id) WHERE kind = 'credit_note';
+
+-- PDFs and other files sent with an email, as [{filename, content_type, data}]
+ALTER TABLE email_outbox ADD COLUMN attachments JSONB;
+
+-- +goose Down
+ALTER TABLE email_outbox DROP COLUMN IF EXISTS attachments;
+DROP TABLE IF EXISTS invoices;
+DROP SEQUENCE IF EXISTS credit_note_number_seq;
+DROP SEQUENCE IF EXISTS receipt_number_seq;
+UPDATE users SET role = 'admin' WHERE role = 'finance';
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('member', 'admin'));
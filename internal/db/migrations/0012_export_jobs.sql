@@ -0,0 +1,26 @@
+-- +goose Up
+-- Report exports run in the background; the finished file lives in the blob
+-- store under blob_key until expires_at.
+CREATE TABLE export_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    report VARCHAR(50) NOT NULL,          -- e.g. 'borrows', 'audit-log'
+    format VARCHAR(10) NOT NULL CHECK (format IN ('csv', 'xlsx', 'pdf')),
+    filters JSONB NOT NULL DEFAULT '{}',  -- report filters, e.g. {"status": "overdue"}
+    status VARCHAR(20) NOT NULL DEFAULT 'queued'
+        CHECK (status IN ('queued', 'running', 'succeeded', 'failed', 'expired')),
+    blob_key TEXT,
+    filename TEXT,
+    size_bytes BIGINT,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    expires_at TIMESTAMP
+);
+CREATE INDEX export_jobs_user_idx ON export_jobs (user_id, created_at DESC);
+CREATE INDEX export_jobs_queue_idx ON export_jobs (created_at) WHERE status IN ('queued', 'running');
+CREATE INDEX export_jobs_expires_idx ON export_jobs (expires_at) WHERE status = 'succeeded';
+
+-- +goose Down
+DROP TABLE IF EXISTS export_jobs;
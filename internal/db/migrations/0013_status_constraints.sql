@@ -0,0 +1,18 @@
+-- +goose Up
+-- Stripe cancellations set payments to 'cancelled', which the original CHECK
+-- rejected, and reservation statuses were never constrained at all.
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('pending', 'paid', 'failed', 'cancelled'));
+
+ALTER TABLE reservations ADD CONSTRAINT reservations_status_check
+    CHECK (status IN ('pending', 'notified', 'fulfilled', 'cancelled', 'picked_up'));
+
+-- +goose Down
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS reservations_status_check;
+
+-- The old CHECK has no 'cancelled'; those payments never completed
+UPDATE payments SET status = 'failed' WHERE status = 'cancelled';
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('paid', 'failed', 'pending'));
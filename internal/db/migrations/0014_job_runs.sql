@@ -0,0 +1,10 @@
+-- +goose Up
+-- When each periodic job last ran, so its schedule survives restarts and
+-- only one instance runs it per period
+CREATE TABLE job_runs (
+    name VARCHAR(50) PRIMARY KEY,         -- e.g. 'notification_digest'
+    last_run_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS job_runs;
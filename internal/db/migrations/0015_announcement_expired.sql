@@ -0,0 +1,12 @@
+-- +goose Up
+-- Scheduled announcements whose expiry passes before they go out are
+-- marked expired instead of staying scheduled forever
+ALTER TABLE announcements DROP CONSTRAINT announcements_status_check;
+ALTER TABLE announcements ADD CONSTRAINT announcements_status_check
+    CHECK (status IN ('scheduled', 'published', 'cancelled', 'expired'));
+
+-- +goose Down
+UPDATE announcements SET status = 'cancelled' WHERE status = 'expired';
+ALTER TABLE announcements DROP CONSTRAINT announcements_status_check;
+ALTER TABLE announcements ADD CONSTRAINT announcements_status_check
+    CHECK (status IN ('scheduled', 'published', 'cancelled'));
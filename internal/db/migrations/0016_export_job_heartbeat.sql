@@ -0,0 +1,8 @@
+-- +goose Up
+-- Running exports check in periodically, so a long export is told apart
+-- from one whose worker died
+ALTER TABLE export_jobs ADD COLUMN heartbeat_at TIMESTAMP;
+UPDATE export_jobs SET heartbeat_at = started_at WHERE status = 'running';
+
+-- +goose Down
+ALTER TABLE export_jobs DROP COLUMN IF EXISTS heartbeat_at;
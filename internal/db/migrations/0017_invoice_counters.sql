@@ -0,0 +1,23 @@
+-- +goose Up
+-- Invoice numbers come from a counter row updated in the issuing
+-- transaction. A sequence hands out numbers that rolled-back transactions
+-- never use, which left gaps in the series.
+CREATE TABLE invoice_counters (
+    kind VARCHAR(20) PRIMARY KEY CHECK (kind IN ('receipt', 'credit_note')),
+    last_number BIGINT NOT NULL DEFAULT 0
+);
+INSERT INTO invoice_counters (kind, last_number)
+SELECT 'receipt', CASE WHEN is_called THEN last_value ELSE 0 END FROM receipt_number_seq
+UNION ALL
+SELECT 'credit_note', CASE WHEN is_called THEN last_value ELSE 0 END FROM credit_note_number_seq;
+DROP SEQUENCE receipt_number_seq;
+DROP SEQUENCE credit_note_number_seq;
+
+-- +goose Down
+CREATE SEQUENCE receipt_number_seq;
+CREATE SEQUENCE credit_note_number_seq;
+SELECT setval('receipt_number_seq', GREATEST(last_number, 1), last_number > 0)
+FROM invoice_counters WHERE kind = 'receipt';
+SELECT setval('credit_note_number_seq', GREATEST(last_number, 1), last_number > 0)
+FROM invoice_counters WHERE kind = 'credit_note';
+DROP TABLE IF EXISTS invoice_counters;
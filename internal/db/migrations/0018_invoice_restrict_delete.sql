@@ -0,0 +1,17 @@
+-- +goose Up
+-- An issued receipt or credit note is kept for the books, so the payment
+-- or refund it was issued for can no longer be deleted out from under it
+ALTER TABLE invoices DROP CONSTRAINT invoices_payment_id_fkey;
+ALTER TABLE invoices ADD CONSTRAINT invoices_payment_id_fkey
+    FOREIGN KEY (payment_id) REFERENCES payments(id) ON DELETE RESTRICT;
+ALTER TABLE invoices DROP CONSTRAINT invoices_refund_id_fkey;
+ALTER TABLE invoices ADD CONSTRAINT invoices_refund_id_fkey
+    FOREIGN KEY (refund_id) REFERENCES refunds(id) ON DELETE RESTRICT;
+
+-- +goose Down
+ALTER TABLE invoices DROP CONSTRAINT invoices_refund_id_fkey;
+ALTER TABLE invoices ADD CONSTRAINT invoices_refund_id_fkey
+    FOREIGN KEY (refund_id) REFERENCES refunds(id) ON DELETE CASCADE;
+ALTER TABLE invoices DROP CONSTRAINT invoices_payment_id_fkey;
+ALTER TABLE invoices ADD CONSTRAINT invoices_payment_id_fkey
+    FOREIGN KEY (payment_id) REFERENCES payments(id) ON DELETE CASCADE;
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestLatestMigration(t *testing.T) {
+	latest, err := LatestMigration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest < 13 {
+		t.Errorf("LatestMigration() = %d, want at least 13", latest)
+	}
+}
+
+// goose splits SQL on semicolons unless told otherwise, so a plpgsql body
+// outside StatementBegin/End breaks the migration halfway through
+func TestMigrationsAreGooseAnnotated(t *testing.T) {
+	names, err := fs.Glob(Migrations, "migrations/*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[int64]string{}
+	for _, path := range names {
+		name := strings.TrimPrefix(path, "migrations/")
+		version, err := MigrationVersion(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if other, ok := seen[version]; ok {
+			t.Errorf("%s and %s share version %d", name, other, version)
+		}
+		seen[version] = name
+
+		data, err := fs.ReadFile(Migrations, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sql := string(data)
+		if !strings.HasPrefix(sql, "-- +goose Up") || !strings.Contains(sql, "-- +goose Down") {
+			t.Errorf("%s: missing +goose Up or Down", name)
+		}
+		inStatement := false
+		for i, line := range strings.Split(sql, "\n") {
+			switch strings.TrimSpace(line) {
+			case "-- +goose StatementBegin":
+				inStatement = true
+			case "-- +goose StatementEnd":
+				inStatement = false
+			}
+			if strings.Contains(line, "$$") && !inStatement {
+				t.Errorf("%s:%d: $$ body outside StatementBegin/End", name, i+1)
+			}
+		}
+	}
+	for v := int64(1); v <= int64(len(seen)); v++ {
+		if _, ok := seen[v]; !ok {
+			t.Errorf("migration %04d is missing", v)
+		}
+	}
+}
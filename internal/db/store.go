@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Store is how services and handlers reach Postgres: every sqlc query plus
+// transactions. Tests substitute a fake that embeds Store and overrides the
+// queries they exercise.
+type Store interface {
+	gen.Querier
+
+	// WithTx runs fn with a Store whose queries share one transaction. It
+	// commits when fn returns nil and rolls back otherwise. Called on a Store
+	// already inside a transaction, it nests with a savepoint.
+	WithTx(ctx context.Context, fn func(tx Store) error) error
+}
+
+// conn is a pool or a transaction: both run queries and can begin a
+// (nested) transaction
+type conn interface {
+	gen.DBTX
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type pgStore struct {
+	*gen.Queries
+	conn conn
+}
+
+// NewStore returns a Store running its queries on pool
+func NewStore(pool *pgxpool.Pool) Store {
+	return &pgStore{Queries: gen.New(pool), conn: pool}
+}
+
+func (s *pgStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) // no-op after Commit
+
+	if err := fn(&pgStore{Queries: gen.New(tx), conn: tx}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,206 @@
+// Package documents renders the customer-facing PDFs: payment receipts and
+// refund credit notes.
+package documents
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/phpdave11/gofpdf"
+)
+
+// Branding is printed on every document
+type Branding struct {
+	Name    string
+	Website string
+}
+
+// Brand is the library's branding
+var Brand = Branding{
+	Name:    "Book Library",
+	Website: "https://himel-s-library.vercel.app",
+}
+
+// Receipt is a paid payment
+type Receipt struct {
+	Number        string // e.g. INV-000042
+	IssuedAt      time.Time
+	CustomerName  string
+	CustomerEmail string
+	Plan          string
+	DurationDays  int32
+	Amount        float64
+	Currency      string
+	Gateway       string
+	TransactionID string
+	PaidAt        time.Time
+	SupportEmail  string // printed in the footer when set
+}
+
+// CreditNote is a processed refund against a receipt
+type CreditNote struct {
+	Number        string // e.g. CN-000007
+	ReceiptNumber string // the receipt it credits
+	IssuedAt      time.Time
+	CustomerName  string
+	CustomerEmail string
+	Plan          string
+	Amount        float64
+	Currency      string
+	Reason        string
+	Gateway       string
+	TransactionID string
+	RequestedAt   time.Time
+	ProcessedAt   time.Time
+	SupportEmail  string // printed in the footer when set
+}
+
+const dateLayout = "January 02, 2006"
+
+// ReceiptPDF renders r as a one-page PDF
+func ReceiptPDF(r Receipt) ([]byte, error) {
+	pdf := newDocument("RECEIPT", r.Number, r.IssuedAt, r.SupportEmail)
+	billedTo(pdf, r.CustomerName, r.CustomerEmail)
+
+	description := fmt.Sprintf("%s subscription", r.Plan)
+	if r.DurationDays > 0 {
+		description = fmt.Sprintf("%s subscription (%d days)", r.Plan, r.DurationDays)
+	}
+	lineItems(pdf, description, money(r.Amount, r.Currency), "Total paid")
+
+	details(pdf, [][2]string{
+		{"Payment date", r.PaidAt.Format(dateLayout)},
+		{"Payment method", gatewayName(r.Gateway)},
+		{"Transaction ID", r.TransactionID},
+		{"Status", "Paid"},
+	})
+	return output(pdf)
+}
+
+// CreditNotePDF renders n as a one-page PDF
+func CreditNotePDF(n CreditNote) ([]byte, error) {
+	pdf := newDocument("CREDIT NOTE", n.Number, n.IssuedAt, n.SupportEmail)
+	billedTo(pdf, n.CustomerName, n.CustomerEmail)
+
+	description := fmt.Sprintf("Refund of %s subscription", n.Plan)
+	if n.ReceiptNumber != "" {
+		description += fmt.Sprintf(" (receipt %s)", n.ReceiptNumber)
+	}
+	lineItems(pdf, description, "-"+money(n.Amount, n.Currency), "Total credited")
+
+	rows := [][2]string{
+		{"Requested", n.RequestedAt.Format(dateLayout)},
+		{"Processed", n.ProcessedAt.Format(dateLayout)},
+		{"Refunded to", gatewayName(n.Gateway)},
+		{"Original transaction", n.TransactionID},
+	}
+	if n.Reason != "" {
+		rows = append(rows, [2]string{"Reason", n.Reason})
+	}
+	details(pdf, rows)
+	return output(pdf)
+}
+
+// newDocument starts an A4 page with the branded header and footer
+func newDocument(title, number string, issuedAt time.Time, email string) *gofpdf.Fpdf {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-20)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(128, 128, 128)
+		footer := Brand.Name
+		for _, s := range []string{Brand.Website, email} {
+			if s != "" {
+				footer += "  |  " + s
+			}
+		}
+		pdf.CellFormat(0, 5, footer, "", 1, "C", false, 0, "")
+		pdf.CellFormat(0, 5, "Thank you for supporting your library.", "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	// Brand band
+	pdf.SetFillColor(24, 24, 27)
+	pdf.Rect(0, 0, 210, 30, "F")
+	pdf.SetXY(20, 10)
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(100, 10, Brand.Name, "", 0, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 10, title, "", 1, "R", false, 0, "")
+
+	pdf.SetY(40)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, "Number: "+number, "", 1, "R", false, 0, "")
+	pdf.CellFormat(0, 6, "Date: "+issuedAt.Format(dateLayout), "", 1, "R", false, 0, "")
+	return pdf
+}
+
+func billedTo(pdf *gofpdf.Fpdf, name, email string) {
+	pdf.SetY(40)
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(0, 6, "Billed to", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	if name != "" {
+		pdf.CellFormat(0, 6, name, "", 1, "L", false, 0, "")
+	}
+	pdf.CellFormat(0, 6, email, "", 1, "L", false, 0, "")
+	pdf.Ln(10)
+}
+
+// lineItems draws the single-line item table with its total
+func lineItems(pdf *gofpdf.Fpdf, description, amount, totalLabel string) {
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(228, 228, 231)
+	pdf.SetDrawColor(180, 180, 180)
+	pdf.CellFormat(130, 9, "Description", "1", 0, "L", true, 0, "")
+	pdf.CellFormat(40, 9, "Amount", "1", 1, "R", true, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(130, 9, description, "1", 0, "L", false, 0, "")
+	pdf.CellFormat(40, 9, amount, "1", 1, "R", false, 0, "")
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(130, 9, totalLabel, "1", 0, "R", false, 0, "")
+	pdf.CellFormat(40, 9, amount, "1", 1, "R", false, 0, "")
+	pdf.Ln(10)
+}
+
+func details(pdf *gofpdf.Fpdf, rows [][2]string) {
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(0, 7, "Details", "", 1, "L", false, 0, "")
+	for _, row := range rows {
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetTextColor(113, 113, 122)
+		pdf.CellFormat(45, 6, row[0], "", 0, "L", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.MultiCell(0, 6, row[1], "", "L", false)
+	}
+}
+
+func output(pdf *gofpdf.Fpdf) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render pdf: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func money(amount float64, currency string) string {
+	return fmt.Sprintf("%.2f %s", amount, strings.ToUpper(currency))
+}
+
+func gatewayName(gateway string) string {
+	switch strings.ToLower(gateway) {
+	case "stripe":
+		return "Card (Stripe)"
+	case "":
+		return "-"
+	default:
+		return gateway
+	}
+}
@@ -0,0 +1,51 @@
+package documents
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestReceiptPDF(t *testing.T) {
+	out, err := ReceiptPDF(Receipt{
+		Number:        "INV-000001",
+		IssuedAt:      time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		CustomerName:  "Ada Lovelace",
+		CustomerEmail: "ada@example.com",
+		Plan:          "Gold",
+		DurationDays:  30,
+		Amount:        9.99,
+		Currency:      "usd",
+		Gateway:       "stripe",
+		TransactionID: "8c1f6a3e-1d2b-4c5d-9e8f-0a1b2c3d4e5f",
+		PaidAt:        time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("ReceiptPDF: %v", err)
+	}
+	if !bytes.HasPrefix(out, []byte("%PDF-")) {
+		t.Fatalf("output is not a PDF: %q", out[:min(len(out), 16)])
+	}
+}
+
+func TestCreditNotePDF(t *testing.T) {
+	out, err := CreditNotePDF(CreditNote{
+		Number:        "CN-000001",
+		ReceiptNumber: "INV-000001",
+		IssuedAt:      time.Now(),
+		CustomerEmail: "ada@example.com",
+		Plan:          "Gold",
+		Amount:        9.99,
+		Currency:      "usd",
+		Reason:        "Charged twice",
+		Gateway:       "stripe",
+		RequestedAt:   time.Now(),
+		ProcessedAt:   time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("CreditNotePDF: %v", err)
+	}
+	if !bytes.HasPrefix(out, []byte("%PDF-")) {
+		t.Fatalf("output is not a PDF")
+	}
+}
@@ -0,0 +1,45 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+type csvSink struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVSink(w io.Writer, headers []string) (*csvSink, error) {
+	s := &csvSink{w: csv.NewWriter(w), record: make([]string, len(headers))}
+	if err := s.w.Write(headers); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *csvSink) Row(cells []any) error {
+	for i, cell := range cells {
+		text := Text(cell)
+		if _, ok := cell.(string); ok {
+			text = escapeFormula(text)
+		}
+		s.record[i] = text
+	}
+	return s.w.Write(s.record)
+}
+
+func (s *csvSink) Close() error {
+	s.w.Flush()
+	return s.w.Error()
+}
+
+// escapeFormula stops spreadsheet apps from evaluating user-entered text
+// that starts like a formula.
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,189 @@
+// Package export renders admin reports as CSV, XLSX or PDF. A report is a
+// list of columns plus a function that streams its rows, so an export covers
+// every matching row without loading the table into memory first.
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+)
+
+// Format is an output format
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+	PDF  Format = "pdf"
+)
+
+// ErrUnknownFormat is returned by ParseFormat for anything but csv, xlsx or pdf
+var ErrUnknownFormat = apierror.New(http.StatusBadRequest, "unknown_format", "invalid format, use csv, xlsx or pdf")
+
+// ParseFormat parses a ?format= value
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case CSV, XLSX, PDF:
+		return f, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// ContentType is the MIME type of f
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case PDF:
+		return "application/pdf"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Column is one column of a report. Value returns the cell for a row: a
+// string, number, bool, time.Time or nil. Anything else is printed with %v.
+type Column[T any] struct {
+	Header string
+	Width  float64 // PDF width relative to the other columns; 0 counts as 1
+	Value  func(T) any
+}
+
+// Report is a report definition: its columns and the query that feeds them
+type Report[T any] struct {
+	Name    string // base file name, e.g. "payments"
+	Title   string // PDF heading and XLSX sheet name
+	Columns []Column[T]
+	// Rows calls yield for every row in order and stops at the first error
+	Rows func(ctx context.Context, yield func(T) error) error
+}
+
+// Renderer is a Report with its row type hidden, so reports over different
+// rows can be picked at runtime.
+type Renderer interface {
+	Filename(f Format) string
+	Render(ctx context.Context, w io.Writer, f Format) error
+}
+
+// Filename is the download name for the report in format f, stamped with
+// the current date.
+func (r Report[T]) Filename(f Format) string {
+	return fmt.Sprintf("%s_%s.%s", r.Name, time.Now().UTC().Format("20060102"), f)
+}
+
+// Render writes the whole report to w
+func (r Report[T]) Render(ctx context.Context, w io.Writer, f Format) error {
+	headers := make([]string, len(r.Columns))
+	widths := make([]float64, len(r.Columns))
+	for i, col := range r.Columns {
+		headers[i] = col.Header
+		widths[i] = col.Width
+		if widths[i] <= 0 {
+			widths[i] = 1
+		}
+	}
+
+	var s sink
+	var err error
+	switch f {
+	case CSV:
+		s, err = newCSVSink(w, headers)
+	case XLSX:
+		s, err = newXLSXSink(w, r.Title, headers, widths)
+	case PDF:
+		s, err = newPDFSink(w, r.Title, headers, widths)
+	default:
+		return ErrUnknownFormat
+	}
+	if err != nil {
+		return err
+	}
+
+	cells := make([]any, len(r.Columns))
+	err = r.Rows(ctx, func(row T) error {
+		for i, col := range r.Columns {
+			cells[i] = col.Value(row)
+		}
+		return s.Row(cells)
+	})
+	if err != nil && !errors.Is(err, errEnough) {
+		return err
+	}
+	return s.Close()
+}
+
+// sink writes rows in one format
+type sink interface {
+	Row(cells []any) error
+	Close() error
+}
+
+// errEnough stops a report early without failing it (the PDF row cap)
+var errEnough = errors.New("export: row limit reached")
+
+// Batches runs a keyset-paginated query. fetch gets the last row of the
+// previous batch (nil for the first) and is called until it returns fewer
+// than size rows; every row is passed to yield.
+func Batches[T any](ctx context.Context, size int, fetch func(ctx context.Context, last *T) ([]T, error), yield func(T) error) error {
+	var last *T
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rows, err := fetch(ctx, last)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := yield(row); err != nil {
+				return err
+			}
+		}
+		if len(rows) < size {
+			return nil
+		}
+		last = &rows[len(rows)-1]
+	}
+}
+
+// timeLayout is how times are written in every format
+const timeLayout = "2006-01-02 15:04:05"
+
+// Text formats a cell as a string
+func Text(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.UTC().Format(timeLayout)
+	case bool:
+		if v {
+			return "yes"
+		}
+		return "no"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
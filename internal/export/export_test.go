@@ -0,0 +1,122 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type item struct {
+	n    int
+	name string
+	at   time.Time
+}
+
+// testReport serves count items in keyset batches of three
+func testReport(count int) (Report[item], *int) {
+	queries := 0
+	return Report[item]{
+		Name:  "items",
+		Title: "Items: all/some",
+		Columns: []Column[item]{
+			{Header: "N", Value: func(i item) any { return i.n }},
+			{Header: "Name", Width: 3, Value: func(i item) any { return i.name }},
+			{Header: "At", Value: func(i item) any { return i.at }},
+		},
+		Rows: func(ctx context.Context, yield func(item) error) error {
+			return Batches(ctx, 3, func(ctx context.Context, last *item) ([]item, error) {
+				queries++
+				start := 0
+				if last != nil {
+					start = last.n
+				}
+				var rows []item
+				for n := start + 1; n <= count && len(rows) < 3; n++ {
+					rows = append(rows, item{n: n, name: "item", at: time.Date(2025, 1, n%28+1, 0, 0, 0, 0, time.UTC)})
+				}
+				return rows, nil
+			}, yield)
+		},
+	}, &queries
+}
+
+func TestRenderCSVStreamsEveryBatch(t *testing.T) {
+	report, queries := testReport(7)
+	var buf bytes.Buffer
+	if err := report.Render(context.Background(), &buf, CSV); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 8 {
+		t.Fatalf("got %d lines, want header + 7 rows:\n%s", len(lines), buf.String())
+	}
+	if lines[0] != "N,Name,At" || lines[7] != "7,item,2025-01-08 00:00:00" {
+		t.Errorf("unexpected CSV:\n%s", buf.String())
+	}
+	if *queries != 3 {
+		t.Errorf("ran %d queries, want 3", *queries)
+	}
+}
+
+func TestCSVEscapesFormulas(t *testing.T) {
+	report := Report[string]{
+		Columns: []Column[string]{{Header: "Value", Value: func(s string) any { return s }}},
+		Rows: func(ctx context.Context, yield func(string) error) error {
+			return yield("=HYPERLINK(\"x\")")
+		},
+	}
+	var buf bytes.Buffer
+	if err := report.Render(context.Background(), &buf, CSV); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"'=HYPERLINK(""x"")"`) {
+		t.Errorf("formula not escaped: %s", buf.String())
+	}
+}
+
+func TestRenderXLSX(t *testing.T) {
+	report, _ := testReport(5)
+	var buf bytes.Buffer
+	if err := report.Render(context.Background(), &buf, XLSX); err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := f.GetRows("Items- all-some")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 6 || rows[5][0] != "5" {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+}
+
+func TestRenderPDFStopsAtCap(t *testing.T) {
+	report, queries := testReport(MaxPDFRows + 10)
+	var buf bytes.Buffer
+	if err := report.Render(context.Background(), &buf, PDF); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Fatal("output is not a PDF")
+	}
+	if want := MaxPDFRows/3 + 1; *queries > want {
+		t.Errorf("ran %d queries after the cap, want at most %d", *queries, want)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat(" XLSX "); err != nil || f != XLSX {
+		t.Errorf("ParseFormat(XLSX) = %q, %v", f, err)
+	}
+	if _, err := ParseFormat("json"); err != ErrUnknownFormat {
+		t.Errorf("ParseFormat(json) error = %v", err)
+	}
+}
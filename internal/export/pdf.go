@@ -0,0 +1,128 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/phpdave11/gofpdf"
+)
+
+// MaxPDFRows caps PDF reports. gofpdf builds the whole document in memory
+// and nobody reads a PDF that long; CSV and XLSX have no cap.
+const MaxPDFRows = 5000
+
+const (
+	pdfMargin    = 15.0
+	pdfRowHeight = 8.0
+)
+
+type pdfSink struct {
+	out     io.Writer
+	pdf     *gofpdf.Fpdf
+	tr      func(string) string
+	headers []string
+	widths  []float64
+	rows    int
+}
+
+func newPDFSink(w io.Writer, title string, headers []string, weights []float64) (*pdfSink, error) {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(false, pdfMargin)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Page %d", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	s := &pdfSink{
+		out:     w,
+		pdf:     pdf,
+		tr:      pdf.UnicodeTranslatorFromDescriptor(""),
+		headers: headers,
+		widths:  columnWidths(pdf, weights),
+	}
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, s.tr(title), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, fmt.Sprintf("Generated on %s UTC", time.Now().UTC().Format(timeLayout)), "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+	s.drawHeader()
+	return s, pdf.Error()
+}
+
+// columnWidths splits the printable width by weight
+func columnWidths(pdf *gofpdf.Fpdf, weights []float64) []float64 {
+	pageWidth, _ := pdf.GetPageSize()
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	widths := make([]float64, len(weights))
+	for i, w := range weights {
+		widths[i] = (pageWidth - 2*pdfMargin) * w / total
+	}
+	return widths
+}
+
+func (s *pdfSink) drawHeader() {
+	s.pdf.SetFont("Helvetica", "B", 9)
+	s.pdf.SetFillColor(180, 180, 255)
+	s.pdf.SetTextColor(0, 0, 0)
+	s.pdf.SetDrawColor(100, 100, 100)
+	for i, h := range s.headers {
+		s.pdf.CellFormat(s.widths[i], 9, s.fit(h, s.widths[i]), "1", 0, "C", true, 0, "")
+	}
+	s.pdf.Ln(-1)
+}
+
+func (s *pdfSink) Row(cells []any) error {
+	if s.rows == MaxPDFRows {
+		return errEnough
+	}
+	_, pageHeight := s.pdf.GetPageSize()
+	if s.pdf.GetY()+pdfRowHeight > pageHeight-pdfMargin-5 {
+		s.pdf.AddPage()
+		s.drawHeader()
+	}
+
+	s.pdf.SetFont("Helvetica", "", 8)
+	if s.rows%2 == 0 {
+		s.pdf.SetFillColor(245, 245, 245)
+	} else {
+		s.pdf.SetFillColor(255, 255, 255)
+	}
+	s.pdf.SetDrawColor(180, 180, 180)
+	for i, cell := range cells {
+		s.pdf.CellFormat(s.widths[i], pdfRowHeight, s.fit(Text(cell), s.widths[i]), "1", 0, "L", true, 0, "")
+	}
+	s.pdf.Ln(-1)
+	s.rows++
+	return s.pdf.Error()
+}
+
+func (s *pdfSink) Close() error {
+	if s.rows == MaxPDFRows {
+		s.pdf.Ln(2)
+		s.pdf.SetFont("Helvetica", "I", 9)
+		s.pdf.CellFormat(0, 6, fmt.Sprintf("Only the first %d rows are shown. Export as CSV or XLSX for the full report.", MaxPDFRows), "", 1, "L", false, 0, "")
+	}
+	return s.pdf.Output(s.out)
+}
+
+// fit converts text to the PDF font's encoding and shortens it to fit a
+// cell of the given width.
+func (s *pdfSink) fit(text string, width float64) string {
+	text = s.tr(text)
+	room := width - 2
+	if s.pdf.GetStringWidth(text) <= room {
+		return text
+	}
+	for len(text) > 0 && s.pdf.GetStringWidth(text+"...") > room {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
@@ -0,0 +1,103 @@
+package export
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type xlsxSink struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+	values []interface{}
+}
+
+func newXLSXSink(w io.Writer, title string, headers []string, widths []float64) (*xlsxSink, error) {
+	f := excelize.NewFile()
+	sheet := sheetName(title)
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return nil, err
+	}
+	stream, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return nil, err
+	}
+	for i, width := range widths {
+		if err := stream.SetColWidth(i+1, i+1, 14*width); err != nil {
+			return nil, err
+		}
+	}
+	if err := stream.SetPanes(&excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		return nil, err
+	}
+
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return nil, err
+	}
+	header := make([]interface{}, len(headers))
+	for i, h := range headers {
+		header[i] = excelize.Cell{StyleID: bold, Value: h}
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		return nil, err
+	}
+	return &xlsxSink{out: w, file: f, stream: stream, row: 1, values: make([]interface{}, len(headers))}, nil
+}
+
+func (s *xlsxSink) Row(cells []any) error {
+	if s.row >= excelize.TotalRows {
+		// The worksheet is full
+		return errEnough
+	}
+	s.row++
+	for i, cell := range cells {
+		switch v := cell.(type) {
+		case time.Time, bool, nil:
+			// Plain text reads the same in every spreadsheet app
+			s.values[i] = Text(v)
+		default:
+			s.values[i] = v
+		}
+	}
+	cell, err := excelize.CoordinatesToCellName(1, s.row)
+	if err != nil {
+		return err
+	}
+	return s.stream.SetRow(cell, s.values)
+}
+
+func (s *xlsxSink) Close() error {
+	defer s.file.Close()
+	if err := s.stream.Flush(); err != nil {
+		return err
+	}
+	return s.file.Write(s.out)
+}
+
+// sheetName makes title a valid worksheet name: at most 31 characters and
+// none of []:*?/\
+func sheetName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, title)
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	if name == "" {
+		name = "Report"
+	}
+	return name
+}
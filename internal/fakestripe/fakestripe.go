@@ -0,0 +1,424 @@
+// Package fakestripe is a local stand-in for the parts of the Stripe API the
+// library calls: Checkout Sessions and Refunds. Its hosted checkout page
+// pays or abandons a session on a click and, like Stripe, delivers a signed
+// checkout.session.completed event to the API's webhook.
+//
+// Point the API at it with STRIPE_API_URL; cmd/fakestripe serves it. State
+// lives in memory and is lost on restart.
+package fakestripe
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stripe/stripe-go/v74"
+	"github.com/stripe/stripe-go/v74/webhook"
+)
+
+// Config is how the fake authenticates clients and reaches the API
+type Config struct {
+	APIKey        string       // key clients must send; any key is accepted when empty
+	WebhookURL    string       // where events go, e.g. http://localhost:8080/stripe/webhook; none are sent when empty
+	WebhookSecret string       // signs events; the API's STRIPE_WEBHOOK_SECRET
+	Client        *http.Client // delivers events; http.DefaultClient when nil
+}
+
+// Server is the fake Stripe API and hosted checkout page
+type Server struct {
+	cfg Config
+	mux *http.ServeMux
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+	refunds  map[string]*Refund
+}
+
+// Session is a Checkout Session as the Stripe API returns it
+type Session struct {
+	ID            string            `json:"id"`
+	Object        string            `json:"object"`
+	AmountTotal   int64             `json:"amount_total"`
+	Currency      string            `json:"currency"`
+	Metadata      map[string]string `json:"metadata"`
+	Mode          string            `json:"mode"`
+	PaymentIntent *string           `json:"payment_intent"`
+	PaymentStatus string            `json:"payment_status"` // unpaid or paid
+	Status        string            `json:"status"`         // open or complete
+	SuccessURL    string            `json:"success_url"`
+	CancelURL     string            `json:"cancel_url"`
+	URL           string            `json:"url"`
+	Created       int64             `json:"created"`
+	Livemode      bool              `json:"livemode"`
+}
+
+// Refund is a Refund as the Stripe API returns it
+type Refund struct {
+	ID            string            `json:"id"`
+	Object        string            `json:"object"`
+	Amount        int64             `json:"amount"`
+	Currency      string            `json:"currency"`
+	PaymentIntent string            `json:"payment_intent"`
+	Reason        *string           `json:"reason"`
+	Status        string            `json:"status"`
+	Metadata      map[string]string `json:"metadata"`
+	Created       int64             `json:"created"`
+}
+
+// New returns a Server with no sessions
+func New(cfg Config) *Server {
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	s := &Server{
+		cfg:      cfg,
+		mux:      http.NewServeMux(),
+		sessions: map[string]*Session{},
+		refunds:  map[string]*Refund{},
+	}
+	s.mux.HandleFunc("POST /v1/checkout/sessions", s.authed(s.createSession))
+	s.mux.HandleFunc("GET /v1/checkout/sessions/{id}", s.authed(s.getSession))
+	s.mux.HandleFunc("POST /v1/refunds", s.authed(s.createRefund))
+	s.mux.HandleFunc("GET /v1/refunds/{id}", s.authed(s.getRefund))
+
+	// What the customer's browser sees instead of checkout.stripe.com
+	s.mux.HandleFunc("GET /checkout/{id}", s.checkoutPage)
+	s.mux.HandleFunc("POST /checkout/{id}/pay", s.pay)
+	s.mux.HandleFunc("POST /checkout/{id}/cancel", s.cancel)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Session returns a copy of the session with the given ID
+func (s *Server) Session(id string) (Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	if !ok {
+		return Session{}, false
+	}
+	return *sess, true
+}
+
+// Pay completes the session as if the customer paid, delivers the webhook
+// event and returns the URL Stripe would send the browser to. The session
+// stays paid when delivery fails; Stripe would retry, Pay does not.
+func (s *Server) Pay(ctx context.Context, id string) (string, error) {
+	s.mu.Lock()
+	sess, ok := s.sessions[id]
+	if !ok {
+		s.mu.Unlock()
+		return "", fmt.Errorf("no such checkout session %q", id)
+	}
+	if sess.Status != "complete" {
+		sess.Status = "complete"
+		sess.PaymentStatus = "paid"
+		sess.PaymentIntent = stripe.String(newID("pi"))
+	}
+	paid := *sess
+	s.mu.Unlock()
+
+	redirect := strings.ReplaceAll(paid.SuccessURL, "{CHECKOUT_SESSION_ID}", paid.ID)
+	return redirect, s.Send(ctx, "checkout.session.completed", paid)
+}
+
+// Send delivers an event wrapping object to the webhook URL, signed with the
+// webhook secret
+func (s *Server) Send(ctx context.Context, eventType string, object any) error {
+	if s.cfg.WebhookURL == "" {
+		return nil
+	}
+	raw, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(map[string]any{
+		"id":               newID("evt"),
+		"object":           "event",
+		"api_version":      stripe.APIVersion,
+		"created":          time.Now().Unix(),
+		"livemode":         false,
+		"pending_webhooks": 1,
+		"type":             eventType,
+		"data":             map[string]json.RawMessage{"object": raw},
+	})
+	if err != nil {
+		return err
+	}
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: payload, Secret: s.cfg.WebhookSecret})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Stripe-Signature", signed.Header)
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("deliver %s: %w", eventType, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("deliver %s: webhook answered %s", eventType, resp.Status)
+	}
+	return nil
+}
+
+// authed checks the secret key stripe-go sends as a bearer token
+func (s *Server) authed(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || key == "" {
+			writeError(w, http.StatusUnauthorized, "", "", "You did not provide an API key.")
+			return
+		}
+		if s.cfg.APIKey != "" && key != s.cfg.APIKey {
+			writeError(w, http.StatusUnauthorized, "", "", "Invalid API Key provided: "+redactKey(key))
+			return
+		}
+		next(w, r)
+	}
+}
+
+var unitAmountParam = regexp.MustCompile(`^line_items\[(\d+)\]\[price_data\]\[unit_amount\]$`)
+
+func (s *Server) createSession(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "", "", "Invalid request body.")
+		return
+	}
+	form := r.PostForm
+	for _, param := range []string{"success_url", "cancel_url"} {
+		if form.Get(param) == "" {
+			writeError(w, http.StatusBadRequest, param, "parameter_missing", "Missing required param: "+param+".")
+			return
+		}
+	}
+
+	sess := &Session{
+		ID:            newID("cs_test"),
+		Object:        "checkout.session",
+		Metadata:      metadata(form),
+		Mode:          form.Get("mode"),
+		PaymentStatus: "unpaid",
+		Status:        "open",
+		SuccessURL:    form.Get("success_url"),
+		CancelURL:     form.Get("cancel_url"),
+		Created:       time.Now().Unix(),
+	}
+	for key, values := range form {
+		m := unitAmountParam.FindStringSubmatch(key)
+		if m == nil {
+			continue
+		}
+		amount, err := strconv.ParseInt(values[0], 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, key, "parameter_invalid_integer", "Invalid integer: "+values[0])
+			return
+		}
+		quantity := int64(1)
+		if q := form.Get("line_items[" + m[1] + "][quantity]"); q != "" {
+			if quantity, err = strconv.ParseInt(q, 10, 64); err != nil {
+				writeError(w, http.StatusBadRequest, "quantity", "parameter_invalid_integer", "Invalid integer: "+q)
+				return
+			}
+		}
+		sess.AmountTotal += amount * quantity
+		sess.Currency = strings.ToLower(form.Get("line_items[" + m[1] + "][price_data][currency]"))
+	}
+	if sess.AmountTotal <= 0 {
+		writeError(w, http.StatusBadRequest, "line_items", "parameter_missing", "A Checkout Session needs at least one line item with an amount.")
+		return
+	}
+	sess.URL = "http://" + r.Host + "/checkout/" + sess.ID
+
+	s.mu.Lock()
+	s.sessions[sess.ID] = sess
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, sess)
+}
+
+func (s *Server) getSession(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.Session(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "session", "resource_missing", fmt.Sprintf("No such checkout.session: '%s'", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, sess)
+}
+
+func (s *Server) createRefund(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "", "", "Invalid request body.")
+		return
+	}
+	intent := r.PostForm.Get("payment_intent")
+	if intent == "" {
+		writeError(w, http.StatusBadRequest, "payment_intent", "parameter_missing", "Missing required param: payment_intent.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var paid *Session
+	for _, sess := range s.sessions {
+		if sess.PaymentIntent != nil && *sess.PaymentIntent == intent {
+			paid = sess
+			break
+		}
+	}
+	if paid == nil {
+		writeError(w, http.StatusNotFound, "payment_intent", "resource_missing", fmt.Sprintf("No such payment_intent: '%s'", intent))
+		return
+	}
+	remaining := paid.AmountTotal
+	for _, re := range s.refunds {
+		if re.PaymentIntent == intent {
+			remaining -= re.Amount
+		}
+	}
+
+	amount := remaining
+	if a := r.PostForm.Get("amount"); a != "" {
+		var err error
+		if amount, err = strconv.ParseInt(a, 10, 64); err != nil || amount <= 0 {
+			writeError(w, http.StatusBadRequest, "amount", "parameter_invalid_integer", "Invalid positive integer: "+a)
+			return
+		}
+	}
+	if amount > remaining || remaining == 0 {
+		writeError(w, http.StatusBadRequest, "amount", "charge_already_refunded",
+			fmt.Sprintf("Refund amount (%d) is greater than unrefunded amount on charge (%d)", amount, remaining))
+		return
+	}
+
+	refund := &Refund{
+		ID:            newID("re"),
+		Object:        "refund",
+		Amount:        amount,
+		Currency:      paid.Currency,
+		PaymentIntent: intent,
+		Status:        "succeeded",
+		Metadata:      metadata(r.PostForm),
+		Created:       time.Now().Unix(),
+	}
+	if reason := r.PostForm.Get("reason"); reason != "" {
+		refund.Reason = &reason
+	}
+	s.refunds[refund.ID] = refund
+	writeJSON(w, http.StatusOK, refund)
+}
+
+func (s *Server) getRefund(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	refund, ok := s.refunds[r.PathValue("id")]
+	var copied Refund
+	if ok {
+		copied = *refund
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "id", "resource_missing", fmt.Sprintf("No such refund: '%s'", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, copied)
+}
+
+var checkoutTemplate = template.Must(template.New("checkout").Parse(`<!doctype html>
+<title>Fake Stripe Checkout</title>
+<h1>Fake Stripe Checkout</h1>
+<p>{{.ID}}: {{.AmountTotal}} {{.Currency}} (smallest unit), {{.PaymentStatus}}</p>
+<form method="post" action="/checkout/{{.ID}}/pay"><button>Pay</button></form>
+<form method="post" action="/checkout/{{.ID}}/cancel"><button>Cancel</button></form>
+`))
+
+func (s *Server) checkoutPage(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.Session(r.PathValue("id"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	checkoutTemplate.Execute(w, sess)
+}
+
+func (s *Server) pay(w http.ResponseWriter, r *http.Request) {
+	redirect, err := s.Pay(r.Context(), r.PathValue("id"))
+	if redirect == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		slog.Warn("fake stripe webhook delivery failed", "session_id", r.PathValue("id"), "error", err)
+	}
+	http.Redirect(w, r, redirect, http.StatusSeeOther)
+}
+
+// cancel sends the customer back like Stripe's back link: the session
+// stays open and unpaid
+func (s *Server) cancel(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.Session(r.PathValue("id"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	http.Redirect(w, r, strings.ReplaceAll(sess.CancelURL, "{CHECKOUT_SESSION_ID}", sess.ID), http.StatusSeeOther)
+}
+
+// metadata collects metadata[key] form parameters
+func metadata(form url.Values) map[string]string {
+	md := map[string]string{}
+	for key, values := range form {
+		if k, ok := strings.CutPrefix(key, "metadata["); ok && strings.HasSuffix(k, "]") {
+			md[strings.TrimSuffix(k, "]")] = values[0]
+		}
+	}
+	return md
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers in Stripe's error format, which stripe-go decodes into
+// a *stripe.Error
+func writeError(w http.ResponseWriter, status int, param, code, message string) {
+	body := map[string]string{"type": "invalid_request_error", "message": message}
+	if param != "" {
+		body["param"] = param
+	}
+	if code != "" {
+		body["code"] = code
+	}
+	writeJSON(w, status, map[string]any{"error": body})
+}
+
+func redactKey(key string) string {
+	if len(key) <= 8 {
+		return "****"
+	}
+	return key[:8] + "****" + key[len(key)-4:]
+}
+
+func newID(prefix string) string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return prefix + "_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,182 @@
+package fakestripe
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stripe/stripe-go/v74"
+	"github.com/stripe/stripe-go/v74/checkout/session"
+	"github.com/stripe/stripe-go/v74/refund"
+	"github.com/stripe/stripe-go/v74/webhook"
+)
+
+const (
+	testKey    = "sk_test_fake"
+	testSecret = "whsec_fake"
+)
+
+// serve starts the fake and points stripe-go at it. Events it sends arrive
+// on the returned channel after being verified like the API verifies them.
+func serve(t *testing.T) (*Server, *httptest.Server, <-chan stripe.Event) {
+	t.Helper()
+	events := make(chan stripe.Event, 4)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		event, err := webhook.ConstructEvent(payload, r.Header.Get("Stripe-Signature"), testSecret)
+		if err != nil {
+			t.Errorf("webhook did not verify: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		events <- event
+	}))
+	t.Cleanup(hook.Close)
+
+	fake := New(Config{APIKey: testKey, WebhookURL: hook.URL, WebhookSecret: testSecret})
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	stripe.Key = testKey
+	stripe.SetBackend(stripe.APIBackend, stripe.GetBackendWithConfig(stripe.APIBackend, &stripe.BackendConfig{
+		URL:               stripe.String(srv.URL),
+		HTTPClient:        srv.Client(),
+		MaxNetworkRetries: stripe.Int64(0),
+	}))
+	t.Cleanup(func() { stripe.SetBackend(stripe.APIBackend, nil) })
+	return fake, srv, events
+}
+
+func newSession(t *testing.T) *stripe.CheckoutSession {
+	t.Helper()
+	params := &stripe.CheckoutSessionParams{
+		LineItems: []*stripe.CheckoutSessionLineItemParams{{
+			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+				Currency:    stripe.String("USD"),
+				ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{Name: stripe.String("Plan")},
+				UnitAmount:  stripe.Int64(1250),
+			},
+			Quantity: stripe.Int64(2),
+		}},
+		Mode:       stripe.String(string(stripe.CheckoutSessionModePayment)),
+		SuccessURL: stripe.String("http://api.test/stripe/success?session_id={CHECKOUT_SESSION_ID}"),
+		CancelURL:  stripe.String("http://api.test/stripe/cancel?session_id={CHECKOUT_SESSION_ID}"),
+	}
+	params.AddMetadata("transaction_id", "tx-1")
+	s, err := session.New(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func noRedirects(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+func TestCheckoutPayFlow(t *testing.T) {
+	_, srv, events := serve(t)
+
+	s := newSession(t)
+	if s.AmountTotal != 2500 || s.Currency != "usd" || s.Metadata["transaction_id"] != "tx-1" {
+		t.Errorf("created %+v", s)
+	}
+	if s.PaymentStatus != stripe.CheckoutSessionPaymentStatusUnpaid || !strings.HasPrefix(s.URL, srv.URL+"/checkout/") {
+		t.Errorf("created status %s, url %s", s.PaymentStatus, s.URL)
+	}
+
+	// The customer clicks Pay on the hosted page
+	client := &http.Client{CheckRedirect: noRedirects}
+	resp, err := client.PostForm(s.URL+"/pay", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if want := "http://api.test/stripe/success?session_id=" + s.ID; resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != want {
+		t.Errorf("pay: %s to %q, want redirect to %q", resp.Status, resp.Header.Get("Location"), want)
+	}
+
+	select {
+	case event := <-events:
+		if event.Type != "checkout.session.completed" {
+			t.Errorf("event type %s", event.Type)
+		}
+		if !strings.Contains(string(event.Data.Raw), `"transaction_id":"tx-1"`) {
+			t.Errorf("event data %s", event.Data.Raw)
+		}
+	default:
+		t.Fatal("no webhook event delivered")
+	}
+
+	got, err := session.Get(s.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.PaymentStatus != stripe.CheckoutSessionPaymentStatusPaid || got.PaymentIntent == nil {
+		t.Fatalf("after paying: status %s, intent %v", got.PaymentStatus, got.PaymentIntent)
+	}
+
+	// Partial refund, then the rest, then nothing is left
+	r, err := refund.New(&stripe.RefundParams{PaymentIntent: stripe.String(got.PaymentIntent.ID), Amount: stripe.Int64(1000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Amount != 1000 || r.Status != stripe.RefundStatusSucceeded {
+		t.Errorf("refund %+v", r)
+	}
+	if r, err = refund.Get(r.ID, nil); err != nil || r.Amount != 1000 {
+		t.Errorf("get refund = %+v, %v", r, err)
+	}
+	if r, err = refund.New(&stripe.RefundParams{PaymentIntent: stripe.String(got.PaymentIntent.ID)}); err != nil || r.Amount != 1500 {
+		t.Errorf("refund of the rest = %+v, %v", r, err)
+	}
+	_, err = refund.New(&stripe.RefundParams{PaymentIntent: stripe.String(got.PaymentIntent.ID)})
+	var stripeErr *stripe.Error
+	if !errors.As(err, &stripeErr) || stripeErr.Code != stripe.ErrorCodeChargeAlreadyRefunded {
+		t.Errorf("over-refund err = %v", err)
+	}
+}
+
+func TestCheckoutCancel(t *testing.T) {
+	_, _, events := serve(t)
+	s := newSession(t)
+
+	client := &http.Client{CheckRedirect: noRedirects}
+	resp, err := client.PostForm(s.URL+"/cancel", url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if want := "http://api.test/stripe/cancel?session_id=" + s.ID; resp.Header.Get("Location") != want {
+		t.Errorf("cancel redirected to %q, want %q", resp.Header.Get("Location"), want)
+	}
+	if got, err := session.Get(s.ID, nil); err != nil || got.PaymentStatus != stripe.CheckoutSessionPaymentStatusUnpaid {
+		t.Errorf("after cancel: %+v, %v", got, err)
+	}
+	if len(events) != 0 {
+		t.Error("cancelling sent an event")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	serve(t)
+
+	_, err := session.Get("cs_test_missing", nil)
+	var stripeErr *stripe.Error
+	if !errors.As(err, &stripeErr) || stripeErr.HTTPStatusCode != http.StatusNotFound || stripeErr.Code != stripe.ErrorCodeResourceMissing {
+		t.Errorf("missing session err = %v", err)
+	}
+
+	_, err = session.New(&stripe.CheckoutSessionParams{Mode: stripe.String("payment")})
+	if !errors.As(err, &stripeErr) || stripeErr.Param != "success_url" {
+		t.Errorf("missing param err = %v", err)
+	}
+
+	stripe.Key = "sk_test_wrong"
+	_, err = session.Get("cs_test_missing", nil)
+	if !errors.As(err, &stripeErr) || stripeErr.HTTPStatusCode != http.StatusUnauthorized {
+		t.Errorf("wrong key err = %v", err)
+	}
+}
@@ -1,14 +1,13 @@
 package handlers
 
 import (
-	"log"
 	"math"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/THEGunDevil/GoForBackend/internal/db"
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
 	"github.com/gin-gonic/gin"
@@ -27,9 +26,8 @@ func timestampToPtr(ts pgtype.Timestamptz) *time.Time {
 	}
 	return nil
 }
-func ListDataByStatusHandler(c *gin.Context) {
+func (h *Handler) ListDataByStatusHandler(c *gin.Context) {
 	status := strings.ToLower(strings.TrimSpace(c.Query("status"))) // ✅ normalize
-	log.Printf("📥 Received status: '%s'", status)                   // ✅ debug log
 
 
 	// Pagination
@@ -58,10 +56,10 @@ func ListDataByStatusHandler(c *gin.Context) {
 			Column3: []string{status},
 		}
 
-		reservations, err := db.Q.ListReservationPaginatedByStatuses(c.Request.Context(), params)
+		reservations, err := h.store.ListReservationPaginatedByStatuses(c.Request.Context(), params)
 		if err != nil {
-			log.Printf("❌ Failed to fetch reservations: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reservations"})
+			requestLogger(c).Error("failed to fetch reservations", "error", err)
+			c.Error(apierror.Internal("Failed to fetch reservations", nil))
 			return
 		}
 
@@ -101,10 +99,10 @@ func ListDataByStatusHandler(c *gin.Context) {
 			Offset: int32(offset),
 		}
 
-		borrows, err := db.Q.ListBorrowPaginatedByBorrowedAt(c.Request.Context(), params)
+		borrows, err := h.store.ListBorrowPaginatedByBorrowedAt(c.Request.Context(), params)
 		if err != nil {
-			log.Print("failed to fetch borrowed data", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to fetch borrowed data"})
+			requestLogger(c).Error("failed to fetch borrowed data", "status", status, "error", err)
+			c.Error(apierror.Internal("failed to fetch borrowed data", nil))
 			return
 		}
 		var borrowResp []models.BorrowResponse
@@ -132,10 +130,10 @@ func ListDataByStatusHandler(c *gin.Context) {
 			Offset: int32(offset),
 		}
 
-		borrows, err := db.Q.ListBorrowPaginatedByReturnedAt(c.Request.Context(), params)
+		borrows, err := h.store.ListBorrowPaginatedByReturnedAt(c.Request.Context(), params)
 		if err != nil {
-			log.Print("failed to fetch borrowed data", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to fetch borrowed data"})
+			requestLogger(c).Error("failed to fetch borrowed data", "status", status, "error", err)
+			c.Error(apierror.Internal("failed to fetch borrowed data", nil))
 			return
 		}
 		var borrowResp []models.BorrowResponse
@@ -163,10 +161,10 @@ func ListDataByStatusHandler(c *gin.Context) {
 			Offset: int32(offset),
 		}
 
-		borrows, err := db.Q.ListBorrowPaginatedByNotReturnedAt(c.Request.Context(), params)
+		borrows, err := h.store.ListBorrowPaginatedByNotReturnedAt(c.Request.Context(), params)
 		if err != nil {
-			log.Print("failed to fetch borrowed data", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to fetch borrowed data"})
+			requestLogger(c).Error("failed to fetch borrowed data", "status", status, "error", err)
+			c.Error(apierror.Internal("failed to fetch borrowed data", nil))
 			return
 		}
 		var borrowResp []models.BorrowResponse
@@ -192,7 +190,7 @@ func ListDataByStatusHandler(c *gin.Context) {
 		query := strings.TrimSpace(c.Query("query")) // get search term
 
 		if query == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Query cannot be empty"})
+			c.Error(apierror.BadRequest("Query cannot be empty"))
 			return
 		}
 
@@ -203,10 +201,10 @@ func ListDataByStatusHandler(c *gin.Context) {
 			Column1: option,
 			Column2: pgtype.Text{String: query, Valid: true},
 		}
-		totalCount, err := db.Q.CountSearchBorrowsByColumn(c.Request.Context(), countParams)
+		totalCount, err := h.store.CountSearchBorrowsByColumn(c.Request.Context(), countParams)
 		if err != nil {
-			log.Printf("failed to count borrowed data: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count borrowed data"})
+			requestLogger(c).Error("failed to count borrowed data", "status", status, "error", err)
+			c.Error(apierror.Internal("Failed to count borrowed data", nil))
 			return
 		}
 		totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
@@ -219,10 +217,10 @@ func ListDataByStatusHandler(c *gin.Context) {
 			Offset:  int32(offset),
 		}
 
-		borrows, err := db.Q.SearchBorrowsWithPagination(c.Request.Context(), params)
+		borrows, err := h.store.SearchBorrowsWithPagination(c.Request.Context(), params)
 		if err != nil {
-			log.Print("failed to fetch borrowed data", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch borrowed data"})
+			requestLogger(c).Error("failed to fetch borrowed data", "status", status, "error", err)
+			c.Error(apierror.Internal("Failed to fetch borrowed data", nil))
 			return
 		}
 
@@ -249,7 +247,7 @@ func ListDataByStatusHandler(c *gin.Context) {
 		})
 
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		c.Error(apierror.BadRequest("Invalid status"))
 	}
 	// } else {
 	// 	c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
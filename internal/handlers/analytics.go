@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	"github.com/THEGunDevil/GoForBackend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AnalyticsBorrowsHandler returns borrows and returns per bucket
+func (h *Handler) AnalyticsBorrowsHandler(c *gin.Context) { h.analytics(c, service.MetricBorrows) }
+
+// AnalyticsMembersHandler returns new member sign-ups per bucket
+func (h *Handler) AnalyticsMembersHandler(c *gin.Context) { h.analytics(c, service.MetricMembers) }
+
+// AnalyticsRevenueHandler returns gross revenue, processed refunds and net
+// revenue per bucket in the required currency
+func (h *Handler) AnalyticsRevenueHandler(c *gin.Context) { h.analytics(c, service.MetricRevenue) }
+
+// AnalyticsReservationsHandler returns reservations created, fulfilled and
+// cancelled per bucket
+func (h *Handler) AnalyticsReservationsHandler(c *gin.Context) {
+	h.analytics(c, service.MetricReservations)
+}
+
+// AnalyticsOverdueHandler returns, per bucket of due date, how many loans
+// were due, how many were late and the overdue rate
+func (h *Handler) AnalyticsOverdueHandler(c *gin.Context) { h.analytics(c, service.MetricOverdue) }
+
+// analytics serves a metric for the from, to, bucket and tz query
+// parameters, alongside the previous period for comparison
+func (h *Handler) analytics(c *gin.Context, metric string) {
+	q, err := service.ParseAnalyticsQuery(c.Request.URL.Query(), time.Now())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response, err := service.Analytics(c.Request.Context(), h.store, metric, q)
+	if errors.Is(err, service.ErrUnknownMetric) || errors.Is(err, service.ErrInvalidRange) {
+		c.Error(err)
+		return
+	}
+	if err != nil {
+		requestLogger(c).Error("failed to compute analytics", "metric", metric, "error", err)
+		c.Error(apierror.Internal("failed to compute analytics", nil))
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
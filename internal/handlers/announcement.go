@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/logging"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/THEGunDevil/GoForBackend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CreateAnnouncementHandler schedules an announcement (admin). Without
+// publish_at it goes out right away.
+func (h *Handler) CreateAnnouncementHandler(c *gin.Context) {
+	var req models.CreateAnnouncementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+
+	publishAt := time.Now().UTC()
+	if req.PublishAt != nil {
+		publishAt = req.PublishAt.UTC()
+	}
+	if req.Priority == "" {
+		req.Priority = "normal"
+	}
+	if err := validateAnnouncement(req.Audience, req.Role, req.UserIDs, publishAt, req.ExpiresAt); err != nil {
+		c.Error(err)
+		return
+	}
+
+	params := gen.CreateAnnouncementParams{
+		Title:     req.Title,
+		Message:   req.Message,
+		Audience:  req.Audience,
+		UserIds:   pgUUIDs(req.UserIDs),
+		Priority:  req.Priority,
+		PublishAt: pgtype.Timestamp{Time: publishAt, Valid: true},
+		ExpiresAt: pgTimestampPtr(req.ExpiresAt),
+	}
+	if req.Audience == "role" {
+		params.AudienceRole = pgtype.Text{String: req.Role, Valid: true}
+	}
+	if v, ok := c.Get("userID"); ok {
+		if adminID, ok := v.(uuid.UUID); ok {
+			params.CreatedBy = pgtype.UUID{Bytes: adminID, Valid: true}
+		}
+	}
+
+	announcement, err := h.store.CreateAnnouncement(c.Request.Context(), params)
+	if err != nil {
+		requestLogger(c).Error("failed to create announcement", "error", err)
+		c.Error(apierror.Internal("failed to create announcement", nil))
+		return
+	}
+	response := toAnnouncementResponse(announcement)
+	h.logAudit(c, "announcement.create", "announcement", response.ID, nil, response)
+
+	if !publishAt.After(time.Now()) {
+		service.Go(c.Request.Context(), h.publishAnnouncementsNow)
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListAnnouncementsHandler lists announcements newest first (admin), with
+// an optional ?status=scheduled|published|cancelled|expired filter.
+func (h *Handler) ListAnnouncementsHandler(c *gin.Context) {
+	status := optionalQuery(c, "status")
+	page, limit := parsePagination(c)
+
+	rows, err := h.store.ListAnnouncements(c.Request.Context(), gen.ListAnnouncementsParams{
+		Status:    status,
+		RowLimit:  int32(limit),
+		RowOffset: int32((page - 1) * limit),
+	})
+	if err != nil {
+		requestLogger(c).Error("failed to list announcements", "error", err)
+		c.Error(apierror.Internal("failed to fetch announcements", nil))
+		return
+	}
+	totalCount, err := h.store.CountAnnouncements(c.Request.Context(), status)
+	if err != nil {
+		c.Error(apierror.Internal("failed to fetch announcements", err))
+		return
+	}
+
+	announcements := make([]models.AnnouncementResponse, 0, len(rows))
+	for _, a := range rows {
+		announcements = append(announcements, toAnnouncementResponse(a))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"page":          page,
+		"limit":         limit,
+		"count":         len(announcements),
+		"total_count":   totalCount,
+		"total_pages":   int(math.Ceil(float64(totalCount) / float64(limit))),
+		"announcements": announcements,
+	})
+}
+
+// GetAnnouncementHandler returns one announcement (admin)
+func (h *Handler) GetAnnouncementHandler(c *gin.Context) {
+	announcement, ok := h.findAnnouncement(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, toAnnouncementResponse(announcement))
+}
+
+// UpdateAnnouncementHandler edits an announcement that has not gone out yet
+func (h *Handler) UpdateAnnouncementHandler(c *gin.Context) {
+	current, ok := h.findAnnouncement(c)
+	if !ok {
+		return
+	}
+	if current.Status != "scheduled" {
+		c.Error(apierror.Conflict("only scheduled announcements can be edited").With("announcement_status", current.Status))
+		return
+	}
+
+	var req models.UpdateAnnouncementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+
+	before := toAnnouncementResponse(current)
+	params := gen.UpdateAnnouncementParams{
+		ID:           current.ID,
+		Title:        current.Title,
+		Message:      current.Message,
+		Audience:     current.Audience,
+		AudienceRole: current.AudienceRole,
+		UserIds:      current.UserIds,
+		Priority:     current.Priority,
+		PublishAt:    current.PublishAt,
+		ExpiresAt:    current.ExpiresAt,
+	}
+	if req.Title != nil {
+		params.Title = *req.Title
+	}
+	if req.Message != nil {
+		params.Message = *req.Message
+	}
+	if req.Audience != nil {
+		params.Audience = *req.Audience
+	}
+	if req.Role != nil {
+		params.AudienceRole = pgtype.Text{String: *req.Role, Valid: *req.Role != ""}
+	}
+	if req.UserIDs != nil {
+		params.UserIds = pgUUIDs(*req.UserIDs)
+	}
+	if req.Priority != nil {
+		params.Priority = *req.Priority
+	}
+	if req.PublishAt != nil {
+		params.PublishAt = pgtype.Timestamp{Time: req.PublishAt.UTC(), Valid: true}
+	}
+	if req.ExpiresAt != nil {
+		params.ExpiresAt = pgTimestampPtr(req.ExpiresAt)
+	}
+	if params.Audience != "role" {
+		params.AudienceRole = pgtype.Text{}
+	}
+	if params.Audience != "users" {
+		params.UserIds = []pgtype.UUID{}
+	}
+
+	userIDs := before.UserIDs
+	if req.UserIDs != nil {
+		userIDs = *req.UserIDs
+	}
+	if err := validateAnnouncement(params.Audience, params.AudienceRole.String, userIDs, params.PublishAt.Time, timePtr(params.ExpiresAt)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	updated, err := h.store.UpdateAnnouncement(c.Request.Context(), params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.Error(apierror.Conflict("announcement was published or cancelled in the meantime"))
+		return
+	}
+	if err != nil {
+		requestLogger(c).Error("failed to update announcement", "announcement_id", uuid.UUID(current.ID.Bytes), "error", err)
+		c.Error(apierror.Internal("failed to update announcement", nil))
+		return
+	}
+	response := toAnnouncementResponse(updated)
+	h.logAudit(c, "announcement.update", "announcement", response.ID, before, response)
+
+	if !updated.PublishAt.Time.After(time.Now().UTC()) {
+		service.Go(c.Request.Context(), h.publishAnnouncementsNow)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CancelAnnouncementHandler stops a scheduled announcement from going out
+func (h *Handler) CancelAnnouncementHandler(c *gin.Context) {
+	current, ok := h.findAnnouncement(c)
+	if !ok {
+		return
+	}
+
+	cancelled, err := h.store.CancelAnnouncement(c.Request.Context(), current.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.Error(apierror.Conflict("only scheduled announcements can be cancelled").With("announcement_status", current.Status))
+		return
+	}
+	if err != nil {
+		requestLogger(c).Error("failed to cancel announcement", "announcement_id", uuid.UUID(current.ID.Bytes), "error", err)
+		c.Error(apierror.Internal("failed to cancel announcement", nil))
+		return
+	}
+	response := toAnnouncementResponse(cancelled)
+	h.logAudit(c, "announcement.cancel", "announcement", response.ID, toAnnouncementResponse(current), response)
+	c.JSON(http.StatusOK, response)
+}
+
+// validateAnnouncement checks the audience-specific fields and the schedule
+func validateAnnouncement(audience, role string, userIDs []uuid.UUID, publishAt time.Time, expiresAt *time.Time) error {
+	switch audience {
+	case "role":
+		if role != "member" && role != "admin" && role != "finance" {
+			return apierror.BadRequest("role must be member, admin or finance for the role audience")
+		}
+	case "users":
+		if len(userIDs) == 0 {
+			return apierror.BadRequest("user_ids is required for the users audience")
+		}
+	}
+	if expiresAt != nil {
+		if !expiresAt.After(publishAt) {
+			return apierror.BadRequest("expires_at must be after publish_at")
+		}
+		if !expiresAt.After(time.Now()) {
+			return apierror.BadRequest("expires_at must be in the future")
+		}
+	}
+	return nil
+}
+
+func (h *Handler) findAnnouncement(c *gin.Context) (gen.Announcement, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid announcement ID"))
+		return gen.Announcement{}, false
+	}
+	announcement, err := h.store.GetAnnouncementByID(c.Request.Context(), pgtype.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.Error(apierror.NotFound("announcement not found"))
+		return announcement, false
+	}
+	if err != nil {
+		c.Error(apierror.Internal("failed to fetch announcement", err))
+		return announcement, false
+	}
+	return announcement, true
+}
+
+// publishAnnouncementsNow runs the scheduler immediately instead of waiting
+// for its next tick
+func (h *Handler) publishAnnouncementsNow(ctx context.Context) {
+	if err := h.svc.Announcements.PublishDue(ctx); err != nil {
+		logging.FromContext(ctx).Error("failed to publish announcements", "error", err)
+	}
+}
+
+func toAnnouncementResponse(a gen.Announcement) models.AnnouncementResponse {
+	userIDs := make([]uuid.UUID, 0, len(a.UserIds))
+	for _, id := range a.UserIds {
+		userIDs = append(userIDs, id.Bytes)
+	}
+	return models.AnnouncementResponse{
+		ID:             a.ID.Bytes,
+		Title:          a.Title,
+		Message:        a.Message,
+		Audience:       a.Audience,
+		Role:           a.AudienceRole.String,
+		UserIDs:        userIDs,
+		Priority:       a.Priority,
+		PublishAt:      a.PublishAt.Time,
+		ExpiresAt:      timePtr(a.ExpiresAt),
+		Status:         a.Status,
+		PublishedAt:    timePtr(a.PublishedAt),
+		RecipientCount: a.RecipientCount,
+		CreatedBy:      uuidPtr(a.CreatedBy),
+		CreatedAt:      a.CreatedAt.Time,
+		UpdatedAt:      a.UpdatedAt.Time,
+	}
+}
+
+func pgUUIDs(ids []uuid.UUID) []pgtype.UUID {
+	out := make([]pgtype.UUID, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, pgtype.UUID{Bytes: id, Valid: true})
+	}
+	return out
+}
+
+func pgTimestampPtr(t *time.Time) pgtype.Timestamp {
+	if t == nil {
+		return pgtype.Timestamp{}
+	}
+	return pgtype.Timestamp{Time: t.UTC(), Valid: true}
+}
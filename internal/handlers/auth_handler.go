@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"log"
 	"net/http"
 	"regexp"
 	"strings"
@@ -11,44 +10,45 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 
-	"github.com/THEGunDevil/GoForBackend/internal/db"
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/mailer"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
 	"github.com/THEGunDevil/GoForBackend/internal/service"
 )
 
 // RegisterHandler handles user registration
-func RegisterHandler(c *gin.Context) {
+func (h *Handler) RegisterHandler(c *gin.Context) {
 	var req models.User
 	if err := c.BindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		c.Error(apierror.BadRequest("invalid request"))
 		return
 	}
 
 	if req.Password != req.ConfirmPassword {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "passwords do not match"})
+		c.Error(apierror.BadRequest("passwords do not match"))
 		return
 	}
 
 	if len(req.FirstName) < 3 || len(req.FirstName) > 25 ||
 		len(req.LastName) < 3 || len(req.LastName) > 25 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "first and last names must be 3-25 chars"})
+		c.Error(apierror.BadRequest("first and last names must be 3-25 chars"))
 		return
 	}
 
 	emailRegex := regexp.MustCompile(`^[\w.%+-]+@[\w.-]+\.[a-zA-Z]{2,}$`)
 	if len(req.Email) == 0 || len(req.Email) > 255 || !emailRegex.MatchString(req.Email) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email format"})
+		c.Error(apierror.BadRequest("invalid email format"))
 		return
 	}
 
 	hashed, err := service.HashPassword(req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process password"})
+		c.Error(apierror.Internal("failed to process password", err))
 		return
 	}
 
-	user, err := db.Q.CreateUser(c.Request.Context(), gen.CreateUserParams{
+	user, err := h.store.CreateUser(c.Request.Context(), gen.CreateUserParams{
 		FirstName:    req.FirstName,
 		LastName:     req.LastName,
 		Email:        req.Email,
@@ -57,10 +57,10 @@ func RegisterHandler(c *gin.Context) {
 	})
 	if err != nil {
 		if strings.Contains(err.Error(), "duplicate") {
-			c.JSON(http.StatusConflict, gin.H{"error": "email already in use"})
+			c.Error(apierror.Conflict("email already in use"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
+		c.Error(apierror.Internal("failed to create user", err))
 		return
 	}
 
@@ -74,61 +74,45 @@ func RegisterHandler(c *gin.Context) {
 		Role:        user.Role.String,
 	}
 
+	if err := service.QueueEmail(c.Request.Context(), h.store, mailer.TemplateWelcome, []string{user.Email}, "", mailer.WelcomeData{Name: user.FirstName}); err != nil {
+		requestLogger(c).Warn("failed to queue welcome email", "user_id", uuid.UUID(user.ID.Bytes), "error", err)
+	}
+
 	c.JSON(http.StatusCreated, resp)
 }
 
 // LoginHandler handles user login and sets refresh token cookie
-func LoginHandler(c *gin.Context) {
-	var body struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
+func (h *Handler) LoginHandler(c *gin.Context) {
+	var body models.LoginRequest
 
 	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		c.Error(apierror.Invalid(err))
 		return
 	}
 
-	user, err := db.Q.GetUserByEmail(c, body.Email)
+	user, err := h.store.GetUserByEmail(c.Request.Context(), body.Email)
 	if err != nil || service.CheckPassword(body.Password, user.PasswordHash) != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		c.Error(apierror.Unauthorized("Invalid email or password"))
+		return
+	}
+	if user.DeletedAt.Valid {
+		c.Error(apierror.Forbidden("This account has been deleted"))
 		return
 	}
 
 	accessToken, err := service.GenerateAccessToken(user.ID.String(), user.Role.String, user.TokenVersion)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate access token"})
+		c.Error(apierror.Internal("Failed to generate access token", err))
 		return
 	}
 
 	refreshToken, err := service.GenerateRefreshToken(user.ID.String(), user.TokenVersion)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate refresh token"})
+		c.Error(apierror.Internal("Failed to generate refresh token", err))
 		return
 	}
 
-	// Determine if running on localhost based on Host header
-	isLocalhost := strings.Contains(c.Request.Host, "localhost") || strings.Contains(c.Request.Host, "127.0.0.1")
-	cookie := &http.Cookie{
-		Name:     "refresh_token",
-		Value:    refreshToken,
-		Path:     "/",                  // Ensure cookie is available site-wide
-		MaxAge:   3600 * 24 * 7,        // 7 days
-		HttpOnly: true,                 // Prevent JavaScript access
-		Secure:   !isLocalhost,         // Secure=true in production, false on localhost
-		SameSite: http.SameSiteLaxMode, // Default to Lax for compatibility
-	}
-
-	// Use SameSite=None for cross-origin requests in production
-	if !isLocalhost {
-		cookie.SameSite = http.SameSiteNoneMode
-	}
-
-	// Avoid setting Domain explicitly unless necessary
-	// If backend is on a different domain (e.g., api.himel-s-library.vercel.app), uncomment and set:
-	// cookie.Domain = "your-backend-domain.com"
-
-	http.SetCookie(c.Writer, cookie)
+	setRefreshCookie(c, refreshToken, 3600*24*7) // 7 days
 
 	c.JSON(http.StatusOK, gin.H{
 		"access_token": accessToken,
@@ -137,99 +121,64 @@ func LoginHandler(c *gin.Context) {
 }
 
 // RefreshHandler refreshes access token and renews refresh token cookie
-func RefreshHandler(c *gin.Context) {
+func (h *Handler) RefreshHandler(c *gin.Context) {
 	cookie, err := c.Cookie("refresh_token")
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing refresh token"})
+		c.Error(apierror.Unauthorized("Missing refresh token"))
 		return
 	}
 
 	token, err := service.VerifyToken(cookie, true)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		c.Error(apierror.Unauthorized("Invalid refresh token"))
 		return
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		c.Error(apierror.Unauthorized("Invalid token claims"))
 		return
 	}
 
 	userIDStr, ok1 := claims["sub"].(string)
 	version, ok2 := claims["token_version"].(float64)
 	if !ok1 || !ok2 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token data"})
+		c.Error(apierror.Unauthorized("Invalid token data"))
 		return
 	}
 
 	userUUID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		c.Error(apierror.Unauthorized("Invalid user ID"))
 		return
 	}
 
-	user, err := db.Q.GetUserByID(c, pgtype.UUID{Bytes: userUUID, Valid: true})
-	if err != nil || user.TokenVersion != int32(version) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token expired or invalid"})
+	user, err := h.store.GetUserByID(c.Request.Context(), pgtype.UUID{Bytes: userUUID, Valid: true})
+	if err != nil || user.TokenVersion != int32(version) || user.DeletedAt.Valid {
+		c.Error(apierror.Unauthorized("Token expired or invalid"))
 		return
 	}
 
 	accessToken, err := service.GenerateAccessToken(userIDStr, user.Role.String, user.TokenVersion)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate new access token"})
+		c.Error(apierror.Internal("Failed to generate new access token", err))
 		return
 	}
 
 	// Renew refresh token
 	refreshToken, err := service.GenerateRefreshToken(userIDStr, user.TokenVersion)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate refresh token"})
+		c.Error(apierror.Internal("Failed to generate refresh token", err))
 		return
 	}
 
-	// Determine if running on localhost
-	isLocalhost := strings.Contains(c.Request.Host, "localhost") || strings.Contains(c.Request.Host, "127.0.0.1")
-	cookieConfig := &http.Cookie{
-		Name:     "refresh_token",
-		Value:    refreshToken,
-		Path:     "/",
-		MaxAge:   3600 * 24 * 7,
-		HttpOnly: true,
-		Secure:   !isLocalhost,
-		SameSite: http.SameSiteLaxMode,
-	}
-
-	if !isLocalhost {
-		cookieConfig.SameSite = http.SameSiteNoneMode
-	}
-
-	// Avoid setting Domain explicitly unless necessary
-	// cookieConfig.Domain = "your-backend-domain.com" // Uncomment if needed
-
-	http.SetCookie(c.Writer, cookieConfig)
+	setRefreshCookie(c, refreshToken, 3600*24*7)
 
 	c.JSON(http.StatusOK, gin.H{"access_token": accessToken})
 }
 
 // LogoutHandler clears the refresh token cookie and invalidates token version
-func LogoutHandler(c *gin.Context) {
-	// Determine if running on localhost
-	isLocalhost := strings.Contains(c.Request.Host, "localhost") || strings.Contains(c.Request.Host, "127.0.0.1")
-	cookie := &http.Cookie{
-		Name:     "refresh_token",
-		Value:    "",
-		Path:     "/",
-		MaxAge:   -1, // Expire immediately
-		HttpOnly: true,
-		Secure:   !isLocalhost,
-		SameSite: http.SameSiteLaxMode,
-	}
-
-	if !isLocalhost {
-		cookie.SameSite = http.SameSiteNoneMode
-	}
-
+func (h *Handler) LogoutHandler(c *gin.Context) {
 	// Invalidate token version for security
 	cookieValue, err := c.Cookie("refresh_token")
 	if err == nil {
@@ -238,14 +187,36 @@ func LogoutHandler(c *gin.Context) {
 			if claims, ok := token.Claims.(jwt.MapClaims); ok {
 				userIDStr, _ := claims["sub"].(string)
 				userUUID, _ := uuid.Parse(userIDStr)
-				if err := db.Q.IncrementTokenVersion(c.Request.Context(), pgtype.UUID{Bytes: userUUID, Valid: true}); err != nil {
+				if err := h.store.IncrementTokenVersion(c.Request.Context(), pgtype.UUID{Bytes: userUUID, Valid: true}); err != nil {
 					// Log error but don't fail logout
-					log.Printf("Failed to increment token version: %v", err)
+					requestLogger(c).Warn("failed to increment token version on logout", "user_id", userUUID, "error", err)
 				}
 			}
 		}
 	}
 
-	http.SetCookie(c.Writer, cookie)
+	setRefreshCookie(c, "", -1) // Expire immediately
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
 }
+
+// SecureCookies marks the refresh token cookie Secure and SameSite=None so
+// the web app on another origin can send it; set from config at startup.
+// Plain-HTTP development setups leave it off and get SameSite=Lax.
+var SecureCookies bool
+
+// setRefreshCookie sets the refresh token cookie; maxAge -1 deletes it
+func setRefreshCookie(c *gin.Context, value string, maxAge int) {
+	cookie := &http.Cookie{
+		Name:     "refresh_token",
+		Value:    value,
+		Path:     "/", // Ensure cookie is available site-wide
+		MaxAge:   maxAge,
+		HttpOnly: true, // Prevent JavaScript access
+		Secure:   SecureCookies,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if SecureCookies {
+		cookie.SameSite = http.SameSiteNoneMode
+	}
+	http.SetCookie(c.Writer, cookie)
+}
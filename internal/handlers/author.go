@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	"github.com/THEGunDevil/GoForBackend/internal/db"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/THEGunDevil/GoForBackend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ListAuthorsHandler lists authors, optionally filtered by ?search=
+func (h *Handler) ListAuthorsHandler(c *gin.Context) {
+	page, limit := parsePagination(c)
+	offset := (page - 1) * limit
+	search := strings.TrimSpace(c.Query("search"))
+
+	rows, err := h.store.ListAuthors(c.Request.Context(), gen.ListAuthorsParams{
+		Column1: search,
+		Limit:   int32(limit),
+		Offset:  int32(offset),
+	})
+	if err != nil {
+		requestLogger(c).Error("failed to list authors", "error", err)
+		c.Error(apierror.Internal("failed to fetch authors", nil))
+		return
+	}
+
+	totalCount, err := h.store.CountAuthors(c.Request.Context(), search)
+	if err != nil {
+		requestLogger(c).Error("failed to count authors", "error", err)
+		c.Error(apierror.Internal("failed to fetch authors", nil))
+		return
+	}
+
+	authors := make([]models.AuthorResponse, 0, len(rows))
+	for _, a := range rows {
+		authors = append(authors, models.AuthorResponse{
+			ID:        a.ID.Bytes,
+			Name:      a.Name,
+			SortName:  a.SortName,
+			Bio:       a.Bio,
+			BookCount: a.BookCount,
+			CreatedAt: a.CreatedAt.Time,
+			UpdatedAt: a.UpdatedAt.Time,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"page":        page,
+		"limit":       limit,
+		"count":       len(authors),
+		"total_count": totalCount,
+		"total_pages": int(math.Ceil(float64(totalCount) / float64(limit))),
+		"authors":     authors,
+	})
+}
+
+// GetAuthorByIDHandler fetches a single author
+func (h *Handler) GetAuthorByIDHandler(c *gin.Context) {
+	authorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid author id"))
+		return
+	}
+
+	author, err := h.store.GetAuthorByID(c.Request.Context(), pgtype.UUID{Bytes: authorID, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.Error(apierror.NotFound("author not found"))
+		} else {
+			c.Error(apierror.Internal("something went wrong", err))
+		}
+		return
+	}
+
+	bookCount, err := h.store.CountBooksByAuthorID(c.Request.Context(), author.ID)
+	if err != nil {
+		c.Error(apierror.Internal("something went wrong", err))
+		return
+	}
+
+	resp := toAuthorResponse(author)
+	resp.BookCount = bookCount
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListBooksByAuthorHandler lists every book an author contributed to
+func (h *Handler) ListBooksByAuthorHandler(c *gin.Context) {
+	authorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid author id"))
+		return
+	}
+	page, limit := parsePagination(c)
+	offset := (page - 1) * limit
+	pgAuthorID := pgtype.UUID{Bytes: authorID, Valid: true}
+
+	author, err := h.store.GetAuthorByID(c.Request.Context(), pgAuthorID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.Error(apierror.NotFound("author not found"))
+		} else {
+			c.Error(apierror.Internal("something went wrong", err))
+		}
+		return
+	}
+
+	books, err := h.store.ListBooksByAuthorID(c.Request.Context(), gen.ListBooksByAuthorIDParams{
+		AuthorID: pgAuthorID,
+		Limit:    int32(limit),
+		Offset:   int32(offset),
+	})
+	if err != nil {
+		requestLogger(c).Error("failed to list books for author", "author_id", authorID, "error", err)
+		c.Error(apierror.Internal("failed to fetch books", nil))
+		return
+	}
+
+	totalCount, err := h.store.CountBooksByAuthorID(c.Request.Context(), pgAuthorID)
+	if err != nil {
+		c.Error(apierror.Internal("failed to fetch books", err))
+		return
+	}
+
+	response := make([]models.BookResponse, 0, len(books))
+	for _, book := range books {
+		response = append(response, toBookResponse(book))
+	}
+
+	authorResp := toAuthorResponse(author)
+	authorResp.BookCount = totalCount
+
+	c.JSON(http.StatusOK, gin.H{
+		"author":      authorResp,
+		"page":        page,
+		"limit":       limit,
+		"count":       len(response),
+		"total_count": totalCount,
+		"total_pages": int(math.Ceil(float64(totalCount) / float64(limit))),
+		"books":       response,
+	})
+}
+
+// CreateAuthorHandler adds an author (admin)
+func (h *Handler) CreateAuthorHandler(c *gin.Context) {
+	var req models.CreateAuthorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if len(req.Name) == 0 || len(req.Name) > 255 {
+		c.Error(apierror.BadRequest("name must be 1-255 characters"))
+		return
+	}
+	if req.SortName == "" {
+		req.SortName = req.Name
+	}
+
+	author, err := h.store.CreateAuthor(c.Request.Context(), gen.CreateAuthorParams{
+		Name:     req.Name,
+		SortName: req.SortName,
+		Bio:      req.Bio,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			c.Error(apierror.Conflict("an author with this name already exists"))
+			return
+		}
+		requestLogger(c).Error("failed to create author", "error", err)
+		c.Error(apierror.Internal("failed to create author", nil))
+		return
+	}
+
+	h.logAudit(c, "author.create", "author", uuid.UUID(author.ID.Bytes), nil, toAuthorResponse(author))
+	c.JSON(http.StatusCreated, toAuthorResponse(author))
+}
+
+// UpdateAuthorHandler updates an author (admin). Renaming an author also
+// refreshes the display author string of their books.
+func (h *Handler) UpdateAuthorHandler(c *gin.Context) {
+	authorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid author id"))
+		return
+	}
+
+	var req models.UpdateAuthorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+	if req.Name != nil && (len(strings.TrimSpace(*req.Name)) == 0 || len(*req.Name) > 255) {
+		c.Error(apierror.BadRequest("name must be 1-255 characters"))
+		return
+	}
+
+	before, err := h.store.GetAuthorByID(c.Request.Context(), pgtype.UUID{Bytes: authorID, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.Error(apierror.NotFound("author not found"))
+		} else {
+			c.Error(apierror.Internal("something went wrong", err))
+		}
+		return
+	}
+
+	author, err := h.store.UpdateAuthorByID(c.Request.Context(), gen.UpdateAuthorByIDParams{
+		Name:     optionalText(req.Name),
+		SortName: optionalText(req.SortName),
+		Bio:      optionalText(req.Bio),
+		ID:       pgtype.UUID{Bytes: authorID, Valid: true},
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			c.Error(apierror.NotFound("author not found"))
+		case isUniqueViolation(err):
+			c.Error(apierror.Conflict("an author with this name already exists"))
+		default:
+			requestLogger(c).Error("failed to update author", "author_id", authorID, "error", err)
+			c.Error(apierror.Internal("failed to update author", nil))
+		}
+		return
+	}
+
+	if req.Name != nil {
+		books, err := h.store.ListBooksByAuthorID(c.Request.Context(), gen.ListBooksByAuthorIDParams{
+			AuthorID: author.ID,
+			Limit:    math.MaxInt32,
+		})
+		if err != nil {
+			requestLogger(c).Warn("failed to list books to resync author", "author_id", authorID, "error", err)
+		}
+		for _, book := range books {
+			if err := h.store.SyncBookAuthorColumn(c.Request.Context(), book.ID); err != nil {
+				requestLogger(c).Warn("failed to resync author string", "book_id", uuid.UUID(book.ID.Bytes), "error", err)
+			}
+		}
+	}
+
+	h.logAudit(c, "author.update", "author", authorID, toAuthorResponse(before), toAuthorResponse(author))
+	c.JSON(http.StatusOK, toAuthorResponse(author))
+}
+
+// SetBookAuthorsHandler replaces the contributors of a book (admin)
+func (h *Handler) SetBookAuthorsHandler(c *gin.Context) {
+	bookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid book id"))
+		return
+	}
+
+	var req models.SetBookAuthorsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+
+	hasAuthor := false
+	for i, a := range req.Authors {
+		if a.Role == "" {
+			req.Authors[i].Role = "author"
+		}
+		if !service.BookAuthorRoles[req.Authors[i].Role] {
+			c.Error(apierror.BadRequest("role must be one of author, editor, translator, illustrator, contributor"))
+			return
+		}
+		if a.AuthorID == nil && strings.TrimSpace(a.Name) == "" {
+			c.Error(apierror.BadRequest("each entry needs an author_id or a name"))
+			return
+		}
+		if req.Authors[i].Role == "author" {
+			hasAuthor = true
+		}
+	}
+	if !hasAuthor {
+		c.Error(apierror.BadRequest("a book needs at least one author"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	pgBookID := pgtype.UUID{Bytes: bookID, Valid: true}
+	if _, err := h.store.GetBookByID(ctx, pgBookID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.Error(apierror.NotFound("book not found"))
+		} else {
+			c.Error(apierror.Internal("something went wrong", err))
+		}
+		return
+	}
+
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		if err := tx.DeleteBookAuthors(ctx, pgBookID); err != nil {
+			return err
+		}
+
+		for i, a := range req.Authors {
+			var authorID pgtype.UUID
+			if a.AuthorID != nil {
+				author, err := tx.GetAuthorByID(ctx, pgtype.UUID{Bytes: *a.AuthorID, Valid: true})
+				if errors.Is(err, pgx.ErrNoRows) {
+					return apierror.BadRequest("author " + a.AuthorID.String() + " not found")
+				}
+				if err != nil {
+					return err
+				}
+				authorID = author.ID
+			} else {
+				author, err := tx.UpsertAuthorByName(ctx, strings.TrimSpace(a.Name))
+				if err != nil {
+					return fmt.Errorf("upsert author %q: %w", a.Name, err)
+				}
+				authorID = author.ID
+			}
+
+			if err := tx.AddBookAuthor(ctx, gen.AddBookAuthorParams{
+				BookID:   pgBookID,
+				AuthorID: authorID,
+				Role:     a.Role,
+				Position: int32(i),
+			}); err != nil {
+				return fmt.Errorf("link author to book: %w", err)
+			}
+		}
+
+		if err := tx.SyncBookAuthorColumn(ctx, pgBookID); err != nil {
+			return fmt.Errorf("sync author string: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(c, err, "failed to update book authors")
+		return
+	}
+
+	authors, _, _, err := service.GetBookClassification(ctx, h.store, pgBookID)
+	if err != nil {
+		c.Error(apierror.Internal("something went wrong", err))
+		return
+	}
+	h.logAudit(c, "book.set_authors", "book", bookID, nil, authors)
+	c.JSON(http.StatusOK, gin.H{"book_id": bookID, "authors": authors})
+}
+
+func toAuthorResponse(a gen.Author) models.AuthorResponse {
+	return models.AuthorResponse{
+		ID:        a.ID.Bytes,
+		Name:      a.Name,
+		SortName:  a.SortName,
+		Bio:       a.Bio,
+		CreatedAt: a.CreatedAt.Time,
+		UpdatedAt: a.UpdatedAt.Time,
+	}
+}
+
+// optionalText maps an optional request field onto a sqlc.narg parameter.
+func optionalText(s *string) pgtype.Text {
+	if s == nil {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: strings.TrimSpace(*s), Valid: true}
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique_violation (23505).
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// isForeignKeyViolation reports whether err is a PostgreSQL foreign_key_violation (23503).
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
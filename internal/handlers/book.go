@@ -6,13 +6,13 @@ import (
 	// "encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"math"
 	"net/http"
 	"strconv"
 	"strings"
 	"sync"
 
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
 	"github.com/THEGunDevil/GoForBackend/internal/db"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
@@ -24,7 +24,7 @@ import (
 )
 
 // CreateBookHandler handles adding books
-func CreateBookHandler(c *gin.Context) {
+func (h *Handler) CreateBookHandler(c *gin.Context) {
 	var req models.CreateBookRequest
 
 	// Try binding JSON first
@@ -39,32 +39,30 @@ func CreateBookHandler(c *gin.Context) {
 		err = c.ShouldBindJSON(&req)
 	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.Invalid(err))
 		return
 	}
 	// VALIDATION
 	if len(req.Title) == 0 || len(req.Title) > 255 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "title must be 1-255 characters"})
+		c.Error(apierror.BadRequest("title must be 1-255 characters"))
 		return
 	}
 	if len(req.Author) == 0 || len(req.Author) > 100 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "author must be 1-100 characters"})
+		c.Error(apierror.BadRequest("author must be 1-100 characters"))
 		return
 	}
 	if len(req.Genre) == 0 || len(req.Genre) > 100 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "genre must be 1-100 characters"})
+		c.Error(apierror.BadRequest("genre must be 1-100 characters"))
 		return
 	}
 	if len(req.Description) == 0 || len(req.Description) > 255 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "description must be 1-255 characters"})
+		c.Error(apierror.BadRequest("description must be 1-255 characters"))
 		return
 	}
 
 	currentYear := time.Now().Year()
 	if req.PublishedYear < 1800 || req.PublishedYear > currentYear {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("published_year must be between 1800 and %d", currentYear),
-		})
+		c.Error(apierror.BadRequest(fmt.Sprintf("published_year must be between 1800 and %d", currentYear)))
 		return
 	}
 
@@ -73,33 +71,34 @@ func CreateBookHandler(c *gin.Context) {
 	if req.Image != nil {
 		f, err := req.Image.Open()
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open image"})
+			c.Error(apierror.Internal("failed to open image", err))
 			return
 		}
 		defer f.Close()
 
-		imageURL, err = service.UploadImageToCloudinary(f, req.Image.Filename)
+		imageURL, err = service.UploadImageToCloudinary(c.Request.Context(), f, req.Image.Filename)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "image upload failed"})
+			c.Error(apierror.Internal("image upload failed", err))
 			return
 		}
 	}
 
 	// CREATE BOOK IN DB
-	bookResp, err := service.AddBook(req, imageURL)
+	bookResp, err := h.svc.Books.AddBook(c.Request.Context(), req, imageURL)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
+	h.logAudit(c, "book.create", "book", bookResp.ID, nil, bookResp)
 
 	// === SEND NOTIFICATIONS TO ALL USERS ===
-	go func(book models.BookResponse) {
-		ctx := context.Background()
-
+	logger := requestLogger(c).With("book_id", bookResp.ID)
+	book := bookResp
+	service.Go(c.Request.Context(), func(ctx context.Context) {
 		// Fetch all user IDs (EXAMPLE QUERY)
-		users, err := db.Q.GetAllUser(ctx)
+		users, err := h.store.GetAllUser(ctx)
 		if err != nil {
-			log.Printf("❌ Failed to fetch users for new arrival notification: %v", err)
+			logger.Error("failed to fetch users for new arrival notification", "error", err)
 			return
 		}
 
@@ -110,11 +109,11 @@ func CreateBookHandler(c *gin.Context) {
 			go func(userID uuid.UUID) {
 				defer wg.Done()
 
-				err := service.NotificationService(ctx, models.SendNotificationRequest{
+				err := h.svc.Notifications.Dispatch(ctx, models.SendNotificationRequest{
 					UserID:            userID,
 					ObjectID:          &book.ID,
 					ObjectTitle:       book.Title,
-					Type:              "NEW_ARRIVAL",
+					Type:              service.NotificationNewArrival,
 					NotificationTitle: "A new book has arrived!",
 					Message: fmt.Sprintf(
 						"A new book titled '%s' is now available in the library. Check it out!",
@@ -123,20 +122,20 @@ func CreateBookHandler(c *gin.Context) {
 				})
 
 				if err != nil {
-					log.Printf("❌ Failed to notify user %s: %v", userID, err)
+					logger.Error("failed to send new arrival notification", "target_user_id", userID, "error", err)
 				}
 			}(u.ID.Bytes)
 		}
 
 		wg.Wait()
-		log.Println("📢 New Arrival notifications sent to all users.")
-	}(bookResp)
+		logger.Info("new arrival notifications sent", "users", len(users))
+	})
 
 	// FINAL RESPONSE
 	c.JSON(http.StatusCreated, bookResp)
 }
 
-func GetBooksHandler(c *gin.Context) {
+func (h *Handler) GetBooksHandler(c *gin.Context) {
 	page := 1
 	limit := 10
 
@@ -154,22 +153,24 @@ func GetBooksHandler(c *gin.Context) {
 
 	offset := (page - 1) * limit
 
+	withDeleted := includeDeleted(c)
 	params := gen.ListBooksPaginatedParams{
-		Limit:  int32(limit),
-		Offset: int32(offset),
+		Limit:   int32(limit),
+		Offset:  int32(offset),
+		Column3: withDeleted,
 	}
 
 	// 1️⃣ Fetch paginated books
-	books, err := db.Q.ListBooksPaginated(c.Request.Context(), params)
+	books, err := h.store.ListBooksPaginated(c.Request.Context(), params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 
 	// 2️⃣ Fetch total count of all books
-	totalCount, err := db.Q.CountBooks(c.Request.Context())
+	totalCount, err := h.store.CountBooks(c.Request.Context(), withDeleted)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 
@@ -179,20 +180,7 @@ func GetBooksHandler(c *gin.Context) {
 	// Convert to response model
 	var response []models.BookResponse
 	for _, book := range books {
-		response = append(response, models.BookResponse{
-			ID:              book.ID.Bytes,
-			Title:           book.Title,
-			Author:          book.Author,
-			PublishedYear:   book.PublishedYear.Int32,
-			Isbn:            book.Isbn.String,
-			AvailableCopies: book.AvailableCopies.Int32,
-			TotalCopies:     book.TotalCopies,
-			Genre:           book.Genre,
-			Description:     book.Description,
-			CreatedAt:       book.CreatedAt.Time,
-			UpdatedAt:       book.UpdatedAt.Time,
-			ImageURL:        book.ImageUrl,
-		})
+		response = append(response, toBookResponse(book))
 	}
 
 	// 4️⃣ Return all pagination info
@@ -207,73 +195,164 @@ func GetBooksHandler(c *gin.Context) {
 }
 
 // GetBookByIDHandler fetches a book by its ID
-func GetBookByIDHandler(c *gin.Context) {
+func (h *Handler) GetBookByIDHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	parsedID, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		c.Error(apierror.BadRequest("Invalid book ID"))
 		return
 	}
 
-	book, err := db.Q.GetBookByID(c.Request.Context(), pgtype.UUID{Bytes: parsedID, Valid: true})
+	book, err := h.store.GetBookByID(c.Request.Context(), pgtype.UUID{Bytes: parsedID, Valid: true})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "book not found"})
+			c.Error(apierror.NotFound("book not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong"})
+			c.Error(apierror.Internal("something went wrong", err))
 		}
 		return
 	}
+	if book.DeletedAt.Valid && !includeDeleted(c) {
+		c.Error(apierror.NotFound("book not found"))
+		return
+	}
 
-	response := models.BookResponse{
-		ID:              book.ID.Bytes,
-		Title:           book.Title,
-		Author:          book.Author,
-		PublishedYear:   book.PublishedYear.Int32,
-		Isbn:            book.Isbn.String,
-		AvailableCopies: book.AvailableCopies.Int32,
-		TotalCopies:     book.TotalCopies,
-		Genre:           book.Genre,
-		Description:     book.Description,
-		CreatedAt:       book.CreatedAt.Time,
-		UpdatedAt:       book.UpdatedAt.Time,
-		ImageURL:        book.ImageUrl,
+	response := toBookResponse(book)
+
+	// Attach the normalized authors, genres and series
+	response.Authors, response.Genres, response.Series, err = service.GetBookClassification(c.Request.Context(), h.store, book.ID)
+	if err != nil {
+		requestLogger(c).Error("failed to load book classification", "book_id", parsedID, "error", err)
+		c.Error(apierror.Internal("something went wrong", nil))
+		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
-// DeleteBookHandler deletes a book by ID
-func DeleteBookHandler(c *gin.Context) {
+// DeleteBookHandler moves a book to the bin. Its borrow, review and
+// reservation history is kept until the book is purged.
+func (h *Handler) DeleteBookHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	parsedID, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book id"})
+		c.Error(apierror.BadRequest("invalid book id"))
 		return
 	}
 
-	_, err = db.Q.DeleteBookByID(c.Request.Context(), pgtype.UUID{Bytes: parsedID, Valid: true})
+	ctx := c.Request.Context()
+	bookID := pgtype.UUID{Bytes: parsedID, Valid: true}
+
+	var book gen.Book
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		// Lock the book so no copy can be checked out between the count
+		// and the delete
+		_, err := tx.GetBookByIDForUpdate(ctx, bookID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NotFound("book not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		// Copies still out on loan have to come back first
+		active, err := tx.CountActiveBorrowsByBookID(ctx, bookID)
+		if err != nil {
+			return err
+		}
+		if active > 0 {
+			return apierror.Conflict("book has copies on loan").With("active_borrows", active)
+		}
+
+		if book, err = tx.SoftDeleteBookByID(ctx, bookID); err != nil {
+			return err
+		}
+		if err := tx.CancelOpenReservationsByBookID(ctx, bookID); err != nil {
+			return fmt.Errorf("cancel reservations: %w", err)
+		}
+		if err := recordAudit(c, tx, "book.delete", "book", parsedID, nil, toBookResponse(book)); err != nil {
+			return fmt.Errorf("audit book deletion: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
+		writeTxError(c, err, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "book deleted", "book": toBookResponse(book)})
+}
+
+// RestoreBookHandler brings a soft-deleted book back into the catalog
+func (h *Handler) RestoreBookHandler(c *gin.Context) {
+	parsedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid book id"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	var book gen.Book
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		var err error
+		book, err = tx.RestoreBookByID(ctx, pgtype.UUID{Bytes: parsedID, Valid: true})
 		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "book not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong"})
+			return apierror.NotFound("deleted book not found")
+		}
+		if err != nil {
+			return err
+		}
+		if err := recordAudit(c, tx, "book.restore", "book", parsedID, nil, toBookResponse(book)); err != nil {
+			return fmt.Errorf("audit book restore: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(c, err, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "book restored", "book": toBookResponse(book)})
+}
+
+// PurgeBookHandler permanently removes a book that is already in the bin,
+// together with its history
+func (h *Handler) PurgeBookHandler(c *gin.Context) {
+	parsedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid book id"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		book, err := tx.PurgeBookByID(ctx, pgtype.UUID{Bytes: parsedID, Valid: true})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NotFound("book not found or not deleted")
+		}
+		if err != nil {
+			return fmt.Errorf("purge book: %w", err)
+		}
+		if err := recordAudit(c, tx, "book.purge", "book", parsedID, toBookResponse(book), nil); err != nil {
+			return fmt.Errorf("audit book purge: %w", err)
 		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(c, err, "something went wrong")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "book deleted"})
+	c.JSON(http.StatusOK, gin.H{"message": "book purged"})
 }
 
 // UpdateBookByIDHandler updates a book by ID
-func UpdateBookByIDHandler(c *gin.Context) {
+func (h *Handler) UpdateBookByIDHandler(c *gin.Context) {
 	idStr := c.Param("id")
-	log.Printf("📝 [DEBUG] UpdateBookByIDHandler called with ID param: %s", idStr)
 	var err error
 	parsedID, err := uuid.Parse(idStr)
 	if err != nil {
-		log.Printf("❌ [DEBUG] Invalid UUID param: %s", idStr)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		c.Error(apierror.BadRequest("Invalid book ID"))
 		return
 	}
 
@@ -287,11 +366,20 @@ func UpdateBookByIDHandler(c *gin.Context) {
 		err = c.ShouldBindJSON(&req)
 	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.Invalid(err))
 		return
 	}
 
-	log.Printf("📘 [DEBUG] Update request: %+v", req)
+
+	before, err := h.store.GetBookByID(c.Request.Context(), pgtype.UUID{Bytes: parsedID, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.Error(apierror.NotFound("book not found"))
+		} else {
+			c.Error(apierror.Internal("something went wrong", err))
+		}
+		return
+	}
 
 	params := gen.UpdateBookByIDParams{
 		ID: pgtype.UUID{Bytes: parsedID, Valid: true},
@@ -321,21 +409,20 @@ func UpdateBookByIDHandler(c *gin.Context) {
 	params.TotalCopies = setInt(req.TotalCopies)
 	params.AvailableCopies = setInt(req.AvailableCopies)
 
-	log.Printf("🧩 [DEBUG] UpdateBookByIDParams ready: %+v", params)
 
 	// Upload image if exists
 	var imageURL string
 	if req.Image != nil {
 		f, err := req.Image.Open()
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open image"})
+			c.Error(apierror.Internal("failed to open image", err))
 			return
 		}
 		defer f.Close()
 
-		imageURL, err = service.UploadImageToCloudinary(f, req.Image.Filename)
+		imageURL, err = service.UploadImageToCloudinary(c.Request.Context(), f, req.Image.Filename)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "image upload failed"})
+			c.Error(apierror.Internal("image upload failed", err))
 			return
 		}
 	}
@@ -343,25 +430,49 @@ func UpdateBookByIDHandler(c *gin.Context) {
 		params.ImageUrl = pgtype.Text{String: imageURL, Valid: true}
 	}
 
-	updatedBook, err := db.Q.UpdateBookByID(c.Request.Context(), params)
+	// The update, the author/genre links that mirror its display strings and
+	// the audit entry commit together
+	ctx := c.Request.Context()
+	var updatedBook gen.Book
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		var err error
+		updatedBook, err = tx.UpdateBookByID(ctx, params)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NotFound("book not found")
+		}
+		if err != nil {
+			return fmt.Errorf("update book: %w", err)
+		}
+		if params.Author.Valid {
+			if err := service.LinkBookAuthors(ctx, tx, updatedBook.ID, service.SplitAuthors(updatedBook.Author)); err != nil {
+				return fmt.Errorf("relink book authors: %w", err)
+			}
+		}
+		if params.Genre.Valid {
+			if err := service.LinkBookGenres(ctx, tx, updatedBook.ID, service.SplitGenres(updatedBook.Genre)); err != nil {
+				return fmt.Errorf("relink book genres: %w", err)
+			}
+		}
+		if err := recordAudit(c, tx, "book.update", "book", parsedID, toBookResponse(before), toBookResponse(updatedBook)); err != nil {
+			return fmt.Errorf("audit book update: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		log.Printf("❌ [DEBUG] DB update failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong"})
+		writeTxError(c, err, "failed to update book")
 		return
 	}
-
-	log.Printf("✅ [DEBUG] Book updated successfully: %s | AvailableCopies=%d",
-		updatedBook.Title, updatedBook.AvailableCopies.Int32)
+	requestLogger(c).Debug("book updated", "book_id", parsedID, "available_copies", updatedBook.AvailableCopies.Int32)
 
 	updatedBookID, err := uuid.FromBytes(updatedBook.ID.Bytes[:])
 	if err != nil {
-		log.Printf("⚠️ [DEBUG] Failed to parse book UUID: %v", err)
+		requestLogger(c).Warn("failed to parse book ID", "error", err)
 		updatedBookID = uuid.Nil
 	}
 
 	// Notify reservations
 	if updatedBook.AvailableCopies.Valid && updatedBook.AvailableCopies.Int32 > 0 {
-		reservations, err := db.Q.GetReservationsByBookID(c.Request.Context(), updatedBook.ID)
+		reservations, err := h.store.GetReservationsByBookID(c.Request.Context(), updatedBook.ID)
 		if err == nil && len(reservations) > 0 {
 			var wg sync.WaitGroup
 
@@ -373,27 +484,27 @@ func UpdateBookByIDHandler(c *gin.Context) {
 					ctx := c.Request.Context()
 					userUUID, _ := uuid.FromBytes(userID.Bytes[:])
 
-					err := service.NotificationService(ctx, models.SendNotificationRequest{
+					err := h.svc.Notifications.Dispatch(ctx, models.SendNotificationRequest{
 						UserID:            userUUID,
 						ObjectID:          &updatedBookID,
 						ObjectTitle:       updatedBook.Title,
-						Type:              "BOOK_AVAILABLE",
+						Type:              service.NotificationBookAvailable,
 						NotificationTitle: "Your reserved book is now available!",
 						Message:           fmt.Sprintf("The book '%s' you reserved is now available.", updatedBook.Title),
 					})
 
 					if err != nil {
-						log.Printf("❌ Failed to send notification to user %s: %v", userUUID, err)
+						requestLogger(c).Error("failed to send book available notification", "target_user_id", userUUID, "error", err)
 						return
 					}
 
 					// Update reservation status to 'notified'
-					_, err = db.Q.UpdateReservationStatus(ctx, gen.UpdateReservationStatusParams{
+					_, err = h.store.UpdateReservationStatus(ctx, gen.UpdateReservationStatusParams{
 						ID:     r.ID,
 						Status: "notified",
 					})
 					if err != nil {
-						log.Printf("❌ Failed to update reservation %s to 'notified': %v", r.ID, err)
+						requestLogger(c).Error("failed to mark reservation notified", "reservation_id", uuid.UUID(r.ID.Bytes), "error", err)
 						return
 					}
 				}(userID)
@@ -403,7 +514,6 @@ func UpdateBookByIDHandler(c *gin.Context) {
 		}
 	}
 
-	log.Printf("✅ [DEBUG] Book update flow complete: ID=%v", updatedBookID)
 
 	c.JSON(http.StatusOK, models.BookResponse{
 		ID:              updatedBook.ID.Bytes,
@@ -422,7 +532,7 @@ func UpdateBookByIDHandler(c *gin.Context) {
 }
 
 // SearchBooksHandler searches books by title/author/genre
-func SearchBooksPaginatedHandler(c *gin.Context) {
+func (h *Handler) SearchBooksPaginatedHandler(c *gin.Context) {
 	// Pagination
 	page := 1
 	limit := 10
@@ -445,17 +555,15 @@ func SearchBooksPaginatedHandler(c *gin.Context) {
 	query := strings.TrimSpace(c.Query("query"))
 	genre := strings.TrimSpace(c.Query("genre"))
 
-	// Default to "all" if genre is empty
-	if genre == "" || genre == "all" {
-		genre = "all"
-	}
+	// Genres are matched on their taxonomy slug; empty means "all"
+	genre = genreFilter(genre)
 
 	// Default to empty string if query is empty (will match all)
 	if query == "" {
 		query = ""
 	}
 
-	log.Printf("🔍 Search: query='%s', genre='%s', page=%d", query, genre, page)
+	requestLogger(c).Debug("searching books", "query", query, "genre", genre, "page", page)
 
 	// SQLC params - pass strings directly
 	params := gen.SearchBooksWithPaginationParams{
@@ -466,15 +574,15 @@ func SearchBooksPaginatedHandler(c *gin.Context) {
 	}
 
 	// Execute query
-	rows, err := db.Q.SearchBooksWithPagination(c.Request.Context(), params)
+	rows, err := h.store.SearchBooksWithPagination(c.Request.Context(), params)
 	if err != nil {
-		log.Printf("❌ Search error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch books"})
+		requestLogger(c).Error("failed to search books", "error", err)
+		c.Error(apierror.Internal("failed to fetch books", nil))
 		return
 	}
 
 	// Count total for pagination (optional but useful)
-	totalCount, _ := db.Q.CountSearchBooks(c.Request.Context(), gen.CountSearchBooksParams{
+	totalCount, _ := h.store.CountSearchBooks(c.Request.Context(), gen.CountSearchBooksParams{
 		Column1: genre,
 		Column2: query, // search query (can be empty)
 	})
@@ -510,16 +618,16 @@ func SearchBooksPaginatedHandler(c *gin.Context) {
 	})
 }
 
-func ListGenresHandler(c *gin.Context) {
-	genres, err := db.Q.ListGenres(c.Request.Context())
+func (h *Handler) ListGenresHandler(c *gin.Context) {
+	genres, err := h.store.ListGenres(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 
 	c.JSON(http.StatusOK, genres)
 }
-func ListBooksByGenreHandler(c *gin.Context) {
+func (h *Handler) ListBooksByGenreHandler(c *gin.Context) {
 	page := 1
 	limit := 10
 
@@ -538,20 +646,21 @@ func ListBooksByGenreHandler(c *gin.Context) {
 	offset := (page - 1) * limit
 	genre := c.Param("genre")
 	if genre == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "genre is required"})
+		c.Error(apierror.BadRequest("genre is required"))
 		return
 	}
+	genre = genreFilter(genre)
 
-	books, err := db.Q.FilterBooksByGenre(c.Request.Context(), gen.FilterBooksByGenreParams{
+	books, err := h.store.FilterBooksByGenre(c.Request.Context(), gen.FilterBooksByGenreParams{
 		Column1:  genre,
 		Limit:  int32(limit),
 		Offset: int32(offset),
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
-	totalCount, _ := db.Q.CountBooksByGenre(c.Request.Context(), genre)
+	totalCount, _ := h.store.CountBooksByGenre(c.Request.Context(), genre)
 	// Map to response model
 	var response []models.BookResponse
 	for _, book := range books {
@@ -580,3 +689,50 @@ func ListBooksByGenreHandler(c *gin.Context) {
 		"books":       response,
 	})
 }
+
+// genreFilter normalizes a genre query value to a taxonomy slug, or "all".
+func genreFilter(genre string) string {
+	genre = strings.TrimSpace(genre)
+	if genre == "" || strings.EqualFold(genre, "all") {
+		return "all"
+	}
+	return service.Slugify(genre)
+}
+
+// toBookResponse converts a books row into the API response model.
+func toBookResponse(book gen.Book) models.BookResponse {
+	return models.BookResponse{
+		ID:              book.ID.Bytes,
+		Title:           book.Title,
+		Author:          book.Author,
+		PublishedYear:   book.PublishedYear.Int32,
+		Isbn:            book.Isbn.String,
+		AvailableCopies: book.AvailableCopies.Int32,
+		TotalCopies:     book.TotalCopies,
+		Genre:           book.Genre,
+		Description:     book.Description,
+		CreatedAt:       book.CreatedAt.Time,
+		UpdatedAt:       book.UpdatedAt.Time,
+		ImageURL:        book.ImageUrl,
+		DeletedAt:       timePtr(book.DeletedAt),
+	}
+}
+
+// timePtr returns nil for a NULL timestamp
+func timePtr(ts pgtype.Timestamp) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	t := ts.Time
+	return &t
+}
+
+// includeDeleted reports whether soft-deleted rows should be returned:
+// only admins may ask for them with ?include_deleted=true.
+func includeDeleted(c *gin.Context) bool {
+	if c.Query("include_deleted") != "true" {
+		return false
+	}
+	role, _ := c.Get("role")
+	return role == "admin"
+}
@@ -7,66 +7,68 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/THEGunDevil/GoForBackend/internal/db"
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
-	"github.com/THEGunDevil/GoForBackend/internal/service"
+	"github.com/THEGunDevil/GoForBackend/internal/telemetry"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-func BorrowBookHandler(c *gin.Context) {
+func (h *Handler) BorrowBookHandler(c *gin.Context) {
 	userIDVal, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apierror.Unauthorized("unauthorized"))
 		return
 	}
 
 	userUUID, ok := userIDVal.(uuid.UUID)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user ID type"})
+		c.Error(apierror.Internal("invalid user ID type", nil))
 		return
 	}
 
 	var req models.CreateBorrowRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		c.Error(apierror.Invalid(err))
 		return
 	}
 
-	borrowRes, err := service.Borrow(userUUID, req)
+	borrowRes, err := h.svc.Borrows.Borrow(c.Request.Context(), userUUID, req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
+	telemetry.Borrows.Inc()
 
 	c.JSON(http.StatusCreated, borrowRes)
 }
 
-func ReturnBookHandler(c *gin.Context) {
+func (h *Handler) ReturnBookHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	parsedID, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid borrow ID"})
+		c.Error(apierror.BadRequest("invalid borrow ID"))
 		return
 	}
 	var req models.ReturnBookRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		c.Error(apierror.Invalid(err))
 		return
 	}
 
-	resp, err := service.Return(parsedID, req)
+	resp, err := h.svc.Borrows.Return(c.Request.Context(), parsedID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
+	telemetry.Returns.Inc()
 
 	c.JSON(http.StatusOK, resp)
 }
-func GetAllBorrowsHandlers(c *gin.Context) {
+func (h *Handler) GetAllBorrowsHandlers(c *gin.Context) {
 	page := 1
 	limit := 10
 
@@ -90,16 +92,16 @@ func GetAllBorrowsHandlers(c *gin.Context) {
 	}
 
 	// 1️⃣ Fetch paginated borrows
-	borrows, err := db.Q.ListBorrowPaginated(c.Request.Context(), params)
+	borrows, err := h.store.ListBorrowPaginated(c.Request.Context(), params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 
 	// 2️⃣ Total count
-	totalCount, err := db.Q.CountAllBorrows(c.Request.Context())
+	totalCount, err := h.store.CountAllBorrows(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 
@@ -113,9 +115,9 @@ func GetAllBorrowsHandlers(c *gin.Context) {
 		if b.ReturnedAt.Valid {
 			returnedAt = &b.ReturnedAt.Time
 		}
-		user, err := db.Q.GetUserByID(c.Request.Context(), b.UserID)
+		user, err := h.store.GetUserByID(c.Request.Context(), b.UserID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Error(apierror.Internal("something went wrong", err))
 			return
 		}
 
@@ -141,22 +143,22 @@ func GetAllBorrowsHandlers(c *gin.Context) {
 		"borrows":     response,
 	})
 }
-func GetBorrowsByUserIDHandler(c *gin.Context) {
+func (h *Handler) GetBorrowsByUserIDHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	parsedID, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(apierror.BadRequest("Invalid user ID"))
 		return
 	}
-	borrows, err := db.Q.ListBorrowByUserID(
+	borrows, err := h.store.ListBorrowByUserID(
 		c.Request.Context(),
 		pgtype.UUID{Bytes: parsedID, Valid: true},
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "borrows not found"})
+			c.Error(apierror.NotFound("borrows not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong"})
+			c.Error(apierror.Internal("something went wrong", err))
 		}
 		return
 	}
@@ -176,22 +178,22 @@ func GetBorrowsByUserIDHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
-func GetBorrowsByBookIDHandler(c *gin.Context) {
+func (h *Handler) GetBorrowsByBookIDHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	parsedID, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		c.Error(apierror.BadRequest("Invalid book ID"))
 		return
 	}
-	borrows, err := db.Q.ListBorrowByBookID(
+	borrows, err := h.store.ListBorrowByBookID(
 		c.Request.Context(),
 		pgtype.UUID{Bytes: parsedID, Valid: true},
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "borrows not found"})
+			c.Error(apierror.NotFound("borrows not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong"})
+			c.Error(apierror.Internal("something went wrong", err))
 		}
 		return
 	}
@@ -211,23 +213,23 @@ func GetBorrowsByBookIDHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
-func GetBorrowByBookAndUserIDHandler(c *gin.Context) {
+func (h *Handler) GetBorrowByBookAndUserIDHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	parsedID, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		c.Error(apierror.BadRequest("Invalid book ID"))
 		return
 	}
 	userIDVal, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "userID not found in context"})
+		c.Error(apierror.Unauthorized("userID not found in context"))
 		return
 	}
 
 	// userIDVal is interface{}, convert to string first
 	userID, ok := userIDVal.(uuid.UUID)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "userID is not a string"})
+		c.Error(apierror.BadRequest("userID is not a string"))
 		return
 	}
 
@@ -235,15 +237,15 @@ func GetBorrowByBookAndUserIDHandler(c *gin.Context) {
 		UserID: pgtype.UUID{Bytes: userID, Valid: true},
 		BookID: pgtype.UUID{Bytes: parsedID, Valid: true},
 	}
-	b, err := db.Q.FilterBorrowByUserAndBookID(
+	b, err := h.store.FilterBorrowByUserAndBookID(
 		c.Request.Context(),
 		params,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "borrows not found"})
+			c.Error(apierror.NotFound("borrows not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong"})
+			c.Error(apierror.Internal("something went wrong", err))
 		}
 		return
 	}
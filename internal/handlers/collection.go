@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/THEGunDevil/GoForBackend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Collection insights help decide what to buy and what to weed. Ranges take
+// ?from=&to=&tz= like /analytics and default to the last 12 months.
+
+// CollectionTurnoverHandler returns borrows per copy over a range, per title
+// (paginated, ?sort=asc for the slowest first) or with ?group=genre per
+// top-level genre
+func (h *Handler) CollectionTurnoverHandler(c *gin.Context) {
+	from, to, err := service.ParseDateRange(c.Request.URL.Query(), time.Now())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	fromTime := pgtype.Timestamp{Time: from.UTC(), Valid: true}
+	toTime := pgtype.Timestamp{Time: to.UTC(), Valid: true}
+
+	switch c.DefaultQuery("group", "title") {
+	case "genre":
+		rows, err := h.store.CollectionTurnoverByGenre(c.Request.Context(), gen.CollectionTurnoverByGenreParams{
+			FromTime: fromTime,
+			ToTime:   toTime,
+		})
+		if err != nil {
+			requestLogger(c).Error("failed to compute genre turnover", "error", err)
+			c.Error(apierror.Internal("failed to compute turnover", nil))
+			return
+		}
+		genres := make([]models.GenreTurnover, 0, len(rows))
+		for _, r := range rows {
+			genres = append(genres, models.GenreTurnover{
+				Genre:    r.Genre,
+				Titles:   int(r.Titles),
+				Copies:   int(r.Copies),
+				Borrows:  int(r.Borrows),
+				Turnover: r.Turnover,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "genres": genres})
+	case "title":
+		page, limit := parsePagination(c)
+		rows, err := h.store.CollectionTurnoverByTitle(c.Request.Context(), gen.CollectionTurnoverByTitleParams{
+			FromTime:  fromTime,
+			ToTime:    toTime,
+			Ascending: c.Query("sort") == "asc",
+			RowLimit:  int32(limit),
+			RowOffset: int32((page - 1) * limit),
+		})
+		if err != nil {
+			requestLogger(c).Error("failed to compute title turnover", "error", err)
+			c.Error(apierror.Internal("failed to compute turnover", nil))
+			return
+		}
+		totalCount, err := h.store.CountCollectionTitles(c.Request.Context())
+		if err != nil {
+			c.Error(apierror.Internal("failed to compute turnover", err))
+			return
+		}
+		titles := make([]models.TitleTurnover, 0, len(rows))
+		for _, r := range rows {
+			titles = append(titles, models.TitleTurnover{
+				BookID:      r.BookID.Bytes,
+				Title:       r.Title,
+				Author:      r.Author,
+				TotalCopies: int(r.TotalCopies),
+				Borrows:     int(r.Borrows),
+				Turnover:    r.Turnover,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"from":        from,
+			"to":          to,
+			"page":        page,
+			"limit":       limit,
+			"count":       len(titles),
+			"total_count": totalCount,
+			"total_pages": int(math.Ceil(float64(totalCount) / float64(limit))),
+			"titles":      titles,
+		})
+	default:
+		c.Error(apierror.BadRequest("group must be title or genre"))
+	}
+}
+
+// DeadStockHandler lists titles not borrowed in the last ?months=N (12 by
+// default), never-borrowed titles first. Titles added since then are left out.
+func (h *Handler) DeadStockHandler(c *gin.Context) {
+	months := 12
+	if m := c.Query("months"); m != "" {
+		parsed, err := strconv.Atoi(m)
+		if err != nil || parsed < 1 || parsed > 120 {
+			c.Error(apierror.BadRequest("months must be between 1 and 120"))
+			return
+		}
+		months = parsed
+	}
+	cutoff := pgtype.Timestamp{Time: time.Now().UTC().AddDate(0, -months, 0), Valid: true}
+	page, limit := parsePagination(c)
+
+	rows, err := h.store.ListDeadStock(c.Request.Context(), gen.ListDeadStockParams{
+		Cutoff:    cutoff,
+		RowLimit:  int32(limit),
+		RowOffset: int32((page - 1) * limit),
+	})
+	if err != nil {
+		requestLogger(c).Error("failed to list dead stock", "error", err)
+		c.Error(apierror.Internal("failed to fetch dead stock", nil))
+		return
+	}
+	totalCount, err := h.store.CountDeadStock(c.Request.Context(), cutoff)
+	if err != nil {
+		c.Error(apierror.Internal("failed to fetch dead stock", err))
+		return
+	}
+
+	books := make([]models.DeadStockBook, 0, len(rows))
+	for _, r := range rows {
+		books = append(books, models.DeadStockBook{
+			BookID:         r.BookID.Bytes,
+			Title:          r.Title,
+			Author:         r.Author,
+			TotalCopies:    int(r.TotalCopies),
+			AddedAt:        r.CreatedAt.Time,
+			LastBorrowedAt: timePtr(r.LastBorrowedAt),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"months":      months,
+		"cutoff":      cutoff.Time,
+		"page":        page,
+		"limit":       limit,
+		"count":       len(books),
+		"total_count": totalCount,
+		"total_pages": int(math.Ceil(float64(totalCount) / float64(limit))),
+		"books":       books,
+	})
+}
+
+// HoldStatsHandler lists each title's average hold queue length and wait
+// time over a range, longest queues first
+func (h *Handler) HoldStatsHandler(c *gin.Context) {
+	from, to, err := service.ParseDateRange(c.Request.URL.Query(), time.Now())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	fromTime := pgtype.Timestamp{Time: from.UTC(), Valid: true}
+	toTime := pgtype.Timestamp{Time: to.UTC(), Valid: true}
+	page, limit := parsePagination(c)
+
+	rows, err := h.store.CollectionHoldStats(c.Request.Context(), gen.CollectionHoldStatsParams{
+		FromTime:  fromTime,
+		ToTime:    toTime,
+		RowLimit:  int32(limit),
+		RowOffset: int32((page - 1) * limit),
+	})
+	if err != nil {
+		requestLogger(c).Error("failed to compute hold stats", "error", err)
+		c.Error(apierror.Internal("failed to compute hold stats", nil))
+		return
+	}
+	totalCount, err := h.store.CountCollectionHoldStats(c.Request.Context(), gen.CountCollectionHoldStatsParams{
+		ToTime:   toTime,
+		FromTime: fromTime,
+	})
+	if err != nil {
+		c.Error(apierror.Internal("failed to compute hold stats", err))
+		return
+	}
+
+	books := make([]models.BookHoldStats, 0, len(rows))
+	for _, r := range rows {
+		stats := models.BookHoldStats{
+			BookID:         r.BookID.Bytes,
+			Title:          r.Title,
+			TotalCopies:    int(r.TotalCopies),
+			QueueLength:    int(r.QueueLength),
+			Holds:          int(r.Holds),
+			AvgQueueLength: r.AvgQueueLength,
+		}
+		if r.AvgWaitSeconds.Valid {
+			hours := r.AvgWaitSeconds.Float64 / 3600
+			stats.AvgWaitHours = &hours
+		}
+		books = append(books, stats)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":        from,
+		"to":          to,
+		"page":        page,
+		"limit":       limit,
+		"count":       len(books),
+		"total_count": totalCount,
+		"total_pages": int(math.Ceil(float64(totalCount) / float64(limit))),
+		"books":       books,
+	})
+}
+
+// maxRecommendations caps ?limit= on purchase recommendations
+const maxRecommendations = 100
+
+// PurchaseRecommendationsHandler ranks titles whose hold queue is longer
+// than the number of copies owned (?limit=, 20 by default, at most 100)
+func (h *Handler) PurchaseRecommendationsHandler(c *gin.Context) {
+	limit := 20
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			limit = min(parsed, maxRecommendations)
+		}
+	}
+	rows, err := h.store.ListPurchaseRecommendations(c.Request.Context(), int32(limit))
+	if err != nil {
+		requestLogger(c).Error("failed to list purchase recommendations", "error", err)
+		c.Error(apierror.Internal("failed to fetch recommendations", nil))
+		return
+	}
+	recommendations := make([]models.PurchaseRecommendation, 0, len(rows))
+	for _, r := range rows {
+		recommendations = append(recommendations, models.PurchaseRecommendation{
+			BookID:          r.BookID.Bytes,
+			Title:           r.Title,
+			Author:          r.Author,
+			TotalCopies:     int(r.TotalCopies),
+			AvailableCopies: int(r.AvailableCopies.Int32),
+			QueueLength:     int(r.QueueLength),
+			SuggestedCopies: int(r.Shortfall),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"count": len(recommendations), "recommendations": recommendations})
+}
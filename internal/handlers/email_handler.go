@@ -1,90 +1,94 @@
 package handlers
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"github.com/gin-gonic/gin"
 	"net/http"
-	"os"
-)
-
-// SendEmailViaResend sends email using Resend API
-func SendEmailViaResend(name, email, to, subject, body string) error {
-	apiKey := os.Getenv("RESEND_API_KEY")
-	if apiKey == "" {
-		return fmt.Errorf("RESEND_API_KEY not set")
-	}
 
-	payload := map[string]interface{}{
-		"from":     "Book Library Support <onboarding@resend.dev>",
-		"to":       []string{to},
-		"subject":  subject,
-		"text":     body,
-		"reply_to": email, // Allow replies to go to the sender
-	}
-
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal json: %v", err)
-	}
-
-	req, err := http.NewRequest("POST", "https://api.resend.com/emails", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 && resp.StatusCode != 202 {
-		var result map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&result)
-		return fmt.Errorf("resend API error %d: %v", resp.StatusCode, result)
-	}
-
-	return nil
-}
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	"github.com/THEGunDevil/GoForBackend/internal/db"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/mailer"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/THEGunDevil/GoForBackend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
 
 // ContactRequest represents the incoming contact form request
 type ContactRequest struct {
-	Name    string `json:"name" binding:"required,min=2,max=50"`
-	Email   string `json:"email" binding:"required,email"`
-	Subject string `json:"subject" binding:"required,min=2,max=100"`
-	Message string `json:"message" binding:"required"`
+	Name     string `json:"name" binding:"required,min=2,max=50"`
+	Email    string `json:"email" binding:"required,email"`
+	Subject  string `json:"subject" binding:"required,min=2,max=100"`
+	Message  string `json:"message" binding:"required,max=5000"`
+	Category string `json:"category" binding:"omitempty,oneof=general ban_appeal billing catalog"`
 }
 
-// ContactHandler handles contact form submissions
-func ContactHandler(c *gin.Context) {
+// ContactHandler opens a support ticket from the contact form and copies it
+// to the support inbox (SUPPORT_EMAIL) when one is configured. Banned users
+// can reach it; their tickets default to the ban_appeal category.
+func (h *Handler) ContactHandler(c *gin.Context) {
+	userID, ok := notificationUserID(c)
+	if !ok {
+		return
+	}
+
 	var req ContactRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.Invalid(err))
 		return
 	}
+	if req.Category == "" {
+		req.Category = "general"
+		if banned, _ := c.Get("isBanned"); banned == true {
+			req.Category = "ban_appeal"
+		}
+	}
 
-	// Debug: Check if API key exists
-	apiKey := os.Getenv("RESEND_API_KEY")
-	fmt.Printf("RESEND_API_KEY exists: %v\n", apiKey != "")
-	fmt.Printf("RESEND_API_KEY length: %d\n", len(apiKey))
-
-	body := fmt.Sprintf("From: %s <%s>\n\n%s", req.Name, req.Email, req.Message)
-	recipient := "himelsd117@gmail.com"
+	ctx := c.Request.Context()
+	var (
+		ticket gen.SupportTicket
+		msg    gen.SupportTicketMessage
+	)
+	err := h.store.WithTx(ctx, func(tx db.Store) error {
+		var err error
+		ticket, err = tx.CreateSupportTicket(ctx, gen.CreateSupportTicketParams{
+			UserID:   pgtype.UUID{Bytes: userID, Valid: true},
+			Name:     req.Name,
+			Email:    req.Email,
+			Subject:  req.Subject,
+			Category: req.Category,
+			Priority: "normal",
+		})
+		if err != nil {
+			return fmt.Errorf("create support ticket: %w", err)
+		}
+		msg, err = tx.CreateSupportTicketMessage(ctx, gen.CreateSupportTicketMessageParams{
+			TicketID: ticket.ID,
+			AuthorID: pgtype.UUID{Bytes: userID, Valid: true},
+			Body:     req.Message,
+		})
+		if err != nil {
+			return fmt.Errorf("save support message: %w", err)
+		}
 
-	if err := SendEmailViaResend(req.Name, req.Email, recipient, req.Subject, body); err != nil {
-		fmt.Printf("Email error: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to send email",
-			"details": err.Error(),
+		supportEmail := h.svc.Support.Email()
+		if supportEmail == "" {
+			return nil
+		}
+		return service.QueueEmail(ctx, tx, mailer.TemplateContact, []string{supportEmail}, req.Email, mailer.ContactData{
+			Name:    req.Name,
+			Email:   req.Email,
+			Subject: fmt.Sprintf("%s (ticket %s, %s)", req.Subject, uuid.UUID(ticket.ID.Bytes), req.Category),
+			Message: req.Message,
 		})
+	})
+	if err != nil {
+		writeTxError(c, err, "failed to create support ticket")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Email sent successfully!"})
+	response := toSupportTicketResponse(ticket)
+	response.Messages = []models.SupportTicketMessageResponse{toSupportTicketMessageResponse(msg)}
+	c.JSON(http.StatusCreated, gin.H{"message": "Support ticket created", "ticket": response})
 }
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	"github.com/THEGunDevil/GoForBackend/internal/blob"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/export"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/THEGunDevil/GoForBackend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CreateExportHandler queues a report export to run in the background.
+// Poll GetExportHandler for its status and download link.
+func (h *Handler) CreateExportHandler(c *gin.Context) {
+	userID, ok := notificationUserID(c)
+	if !ok {
+		return
+	}
+	var req models.CreateExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+	if req.Format == "" {
+		req.Format = string(export.CSV)
+	}
+	format, err := export.ParseFormat(req.Format)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	role, _ := c.Get("role")
+	if roleName, _ := role.(string); !service.CanExport(roleName, req.Report) {
+		c.Error(apierror.Forbidden("you cannot export this report"))
+		return
+	}
+
+	job, err := h.svc.Exports.Queue(c.Request.Context(), userID, req.Report, format, req.Filters)
+	if errors.Is(err, service.ErrUnknownReport) {
+		c.Error(service.ErrUnknownReport.With("reports", service.ReportNames))
+		return
+	}
+	if errors.Is(err, service.ErrInvalidFilter) {
+		c.Error(err)
+		return
+	}
+	if err != nil {
+		requestLogger(c).Error("failed to queue export", "report", req.Report, "error", err)
+		c.Error(apierror.Internal("failed to queue export", nil))
+		return
+	}
+	h.logAudit(c, "report.export_queued", "export_job", uuid.UUID(job.ID.Bytes), nil, gin.H{"report": job.Report, "format": job.Format, "filters": req.Filters})
+	c.JSON(http.StatusAccepted, h.toExportJobResponse(job))
+}
+
+// ListExportsHandler lists the caller's exports, newest first
+func (h *Handler) ListExportsHandler(c *gin.Context) {
+	userID, ok := notificationUserID(c)
+	if !ok {
+		return
+	}
+	page, limit := parsePagination(c)
+	rows, err := h.store.ListExportJobsByUser(c.Request.Context(), gen.ListExportJobsByUserParams{
+		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+		Limit:  int32(limit),
+		Offset: int32((page - 1) * limit),
+	})
+	if err != nil {
+		requestLogger(c).Error("failed to list exports", "error", err)
+		c.Error(apierror.Internal("failed to fetch exports", nil))
+		return
+	}
+	totalCount, err := h.store.CountExportJobsByUser(c.Request.Context(), pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		c.Error(apierror.Internal("failed to fetch exports", err))
+		return
+	}
+
+	exports := make([]models.ExportJobResponse, 0, len(rows))
+	for _, job := range rows {
+		exports = append(exports, h.toExportJobResponse(job))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"page":        page,
+		"limit":       limit,
+		"count":       len(exports),
+		"total_count": totalCount,
+		"total_pages": int(math.Ceil(float64(totalCount) / float64(limit))),
+		"exports":     exports,
+	})
+}
+
+// GetExportHandler returns one of the caller's exports. Once it has
+// succeeded the response carries a fresh signed download link.
+func (h *Handler) GetExportHandler(c *gin.Context) {
+	userID, ok := notificationUserID(c)
+	if !ok {
+		return
+	}
+	job, ok := h.findExportJob(c)
+	if !ok {
+		return
+	}
+	if uuid.UUID(job.UserID.Bytes) != userID {
+		c.Error(apierror.NotFound("export not found"))
+		return
+	}
+	c.JSON(http.StatusOK, h.toExportJobResponse(job))
+}
+
+// DownloadExportHandler serves an export's file. It needs no login: the
+// signed, expiring link from GetExportHandler is the credential.
+func (h *Handler) DownloadExportHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid export ID"))
+		return
+	}
+	err = h.svc.Exports.VerifyDownload(id, c.Query("expires"), c.Query("signature"))
+	if errors.Is(err, blob.ErrURLExpired) {
+		c.Error(apierror.Gone("download link has expired, fetch the export again for a new one"))
+		return
+	}
+	if err != nil {
+		c.Error(apierror.Forbidden("invalid download link"))
+		return
+	}
+
+	job, ok := h.findExportJob(c)
+	if !ok {
+		return
+	}
+	if job.Status != "succeeded" || !job.BlobKey.Valid {
+		c.Error(apierror.Gone("export file is no longer available").With("job_status", job.Status))
+		return
+	}
+
+	file, err := h.svc.Exports.Blobs().Open(c.Request.Context(), job.BlobKey.String)
+	if errors.Is(err, blob.ErrNotFound) {
+		c.Error(apierror.Gone("export file is no longer available"))
+		return
+	}
+	if err != nil {
+		requestLogger(c).Error("failed to open export", "export_id", id, "error", err)
+		c.Error(apierror.Internal("failed to open export", nil))
+		return
+	}
+	defer file.Close()
+
+	size := int64(-1)
+	if job.SizeBytes.Valid {
+		size = job.SizeBytes.Int64
+	}
+	c.DataFromReader(http.StatusOK, size, export.Format(job.Format).ContentType(), file, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, job.Filename.String),
+		"Cache-Control":       "private, no-store",
+	})
+}
+
+func (h *Handler) findExportJob(c *gin.Context) (gen.ExportJob, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid export ID"))
+		return gen.ExportJob{}, false
+	}
+	job, err := h.store.GetExportJobByID(c.Request.Context(), pgtype.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.Error(apierror.NotFound("export not found"))
+		return job, false
+	}
+	if err != nil {
+		c.Error(apierror.Internal("failed to fetch export", err))
+		return job, false
+	}
+	return job, true
+}
+
+func (h *Handler) toExportJobResponse(job gen.ExportJob) models.ExportJobResponse {
+	filters := map[string]string{}
+	json.Unmarshal(job.Filters, &filters)
+	response := models.ExportJobResponse{
+		ID:         job.ID.Bytes,
+		Report:     job.Report,
+		Format:     job.Format,
+		Filters:    filters,
+		Status:     job.Status,
+		Filename:   job.Filename.String,
+		Error:      job.Error.String,
+		CreatedAt:  job.CreatedAt.Time,
+		StartedAt:  timePtr(job.StartedAt),
+		FinishedAt: timePtr(job.FinishedAt),
+		ExpiresAt:  timePtr(job.ExpiresAt),
+	}
+	if job.SizeBytes.Valid {
+		size := job.SizeBytes.Int64
+		response.SizeBytes = &size
+	}
+	if job.Status == "succeeded" {
+		url, expires := h.svc.Exports.DownloadURL(job, time.Now())
+		response.DownloadURL = url
+		response.DownloadURLExpiresAt = &expires
+	}
+	return response
+}
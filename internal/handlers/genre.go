@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	"github.com/THEGunDevil/GoForBackend/internal/db"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/THEGunDevil/GoForBackend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ListGenreTaxonomyHandler returns every genre with its parent and book count
+func (h *Handler) ListGenreTaxonomyHandler(c *gin.Context) {
+	rows, err := h.store.ListGenreTaxonomy(c.Request.Context())
+	if err != nil {
+		requestLogger(c).Error("failed to list genres", "error", err)
+		c.Error(apierror.Internal("failed to fetch genres", nil))
+		return
+	}
+
+	genres := make([]models.GenreResponse, 0, len(rows))
+	for _, g := range rows {
+		genres = append(genres, models.GenreResponse{
+			ID:          g.ID.Bytes,
+			Name:        g.Name,
+			Slug:        g.Slug,
+			ParentID:    uuidPtr(g.ParentID),
+			Description: g.Description,
+			BookCount:   g.BookCount,
+		})
+	}
+
+	c.JSON(http.StatusOK, genres)
+}
+
+// CreateGenreHandler adds a genre to the taxonomy (admin)
+func (h *Handler) CreateGenreHandler(c *gin.Context) {
+	var req models.CreateGenreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if len(req.Name) == 0 || len(req.Name) > 100 {
+		c.Error(apierror.BadRequest("name must be 1-100 characters"))
+		return
+	}
+	slug := service.Slugify(req.Slug)
+	if slug == "" {
+		slug = service.Slugify(req.Name)
+	}
+	if slug == "" {
+		c.Error(apierror.BadRequest("slug must contain letters or digits"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	var g gen.Genre
+	err := h.store.WithTx(ctx, func(tx db.Store) error {
+		parentID := pgtype.UUID{}
+		if req.ParentID != nil {
+			if err := validateGenreParent(ctx, tx, *req.ParentID, uuid.Nil); err != nil {
+				return err
+			}
+			parentID = pgtype.UUID{Bytes: *req.ParentID, Valid: true}
+		}
+
+		var err error
+		g, err = tx.CreateGenre(ctx, gen.CreateGenreParams{
+			Name:        req.Name,
+			Slug:        slug,
+			ParentID:    parentID,
+			Description: req.Description,
+		})
+		if isUniqueViolation(err) {
+			return apierror.Conflict("a genre with this slug already exists")
+		}
+		if err != nil {
+			return fmt.Errorf("create genre: %w", err)
+		}
+		if err := recordAudit(c, tx, "genre.create", "genre", uuid.UUID(g.ID.Bytes), nil, toGenreResponse(g)); err != nil {
+			return fmt.Errorf("audit genre creation: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(c, err, "failed to create genre")
+		return
+	}
+
+	c.JSON(http.StatusCreated, toGenreResponse(g))
+}
+
+// UpdateGenreHandler renames or re-parents a genre (admin)
+func (h *Handler) UpdateGenreHandler(c *gin.Context) {
+	genreID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid genre id"))
+		return
+	}
+
+	var req models.UpdateGenreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+	if req.Name != nil && (len(strings.TrimSpace(*req.Name)) == 0 || len(*req.Name) > 100) {
+		c.Error(apierror.BadRequest("name must be 1-100 characters"))
+		return
+	}
+
+	params := gen.UpdateGenreByIDParams{
+		Name:        optionalText(req.Name),
+		Description: optionalText(req.Description),
+		ID:          pgtype.UUID{Bytes: genreID, Valid: true},
+	}
+	if req.Slug != nil {
+		slug := service.Slugify(*req.Slug)
+		if slug == "" {
+			c.Error(apierror.BadRequest("slug must contain letters or digits"))
+			return
+		}
+		params.Slug = pgtype.Text{String: slug, Valid: true}
+	}
+	switch {
+	case req.ClearParent && req.ParentID != nil:
+		c.Error(apierror.BadRequest("parent_id and clear_parent cannot be combined"))
+		return
+	case req.ClearParent:
+		params.ClearParent = true
+	case req.ParentID != nil:
+		params.ParentID = pgtype.UUID{Bytes: *req.ParentID, Valid: true}
+	}
+
+	ctx := c.Request.Context()
+	var g gen.Genre
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		if req.ParentID != nil {
+			if err := validateGenreParent(ctx, tx, *req.ParentID, genreID); err != nil {
+				return err
+			}
+		}
+
+		before, err := tx.GetGenreByID(ctx, params.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NotFound("genre not found")
+		}
+		if err != nil {
+			return fmt.Errorf("fetch genre: %w", err)
+		}
+		g, err = tx.UpdateGenreByID(ctx, params)
+		if isUniqueViolation(err) {
+			return apierror.Conflict("a genre with this slug already exists")
+		}
+		if err != nil {
+			return fmt.Errorf("update genre: %w", err)
+		}
+		if err := recordAudit(c, tx, "genre.update", "genre", genreID, toGenreResponse(before), toGenreResponse(g)); err != nil {
+			return fmt.Errorf("audit genre update: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(c, err, "failed to update genre")
+		return
+	}
+
+	c.JSON(http.StatusOK, toGenreResponse(g))
+}
+
+// SetBookGenresHandler replaces a book's genres with existing taxonomy
+// entries, given by slug or name (admin)
+func (h *Handler) SetBookGenresHandler(c *gin.Context) {
+	bookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid book id"))
+		return
+	}
+
+	var req models.SetBookGenresRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	pgBookID := pgtype.UUID{Bytes: bookID, Valid: true}
+	if _, err := h.store.GetBookByID(ctx, pgBookID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.Error(apierror.NotFound("book not found"))
+		} else {
+			c.Error(apierror.Internal("something went wrong", err))
+		}
+		return
+	}
+
+	// Resolve every genre first so an unknown slug doesn't leave the book half-updated
+	var genreIDs []pgtype.UUID
+	for _, name := range req.Genres {
+		g, err := h.store.GetGenreBySlug(ctx, service.Slugify(name))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				c.Error(apierror.BadRequest("unknown genre: " + name))
+			} else {
+				c.Error(apierror.Internal("something went wrong", err))
+			}
+			return
+		}
+		genreIDs = append(genreIDs, g.ID)
+	}
+
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		if err := tx.DeleteBookGenres(ctx, pgBookID); err != nil {
+			return err
+		}
+		for _, id := range genreIDs {
+			if err := tx.AddBookGenre(ctx, gen.AddBookGenreParams{BookID: pgBookID, GenreID: id}); err != nil {
+				return fmt.Errorf("link genre to book: %w", err)
+			}
+		}
+		if err := tx.SyncBookGenreColumn(ctx, pgBookID); err != nil {
+			return fmt.Errorf("sync genre string: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(c, err, "failed to update book genres")
+		return
+	}
+
+	_, genres, _, err := service.GetBookClassification(ctx, h.store, pgBookID)
+	if err != nil {
+		c.Error(apierror.Internal("something went wrong", err))
+		return
+	}
+	h.logAudit(c, "book.set_genres", "book", bookID, nil, genres)
+	c.JSON(http.StatusOK, gin.H{"book_id": bookID, "genres": genres})
+}
+
+// validateGenreParent keeps the taxonomy two levels deep: a parent must
+// exist, must not be the genre itself and must not have a parent of its own,
+// and an existing genre (genreID is uuid.Nil for a new one) must not have
+// sub-genres of its own. It locks both genres, so run it in the transaction
+// that writes the genre: a concurrent re-parent then waits and sees the
+// result instead of nesting a third level.
+func validateGenreParent(ctx context.Context, tx db.Store, parentID, genreID uuid.UUID) error {
+	if parentID == genreID {
+		return apierror.BadRequest("a genre cannot be its own parent")
+	}
+	ids := []pgtype.UUID{{Bytes: parentID, Valid: true}}
+	if genreID != uuid.Nil {
+		ids = append(ids, pgtype.UUID{Bytes: genreID, Valid: true})
+	}
+	if err := tx.LockGenres(ctx, ids); err != nil {
+		return fmt.Errorf("lock genres: %w", err)
+	}
+
+	parent, err := tx.GetGenreByID(ctx, ids[0])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return apierror.BadRequest("parent genre not found")
+	}
+	if err != nil {
+		return fmt.Errorf("fetch parent genre: %w", err)
+	}
+	if parent.ParentID.Valid {
+		return apierror.BadRequest("parent genre must be a top-level genre")
+	}
+	if genreID != uuid.Nil {
+		children, err := tx.CountChildGenres(ctx, ids[1])
+		if err != nil {
+			return fmt.Errorf("count sub-genres: %w", err)
+		}
+		if children > 0 {
+			return apierror.BadRequest("a genre with sub-genres cannot be given a parent")
+		}
+	}
+	return nil
+}
+
+func toGenreResponse(g gen.Genre) models.GenreResponse {
+	return models.GenreResponse{
+		ID:          g.ID.Bytes,
+		Name:        g.Name,
+		Slug:        g.Slug,
+		ParentID:    uuidPtr(g.ParentID),
+		Description: g.Description,
+	}
+}
+
+func uuidPtr(id pgtype.UUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	u := uuid.UUID(id.Bytes)
+	return &u
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	"github.com/THEGunDevil/GoForBackend/internal/db"
+	"github.com/THEGunDevil/GoForBackend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// Services are the application services the handlers call into
+type Services struct {
+	Notifications *service.NotificationDispatcher
+	Announcements *service.Announcements
+	Books         *service.Books
+	Borrows       *service.Borrows
+	Exports       *service.Exports
+	Support       *service.Support
+	EmailOutbox   *service.EmailOutbox
+	Health        *service.Health
+}
+
+// Handler serves the HTTP API. Its methods are the gin handlers main
+// registers; each one reaches Postgres through store with the request's
+// context.
+type Handler struct {
+	store db.Store
+	svc   Services
+}
+
+// New returns a Handler using store and services
+func New(store db.Store, services Services) *Handler {
+	return &Handler{store: store, svc: services}
+}
+
+// writeTxError records the error a WithTx ended with: the *apierror.Error a
+// step returned to roll back, or an internal error described by message when
+// beginning, committing or an unexpected step failed
+func writeTxError(c *gin.Context, err error, message string) {
+	var apiErr *apierror.Error
+	if errors.As(err, &apiErr) {
+		c.Error(apiErr)
+		return
+	}
+	c.Error(apierror.Internal(message, err))
+}
@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	"github.com/THEGunDevil/GoForBackend/internal/db"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ctxKey struct{}
+
+// fakeStore implements the queries DeleteBookHandler runs. Any other query
+// panics through the nil embedded Store.
+type fakeStore struct {
+	db.Store
+	activeBorrows int64
+	book          *gen.Book
+	user          *gen.User
+	auditErr      error
+	genreErr      error
+
+	// what the handler did
+	sawRequestCtx bool
+	locked        bool
+	cancelled     []pgtype.UUID
+	audits        []gen.CreateAuditLogParams
+	rolledBack    bool
+}
+
+func (s *fakeStore) WithTx(ctx context.Context, fn func(tx db.Store) error) error {
+	err := fn(s)
+	s.rolledBack = err != nil
+	return err
+}
+
+func (s *fakeStore) CountActiveBorrowsByBookID(ctx context.Context, id pgtype.UUID) (int64, error) {
+	if !s.locked {
+		return 0, errors.New("borrows counted before the book was locked")
+	}
+	s.sawRequestCtx = ctx.Value(ctxKey{}) != nil
+	return s.activeBorrows, nil
+}
+
+func (s *fakeStore) GetBookByIDForUpdate(_ context.Context, id pgtype.UUID) (gen.Book, error) {
+	s.locked = true
+	if s.book == nil {
+		return gen.Book{}, pgx.ErrNoRows
+	}
+	return *s.book, nil
+}
+
+func (s *fakeStore) SoftDeleteBookByID(_ context.Context, id pgtype.UUID) (gen.Book, error) {
+	if s.book == nil {
+		return gen.Book{}, pgx.ErrNoRows
+	}
+	return *s.book, nil
+}
+
+func (s *fakeStore) CancelOpenReservationsByBookID(_ context.Context, id pgtype.UUID) error {
+	s.cancelled = append(s.cancelled, id)
+	return nil
+}
+
+func (s *fakeStore) CreateAuditLog(_ context.Context, arg gen.CreateAuditLogParams) error {
+	if s.auditErr != nil {
+		return s.auditErr
+	}
+	s.audits = append(s.audits, arg)
+	return nil
+}
+
+func (s *fakeStore) GetBookByID(_ context.Context, id pgtype.UUID) (gen.Book, error) {
+	if s.book == nil {
+		return gen.Book{}, pgx.ErrNoRows
+	}
+	return *s.book, nil
+}
+
+func (s *fakeStore) UpdateBookByID(_ context.Context, arg gen.UpdateBookByIDParams) (gen.Book, error) {
+	book := *s.book
+	if arg.Genre.Valid {
+		book.Genre = arg.Genre.String
+	}
+	return book, nil
+}
+
+func (s *fakeStore) DeleteBookGenres(context.Context, pgtype.UUID) error { return nil }
+
+func (s *fakeStore) UpsertGenreBySlug(context.Context, gen.UpsertGenreBySlugParams) (gen.Genre, error) {
+	return gen.Genre{}, s.genreErr
+}
+
+func (s *fakeStore) AddBookGenre(context.Context, gen.AddBookGenreParams) error { return nil }
+
+func (s *fakeStore) GetUserByID(context.Context, pgtype.UUID) (gen.User, error) {
+	if s.user == nil {
+		return gen.User{}, pgx.ErrNoRows
+	}
+	return *s.user, nil
+}
+
+func (s *fakeStore) UpdateUserBanByUserID(_ context.Context, arg gen.UpdateUserBanByUserIDParams) (gen.User, error) {
+	user := *s.user
+	user.IsBanned = arg.IsBanned
+	user.BanReason = arg.BanReason
+	return user, nil
+}
+
+func deleteBook(t *testing.T, store *fakeStore, id uuid.UUID) (*httptest.ResponseRecorder, gin.H) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Errors())
+	r.DELETE("/books/:id", New(store, Services{}).DeleteBookHandler)
+
+	req := httptest.NewRequest(http.MethodDelete, "/books/"+id.String(), nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxKey{}, true))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var body gin.H
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not JSON: %q", w.Body.String())
+	}
+	return w, body
+}
+
+func TestDeleteBookHandler(t *testing.T) {
+	id := uuid.New()
+	book := gen.Book{ID: pgtype.UUID{Bytes: id, Valid: true}, Title: "Dune"}
+
+	store := &fakeStore{book: &book}
+	w, body := deleteBook(t, store, id)
+	if w.Code != http.StatusOK || body["message"] != "book deleted" {
+		t.Fatalf("got %d %v", w.Code, body)
+	}
+	if !store.sawRequestCtx {
+		t.Error("queries did not get the request context")
+	}
+	if len(store.cancelled) != 1 || store.cancelled[0].Bytes != id {
+		t.Errorf("reservations cancelled for %v", store.cancelled)
+	}
+	if len(store.audits) != 1 || store.audits[0].Action != "book.delete" || store.audits[0].TargetID != id.String() {
+		t.Errorf("audits = %+v", store.audits)
+	}
+}
+
+func TestDeleteBookHandlerErrors(t *testing.T) {
+	id := uuid.New()
+	book := gen.Book{ID: pgtype.UUID{Bytes: id, Valid: true}}
+	tests := []struct {
+		name         string
+		store        *fakeStore
+		wantStatus   int
+		wantCode     string
+		wantDetail   string
+		wantRollback bool
+	}{
+		{
+			name:         "copies on loan",
+			store:        &fakeStore{activeBorrows: 2, book: &book},
+			wantStatus:   http.StatusConflict,
+			wantCode:     "conflict",
+			wantDetail:   "book has copies on loan",
+			wantRollback: true,
+		},
+		{
+			name:         "unknown book",
+			store:        &fakeStore{},
+			wantStatus:   http.StatusNotFound,
+			wantCode:     "not_found",
+			wantDetail:   "book not found",
+			wantRollback: true,
+		},
+		{
+			name:         "audit fails",
+			store:        &fakeStore{book: &book, auditErr: errors.New("connection reset")},
+			wantStatus:   http.StatusInternalServerError,
+			wantCode:     "internal",
+			wantDetail:   "something went wrong",
+			wantRollback: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, body := deleteBook(t, tt.store, id)
+			if w.Code != tt.wantStatus || body["code"] != tt.wantCode || body["detail"] != tt.wantDetail {
+				t.Errorf("got %d %v, want %d %s %q", w.Code, body, tt.wantStatus, tt.wantCode, tt.wantDetail)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != apierror.ContentType {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			if tt.store.rolledBack != tt.wantRollback {
+				t.Errorf("rolled back = %v, want %v", tt.store.rolledBack, tt.wantRollback)
+			}
+		})
+	}
+}
+
+// The genre relink runs in the update's transaction, so failing it rolls
+// back the update and writes no audit entry
+func TestUpdateBookHandlerRollsBack(t *testing.T) {
+	id := uuid.New()
+	book := gen.Book{ID: pgtype.UUID{Bytes: id, Valid: true}, Title: "Dune", Genre: "Fiction"}
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range []struct {
+		name       string
+		genreErr   error
+		wantStatus int
+	}{
+		{"relink fails", errors.New("connection reset"), http.StatusInternalServerError},
+		{"relink succeeds", nil, http.StatusOK},
+	} {
+		store := &fakeStore{book: &book, genreErr: tt.genreErr}
+		r := gin.New()
+		r.Use(middleware.Errors())
+		r.PATCH("/books/:id", New(store, Services{}).UpdateBookByIDHandler)
+		req := httptest.NewRequest(http.MethodPatch, "/books/"+id.String(), strings.NewReader(`{"genre": "Science Fiction"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.wantStatus, w.Body.String())
+		}
+		failed := tt.genreErr != nil
+		if store.rolledBack != failed || (len(store.audits) == 0) != failed {
+			t.Errorf("%s: rolled back %v with %d audits", tt.name, store.rolledBack, len(store.audits))
+		}
+	}
+}
+
+// A ban is only applied if its audit entry is written with it
+func TestBanUserHandlerAuditsInTx(t *testing.T) {
+	id := uuid.New()
+	user := gen.User{ID: pgtype.UUID{Bytes: id, Valid: true}, Email: "reader@example.com"}
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range []struct {
+		name       string
+		auditErr   error
+		wantStatus int
+	}{
+		{"audit fails", errors.New("connection reset"), http.StatusInternalServerError},
+		{"audit written", nil, http.StatusOK},
+	} {
+		store := &fakeStore{user: &user, auditErr: tt.auditErr}
+		r := gin.New()
+		r.Use(middleware.Errors())
+		r.PATCH("/users/:id/ban", New(store, Services{}).BanUserByIDHandler)
+		req := httptest.NewRequest(http.MethodPatch, "/users/"+id.String()+"/ban", strings.NewReader(`{"is_banned": true, "ban_reason": "spam"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.wantStatus, w.Body.String())
+		}
+		failed := tt.auditErr != nil
+		if store.rolledBack != failed {
+			t.Errorf("%s: rolled back = %v", tt.name, store.rolledBack)
+		}
+		if !failed && (len(store.audits) != 1 || store.audits[0].Action != "user.ban") {
+			t.Errorf("%s: audits = %+v", tt.name, store.audits)
+		}
+	}
+}
+
+func TestTruncateDescription(t *testing.T) {
+	if got := truncateDescription("A short note."); got != "A short note." {
+		t.Errorf("short description changed to %q", got)
+	}
+	for _, long := range []string{strings.Repeat("a", 300), strings.Repeat("é", 200)} {
+		got := truncateDescription(long)
+		if len(got) > maxMARCDescription || !utf8.ValidString(got) || !strings.HasSuffix(got, "…") {
+			t.Errorf("truncated to %d bytes: %q", len(got), got)
+		}
+	}
+}
+
+// fakeGenreStore serves validateGenreParent's queries and records which
+// genres it locked before reading them
+type fakeGenreStore struct {
+	db.Store
+	parent    gen.Genre
+	parentErr error
+	children  int64
+	locked    []pgtype.UUID
+}
+
+func (s *fakeGenreStore) LockGenres(_ context.Context, ids []pgtype.UUID) error {
+	s.locked = ids
+	return nil
+}
+
+func (s *fakeGenreStore) GetGenreByID(context.Context, pgtype.UUID) (gen.Genre, error) {
+	if s.locked == nil {
+		return gen.Genre{}, errors.New("read before lock")
+	}
+	return s.parent, s.parentErr
+}
+
+func (s *fakeGenreStore) CountChildGenres(context.Context, pgtype.UUID) (int64, error) {
+	return s.children, nil
+}
+
+func TestValidateGenreParent(t *testing.T) {
+	parentID, genreID := uuid.New(), uuid.New()
+	tests := []struct {
+		name       string
+		store      *fakeGenreStore
+		genreID    uuid.UUID
+		wantStatus int // 0 for no error
+		wantDetail string
+	}{
+		{"ok", &fakeGenreStore{}, genreID, 0, ""},
+		{"new genre", &fakeGenreStore{children: 3}, uuid.Nil, 0, ""},
+		{"missing parent", &fakeGenreStore{parentErr: pgx.ErrNoRows}, genreID, http.StatusBadRequest, "parent genre not found"},
+		{"lookup fails", &fakeGenreStore{parentErr: errors.New("connection reset")}, genreID, http.StatusInternalServerError, ""},
+		{"nested parent", &fakeGenreStore{parent: gen.Genre{ParentID: pgtype.UUID{Bytes: uuid.New(), Valid: true}}}, genreID, http.StatusBadRequest, "parent genre must be a top-level genre"},
+		{"has sub-genres", &fakeGenreStore{children: 1}, genreID, http.StatusBadRequest, "a genre with sub-genres cannot be given a parent"},
+	}
+	for _, tt := range tests {
+		err := validateGenreParent(context.Background(), tt.store, parentID, tt.genreID)
+		if tt.wantStatus == 0 {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+		} else if got := apierror.From(err); got.Status != tt.wantStatus || (tt.wantDetail != "" && got.Detail != tt.wantDetail) {
+			t.Errorf("%s: got %d %q, want %d %q", tt.name, got.Status, got.Detail, tt.wantStatus, tt.wantDetail)
+		}
+		want := []pgtype.UUID{{Bytes: parentID, Valid: true}}
+		if tt.genreID != uuid.Nil {
+			want = append(want, pgtype.UUID{Bytes: genreID, Valid: true})
+		}
+		if !slices.Equal(tt.store.locked, want) {
+			t.Errorf("%s: locked %v, want %v", tt.name, tt.store.locked, want)
+		}
+	}
+
+	if err := validateGenreParent(context.Background(), &fakeGenreStore{}, genreID, genreID); apierror.From(err).Status != http.StatusBadRequest {
+		t.Errorf("own parent: %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthzHandler is the liveness probe: the process is up and serving. It
+// checks no dependencies, so a database outage does not get it restarted.
+func (h *Handler) HealthzHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// ReadyzHandler is the readiness probe: 200 when every required dependency
+// is usable, 503 otherwise or while shutting down
+func (h *Handler) ReadyzHandler(c *gin.Context) {
+	response, ready := h.svc.Health.Readiness(c.Request.Context())
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, response)
+}
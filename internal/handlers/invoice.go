@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	"github.com/THEGunDevil/GoForBackend/internal/documents"
+	"github.com/THEGunDevil/GoForBackend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DownloadPaymentReceiptHandler serves the PDF receipt of a paid payment to
+// its owner or to finance staff
+func (h *Handler) DownloadPaymentReceiptHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid payment ID"))
+		return
+	}
+	ctx := c.Request.Context()
+
+	payment, err := h.store.GetPaymentByID(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.Error(apierror.NotFound("payment not found"))
+		return
+	}
+	if err != nil {
+		c.Error(apierror.Internal("failed to get payment", err))
+		return
+	}
+	if !canSeeFinancialDocument(c, payment.UserID) {
+		c.Error(apierror.NotFound("payment not found"))
+		return
+	}
+	if payment.Status != "paid" {
+		c.Error(apierror.Conflict("receipts are only available for paid payments").With("payment_status", payment.Status))
+		return
+	}
+
+	receipt, err := service.BuildReceipt(ctx, h.store, payment, h.svc.Support.Email())
+	if err != nil {
+		requestLogger(c).Error("failed to build receipt", "payment_id", id, "error", err)
+		c.Error(apierror.Internal("failed to generate receipt", nil))
+		return
+	}
+	pdf, err := documents.ReceiptPDF(receipt)
+	if err != nil {
+		requestLogger(c).Error("failed to render receipt", "receipt", receipt.Number, "error", err)
+		c.Error(apierror.Internal("failed to generate receipt", nil))
+		return
+	}
+	writePDF(c, service.ReceiptFilename(receipt.Number), pdf)
+}
+
+// DownloadRefundCreditNoteHandler serves the PDF credit note of a processed
+// refund to the payment's owner or to finance staff
+func (h *Handler) DownloadRefundCreditNoteHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid refund ID"))
+		return
+	}
+	ctx := c.Request.Context()
+
+	refund, err := h.store.GetRefundByID(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.Error(apierror.NotFound("refund not found"))
+		return
+	}
+	if err != nil {
+		c.Error(apierror.Internal("failed to get refund", err))
+		return
+	}
+	payment, err := h.store.GetPaymentByID(ctx, refund.PaymentID)
+	if err != nil {
+		c.Error(apierror.Internal("failed to get payment", err))
+		return
+	}
+	if !canSeeFinancialDocument(c, payment.UserID) {
+		c.Error(apierror.NotFound("refund not found"))
+		return
+	}
+	if refund.Status != "processed" {
+		c.Error(apierror.Conflict("credit notes are only available for processed refunds").With("refund_status", refund.Status))
+		return
+	}
+
+	note, err := service.BuildCreditNote(ctx, h.store, refund, payment, h.svc.Support.Email())
+	if err != nil {
+		requestLogger(c).Error("failed to build credit note", "refund_id", id, "error", err)
+		c.Error(apierror.Internal("failed to generate credit note", nil))
+		return
+	}
+	pdf, err := documents.CreditNotePDF(note)
+	if err != nil {
+		requestLogger(c).Error("failed to render credit note", "credit_note", note.Number, "error", err)
+		c.Error(apierror.Internal("failed to generate credit note", nil))
+		return
+	}
+	writePDF(c, service.CreditNoteFilename(note.Number), pdf)
+}
+
+// canSeeFinancialDocument allows the owner, admins and finance staff.
+// Everyone else gets a 404 so document IDs cannot be probed.
+func canSeeFinancialDocument(c *gin.Context, ownerID pgtype.UUID) bool {
+	role, _ := c.Get("role")
+	if role == "admin" || role == "finance" {
+		return true
+	}
+	v, _ := c.Get("userID")
+	userID, ok := v.(uuid.UUID)
+	return ok && ownerID.Valid && uuid.UUID(ownerID.Bytes) == userID
+}
+
+func writePDF(c *gin.Context, filename string, pdf []byte) {
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"log/slog"
+
+	"github.com/THEGunDevil/GoForBackend/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// requestLogger returns the logger for c, tagged with its request ID
+func requestLogger(c *gin.Context) *slog.Logger {
+	return logging.FromContext(c.Request.Context())
+}
@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	"github.com/THEGunDevil/GoForBackend/internal/marc"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	marcContentType    = "application/marc"
+	marcXMLContentType = "application/marcxml+xml"
+
+	// maxMARCUpload caps the size of an uploaded MARC file (10 MB is a few
+	// thousand records).
+	maxMARCUpload = 10 << 20
+
+	// maxMARCDescription is CreateBookHandler's description limit, in bytes
+	maxMARCDescription = 255
+)
+
+// MARCImportResult reports what happened to a single record in an import.
+type MARCImportResult struct {
+	Index  int                  `json:"index"`
+	Title  string               `json:"title,omitempty"`
+	Isbn   string               `json:"isbn,omitempty"`
+	Status string               `json:"status"` // created, skipped, failed
+	Error  string               `json:"error,omitempty"`
+	Book   *models.BookResponse `json:"book,omitempty"`
+}
+
+// ExportBookMARCHandler returns a single book as MARCXML (default) or
+// binary MARC21 with ?format=marc.
+func (h *Handler) ExportBookMARCHandler(c *gin.Context) {
+	parsedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid book id"))
+		return
+	}
+
+	book, err := h.store.GetBookByID(c.Request.Context(), pgtype.UUID{Bytes: parsedID, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.Error(apierror.NotFound("book not found"))
+		} else {
+			c.Error(apierror.Internal("something went wrong", err))
+		}
+		return
+	}
+
+	writeMARC(c, c.Query("format"), "book_"+parsedID.String(), []*marc.Record{marc.FromBook(toBookResponse(book))})
+}
+
+// ExportBooksMARCHandler returns the whole catalog as one MARC file.
+func (h *Handler) ExportBooksMARCHandler(c *gin.Context) {
+	books, err := h.store.ListAllBooks(c.Request.Context())
+	if err != nil {
+		requestLogger(c).Error("failed to list books for MARC export", "error", err)
+		c.Error(apierror.Internal("failed to fetch books", nil))
+		return
+	}
+
+	records := make([]*marc.Record, 0, len(books))
+	for _, book := range books {
+		records = append(records, marc.FromBook(toBookResponse(book)))
+	}
+
+	writeMARC(c, c.Query("format"), "books_"+time.Now().Format("20060102"), records)
+}
+
+// ImportBookMARCHandler creates one book from a single MARC record.
+func (h *Handler) ImportBookMARCHandler(c *gin.Context) {
+	records, err := readMARCUpload(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if len(records) != 1 {
+		c.Error(apierror.BadRequest(fmt.Sprintf("expected exactly one record, got %d; use /books/marc/batch for files with several records", len(records))))
+		return
+	}
+
+	copies, err := marcCopies(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	result := h.importMARCRecord(c, 0, records[0], copies)
+	switch result.Status {
+	case "created":
+		c.JSON(http.StatusCreated, result.Book)
+	case "skipped":
+		c.Error(apierror.Conflict(result.Error))
+	default:
+		c.Error(apierror.BadRequest(result.Error))
+	}
+}
+
+// ImportBooksMARCHandler imports every record in an uploaded MARC file.
+// Records that fail validation or duplicate an existing ISBN are reported
+// and skipped; the rest are created.
+func (h *Handler) ImportBooksMARCHandler(c *gin.Context) {
+	records, err := readMARCUpload(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if len(records) == 0 {
+		c.Error(apierror.BadRequest("no MARC records found"))
+		return
+	}
+
+	copies, err := marcCopies(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var created, skipped, failed int
+	results := make([]MARCImportResult, 0, len(records))
+	for i, rec := range records {
+		result := h.importMARCRecord(c, i, rec, copies)
+		switch result.Status {
+		case "created":
+			created++
+		case "skipped":
+			skipped++
+		default:
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	requestLogger(c).Info("MARC import finished", "created", created, "skipped", skipped, "failed", failed)
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":   len(records),
+		"created": created,
+		"skipped": skipped,
+		"failed":  failed,
+		"results": results,
+	})
+}
+
+// importMARCRecord maps, validates and stores a single record.
+func (h *Handler) importMARCRecord(c *gin.Context, index int, rec *marc.Record, copies int) MARCImportResult {
+	req := marc.ToBook(rec)
+	req.TotalCopies = copies
+	req.Description = truncateDescription(req.Description)
+
+	result := MARCImportResult{Index: index, Title: req.Title, Isbn: req.Isbn}
+
+	if err := validateMARCBook(req); err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+
+	if req.Isbn != "" {
+		_, err := h.store.GetBookByISBN(c.Request.Context(), pgtype.Text{String: req.Isbn, Valid: true})
+		if err == nil {
+			result.Status = "skipped"
+			result.Error = "a book with this isbn already exists"
+			return result
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			requestLogger(c).Error("MARC import: isbn lookup failed", "record", index, "error", err)
+			result.Status = "failed"
+			result.Error = "failed to check isbn"
+			return result
+		}
+	}
+
+	book, err := h.svc.Books.AddBook(c.Request.Context(), req, "")
+	if err != nil {
+		requestLogger(c).Error("MARC import: failed to create book", "record", index, "title", req.Title, "error", err)
+		result.Status = "failed"
+		result.Error = "failed to create book"
+		return result
+	}
+
+	h.logAudit(c, "book.import_marc", "book", book.ID, nil, book)
+
+	result.Status = "created"
+	result.Book = &book
+	return result
+}
+
+// validateMARCBook applies the same limits as CreateBookHandler. The
+// description is not checked: importMARCRecord truncates it instead, since
+// 520 notes often run long.
+func validateMARCBook(req models.CreateBookRequest) error {
+	if len(req.Title) == 0 || len(req.Title) > 255 {
+		return errors.New("title (245 $a) must be 1-255 characters")
+	}
+	if len(req.Author) == 0 || len(req.Author) > 100 {
+		return errors.New("author (100 $a) must be 1-100 characters")
+	}
+	if len(req.Genre) > 100 {
+		return errors.New("genre (650 $a) must be at most 100 characters")
+	}
+	if req.PublishedYear != 0 && (req.PublishedYear < 1800 || req.PublishedYear > time.Now().Year()) {
+		return fmt.Errorf("published year (264 $c) must be between 1800 and %d", time.Now().Year())
+	}
+	return nil
+}
+
+// truncateDescription cuts s to maxMARCDescription bytes at a character
+// boundary, ending it with an ellipsis when anything was cut
+func truncateDescription(s string) string {
+	if len(s) <= maxMARCDescription {
+		return s
+	}
+	const ellipsis = "…"
+	cut := maxMARCDescription - len(ellipsis)
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return strings.TrimRight(s[:cut], " ") + ellipsis
+}
+
+// readMARCUpload reads records from a multipart "file" field or the raw
+// request body. The format comes from ?format=marc|marcxml, falling back to
+// sniffing the payload.
+func readMARCUpload(c *gin.Context) ([]*marc.Record, error) {
+	var data []byte
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return nil, apierror.BadRequest("file is required")
+		}
+		if fh.Size > maxMARCUpload {
+			return nil, apierror.TooLarge("file is too large")
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return nil, apierror.BadRequest("failed to open file")
+		}
+		defer f.Close()
+		if data, err = io.ReadAll(f); err != nil {
+			return nil, apierror.BadRequest("failed to read file")
+		}
+	} else {
+		var err error
+		data, err = io.ReadAll(io.LimitReader(c.Request.Body, maxMARCUpload+1))
+		if err != nil {
+			return nil, apierror.BadRequest("failed to read request body")
+		}
+		if len(data) > maxMARCUpload {
+			return nil, apierror.TooLarge("request body is too large")
+		}
+	}
+
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = "marc"
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '<' {
+			format = "marcxml"
+		}
+	}
+
+	var (
+		records []*marc.Record
+		err     error
+	)
+	switch format {
+	case "marcxml", "xml":
+		records, err = marc.DecodeXML(bytes.NewReader(data))
+	case "marc", "mrc", "iso2709":
+		records, err = marc.ReadAll(bytes.NewReader(data))
+	default:
+		return nil, apierror.BadRequest("format must be marc or marcxml")
+	}
+	if err != nil {
+		return nil, apierror.New(http.StatusBadRequest, "invalid_marc", "invalid MARC data: "+err.Error())
+	}
+	return records, nil
+}
+
+func marcCopies(c *gin.Context) (int, error) {
+	copies := 1
+	if v := c.Query("copies"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 {
+			return 0, apierror.BadRequest("copies must be a positive integer")
+		}
+		copies = parsed
+	}
+	return copies, nil
+}
+
+// writeMARC streams records as MARCXML or binary MARC21 as an attachment.
+func writeMARC(c *gin.Context, format, filename string, records []*marc.Record) {
+	switch strings.ToLower(format) {
+	case "", "marcxml", "xml":
+		var buf bytes.Buffer
+		if err := marc.EncodeXML(&buf, records); err != nil {
+			requestLogger(c).Error("MARCXML export failed", "error", err)
+			c.Error(apierror.Internal("failed to encode records", nil))
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xml"`, filename))
+		c.Data(http.StatusOK, marcXMLContentType, buf.Bytes())
+	case "marc", "mrc", "iso2709":
+		var buf bytes.Buffer
+		w := marc.NewWriter(&buf)
+		for _, rec := range records {
+			if err := w.Write(rec); err != nil {
+				requestLogger(c).Error("MARC21 export failed", "control_number", rec.ControlField("001"), "error", err)
+				c.Error(apierror.Internal("failed to encode records", nil))
+				return
+			}
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.mrc"`, filename))
+		c.Data(http.StatusOK, marcContentType, buf.Bytes())
+	default:
+		c.Error(apierror.BadRequest("format must be marc or marcxml"))
+	}
+}
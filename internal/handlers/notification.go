@@ -1,34 +1,49 @@
 package handlers
 
 import (
-	"log"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
 	"github.com/THEGunDevil/GoForBackend/internal/db"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/THEGunDevil/GoForBackend/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-// GetUserNotificationsByUserIDHandler fetches notifications for a user with optional pagination
-func GetUserNotificationsByUserIDHandler(c *gin.Context) {
+// GetUserNotificationsByUserIDHandler fetches notifications for a user with optional pagination.
+// Kept for older clients; GET /notifications adds cursors and filters.
+func (h *Handler) GetUserNotificationsByUserIDHandler(c *gin.Context) {
 	userIDVal, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "userID not found in context"})
+		c.Error(apierror.Unauthorized("userID not found in context"))
 		return
 	}
 
 	userID, ok := userIDVal.(uuid.UUID)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid userID type"})
+		c.Error(apierror.Unauthorized("invalid userID type"))
 		return
 	}
 
-	// Pagination parameters
-	limit := int32(50)
-	offset := int32(0)
+	// Pagination parameters (?page=&limit=, 50 newest by default)
+	limit, offset := int32(50), int32(0)
+	if c.Query("limit") != "" || c.Query("page") != "" {
+		page, l := parsePagination(c)
+		l = min(l, maxNotificationPageSize)
+		limit, offset = int32(l), int32((page-1)*l)
+	}
 
 	params := gen.GetUserNotificationsByUserIDParams{
 		ID:     pgtype.UUID{Bytes: userID, Valid: true},
@@ -36,12 +51,12 @@ func GetUserNotificationsByUserIDHandler(c *gin.Context) {
 		Offset: offset,
 	}
 
-	notifications, err := db.Q.GetUserNotificationsByUserID(
+	notifications, err := h.store.GetUserNotificationsByUserID(
 		c.Request.Context(),
 		params,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		c.Error(apierror.Internal("Failed to fetch notifications", err))
 		return
 	}
 
@@ -49,57 +64,487 @@ func GetUserNotificationsByUserIDHandler(c *gin.Context) {
 	response := make([]models.Notification, 0)
 
 	for _, n := range notifications {
-		var objectID *uuid.UUID
-		if n.ObjectID.Valid {
-			id := n.ObjectID.Bytes
-			uuidVal, err := uuid.FromBytes(id[:])
-			if err == nil {
-				objectID = &uuidVal
-			}
-		}
-
-		response = append(response, models.Notification{
-			ID:                n.EventID.Bytes,
-			UserID:            userID,
-			UserName:          "",
-			ObjectID:          objectID,
-			ObjectTitle:       n.ObjectTitle.String,
-			Type:              n.Type,
-			NotificationTitle: n.NotificationTitle,
-			Message:           n.Message,
-			Metadata:          n.Metadata,
-			IsRead:            n.IsRead, // This is now correct (false for broadcast, true if read)
-			CreatedAt:         n.CreatedAt.Time,
-		})
+		response = append(response, toNotification(n, userID))
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
 // MarkAllNotificationsAsReadHandler marks all unread notifications for the user as read
-func MarkAllNotificationsAsReadHandler(c *gin.Context) {
+func (h *Handler) MarkAllNotificationsAsReadHandler(c *gin.Context) {
 	userIDVal, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.Error(apierror.Unauthorized("Unauthorized"))
 		return
 	}
 
 	userUUID, ok := userIDVal.(uuid.UUID)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID in context"})
+		c.Error(apierror.Internal("Invalid user ID in context", nil))
 		return
 	}
 
-	err := db.Q.MarkAllNotificationsAsRead(
+	err := h.store.MarkAllNotificationsAsRead(
 		c.Request.Context(),
 		pgtype.UUID{Bytes: userUUID, Valid: true},
 	)
 
 	if err != nil {
-		log.Printf("Failed to mark all as read for user %v: %v", userUUID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notifications as read"})
+		requestLogger(c).Error("failed to mark all notifications read", "error", err)
+		c.Error(apierror.Internal("Failed to mark notifications as read", nil))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "All notifications marked as read successfully"})
-}
\ No newline at end of file
+}
+
+const (
+	defaultNotificationPageSize = 20
+	maxNotificationPageSize     = 100
+)
+
+// ListNotificationsHandler returns the user's inbox newest first with cursor
+// pagination. Query: limit, cursor (next_cursor from the previous page),
+// type, and read=true|false.
+func (h *Handler) ListNotificationsHandler(c *gin.Context) {
+	userID, ok := notificationUserID(c)
+	if !ok {
+		return
+	}
+
+	params := gen.ListUserNotificationsParams{
+		UserID:   pgtype.UUID{Bytes: userID, Valid: true},
+		Type:     optionalQuery(c, "type"),
+		RowLimit: defaultNotificationPageSize,
+	}
+	if l := c.Query("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			c.Error(apierror.BadRequest("invalid limit"))
+			return
+		}
+		params.RowLimit = int32(min(n, maxNotificationPageSize))
+	}
+	if r := c.Query("read"); r != "" {
+		read, err := strconv.ParseBool(r)
+		if err != nil {
+			c.Error(apierror.BadRequest("invalid read filter, use true or false"))
+			return
+		}
+		params.IsRead = pgtype.Bool{Bool: read, Valid: true}
+	}
+	if cur := c.Query("cursor"); cur != "" {
+		createdAt, id, err := decodeNotificationCursor(cur)
+		if err != nil {
+			c.Error(apierror.BadRequest("invalid cursor"))
+			return
+		}
+		params.CursorCreatedAt = pgtype.Timestamp{Time: createdAt, Valid: true}
+		params.CursorID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+
+	// Fetch one extra row to know whether another page exists
+	pageSize := params.RowLimit
+	params.RowLimit++
+	rows, err := h.store.ListUserNotifications(c.Request.Context(), params)
+	if err != nil {
+		requestLogger(c).Error("failed to list notifications", "error", err)
+		c.Error(apierror.Internal("Failed to fetch notifications", nil))
+		return
+	}
+
+	hasMore := len(rows) > int(pageSize)
+	if hasMore {
+		rows = rows[:pageSize]
+	}
+	response := make([]models.Notification, 0, len(rows))
+	for _, n := range rows {
+		response = append(response, toNotification(gen.GetUserNotificationsByUserIDRow(n), userID))
+	}
+
+	var nextCursor *string
+	if hasMore {
+		last := rows[len(rows)-1]
+		cur := encodeNotificationCursor(last.CreatedAt.Time, last.EventID.Bytes)
+		nextCursor = &cur
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": response,
+		"count":         len(response),
+		"has_more":      hasMore,
+		"next_cursor":   nextCursor,
+	})
+}
+
+// MarkNotificationReadHandler marks one inbox item as read. Broadcasts get
+// their status row here the first time.
+func (h *Handler) MarkNotificationReadHandler(c *gin.Context) {
+	userID, ok := notificationUserID(c)
+	if !ok {
+		return
+	}
+	n, ok := h.findUserNotification(c, userID)
+	if !ok {
+		return
+	}
+
+	err := h.store.MarkNotificationAsRead(c.Request.Context(), gen.MarkNotificationAsReadParams{
+		UserID:  pgtype.UUID{Bytes: userID, Valid: true},
+		EventID: n.EventID,
+	})
+	if err != nil {
+		requestLogger(c).Error("failed to mark notification read", "event_id", uuid.UUID(n.EventID.Bytes), "error", err)
+		c.Error(apierror.Internal("Failed to mark notification as read", nil))
+		return
+	}
+
+	notification := toNotification(gen.GetUserNotificationsByUserIDRow(n), userID)
+	if !notification.IsRead {
+		now := time.Now()
+		notification.IsRead, notification.ReadAt = true, &now
+	}
+	c.JSON(http.StatusOK, notification)
+}
+
+// DismissNotificationHandler hides an inbox item for this user only
+func (h *Handler) DismissNotificationHandler(c *gin.Context) {
+	userID, ok := notificationUserID(c)
+	if !ok {
+		return
+	}
+	n, ok := h.findUserNotification(c, userID)
+	if !ok {
+		return
+	}
+
+	err := h.store.DismissNotification(c.Request.Context(), gen.DismissNotificationParams{
+		UserID:  pgtype.UUID{Bytes: userID, Valid: true},
+		EventID: n.EventID,
+	})
+	if err != nil {
+		requestLogger(c).Error("failed to dismiss notification", "event_id", uuid.UUID(n.EventID.Bytes), "error", err)
+		c.Error(apierror.Internal("Failed to dismiss notification", nil))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification dismissed"})
+}
+
+// UnreadNotificationCountHandler returns the unread badge count
+func (h *Handler) UnreadNotificationCountHandler(c *gin.Context) {
+	userID, ok := notificationUserID(c)
+	if !ok {
+		return
+	}
+
+	count, err := h.store.CountUnreadNotifications(c.Request.Context(), pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		requestLogger(c).Error("failed to count unread notifications", "error", err)
+		c.Error(apierror.Internal("Failed to count notifications", nil))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unread_count": count})
+}
+
+// notificationUserID reads the authenticated user, answering 401 if missing
+func notificationUserID(c *gin.Context) (uuid.UUID, bool) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.Error(apierror.Unauthorized("userID not found in context"))
+		return uuid.Nil, false
+	}
+	userID, ok := userIDVal.(uuid.UUID)
+	if !ok {
+		c.Error(apierror.Unauthorized("invalid userID type"))
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// findUserNotification loads the :id inbox item, answering 400/404 when it
+// is malformed, not visible to the user, or already dismissed.
+func (h *Handler) findUserNotification(c *gin.Context, userID uuid.UUID) (gen.GetUserNotificationRow, bool) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid notification ID"))
+		return gen.GetUserNotificationRow{}, false
+	}
+
+	n, err := h.store.GetUserNotification(c.Request.Context(), gen.GetUserNotificationParams{
+		UserID:  pgtype.UUID{Bytes: userID, Valid: true},
+		EventID: pgtype.UUID{Bytes: eventID, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.Error(apierror.NotFound("notification not found"))
+		return n, false
+	}
+	if err != nil {
+		c.Error(apierror.Internal("Failed to fetch notification", err))
+		return n, false
+	}
+	return n, true
+}
+
+// toNotification maps an inbox row. The other inbox queries return the same
+// columns, so their rows convert to this type directly.
+func toNotification(n gen.GetUserNotificationsByUserIDRow, userID uuid.UUID) models.Notification {
+	return models.Notification{
+		ID:                n.EventID.Bytes,
+		UserID:            userID,
+		ObjectID:          uuidPtr(n.ObjectID),
+		ObjectTitle:       n.ObjectTitle.String,
+		Type:              n.Type,
+		NotificationTitle: n.NotificationTitle,
+		Message:           n.Message,
+		Metadata:          n.Metadata,
+		IsRead:            n.IsRead, // false for broadcasts without a status row
+		ReadAt:            timePtr(n.ReadAt),
+		CreatedAt:         n.CreatedAt.Time,
+	}
+}
+
+// Cursors are opaque to clients: base64 of "<created_at>|<event id>"
+func encodeNotificationCursor(createdAt time.Time, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.Format(time.RFC3339Nano) + "|" + id.String()))
+}
+
+func decodeNotificationCursor(s string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	ts, idStr, found := strings.Cut(string(raw), "|")
+	if !found {
+		return time.Time{}, uuid.Nil, fmt.Errorf("malformed cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	return createdAt, id, nil
+}
+
+const (
+	// streamHeartbeat keeps proxies and load balancers from closing idle streams
+	streamHeartbeat = 25 * time.Second
+	// streamReplayLimit caps how many missed notifications a reconnect replays
+	streamReplayLimit = 100
+)
+
+// StreamNotificationsHandler pushes new notifications to the user over
+// Server-Sent Events. A reconnecting client sends Last-Event-ID (or
+// ?last_event_id=) and first receives whatever it missed since that event.
+func (h *Handler) StreamNotificationsHandler(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.Error(apierror.Unauthorized("userID not found in context"))
+		return
+	}
+	userID, ok := userIDVal.(uuid.UUID)
+	if !ok {
+		c.Error(apierror.Unauthorized("invalid userID type"))
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	// Subscribe before replaying so nothing created in between is lost
+	updates, unsubscribe := service.SubscribeNotifications(userID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // disable nginx response buffering
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 5000\n\n")
+	c.Writer.Flush()
+
+	// 1️⃣ Replay what was missed since Last-Event-ID
+	replayed := map[uuid.UUID]bool{}
+	if afterID, err := uuid.Parse(lastEventID); err == nil {
+		missed, err := h.store.ListUserNotificationsAfter(c.Request.Context(), gen.ListUserNotificationsAfterParams{
+			UserID:       pgtype.UUID{Bytes: userID, Valid: true},
+			AfterEventID: pgtype.UUID{Bytes: afterID, Valid: true},
+			RowLimit:     streamReplayLimit,
+		})
+		if err != nil {
+			requestLogger(c).Error("failed to replay notifications", "error", err)
+		}
+		for _, n := range missed {
+			notification := toNotification(gen.GetUserNotificationsByUserIDRow(n), userID)
+			if err := writeNotificationEvent(c.Writer, notification); err != nil {
+				return
+			}
+			replayed[notification.ID] = true
+		}
+		c.Writer.Flush()
+	}
+
+	// 2️⃣ Live updates and heartbeats until the client goes away
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+		case n, ok := <-updates:
+			if !ok {
+				return
+			}
+			if replayed[n.ID] {
+				delete(replayed, n.ID)
+				continue
+			}
+			if err := writeNotificationEvent(c.Writer, n); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+// writeNotificationEvent writes one SSE frame; the event ID doubles as the
+// resume point for Last-Event-ID.
+func writeNotificationEvent(w io.Writer, n models.Notification) error {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: notification\ndata: %s\n\n", n.ID, data)
+	return err
+}
+
+// GetNotificationPreferencesHandler returns the effective channels for every
+// notification type, falling back to the defaults where the user has no row.
+func (h *Handler) GetNotificationPreferencesHandler(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.Error(apierror.Unauthorized("userID not found in context"))
+		return
+	}
+	userID, ok := userIDVal.(uuid.UUID)
+	if !ok {
+		c.Error(apierror.Unauthorized("invalid userID type"))
+		return
+	}
+
+	prefs, err := h.loadNotificationPreferences(c, userID)
+	if err != nil {
+		requestLogger(c).Error("failed to load notification preferences", "error", err)
+		c.Error(apierror.Internal("failed to fetch notification preferences", nil))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"preferences": prefs})
+}
+
+// UpdateNotificationPreferencesHandler sets channels for one or more types.
+// Channels left out of an entry keep their current value.
+func (h *Handler) UpdateNotificationPreferencesHandler(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.Error(apierror.Unauthorized("userID not found in context"))
+		return
+	}
+	userID, ok := userIDVal.(uuid.UUID)
+	if !ok {
+		c.Error(apierror.Unauthorized("invalid userID type"))
+		return
+	}
+
+	var req models.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+	for _, p := range req.Preferences {
+		if !service.IsNotificationType(p.Type) {
+			c.Error(apierror.BadRequest("unknown notification type: "+p.Type).With("types", service.NotificationTypes))
+			return
+		}
+	}
+
+	current, err := h.loadNotificationPreferences(c, userID)
+	if err != nil {
+		c.Error(apierror.Internal("failed to fetch notification preferences", err))
+		return
+	}
+	byType := make(map[string]models.NotificationChannels, len(current))
+	for _, p := range current {
+		byType[p.Type] = p.NotificationChannels
+	}
+
+	ctx := c.Request.Context()
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		for _, p := range req.Preferences {
+			ch := byType[p.Type]
+			if p.InApp != nil {
+				ch.InApp = *p.InApp
+			}
+			if p.Email != nil {
+				ch.Email = *p.Email
+			}
+			if p.Digest != nil {
+				ch.Digest = *p.Digest
+			}
+			if _, err := tx.UpsertNotificationPreference(ctx, gen.UpsertNotificationPreferenceParams{
+				UserID: pgtype.UUID{Bytes: userID, Valid: true},
+				Type:   p.Type,
+				InApp:  ch.InApp,
+				Email:  ch.Email,
+				Digest: ch.Digest,
+			}); err != nil {
+				return fmt.Errorf("save %s preference: %w", p.Type, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(c, err, "failed to save notification preferences")
+		return
+	}
+
+	prefs, err := h.loadNotificationPreferences(c, userID)
+	if err != nil {
+		c.Error(apierror.Internal("failed to fetch notification preferences", err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"preferences": prefs})
+}
+
+// loadNotificationPreferences merges stored rows with the per-type defaults
+func (h *Handler) loadNotificationPreferences(c *gin.Context, userID uuid.UUID) ([]models.NotificationPreference, error) {
+	rows, err := h.store.ListNotificationPreferences(c.Request.Context(), pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]gen.NotificationPreference, len(rows))
+	for _, r := range rows {
+		stored[r.Type] = r
+	}
+
+	prefs := make([]models.NotificationPreference, 0, len(service.NotificationTypes))
+	for _, t := range service.NotificationTypes {
+		r, ok := stored[t]
+		if !ok {
+			prefs = append(prefs, models.NotificationPreference{Type: t, NotificationChannels: service.DefaultChannels(t), IsDefault: true})
+			continue
+		}
+		prefs = append(prefs, models.NotificationPreference{
+			Type:                 t,
+			NotificationChannels: models.NotificationChannels{InApp: r.InApp, Email: r.Email, Digest: r.Digest},
+		})
+	}
+	return prefs, nil
+}
@@ -5,22 +5,23 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/THEGunDevil/GoForBackend/internal/db"
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
 	"github.com/gin-gonic/gin"
 )
 
-func OverviewHandler(c *gin.Context) {
+func (h *Handler) OverviewHandler(c *gin.Context) {
+	ctx := c.Request.Context()
 	now := time.Now()
 
 	// -------------------- STATS --------------------
-	row, err := db.Q.GetStats(c, gen.GetStatsParams{
+	row, err := h.store.GetStats(ctx, gen.GetStatsParams{
 		Column1: int32(now.Month()),
 		Column2: int32(now.Year()),
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 
@@ -43,9 +44,9 @@ func OverviewHandler(c *gin.Context) {
 	}
 
 	// -------------------- BOOKS PER MONTH --------------------
-	dbBpm, err := db.Q.GetBooksPerMonth(c)
+	dbBpm, err := h.store.GetBooksPerMonth(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 	for _, b := range dbBpm {
@@ -56,9 +57,9 @@ func OverviewHandler(c *gin.Context) {
 	}
 
 	// -------------------- CATEGORY DATA --------------------
-	dbCat, err := db.Q.GetCategoryData(c)
+	dbCat, err := h.store.GetCategoryData(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 	for _, cdata := range dbCat {
@@ -69,9 +70,9 @@ func OverviewHandler(c *gin.Context) {
 	}
 
 	// -------------------- TOP BORROWED BOOKS --------------------
-	dbTop, err := db.Q.GetTopBorrowedBooks(c)
+	dbTop, err := h.store.GetTopBorrowedBooks(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 	for _, t := range dbTop {
@@ -82,9 +83,9 @@ func OverviewHandler(c *gin.Context) {
 	}
 
 	// -------------------- SUBSCRIPTION PLANS --------------------
-	dbPlans, err := db.Q.GetSubscriptionPlans(c)
+	dbPlans, err := h.store.GetSubscriptionPlans(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 	for _, p := range dbPlans {
@@ -95,9 +96,9 @@ func OverviewHandler(c *gin.Context) {
 	}
 
 	// -------------------- SUBSCRIPTION HISTORY --------------------
-	dbHist, err := db.Q.GetSubscriptionHistory(c)
+	dbHist, err := h.store.GetSubscriptionHistory(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 	for _, h := range dbHist {
@@ -6,18 +6,18 @@ import (
 	"errors" // New import for errors.As
 	"fmt"
 	"io"
-	"log"
 	"net/http"
-	"os"
+	"strconv"
 	"time"
 
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
 	"github.com/THEGunDevil/GoForBackend/internal/db"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
 	"github.com/THEGunDevil/GoForBackend/internal/service"
+	"github.com/THEGunDevil/GoForBackend/internal/telemetry"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn" // New import for checking PG error codes
 	"github.com/jackc/pgx/v5/pgtype"
 
@@ -30,167 +30,172 @@ func getInvalidUUID() pgtype.UUID {
 	return pgtype.UUID{Bytes: uuid.Nil, Valid: false}
 }
 
-func StripeWebhookHandler(c *gin.Context) {
+func (h *Handler) StripeWebhookHandler(c *gin.Context) {
+	defer func() {
+		if status := c.Writer.Status(); status >= http.StatusBadRequest {
+			telemetry.WebhookFailures.WithLabelValues("stripe", strconv.Itoa(status)).Inc()
+		}
+	}()
+
+	logger := requestLogger(c).With("provider", "stripe")
+
 	// 1. Read Body
 	const MaxBodyBytes = int64(65536)
 	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxBodyBytes)
 	payload, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		log.Println("❌ [Webhook] Failed to read request body")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		logger.Warn("failed to read webhook body", "error", err)
+		c.Error(apierror.BadRequest("failed to read request body"))
 		return
 	}
 
 	// 2. Verify Signature
 	sigHeader := c.GetHeader("Stripe-Signature")
-	endpointSecret := os.Getenv("STRIPE_WEBHOOK_SECRET")
-
-	log.Printf("🔍 [Webhook] Received event. Signature: %s... Secret Length: %d",
-		sigHeader[:10], len(endpointSecret))
+	endpointSecret := service.Stripe.WebhookSecret
 
 	event, err := webhook.ConstructEvent(payload, sigHeader, endpointSecret)
 	if err != nil {
-		log.Printf("❌ [Webhook] Signature verification failed: %v", err)
-		log.Println("💡 Tip: Ensure STRIPE_WEBHOOK_SECRET matches the one in Stripe Dashboard > Developers > Webhooks")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook signature"})
+		// Usually STRIPE_WEBHOOK_SECRET does not match the endpoint's secret
+		// in the Stripe dashboard
+		logger.Warn("webhook signature verification failed", "error", err)
+		c.Error(apierror.BadRequest("invalid webhook signature"))
 		return
 	}
 
-	log.Printf("✅ [Webhook] Event Verified. Type: %s", event.Type)
+	logger = logger.With("event_id", event.ID, "event_type", string(event.Type))
+	logger.Info("webhook event verified")
 
 	// 3. Handle Event
 	switch event.Type {
 	case "checkout.session.completed":
 		var session stripe.CheckoutSession
 		if err := json.Unmarshal(event.Data.Raw, &session); err != nil {
-			log.Printf("❌ [Webhook] JSON Unmarshal error: %v", err)
-			c.Status(http.StatusBadRequest)
+			logger.Warn("failed to decode checkout session", "error", err)
+			c.Error(apierror.BadRequest("invalid checkout session"))
 			return
 		}
 
-		// Log Metadata to debug missing ID
-		log.Printf("🔍 [Webhook] Metadata Received: %+v", session.Metadata)
-
 		transactionID := session.Metadata["transaction_id"]
 		if transactionID == "" {
-			log.Println("❌ [Webhook] transaction_id MISSING in metadata. InitializeStripePayment might be wrong.")
-			c.Status(http.StatusBadRequest)
+			logger.Error("checkout session has no transaction_id metadata", "session_id", session.ID)
+			c.Error(apierror.BadRequest("checkout session has no transaction_id"))
 			return
 		}
 
 		tranUUID, err := uuid.Parse(transactionID)
 		if err != nil {
-			log.Printf("❌ [Webhook] Invalid UUID format: %s", transactionID)
-			c.Status(http.StatusBadRequest)
+			logger.Warn("invalid transaction_id in metadata", "transaction_id", transactionID)
+			c.Error(apierror.BadRequest("invalid transaction_id"))
 			return
 		}
 
 		// Database Operations
 		ctx := c.Request.Context()
+		logger = logger.With("transaction_id", tranUUID)
 
 		// Check if payment exists
-		payment, err := db.Q.GetPaymentByTransactionID(ctx, pgtype.UUID{Bytes: tranUUID, Valid: true})
+		payment, err := h.store.GetPaymentByTransactionID(ctx, pgtype.UUID{Bytes: tranUUID, Valid: true})
 		if err != nil {
-			log.Printf("❌ [Webhook] DB: Payment not found for ID: %s", transactionID)
-			c.Status(http.StatusNotFound)
+			logger.Warn("payment not found", "error", err)
+			c.Error(apierror.NotFound("payment not found"))
 			return
 		}
 
 		// Check status
 		if payment.Status == "paid" {
-			log.Println("ℹ️ [Webhook] Payment already marked as paid. Skipping fulfillment.")
+			logger.Info("payment already paid, skipping fulfillment")
 			c.Status(http.StatusOK)
 			return
 		}
 
-		// Begin Transaction
-		tx, err := db.DB.BeginTx(ctx, pgx.TxOptions{})
-		if err != nil {
-			log.Printf("❌ [Webhook] DB: Failed to start transaction: %v", err)
-			c.Status(http.StatusInternalServerError)
-			return
-		}
-		defer tx.Rollback(ctx)
-		txQueries := gen.New(tx)
+		var (
+			plan       gen.SubscriptionPlan
+			sub        gen.Subscription
+			start, end time.Time
+		)
+		err = h.store.WithTx(ctx, func(tx db.Store) error {
+			// Calculate Subscription Dates
+			var err error
+			plan, err = tx.GetSubscriptionPlanByID(ctx, payment.PlanID)
+			if err != nil {
+				return fmt.Errorf("subscription plan %v not found: %w", uuid.UUID(payment.PlanID.Bytes), err)
+			}
+			start = time.Now().UTC()
+			end = start.Add(time.Duration(plan.DurationDays) * 24 * time.Hour)
+
+			// Create Subscription
+			sub, err = tx.CreateSubscription(ctx, gen.CreateSubscriptionParams{
+				UserID:    payment.UserID,
+				PlanID:    payment.PlanID,
+				StartDate: pgtype.Timestamp{Time: start, Valid: true},
+				EndDate:   pgtype.Timestamp{Time: end, Valid: true},
+				Status:    "active",
+			})
 
-		// Calculate Subscription Dates
-		plan, err := txQueries.GetSubscriptionPlanByID(ctx, payment.PlanID)
-		if err != nil {
-			log.Printf("❌ [Webhook] Subscription plan not found for ID: %s", payment.PlanID)
-			c.Status(http.StatusInternalServerError)
-			return
-		}
-		start := time.Now().UTC()
-		end := start.Add(time.Duration(plan.DurationDays) * 24 * time.Hour)
-
-		var sub gen.Subscription
-		// Create Subscription
-		sub, err = txQueries.CreateSubscription(ctx, gen.CreateSubscriptionParams{
-			UserID:    payment.UserID,
-			PlanID:    payment.PlanID,
-			StartDate: pgtype.Timestamp{Time: start, Valid: true},
-			EndDate:   pgtype.Timestamp{Time: end, Valid: true},
-			Status:    "active",
-		})
+			// --- CRITICAL ERROR HANDLING BLOCK ---
+			if err != nil {
+				var pgErr *pgconn.PgError
+				if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+					// 23505 is the PostgreSQL code for unique_violation.
+					// This usually means the user already has an active subscription.
+					logger.Warn("user already has an active subscription, marking payment paid anyway", "user_id", uuid.UUID(payment.UserID.Bytes))
+
+					// Set sub ID to invalid so we skip linking it below, but allow the payment status update to proceed.
+					sub.ID = getInvalidUUID()
+				} else {
+					// A real, unexpected DB error occurred (e.g., NOT NULL violation, connection lost).
+					return fmt.Errorf("create subscription: %w", err)
+				}
+			}
+			// --- END CRITICAL BLOCK ---
+
+			// Update Payment Status to PAID (IDEMPOTENCY)
+			if _, err := tx.UpdatePaymentStatus(ctx, gen.UpdatePaymentStatusParams{
+				ID:     payment.ID,
+				Status: "paid",
+			}); err != nil {
+				return fmt.Errorf("update payment status: %w", err)
+			}
 
-		// --- CRITICAL ERROR HANDLING BLOCK ---
-		if err != nil {
-			var pgErr *pgconn.PgError
-			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-				// 23505 is the PostgreSQL code for unique_violation.
-				// This usually means the user already has an active subscription.
-				log.Printf("⚠️ [Webhook] DB Constraint Failed (23505): User %s already has an active subscription. Proceeding to mark payment as paid.", payment.UserID)
-
-				// Set sub ID to invalid so we skip linking it below, but allow the payment status update to proceed.
-				sub.ID = getInvalidUUID()
-			} else {
-				// A real, unexpected DB error occurred (e.g., NOT NULL violation, connection lost).
-				log.Printf("❌ [Webhook] DB: CreateSubscription failed unexpectedly: %v", err)
-				c.Status(http.StatusInternalServerError)
-				return
+			// Update Payment with Sub ID (ONLY if subscription creation succeeded)
+			if sub.ID.Valid {
+				if _, err := tx.UpdatePaymentSubscriptionID(ctx, gen.UpdatePaymentSubscriptionIDParams{
+					ID:             payment.ID,
+					SubscriptionID: pgtype.UUID{Bytes: sub.ID.Bytes, Valid: true},
+				}); err != nil {
+					return fmt.Errorf("link subscription to payment: %w", err)
+				}
+			}
+
+			// System action: no actor, the request came from Stripe
+			if err := recordAudit(c, tx, "payment.paid", "payment", uuid.UUID(payment.ID.Bytes), payment, gin.H{
+				"status":          "paid",
+				"subscription_id": sub.ID,
+				"source":          "stripe_webhook",
+			}); err != nil {
+				return fmt.Errorf("write audit entry: %w", err)
 			}
-		}
-		// --- END CRITICAL BLOCK ---
 
-		// Update Payment Status to PAID (IDEMPOTENCY)
-		_, err = txQueries.UpdatePaymentStatus(ctx, gen.UpdatePaymentStatusParams{
-			ID:     payment.ID,
-			Status: "paid",
+			// Receipt goes out only if the payment commits
+			return service.QueueReceiptEmail(ctx, tx, payment, h.svc.Support.Email())
 		})
 		if err != nil {
-			log.Printf("❌ [Webhook] DB: UpdatePaymentStatus failed: %v", err)
-			c.Status(http.StatusInternalServerError)
+			logger.Error("failed to mark payment paid", "error", err)
+			c.Error(apierror.Internal("failed to mark payment paid", nil))
 			return
 		}
-
-		// Update Payment with Sub ID (ONLY if subscription creation succeeded)
+		telemetry.Payments.WithLabelValues("paid").Inc()
 		if sub.ID.Valid {
-			_, err = txQueries.UpdatePaymentSubscriptionID(ctx, gen.UpdatePaymentSubscriptionIDParams{
-				ID:             payment.ID,
-				SubscriptionID: pgtype.UUID{Bytes: sub.ID.Bytes, Valid: true},
-			})
-			if err != nil {
-				log.Printf("❌ [Webhook] DB: UpdatePaymentStatus with subscription ID failed: %v", err)
-				c.Status(http.StatusInternalServerError)
-				return
-			}
-			log.Printf("✅ [Webhook] SUCCESS! Payment %s updated to PAID, Sub %s created and linked.", transactionID, sub.ID.String())
+			logger.Info("payment marked as paid", "subscription_id", uuid.UUID(sub.ID.Bytes))
 		} else {
-			log.Printf("✅ [Webhook] SUCCESS! Payment %s updated to PAID, Subscription creation skipped (pre-existing sub constraint).", transactionID)
+			logger.Info("payment marked as paid, subscription creation skipped")
 		}
-
-		if err := tx.Commit(ctx); err != nil {
-			log.Printf("❌ [Webhook] DB: Commit failed: %v", err)
-			c.Status(http.StatusInternalServerError)
-			return
-		} // Send notification asynchronously
-		go func() {
-			// Use a fresh background context (don't use the request ctx – it may be cancelled)
-			bgCtx := context.Background()
-
+		// Send notification asynchronously
+		// service.Go detaches from the request ctx, which is cancelled once we respond
+		service.Go(c.Request.Context(), func(bgCtx context.Context) {
 			notifReq := models.SendNotificationRequest{
 				UserID:            payment.UserID.Bytes, // Adjust if needed
-				Type:              "subscription_created",
+				Type:              service.NotificationSubscriptionCreated,
 				NotificationTitle: fmt.Sprintf("Subscription to %s Activated!", plan.Name),
 				Message: fmt.Sprintf("Your subscription is active from %s to %s.",
 					start.Format("January 02, 2006"), end.Format("January 02, 2006")),
@@ -198,16 +203,16 @@ func StripeWebhookHandler(c *gin.Context) {
 				ObjectTitle: plan.Name,
 			}
 
-			if err := service.NotificationService(bgCtx, notifReq); err != nil {
-				log.Printf("⚠️ [Webhook] Failed to send subscription notification: %v", err)
+			if err := h.svc.Notifications.Dispatch(bgCtx, notifReq); err != nil {
+				logger.Warn("failed to send subscription notification", "error", err)
 				// Optional: send to Sentry/Slack for monitoring
 			} else {
-				log.Printf("✅ [Webhook] Subscription notification sent for user %s", payment.UserID)
+				logger.Info("subscription notification sent", "user_id", uuid.UUID(payment.UserID.Bytes))
 			}
-		}()
+		})
 
 	default:
-		log.Printf("ℹ️ [Webhook] Unhandled event type: %s", event.Type)
+		logger.Info("unhandled webhook event type")
 	}
 
 	c.Status(http.StatusOK)
@@ -2,17 +2,19 @@ package handlers
 
 import (
 	"errors"
-	"log"
+	"fmt"
 	"math"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
 	"github.com/THEGunDevil/GoForBackend/internal/db"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
 	"github.com/THEGunDevil/GoForBackend/internal/service"
+	"github.com/THEGunDevil/GoForBackend/internal/telemetry"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -21,30 +23,38 @@ import (
 
 // =======================
 // Payments Handlers
-func CreatePaymentHandler(c *gin.Context) {
+func (h *Handler) CreatePaymentHandler(c *gin.Context) {
 	var req models.CreatePaymentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload", "details": err.Error()})
+		c.Error(apierror.Invalid(err))
 		return
 	}
 
 	if req.UserID == uuid.Nil || req.PlanID == uuid.Nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id and plan_id are required"})
+		c.Error(apierror.BadRequest("user_id and plan_id are required"))
 		return
 	}
 
 	ctx := c.Request.Context()
 
 	// Fetch plan details
-	plan, err := db.Q.GetSubscriptionPlanByID(ctx, pgtype.UUID{Bytes: req.PlanID, Valid: true})
+	plan, err := h.store.GetSubscriptionPlanByID(ctx, pgtype.UUID{Bytes: req.PlanID, Valid: true})
 	// log.Printf("Subscription plan ID: %+v\n", plan.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.Error(apierror.BadRequest("invalid plan_id"))
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid plan_id"})
+		c.Error(apierror.Internal("failed to fetch plan", err))
+		return
+	}
+	user, err := h.store.GetUserByID(ctx, pgtype.UUID{Bytes: req.UserID, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.Error(apierror.BadRequest("invalid user_id"))
 		return
 	}
-	user, err := db.Q.GetUserByID(ctx, pgtype.UUID{Bytes: req.UserID, Valid: true})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+		c.Error(apierror.Internal("failed to fetch user", err))
 		return
 	}
 	// Insert pending payment; transaction_id will be auto-generated by Postgres
@@ -57,23 +67,29 @@ func CreatePaymentHandler(c *gin.Context) {
 		PaymentGateway: pgtype.Text{String: "stripe", Valid: true},
 	}
 
-	payment, err := db.Q.CreatePayment(ctx, params)
+	payment, err := h.store.CreatePayment(ctx, params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create payment", "details": err.Error()})
+		c.Error(apierror.Internal("failed to create payment", err))
 		return
 	}
 
-	log.Printf("Payment created: id=%s, transaction_id=%s, amount=%f %s",
-		payment.ID, payment.TransactionID, payment.Amount, payment.Currency)
+	telemetry.Payments.WithLabelValues(payment.Status).Inc()
+	requestLogger(c).Info("payment created",
+		"payment_id", uuid.UUID(payment.ID.Bytes),
+		"transaction_id", uuid.UUID(payment.TransactionID.Bytes),
+		"amount", payment.Amount,
+		"currency", payment.Currency,
+	)
 
 	// Initialize Stripe payment
 	redirectURL, err := service.InitializeStripePayment(&payment)
+	if errors.Is(err, service.ErrPaymentsDisabled) {
+		c.Error(err)
+		return
+	}
 	if err != nil {
-		log.Println("Stripe session creation failed:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to initialize payment gateway",
-			"details": err.Error(),
-		})
+		requestLogger(c).Error("stripe session creation failed", "error", err)
+		c.Error(apierror.Internal("failed to initialize payment gateway", nil))
 		return
 	}
 
@@ -85,23 +101,23 @@ func CreatePaymentHandler(c *gin.Context) {
 	})
 }
 
-func GetPaymentHandler(c *gin.Context) {
+func (h *Handler) GetPaymentHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment ID"})
+		c.Error(apierror.BadRequest("invalid payment ID"))
 		return
 	}
 
-	payment, err := db.Q.GetPaymentByID(c.Request.Context(), pgtype.UUID{Bytes: id, Valid: true})
+	payment, err := h.store.GetPaymentByID(c.Request.Context(), pgtype.UUID{Bytes: id, Valid: true})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get payment", "details": err.Error()})
+		c.Error(apierror.Internal("failed to get payment", err))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"payment": payment})
 }
-func ListAllPaymentsHandler(c *gin.Context) {
+func (h *Handler) ListAllPaymentsHandler(c *gin.Context) {
 	// Pagination params (default: page 1, limit 20)
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
@@ -114,9 +130,9 @@ func ListAllPaymentsHandler(c *gin.Context) {
 	offset := (page - 1) * limit
 
 	// Fetch payments
-	payments, err := db.Q.GetAllPayments(c.Request.Context(), gen.GetAllPaymentsParams{Limit: int32(limit), Offset: int32(offset)})
+	payments, err := h.store.GetAllPayments(c.Request.Context(), gen.GetAllPaymentsParams{Limit: int32(limit), Offset: int32(offset)})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list payments"})
+		c.Error(apierror.Internal("failed to list payments", err))
 		return
 	}
 
@@ -128,7 +144,7 @@ func ListAllPaymentsHandler(c *gin.Context) {
 		var processedAt *time.Time
 
 		// --- 1. Fetch Refund (Safely) ---
-		refunds, err := db.Q.GetRefundByPaymentID(c.Request.Context(), r.ID)
+		refunds, err := h.store.GetRefundByPaymentID(c.Request.Context(), r.ID)
 		if err != nil {
 			// ✅ Check if the error is specifically "No Rows"
 			if errors.Is(err, pgx.ErrNoRows) {
@@ -136,7 +152,7 @@ func ListAllPaymentsHandler(c *gin.Context) {
 				// We do nothing here, just let the variables stay empty.
 			} else {
 				// If it's a real database error (connection, etc), we fail.
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong fetching refunds"})
+				c.Error(apierror.Internal("something went wrong fetching refunds", err))
 				return
 			}
 		} else {
@@ -154,13 +170,13 @@ func ListAllPaymentsHandler(c *gin.Context) {
 		// --- 2. Fetch User (Safely) ---
 		username := "Unknown User"
 
-		users, err := db.Q.GetUserByID(c.Request.Context(), r.UserID)
+		users, err := h.store.GetUserByID(c.Request.Context(), r.UserID)
 		if err != nil {
 			// ✅ Check if user is missing (e.g., deleted account)
 			if errors.Is(err, pgx.ErrNoRows) {
 				// User not found, keep default "Unknown User"
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong fetching users"})
+				c.Error(apierror.Internal("something went wrong fetching users", err))
 				return
 			}
 		} else {
@@ -195,14 +211,14 @@ func ListAllPaymentsHandler(c *gin.Context) {
 		})
 	}
 
-	totalPayments, err := db.Q.CountPayments(c.Request.Context())
+	totalPayments, err := h.store.CountPayments(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count payments"})
+		c.Error(apierror.Internal("failed to count payments", err))
 		return
 	}
-	totalSales, err := db.Q.GetTotalSales(c.Request.Context())
+	totalSales, err := h.store.GetTotalSales(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to calculate total sales"})
+		c.Error(apierror.Internal("failed to calculate total sales", err))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -215,7 +231,7 @@ func ListAllPaymentsHandler(c *gin.Context) {
 		},
 	})
 }
-func SearchPaymentsPaginatedHandler(c *gin.Context) {
+func (h *Handler) SearchPaymentsPaginatedHandler(c *gin.Context) {
 	// Pagination
 	page := 1
 	limit := 10
@@ -245,17 +261,17 @@ func SearchPaymentsPaginatedHandler(c *gin.Context) {
 		Offset:  int32(offset),
 	}
 	// Execute search query
-	rows, err := db.Q.SearchPaymentsByEmailWithPagination(c.Request.Context(), params)
+	rows, err := h.store.SearchPaymentsByEmailWithPagination(c.Request.Context(), params)
 	if err != nil {
-		log.Printf("❌ Search error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch users"})
+		requestLogger(c).Error("failed to search payments", "error", err)
+		c.Error(apierror.Internal("failed to fetch users", nil))
 		return
 	}
 
 	// Count total matching users
-	count, err := db.Q.CountPaymentsByEmail(c.Request.Context(), pgtype.Text{String: query, Valid: true})
+	count, err := h.store.CountPaymentsByEmail(c.Request.Context(), pgtype.Text{String: query, Valid: true})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count payments"})
+		c.Error(apierror.Internal("failed to count payments", err))
 		return
 	}
 	totalPages := int(math.Ceil(float64(count) / float64(limit)))
@@ -268,7 +284,7 @@ func SearchPaymentsPaginatedHandler(c *gin.Context) {
 		var processedAt *time.Time
 
 		// --- 1. Fetch Refund (Safely) ---
-		refunds, err := db.Q.GetRefundByPaymentID(c.Request.Context(), r.ID)
+		refunds, err := h.store.GetRefundByPaymentID(c.Request.Context(), r.ID)
 		if err != nil {
 			// ✅ Check if the error is specifically "No Rows"
 			if errors.Is(err, pgx.ErrNoRows) {
@@ -276,7 +292,7 @@ func SearchPaymentsPaginatedHandler(c *gin.Context) {
 				// We do nothing here, just let the variables stay empty.
 			} else {
 				// If it's a real database error (connection, etc), we fail.
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong fetching refunds"})
+				c.Error(apierror.Internal("something went wrong fetching refunds", err))
 				return
 			}
 		} else {
@@ -294,13 +310,13 @@ func SearchPaymentsPaginatedHandler(c *gin.Context) {
 		// --- 2. Fetch User (Safely) ---
 		username := "Unknown User"
 
-		users, err := db.Q.GetUserByID(c.Request.Context(), r.UserID)
+		users, err := h.store.GetUserByID(c.Request.Context(), r.UserID)
 		if err != nil {
 			// ✅ Check if user is missing (e.g., deleted account)
 			if errors.Is(err, pgx.ErrNoRows) {
 				// User not found, keep default "Unknown User"
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong fetching users"})
+				c.Error(apierror.Internal("something went wrong fetching users", err))
 				return
 			}
 		} else {
@@ -344,53 +360,90 @@ func SearchPaymentsPaginatedHandler(c *gin.Context) {
 		},
 	})
 }
-func UpdatePaymentStatusHandler(c *gin.Context) {
+func (h *Handler) UpdatePaymentStatusHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment ID"})
+		c.Error(apierror.BadRequest("invalid payment ID"))
 		return
 	}
 
-	var req struct {
-		Status string `json:"status"`
-	}
+	var req models.UpdatePaymentStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "details": err.Error()})
+		c.Error(apierror.Invalid(err))
 		return
 	}
 
 	// Validate status
-	validStatuses := map[string]bool{"paid": true, "failed": true, "pending": true}
+	validStatuses := map[string]bool{"paid": true, "failed": true, "pending": true, "cancelled": true}
 	if !validStatuses[req.Status] {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status value"})
+		c.Error(apierror.BadRequest("invalid status value"))
 		return
 	}
 
+	ctx := c.Request.Context()
 	params := gen.UpdatePaymentStatusParams{
 		ID:     pgtype.UUID{Bytes: id, Valid: true},
 		Status: req.Status,
 	}
 
-	payment, err := db.Q.UpdatePaymentStatus(c.Request.Context(), params)
+	var payment gen.Payment
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		before, err := tx.GetPaymentByID(ctx, params.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NotFound("payment not found")
+		}
+		if err != nil {
+			return fmt.Errorf("fetch payment: %w", err)
+		}
+		if payment, err = tx.UpdatePaymentStatus(ctx, params); err != nil {
+			return fmt.Errorf("update payment status: %w", err)
+		}
+		if err := recordAudit(c, tx, "payment.status_update", "payment", id, before, payment); err != nil {
+			return fmt.Errorf("audit payment status: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update payment status", "details": err.Error()})
+		writeTxError(c, err, "failed to update payment status")
 		return
 	}
+	telemetry.Payments.WithLabelValues(payment.Status).Inc()
 
 	c.JSON(http.StatusOK, gin.H{"payment": payment})
 }
 
-func DeletePaymentByIDHandler(c *gin.Context) {
+func (h *Handler) DeletePaymentByIDHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment ID"})
+		c.Error(apierror.BadRequest("invalid payment ID"))
 		return
 	}
 
-	if err := db.Q.DeletePayment(c.Request.Context(), pgtype.UUID{Bytes: id, Valid: true}); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete payment", "details": err.Error()})
+	ctx := c.Request.Context()
+	paymentID := pgtype.UUID{Bytes: id, Valid: true}
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		before, err := tx.GetPaymentByID(ctx, paymentID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NotFound("payment not found")
+		}
+		if err != nil {
+			return fmt.Errorf("fetch payment: %w", err)
+		}
+		if err := tx.DeletePayment(ctx, paymentID); err != nil {
+			if isForeignKeyViolation(err) {
+				return apierror.Conflict("payment has an issued receipt or credit note and cannot be deleted")
+			}
+			return fmt.Errorf("delete payment: %w", err)
+		}
+		if err := recordAudit(c, tx, "payment.delete", "payment", id, before, nil); err != nil {
+			return fmt.Errorf("audit payment deletion: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(c, err, "failed to delete payment")
 		return
 	}
 
@@ -2,12 +2,11 @@ package handlers
 
 import (
 	"errors"
-	"log"
 	// "fmt"
 	"net/http"
 	"time"
 
-	"github.com/THEGunDevil/GoForBackend/internal/db"
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
 	"github.com/THEGunDevil/GoForBackend/internal/service"
 	"github.com/gin-gonic/gin"
@@ -16,61 +15,65 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-func GetProfileDataByIDHandler(c *gin.Context) {
+func (h *Handler) GetProfileDataByIDHandler(c *gin.Context) {
 	idStr := c.Param("id")
 
 	parsedID, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(apierror.BadRequest("Invalid user ID"))
 		return
 	}
 
 	ctx := c.Request.Context()
 
 	// Get user info
-	user, err := db.Q.GetUserByID(ctx, pgtype.UUID{Bytes: parsedID, Valid: true})
+	user, err := h.store.GetUserByID(ctx, pgtype.UUID{Bytes: parsedID, Valid: true})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			c.Error(apierror.NotFound("user not found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
+		c.Error(apierror.Internal("failed to get user", err))
 		return
 	}
 
 	// Get reviews by this user
-	dbReviews, err := db.Q.GetReviewsByUserID(ctx, pgtype.UUID{Bytes: parsedID, Valid: true})
+	dbReviews, err := h.store.GetReviewsByUserID(ctx, pgtype.UUID{Bytes: parsedID, Valid: true})
 	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get reviews"})
+		c.Error(apierror.Internal("failed to get reviews", err))
 		return
 	}
 
 	// Get borrows by this user
-	dbBorrows, err := db.Q.ListBorrowByUserID(ctx, pgtype.UUID{Bytes: parsedID, Valid: true})
+	dbBorrows, err := h.store.ListBorrowByUserID(ctx, pgtype.UUID{Bytes: parsedID, Valid: true})
 	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get borrows"})
+		c.Error(apierror.Internal("failed to get borrows", err))
+		return
+	}
+	ps, err := h.store.GetUserProfileStats(ctx, pgtype.UUID{Bytes: parsedID, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.Error(apierror.NotFound("user not found"))
 		return
 	}
-	ps, err := db.Q.GetUserProfileStats(ctx, pgtype.UUID{Bytes: parsedID, Valid: true})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(apierror.Internal("failed to get profile stats", err))
 		return
 	}
-	activeBorrowsCount, err := db.Q.CountActiveBorrowsByUserID(
+	activeBorrowsCount, err := h.store.CountActiveBorrowsByUserID(
 		c.Request.Context(),
 		pgtype.UUID{Bytes: user.ID.Bytes, Valid: true},
 	)
 	if err != nil {
-		log.Printf("Failed to count active borrows for user %v: %v", user.ID, err)
+		requestLogger(c).Warn("failed to count active borrows", "error", err)
 		activeBorrowsCount = 0
 	}
 
-	allBorrowsCount, err := db.Q.CountBorrowedBooksByUserID(
+	allBorrowsCount, err := h.store.CountBorrowedBooksByUserID(
 		c.Request.Context(),
 		pgtype.UUID{Bytes: user.ID.Bytes, Valid: true},
 	)
 	if err != nil {
-		log.Printf("Failed to count all borrows for user %v: %v", user.ID, err)
+		requestLogger(c).Warn("failed to count borrows", "error", err)
 		allBorrowsCount = 0
 	}
 	// Prepare user response
@@ -115,9 +118,9 @@ func GetProfileDataByIDHandler(c *gin.Context) {
 	var reviewResponses []models.ReviewResponse
 	for _, r := range dbReviews {
 		// Fetch book info for this review
-		book, err := db.Q.GetBookByID(ctx, pgtype.UUID{Bytes: r.BookID.Bytes, Valid: true})
+		book, err := h.store.GetBookByID(ctx, pgtype.UUID{Bytes: r.BookID.Bytes, Valid: true})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get book for review"})
+			c.Error(apierror.Internal("failed to get book for review", err))
 			return
 		}
 
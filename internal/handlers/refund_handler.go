@@ -4,10 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"time"
 
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
 	"github.com/THEGunDevil/GoForBackend/internal/db"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
@@ -22,20 +22,20 @@ import (
 // Refunds Handlers
 // =======================
 
-func CreateRefundHandler(c *gin.Context) {
+func (h *Handler) CreateRefundHandler(c *gin.Context) {
 	var req models.CreateRefundRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "details": err.Error()})
+		c.Error(apierror.Invalid(err))
 		return
 	}
 
 	// Validate required fields
 	if req.PaymentID == uuid.Nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "payment_id is required"})
+		c.Error(apierror.BadRequest("payment_id is required"))
 		return
 	}
 	if req.Amount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be greater than 0"})
+		c.Error(apierror.BadRequest("amount must be greater than 0"))
 		return
 	}
 
@@ -52,9 +52,9 @@ func CreateRefundHandler(c *gin.Context) {
 		ProcessedAt: pgtype.Timestamp{Valid: false}, // initially NULL
 	}
 
-	ref, err := db.Q.CreateRefund(c.Request.Context(), params)
+	ref, err := h.store.CreateRefund(c.Request.Context(), params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create refund", "details": err.Error()})
+		c.Error(apierror.Internal("failed to create refund", err))
 		return
 	}
 
@@ -71,17 +71,17 @@ func CreateRefundHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "refund created", "refund": res})
 }
 
-func GetRefundHandler(c *gin.Context) {
+func (h *Handler) GetRefundHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid refund ID"})
+		c.Error(apierror.BadRequest("invalid refund ID"))
 		return
 	}
 
-	refund, err := db.Q.GetRefundByID(c.Request.Context(), pgtype.UUID{Bytes: id, Valid: true})
+	refund, err := h.store.GetRefundByID(c.Request.Context(), pgtype.UUID{Bytes: id, Valid: true})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get refund", "details": err.Error()})
+		c.Error(apierror.Internal("failed to get refund", err))
 		return
 	}
 
@@ -109,135 +109,128 @@ func GetRefundHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"refund": response})
 }
 
-func ListRefundsByPaymentHandler(c *gin.Context) {
+func (h *Handler) ListRefundsByPaymentHandler(c *gin.Context) {
 	paymentIDStr := c.Param("payment_id")
 	paymentID, err := uuid.Parse(paymentIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment ID"})
+		c.Error(apierror.BadRequest("invalid payment ID"))
 		return
 	}
 
-	refunds, err := db.Q.ListRefundsByPayment(c.Request.Context(), pgtype.UUID{Bytes: paymentID, Valid: true})
+	refunds, err := h.store.ListRefundsByPayment(c.Request.Context(), pgtype.UUID{Bytes: paymentID, Valid: true})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list refunds", "details": err.Error()})
+		c.Error(apierror.Internal("failed to list refunds", err))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"refunds": refunds})
 }
 
-func ListRefundsByStatusHandler(c *gin.Context) {
+func (h *Handler) ListRefundsByStatusHandler(c *gin.Context) {
 	status := c.Query("status")
 	validStatuses := map[string]bool{"requested": true, "processed": true, "rejected": true}
 	if !validStatuses[status] {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status filter"})
+		c.Error(apierror.BadRequest("invalid status filter"))
 		return
 	}
 
-	refunds, err := db.Q.ListRefundsByStatus(c.Request.Context(), status)
+	refunds, err := h.store.ListRefundsByStatus(c.Request.Context(), status)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list refunds", "details": err.Error()})
+		c.Error(apierror.Internal("failed to list refunds", err))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"refunds": refunds})
 }
 
-func UpdateRefundStatusHandler(c *gin.Context) {
+func (h *Handler) UpdateRefundStatusHandler(c *gin.Context) {
 	// 1. Validate Refund ID from URL
 	idStr := c.Param("id")
 	refundID, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid refund ID format"})
+		c.Error(apierror.BadRequest("invalid refund ID format"))
 		return
 	}
 
 	// 2. Bind and validate request payload
 	var req models.CreateRefundRequest // Assuming this struct contains the desired Status
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload", "details": err.Error()})
+		c.Error(apierror.Invalid(err))
 		return
 	}
 
 	// 3. Validate status value
 	validStatuses := map[string]bool{"requested": true, "processed": true, "rejected": true}
 	if !validStatuses[req.Status] {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status value"})
+		c.Error(apierror.BadRequest("invalid status value"))
 		return
 	}
 
 	// --- TRANSACTION START ---
-	// 4. Begin a database transaction
-	tx, err := db.DB.Begin(c.Request.Context()) // Assuming 'db.Pool' is your *pgxpool.Pool
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start database transaction", "details": err.Error()})
-		return
-	}
-	defer func() {
-		// Rollback is safe to call even if the transaction is already committed.
-		// It only executes if the transaction is still active (e.g., due to an earlier error).
-		_ = tx.Rollback(c.Request.Context())
-	}()
-
-	// Use the transaction-aware query object
-	txQ := db.Q.WithTx(tx) // Assuming this method returns a query object bound to the transaction
-
-	// 5. Fetch the existing Refund to get the PaymentID (Step: Refund -> Payment)
-	originalRefund, err := txQ.GetRefundByID(c.Request.Context(), pgtype.UUID{Bytes: refundID, Valid: true})
-	if err != nil {
+	ctx := c.Request.Context()
+	var (
+		originalRefund, updatedRefund gen.Refund
+		payment                       gen.Payment
+	)
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		// 4. Fetch the existing Refund to get the PaymentID (Step: Refund -> Payment)
+		var err error
+		originalRefund, err = tx.GetRefundByID(ctx, pgtype.UUID{Bytes: refundID, Valid: true})
 		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "refund not found"})
-			return
+			return apierror.NotFound("refund not found")
+		}
+		if err != nil {
+			return fmt.Errorf("fetch refund: %w", err)
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch refund details", "details": err.Error()})
-		return
-	}
 
-	// 6. Fetch the Payment using the PaymentID to get the SubscriptionID (Step: Payment -> Subscription)
-	// NOTE: You must have a query function like GetPaymentByID that returns a struct containing SubscriptionID
-	payment, err := txQ.GetPaymentByID(c.Request.Context(), originalRefund.PaymentID)
-	if err != nil {
+		// 5. Fetch the Payment using the PaymentID to get the SubscriptionID (Step: Payment -> Subscription)
+		payment, err = tx.GetPaymentByID(ctx, originalRefund.PaymentID)
 		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found (data inconsistency)"})
-			return
+			return apierror.NotFound("payment not found (data inconsistency)")
+		}
+		if err != nil {
+			return fmt.Errorf("fetch payment: %w", err)
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch payment details", "details": err.Error()})
-		return
-	}
-
-	// 7. Prepare and execute the Refund status update
-	var processedAt pgtype.Timestamp
-	if req.Status == "processed" {
-		processedAt = pgtype.Timestamp{Time: time.Now().UTC(), Valid: true}
-	}
-
-	updateRefundParams := gen.UpdateRefundStatusParams{
-		ID:          pgtype.UUID{Bytes: refundID, Valid: true},
-		Status:      req.Status,
-		ProcessedAt: processedAt,
-	}
 
-	updatedRefund, err := txQ.UpdateRefundStatus(c.Request.Context(), updateRefundParams)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update refund status", "details": err.Error()})
-		return
-	}
+		// 6. Prepare and execute the Refund status update
+		var processedAt pgtype.Timestamp
+		if req.Status == "processed" {
+			processedAt = pgtype.Timestamp{Time: time.Now().UTC(), Valid: true}
+		}
+		updatedRefund, err = tx.UpdateRefundStatus(ctx, gen.UpdateRefundStatusParams{
+			ID:          pgtype.UUID{Bytes: refundID, Valid: true},
+			Status:      req.Status,
+			ProcessedAt: processedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("update refund status: %w", err)
+		}
+		if err := recordAudit(c, tx, "refund.status_update", "refund", refundID, originalRefund, updatedRefund); err != nil {
+			return fmt.Errorf("write audit log: %w", err)
+		}
 
-	// 8. Conditionally update Subscription status (only if refund is processed)
-	if updatedRefund.Status == "processed" {
-		_, err = txQ.UpdateSubscription(c.Request.Context(), gen.UpdateSubscriptionParams{
+		// 7. Conditionally update Subscription status (only if refund is processed)
+		if updatedRefund.Status != "processed" {
+			return nil
+		}
+		cancelled, err := tx.UpdateSubscription(ctx, gen.UpdateSubscriptionParams{
 			ID:     payment.SubscriptionID, // Use the ID fetched from the payment record
 			Status: "cancelled",
 		})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel associated subscription", "details": err.Error()})
-			return
+			return fmt.Errorf("cancel associated subscription: %w", err)
 		}
-	}
-
-	// 9. Commit the transaction
-	if err := tx.Commit(c.Request.Context()); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to commit transaction", "details": err.Error()})
+		if err := recordAudit(c, tx, "subscription.cancel", "subscription", uuid.UUID(payment.SubscriptionID.Bytes), nil, cancelled); err != nil {
+			return fmt.Errorf("write audit log: %w", err)
+		}
+		// Number the credit note now so the series follows processing order
+		if _, err := service.IssueCreditNote(ctx, tx, updatedRefund); err != nil {
+			return fmt.Errorf("issue credit note: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(c, err, "failed to update refund status")
 		return
 	}
 	// --- TRANSACTION END ---
@@ -245,30 +238,28 @@ func UpdateRefundStatusHandler(c *gin.Context) {
 		// 1. Convert the database's raw UUID ([16]byte) to the standard uuid.UUID type
 		refundUUID := uuid.UUID(updatedRefund.ID.Bytes)
 		userUUID := uuid.UUID(payment.UserID.Bytes)
+		logger := requestLogger(c).With("refund_id", refundUUID)
 
-		// 2. Define the anonymous function to accept arguments (r, u, amount)
-		go func(r gen.Refund, u uuid.UUID, amount float64) {
-			// 3. Define the actual context
-			bgCtx := context.Background()
-
-			// 4. Use the passed arguments to construct the request
+		// 2. Send the notification in the background
+		r, u, amount := updatedRefund, userUUID, updatedRefund.Amount
+		service.Go(c.Request.Context(), func(bgCtx context.Context) {
+			// 3. Use the captured values to construct the request
 			notifReq := models.SendNotificationRequest{
 				UserID:            u, // Use the passed 'u'
-				Type:              "refund_processed",
+				Type:              service.NotificationRefundProcessed,
 				NotificationTitle: fmt.Sprintf("Refund Processed: %s", r.Status),                               // Use the passed 'r'
 				Message:           fmt.Sprintf("Your refund of %.2f has been successfully processed.", amount), // Use the passed 'amount'
 				ObjectID:          &refundUUID,
 				ObjectTitle:       "Refund",
 			}
 
-			if err := service.NotificationService(bgCtx, notifReq); err != nil {
-				log.Printf("⚠️ [RefundHandler] Failed to send refund notification: %v", err)
+			if err := h.svc.Notifications.Dispatch(bgCtx, notifReq); err != nil {
+				logger.Warn("failed to send refund notification", "error", err)
 			} else {
-				log.Printf("✅ [RefundHandler] Refund notification sent for user %s", u)
+				logger.Info("refund notification sent", "target_user_id", u)
 			}
 
-			// 5. Pass the values when calling the anonymous function
-		}(updatedRefund, userUUID, updatedRefund.Amount)
+		})
 	}
 	// 10. Construct final successful response
 	response := models.Refund{
@@ -284,16 +275,37 @@ func UpdateRefundStatusHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"refund": response})
 }
 
-func DeleteRefundHandler(c *gin.Context) {
+func (h *Handler) DeleteRefundHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid refund ID"})
+		c.Error(apierror.BadRequest("invalid refund ID"))
 		return
 	}
 
-	if err := db.Q.DeleteRefund(c.Request.Context(), pgtype.UUID{Bytes: id, Valid: true}); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete refund", "details": err.Error()})
+	ctx := c.Request.Context()
+	refundID := pgtype.UUID{Bytes: id, Valid: true}
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		before, err := tx.GetRefundByID(ctx, refundID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NotFound("refund not found")
+		}
+		if err != nil {
+			return fmt.Errorf("fetch refund: %w", err)
+		}
+		if err := tx.DeleteRefund(ctx, refundID); err != nil {
+			if isForeignKeyViolation(err) {
+				return apierror.Conflict("refund has an issued credit note and cannot be deleted")
+			}
+			return fmt.Errorf("delete refund: %w", err)
+		}
+		if err := recordAudit(c, tx, "refund.delete", "refund", id, before, nil); err != nil {
+			return fmt.Errorf("audit refund deletion: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(c, err, "failed to delete refund")
 		return
 	}
 
@@ -1,62 +1,62 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 
-	"github.com/THEGunDevil/GoForBackend/internal/db"
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-func CreateReservationHandler(c *gin.Context) {
+func (h *Handler) CreateReservationHandler(c *gin.Context) {
 	// Get the logged-in user ID from context
 	requestingUserID, _ := c.Get("userID")
 	userUUID := requestingUserID.(uuid.UUID)
 
-	var req struct {
-		BookID string `json:"book_id" binding:"required"`
-	}
+	var req models.CreateReservationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.Invalid(err))
 		return
 	}
 
 	bookUUID, err := uuid.Parse(req.BookID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID format"})
+		c.Error(apierror.BadRequest("Invalid book ID format"))
 		return
 	}
 
 	// Check if book exists
-	book, err := db.Q.GetBookByID(c.Request.Context(), pgtype.UUID{Bytes: bookUUID, Valid: true})
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+	book, err := h.store.GetBookByID(c.Request.Context(), pgtype.UUID{Bytes: bookUUID, Valid: true})
+	if err != nil || book.DeletedAt.Valid {
+		c.Error(apierror.NotFound("Book not found"))
 		return
 	}
 
 	if book.AvailableCopies.Int32 > 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Book is available, please borrow instead"})
+		c.Error(apierror.BadRequest("Book is available, please borrow instead"))
 		return
 	}
 
 	// Create reservation
-	r, err := db.Q.CreateReservation(c.Request.Context(), gen.CreateReservationParams{
+	r, err := h.store.CreateReservation(c.Request.Context(), gen.CreateReservationParams{
 		UserID: pgtype.UUID{Bytes: userUUID, Valid: true},
 		BookID: pgtype.UUID{Bytes: bookUUID, Valid: true},
 	})
 	if err != nil {
 		// Handle unique constraint violation (already reserved)
 		if strings.Contains(err.Error(), "unique_user_book") {
-			c.JSON(http.StatusConflict, gin.H{"error": "You already have a reservation for this book"})
+			c.Error(apierror.Conflict("You already have a reservation for this book"))
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reservation"})
+		c.Error(apierror.Internal("Failed to create reservation", err))
 		return
 	}
 
@@ -76,17 +76,17 @@ func CreateReservationHandler(c *gin.Context) {
 }
 
 // GetReservationsHandler gets reservations based on user role
-func GetReservationsHandler(c *gin.Context) {
+func (h *Handler) GetReservationsHandler(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(apierror.Unauthorized("User not authenticated"))
 		return
 	}
 
 	role, _ := c.Get("role")
 	userUUID, ok := userID.(uuid.UUID)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(apierror.BadRequest("Invalid user ID"))
 		return
 	}
 
@@ -115,9 +115,9 @@ func GetReservationsHandler(c *gin.Context) {
 			Offset: int32(offset),
 		}
 
-		adminRes, err := db.Q.GetAllReservations(ctx, params)
+		adminRes, err := h.store.GetAllReservations(ctx, params)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reservations"})
+			c.Error(apierror.Internal("Failed to fetch reservations", err))
 			return
 		}
 
@@ -148,9 +148,9 @@ func GetReservationsHandler(c *gin.Context) {
 			Offset: int32(offset),
 		}
 
-		userRes, err := db.Q.GetUserReservations(ctx, params)
+		userRes, err := h.store.GetUserReservations(ctx, params)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reservations"})
+			c.Error(apierror.Internal("Failed to fetch reservations", err))
 			return
 		}
 
@@ -186,18 +186,18 @@ func GetReservationsHandler(c *gin.Context) {
 // CreateReservationHandler creates a new book reservation
 
 // GetNextReservationHandler gets the next pending reservation for a book (admin only)
-func GetNextReservationHandler(c *gin.Context) {
+func (h *Handler) GetNextReservationHandler(c *gin.Context) {
 	bookIDStr := c.Param("id")
 	bookUUID, err := uuid.Parse(bookIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		c.Error(apierror.BadRequest("Invalid book ID"))
 		return
 	}
 
-	r, err := db.Q.GetNextReservationForBook(c.Request.Context(),
+	r, err := h.store.GetNextReservationForBook(c.Request.Context(),
 		pgtype.UUID{Bytes: bookUUID, Valid: true})
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No pending reservations for this book"})
+		c.Error(apierror.NotFound("No pending reservations for this book"))
 		return
 	}
 	resp := models.ReservationResponse{
@@ -217,26 +217,24 @@ func GetNextReservationHandler(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, resp)
 }
-func UpdateReservationStatusHandler(c *gin.Context) {
+func (h *Handler) UpdateReservationStatusHandler(c *gin.Context) {
 	// 1️⃣ Get reservation ID from URL
 	idStr := c.Param("id")
 	reservationID, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reservation ID"})
+		c.Error(apierror.BadRequest("Invalid reservation ID"))
 		return
 	}
 
 	// 2️⃣ Bind JSON body for status
-	var req struct {
-		Status string `json:"status" binding:"required,oneof=pending notified fulfilled cancelled picked_up"`
-	}
+	var req models.UpdateReservationStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body or status"})
+		c.Error(apierror.Invalid(err))
 		return
 	}
 
 	// 3️⃣ Update reservation status
-	updatedRes, err := db.Q.UpdateReservationStatus(
+	updatedRes, err := h.store.UpdateReservationStatus(
 		c.Request.Context(),
 		gen.UpdateReservationStatusParams{
 			ID:     pgtype.UUID{Bytes: reservationID, Valid: true},
@@ -244,20 +242,21 @@ func UpdateReservationStatusHandler(c *gin.Context) {
 		},
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reservation status"})
+		c.Error(apierror.Internal("Failed to update reservation status", err))
 		return
 	}
+	h.logAudit(c, "reservation.status_update", "reservation", reservationID, nil, updatedRes)
 
 	// 4️⃣ Fetch user & book info
-	user, err := db.Q.GetUserByID(c.Request.Context(), pgtype.UUID{Bytes: updatedRes.UserID.Bytes, Valid: true})
+	user, err := h.store.GetUserByID(c.Request.Context(), pgtype.UUID{Bytes: updatedRes.UserID.Bytes, Valid: true})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user info"})
+		c.Error(apierror.Internal("Failed to fetch user info", err))
 		return
 	}
 
-	book, err := db.Q.GetBookByID(c.Request.Context(), pgtype.UUID{Bytes: updatedRes.BookID.Bytes, Valid: true})
+	book, err := h.store.GetBookByID(c.Request.Context(), pgtype.UUID{Bytes: updatedRes.BookID.Bytes, Valid: true})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book info"})
+		c.Error(apierror.Internal("Failed to fetch book info", err))
 		return
 	}
 
@@ -283,29 +282,29 @@ func UpdateReservationStatusHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-func GetReservationsByBookIDAndUserIDHandler(c *gin.Context) {
+func (h *Handler) GetReservationsByBookIDAndUserIDHandler(c *gin.Context) {
 	bookIDStr := c.Param("id")      // from /reservations/book/:id
 	userIDStr := c.Query("user_id") // from ?user_id=<UUID>
 
 	if bookIDStr == "" || userIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing book_id or user_id"})
+		c.Error(apierror.BadRequest("Missing book_id or user_id"))
 		return
 	}
 
 	bookUUID, err := uuid.Parse(bookIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		c.Error(apierror.BadRequest("Invalid book ID"))
 		return
 	}
 
 	userUUID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(apierror.BadRequest("Invalid user ID"))
 		return
 	}
 
 	// ✅ Call a new query that filters by BOTH
-	r, err := db.Q.GetReservationsByBookIDAndUserID(
+	r, err := h.store.GetReservationsByBookIDAndUserID(
 		c.Request.Context(),
 		gen.GetReservationsByBookIDAndUserIDParams{
 			BookID: pgtype.UUID{Bytes: bookUUID, Valid: true},
@@ -313,7 +312,7 @@ func GetReservationsByBookIDAndUserIDHandler(c *gin.Context) {
 		},
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reservations"})
+		c.Error(apierror.Internal("Failed to fetch reservations", err))
 		return
 	}
 	resp := models.ReservationResponse{
@@ -335,21 +334,21 @@ func GetReservationsByBookIDAndUserIDHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
-func GetReservationsByBookIDHandler(c *gin.Context) {
+func (h *Handler) GetReservationsByBookIDHandler(c *gin.Context) {
 	idStr := c.Param("id") // Correct: Param, not Params
 	bookID, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		c.Error(apierror.BadRequest("Invalid book ID"))
 		return
 	}
 
 	// Fetch raw reservations from DB
-	dbReservations, err := db.Q.GetReservationsByBookID(
+	dbReservations, err := h.store.GetReservationsByBookID(
 		c.Request.Context(),
 		pgtype.UUID{Bytes: bookID, Valid: true},
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reservations"})
+		c.Error(apierror.Internal("Failed to fetch reservations", err))
 		return
 	}
 
@@ -375,18 +374,22 @@ func GetReservationsByBookIDHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, reservations)
 }
-func GetReservationsByReservationID(c *gin.Context) {
+func (h *Handler) GetReservationsByReservationID(c *gin.Context) {
 	idStr := c.Param("id") // Correct: Param, not Params
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		c.Error(apierror.BadRequest("Invalid book ID"))
 		return
 	}
 
 	// Fetch raw reservations from DB
-	r, err := db.Q.GetReservationsByReservationID(c.Request.Context(), pgtype.UUID{Bytes: id, Valid: true})
+	r, err := h.store.GetReservationsByReservationID(c.Request.Context(), pgtype.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.Error(apierror.NotFound("reservation not found"))
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reservation ID"})
+		c.Error(apierror.Internal("failed to fetch reservation", err))
 		return
 	}
 	res := models.ReservationResponse{
@@ -2,57 +2,59 @@ package handlers
 
 import (
 	"errors"
-	"github.com/THEGunDevil/GoForBackend/internal/db"
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
-	"log"
 	"net/http"
 )
 
 // CreateReviewHandler creates a new review
-func CreateReviewHandler(c *gin.Context) {
+func (h *Handler) CreateReviewHandler(c *gin.Context) {
 	// Extract userID from context (set by AuthMiddleware)
 	userIDVal, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		c.Error(apierror.Unauthorized("user not authenticated"))
 		return
 	}
 	userUUID := userIDVal.(uuid.UUID)
 
 	// Bind JSON
-	var req struct {
-		BookID  *uuid.UUID `json:"bookId,omitempty"`
-		Rating  *int       `json:"rating,omitempty"`
-		Comment *string    `json:"comment,omitempty"`
-	}
+	var req models.CreateReviewRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		c.Error(apierror.Invalid(err))
 		return
 	}
 	if req.BookID == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "bookId is required"})
+		c.Error(apierror.BadRequest("bookId is required"))
 		return
 	}
 	bookUUID, err := uuid.Parse(req.BookID.String())
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bookID"})
+		c.Error(apierror.BadRequest("invalid bookID"))
 		return
 	}
 	// Validate input
 
 	// Must provide at least comment or rating
 	if req.Rating == nil && (req.Comment == nil || *req.Comment == "") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "rating or comment required"})
+		c.Error(apierror.BadRequest("rating or comment required"))
 		return
 	}
 
 	// Validate rating if it's provided
 	if req.Rating != nil && (*req.Rating < 1 || *req.Rating > 5) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "rating must be between 1 and 5"})
+		c.Error(apierror.BadRequest("rating must be between 1 and 5"))
+		return
+	}
+
+	// Deleted books no longer take reviews
+	book, err := h.store.GetBookByID(c.Request.Context(), pgtype.UUID{Bytes: bookUUID, Valid: true})
+	if err != nil || book.DeletedAt.Valid {
+		c.Error(apierror.NotFound("book not found"))
 		return
 	}
 
@@ -72,12 +74,9 @@ if req.Comment != nil && *req.Comment != "" {
     review.Comment = pgtype.Text{String: *req.Comment, Valid: true}
 }
 
-	createdReview, err := db.Q.CreateReview(db.Ctx, review)
+	createdReview, err := h.store.CreateReview(c.Request.Context(), review)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to create review",
-			"details": err.Error(),
-		})
+		c.Error(apierror.Internal("failed to create review", err))
 		return
 	}
 
@@ -88,10 +87,10 @@ if req.Comment != nil && *req.Comment != "" {
 }
 
 // UpdateReviewByIDHandler updates an existing review
-func UpdateReviewByIDHandler(c *gin.Context) {
+func (h *Handler) UpdateReviewByIDHandler(c *gin.Context) {
 	userIDVal, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		c.Error(apierror.Unauthorized("user not authenticated"))
 		return
 	}
 	userUUID := userIDVal.(uuid.UUID)
@@ -99,35 +98,35 @@ func UpdateReviewByIDHandler(c *gin.Context) {
 	reviewIDStr := c.Param("id")
 	reviewID, err := uuid.Parse(reviewIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid review ID"})
+		c.Error(apierror.BadRequest("invalid review ID"))
 		return
 	}
 
 	var req models.UpdateReviewRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		c.Error(apierror.Invalid(err))
 		return
 	}
 
 	// Verify ownership
-	reviews, err := db.Q.GetReviewsByReviewID(c.Request.Context(), pgtype.UUID{Bytes: reviewID, Valid: true})
+	reviews, err := h.store.GetReviewsByReviewID(c.Request.Context(), pgtype.UUID{Bytes: reviewID, Valid: true})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "review not found"})
+			c.Error(apierror.NotFound("review not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+			c.Error(apierror.Internal("database error", err))
 		}
 		return
 	}
 
 	if len(reviews) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "review not found"})
+		c.Error(apierror.NotFound("review not found"))
 		return
 	}
 
 	review := reviews[0] // take the first review
 	if review.UserID.Bytes != userUUID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "you can only update your own review"})
+		c.Error(apierror.Forbidden("you can only update your own review"))
 		return
 	}
 
@@ -140,13 +139,13 @@ func UpdateReviewByIDHandler(c *gin.Context) {
 		params.Comment = pgtype.Text{String: *req.Comment, Valid: true}
 	}
 
-	updatedReview, err := db.Q.UpdateReviewByID(c, params)
+	updatedReview, err := h.store.UpdateReviewByID(c.Request.Context(), params)
 	if err != nil {
-		log.Printf("UpdateReviewByID error: %v", err)
+		requestLogger(c).Error("failed to update review", "error", err)
 		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "review not found"})
+			c.Error(apierror.NotFound("review not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong"})
+			c.Error(apierror.Internal("something went wrong", nil))
 		}
 		return
 	}
@@ -157,32 +156,32 @@ func UpdateReviewByIDHandler(c *gin.Context) {
 	})
 }
 
-func GetReviewsByBookIDHandler(c *gin.Context) {
+func (h *Handler) GetReviewsByBookIDHandler(c *gin.Context) {
 	bookIDStr := c.Param("id")
 	bookID, err := uuid.Parse(bookIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		c.Error(apierror.BadRequest("Invalid book ID"))
 		return
 	}
 
-	book, err := db.Q.GetBookByID(c.Request.Context(), pgtype.UUID{Bytes: bookID, Valid: true})
+	book, err := h.store.GetBookByID(c.Request.Context(), pgtype.UUID{Bytes: bookID, Valid: true})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 
-	dbReviews, err := db.Q.GetReviewsByBookID(c.Request.Context(), pgtype.UUID{Bytes: bookID, Valid: true})
+	dbReviews, err := h.store.GetReviewsByBookID(c.Request.Context(), pgtype.UUID{Bytes: bookID, Valid: true})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 
 	var reviews []models.ReviewResponse
 	for _, r := range dbReviews {
-		user, err := db.Q.GetUserByID(c.Request.Context(), pgtype.UUID{Bytes: r.UserID.Bytes, Valid: true})
+		user, err := h.store.GetUserByID(c.Request.Context(), pgtype.UUID{Bytes: r.UserID.Bytes, Valid: true})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Error(apierror.Internal("something went wrong", err))
 			return
 		}
 
@@ -202,34 +201,34 @@ func GetReviewsByBookIDHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, reviews)
 }
-func GetReviewsByUserIDHandler(c *gin.Context) {
+func (h *Handler) GetReviewsByUserIDHandler(c *gin.Context) {
 	userIDStr := c.Param("id")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(apierror.BadRequest("Invalid user ID"))
 		return
 	}
 
 	// Fetch all reviews by this user
-	dbReviews, err := db.Q.GetReviewsByUserID(c.Request.Context(), pgtype.UUID{Bytes: userID, Valid: true})
+	dbReviews, err := h.store.GetReviewsByUserID(c.Request.Context(), pgtype.UUID{Bytes: userID, Valid: true})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 
 	var reviews []models.ReviewResponse
 	for _, r := range dbReviews {
 		// Fetch the user info
-		user, err := db.Q.GetUserByID(c.Request.Context(), pgtype.UUID{Bytes: r.UserID.Bytes, Valid: true})
+		user, err := h.store.GetUserByID(c.Request.Context(), pgtype.UUID{Bytes: r.UserID.Bytes, Valid: true})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Error(apierror.Internal("something went wrong", err))
 			return
 		}
 
 		// Fetch the book info
-		book, err := db.Q.GetBookByID(c.Request.Context(), pgtype.UUID{Bytes: r.BookID.Bytes, Valid: true})
+		book, err := h.store.GetBookByID(c.Request.Context(), pgtype.UUID{Bytes: r.BookID.Bytes, Valid: true})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Error(apierror.Internal("something went wrong", err))
 			return
 		}
 
@@ -248,39 +247,39 @@ func GetReviewsByUserIDHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, reviews)
 }
-func GetReviewsByReviewIDHandler(c *gin.Context) {
+func (h *Handler) GetReviewsByReviewIDHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	reviewID, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		c.Error(apierror.BadRequest("Invalid review ID"))
 		return
 	}
 
 	// Fetch the review by its ID
-	dbReviews, err := db.Q.GetReviewsByReviewID(c.Request.Context(), pgtype.UUID{Bytes: reviewID, Valid: true})
+	dbReviews, err := h.store.GetReviewsByReviewID(c.Request.Context(), pgtype.UUID{Bytes: reviewID, Valid: true})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 
 	if len(dbReviews) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "review not found"})
+		c.Error(apierror.NotFound("review not found"))
 		return
 	}
 
 	r := dbReviews[0]
 
 	// Fetch the user info
-	user, err := db.Q.GetUserByID(c.Request.Context(), pgtype.UUID{Bytes: r.UserID.Bytes, Valid: true})
+	user, err := h.store.GetUserByID(c.Request.Context(), pgtype.UUID{Bytes: r.UserID.Bytes, Valid: true})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 
 	// Fetch the book info
-	book, err := db.Q.GetBookByID(c.Request.Context(), pgtype.UUID{Bytes: r.BookID.Bytes, Valid: true})
+	book, err := h.store.GetBookByID(c.Request.Context(), pgtype.UUID{Bytes: r.BookID.Bytes, Valid: true})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 
@@ -299,20 +298,20 @@ func GetReviewsByReviewIDHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, review)
 }
 
-func DeleteReviewsByIDHandler(c *gin.Context) {
+func (h *Handler) DeleteReviewsByIDHandler(c *gin.Context) {
 	reviewParam := c.Param("id")
 	reviewId, err := uuid.Parse(reviewParam)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		c.Error(apierror.BadRequest("Invalid review ID"))
 		return
 	}
-	err = db.Q.DeleteReview(db.Ctx, pgtype.UUID{Bytes: reviewId, Valid: true})
+	err = h.store.DeleteReview(c.Request.Context(), pgtype.UUID{Bytes: reviewId, Valid: true})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "review not found"})
+			c.Error(apierror.NotFound("review not found"))
 		} else {
 			// Any other DB or server error
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong"})
+			c.Error(apierror.Internal("something went wrong", err))
 		}
 		return
 	}
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/THEGunDevil/GoForBackend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ListSeriesHandler lists series, optionally filtered by ?search=
+func (h *Handler) ListSeriesHandler(c *gin.Context) {
+	page, limit := parsePagination(c)
+	offset := (page - 1) * limit
+	search := strings.TrimSpace(c.Query("search"))
+
+	rows, err := h.store.ListSeries(c.Request.Context(), gen.ListSeriesParams{
+		Column1: search,
+		Limit:   int32(limit),
+		Offset:  int32(offset),
+	})
+	if err != nil {
+		requestLogger(c).Error("failed to list series", "error", err)
+		c.Error(apierror.Internal("failed to fetch series", nil))
+		return
+	}
+
+	totalCount, err := h.store.CountSeries(c.Request.Context(), search)
+	if err != nil {
+		c.Error(apierror.Internal("failed to fetch series", err))
+		return
+	}
+
+	series := make([]models.SeriesResponse, 0, len(rows))
+	for _, s := range rows {
+		series = append(series, models.SeriesResponse{
+			ID:          s.ID.Bytes,
+			Name:        s.Name,
+			Description: s.Description,
+			BookCount:   s.BookCount,
+			CreatedAt:   s.CreatedAt.Time,
+			UpdatedAt:   s.UpdatedAt.Time,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"page":        page,
+		"limit":       limit,
+		"count":       len(series),
+		"total_count": totalCount,
+		"total_pages": int(math.Ceil(float64(totalCount) / float64(limit))),
+		"series":      series,
+	})
+}
+
+// GetSeriesByIDHandler returns a series with its books in reading order
+func (h *Handler) GetSeriesByIDHandler(c *gin.Context) {
+	seriesID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid series id"))
+		return
+	}
+
+	s, err := h.store.GetSeriesByID(c.Request.Context(), pgtype.UUID{Bytes: seriesID, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.Error(apierror.NotFound("series not found"))
+		} else {
+			c.Error(apierror.Internal("something went wrong", err))
+		}
+		return
+	}
+
+	books, err := h.store.ListBooksInSeries(c.Request.Context(), s.ID)
+	if err != nil {
+		requestLogger(c).Error("failed to list books in series", "series_id", seriesID, "error", err)
+		c.Error(apierror.Internal("failed to fetch books", nil))
+		return
+	}
+
+	resp := toSeriesResponse(s)
+	resp.BookCount = int64(len(books))
+	resp.Books = make([]models.SeriesBookEntry, 0, len(books))
+	for _, b := range books {
+		resp.Books = append(resp.Books, models.SeriesBookEntry{
+			Position: service.Float8Ptr(b.Position),
+			Book: models.BookResponse{
+				ID:              b.ID.Bytes,
+				Title:           b.Title,
+				Author:          b.Author,
+				PublishedYear:   b.PublishedYear.Int32,
+				Isbn:            b.Isbn.String,
+				AvailableCopies: b.AvailableCopies.Int32,
+				TotalCopies:     b.TotalCopies,
+				Genre:           b.Genre,
+				Description:     b.Description,
+				ImageURL:        b.ImageUrl,
+				CreatedAt:       b.CreatedAt.Time,
+				UpdatedAt:       b.UpdatedAt.Time,
+			},
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateSeriesHandler adds a series (admin)
+func (h *Handler) CreateSeriesHandler(c *gin.Context) {
+	var req models.CreateSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if len(req.Name) == 0 || len(req.Name) > 255 {
+		c.Error(apierror.BadRequest("name must be 1-255 characters"))
+		return
+	}
+
+	s, err := h.store.CreateSeries(c.Request.Context(), gen.CreateSeriesParams{
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			c.Error(apierror.Conflict("a series with this name already exists"))
+			return
+		}
+		requestLogger(c).Error("failed to create series", "error", err)
+		c.Error(apierror.Internal("failed to create series", nil))
+		return
+	}
+
+	h.logAudit(c, "series.create", "series", uuid.UUID(s.ID.Bytes), nil, toSeriesResponse(s))
+	c.JSON(http.StatusCreated, toSeriesResponse(s))
+}
+
+// UpdateSeriesHandler updates a series (admin)
+func (h *Handler) UpdateSeriesHandler(c *gin.Context) {
+	seriesID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid series id"))
+		return
+	}
+
+	var req models.UpdateSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+	if req.Name != nil && (len(strings.TrimSpace(*req.Name)) == 0 || len(*req.Name) > 255) {
+		c.Error(apierror.BadRequest("name must be 1-255 characters"))
+		return
+	}
+
+	before, err := h.store.GetSeriesByID(c.Request.Context(), pgtype.UUID{Bytes: seriesID, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.Error(apierror.NotFound("series not found"))
+		} else {
+			c.Error(apierror.Internal("something went wrong", err))
+		}
+		return
+	}
+
+	s, err := h.store.UpdateSeriesByID(c.Request.Context(), gen.UpdateSeriesByIDParams{
+		Name:        optionalText(req.Name),
+		Description: optionalText(req.Description),
+		ID:          pgtype.UUID{Bytes: seriesID, Valid: true},
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			c.Error(apierror.NotFound("series not found"))
+		case isUniqueViolation(err):
+			c.Error(apierror.Conflict("a series with this name already exists"))
+		default:
+			requestLogger(c).Error("failed to update series", "series_id", seriesID, "error", err)
+			c.Error(apierror.Internal("failed to update series", nil))
+		}
+		return
+	}
+
+	h.logAudit(c, "series.update", "series", seriesID, toSeriesResponse(before), toSeriesResponse(s))
+	c.JSON(http.StatusOK, toSeriesResponse(s))
+}
+
+// SetBookSeriesHandler places a book in a series, or removes it when
+// series_id is null (admin)
+func (h *Handler) SetBookSeriesHandler(c *gin.Context) {
+	bookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid book id"))
+		return
+	}
+
+	var req models.SetBookSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+	if req.Position != nil && *req.Position < 0 {
+		c.Error(apierror.BadRequest("position must not be negative"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	pgBookID := pgtype.UUID{Bytes: bookID, Valid: true}
+	if _, err := h.store.GetBookByID(ctx, pgBookID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.Error(apierror.NotFound("book not found"))
+		} else {
+			c.Error(apierror.Internal("something went wrong", err))
+		}
+		return
+	}
+
+	if req.SeriesID == nil {
+		if err := h.store.RemoveBookSeries(ctx, pgBookID); err != nil {
+			c.Error(apierror.Internal("failed to update book series", err))
+			return
+		}
+		h.logAudit(c, "book.set_series", "book", bookID, nil, nil)
+		c.JSON(http.StatusOK, gin.H{"book_id": bookID, "series": nil})
+		return
+	}
+
+	s, err := h.store.GetSeriesByID(ctx, pgtype.UUID{Bytes: *req.SeriesID, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.Error(apierror.BadRequest("series not found"))
+		} else {
+			c.Error(apierror.Internal("something went wrong", err))
+		}
+		return
+	}
+
+	position := pgtype.Float8{}
+	if req.Position != nil {
+		position = pgtype.Float8{Float64: *req.Position, Valid: true}
+	}
+	if err := h.store.SetBookSeries(ctx, gen.SetBookSeriesParams{
+		BookID:   pgBookID,
+		SeriesID: s.ID,
+		Position: position,
+	}); err != nil {
+		requestLogger(c).Error("failed to set book series", "book_id", bookID, "error", err)
+		c.Error(apierror.Internal("failed to update book series", nil))
+		return
+	}
+
+	ref := models.BookSeriesRef{ID: s.ID.Bytes, Name: s.Name, Position: req.Position}
+	h.logAudit(c, "book.set_series", "book", bookID, nil, ref)
+	c.JSON(http.StatusOK, gin.H{
+		"book_id": bookID,
+		"series":  ref,
+	})
+}
+
+func toSeriesResponse(s gen.Series) models.SeriesResponse {
+	return models.SeriesResponse{
+		ID:          s.ID.Bytes,
+		Name:        s.Name,
+		Description: s.Description,
+		CreatedAt:   s.CreatedAt.Time,
+		UpdatedAt:   s.UpdatedAt.Time,
+	}
+}
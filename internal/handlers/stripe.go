@@ -3,24 +3,24 @@ package handlers
 import (
 	"context"
 	"fmt"
-	"log"
 	"net/http"
-	"os"
 	"time"
 
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
 	"github.com/THEGunDevil/GoForBackend/internal/db"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/logging"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
 	"github.com/THEGunDevil/GoForBackend/internal/service"
+	"github.com/THEGunDevil/GoForBackend/internal/telemetry"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stripe/stripe-go/v74"
 	"github.com/stripe/stripe-go/v74/checkout/session"
 )
 
-func processPaidCheckoutSession(ctx context.Context, checkoutSession *stripe.CheckoutSession) error {
+func (h *Handler) processPaidCheckoutSession(ctx context.Context, checkoutSession *stripe.CheckoutSession) error {
 	transactionIDStr, ok := checkoutSession.Metadata["transaction_id"]
 	if !ok || transactionIDStr == "" {
 		return fmt.Errorf("missing transaction_id in metadata")
@@ -31,7 +31,7 @@ func processPaidCheckoutSession(ctx context.Context, checkoutSession *stripe.Che
 		return fmt.Errorf("invalid transaction_id UUID: %v", err)
 	}
 
-	payment, err := db.Q.GetPaymentByTransactionID(ctx, pgtype.UUID{Bytes: tranUUID, Valid: true})
+	payment, err := h.store.GetPaymentByTransactionID(ctx, pgtype.UUID{Bytes: tranUUID, Valid: true})
 	if err != nil {
 		return fmt.Errorf("payment not found: %v", err)
 	}
@@ -40,58 +40,69 @@ func processPaidCheckoutSession(ctx context.Context, checkoutSession *stripe.Che
 		return nil // already processed — idempotent
 	}
 
-	tx, err := db.DB.BeginTx(ctx, pgx.TxOptions{})
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback(ctx)
+	var (
+		plan       gen.SubscriptionPlan
+		sub        gen.Subscription
+		start, end time.Time
+	)
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		var err error
+		plan, err = tx.GetSubscriptionPlanByID(ctx, payment.PlanID)
+		if err != nil {
+			return err
+		}
 
-	txQueries := gen.New(tx)
+		start = time.Now().UTC()
+		end = start.Add(time.Duration(plan.DurationDays) * 24 * time.Hour)
 
-	plan, err := txQueries.GetSubscriptionPlanByID(ctx, payment.PlanID)
-	if err != nil {
-		return err
-	}
-
-	start := time.Now().UTC()
-	end := start.Add(time.Duration(plan.DurationDays) * 24 * time.Hour)
+		sub, err = tx.CreateSubscription(ctx, gen.CreateSubscriptionParams{
+			UserID:    payment.UserID,
+			PlanID:    payment.PlanID,
+			StartDate: pgtype.Timestamp{Time: start, Valid: true},
+			EndDate:   pgtype.Timestamp{Time: end, Valid: true},
+			Status:    "active",
+		})
+		if err != nil {
+			return err
+		}
 
-	sub, err := txQueries.CreateSubscription(ctx, gen.CreateSubscriptionParams{
-		UserID:    payment.UserID,
-		PlanID:    payment.PlanID,
-		StartDate: pgtype.Timestamp{Time: start, Valid: true},
-		EndDate:   pgtype.Timestamp{Time: end, Valid: true},
-		Status:    "active",
-	})
-	if err != nil {
-		return err
-	}
+		if _, err = tx.UpdatePaymentStatus(ctx, gen.UpdatePaymentStatusParams{
+			ID:     payment.ID,
+			Status: "paid",
+		}); err != nil {
+			return err
+		}
 
-	if _, err = txQueries.UpdatePaymentStatus(ctx, gen.UpdatePaymentStatusParams{
-		ID:     payment.ID,
-		Status: "paid",
-	}); err != nil {
-		return err
-	}
+		if _, err = tx.UpdatePaymentSubscriptionID(ctx, gen.UpdatePaymentSubscriptionIDParams{
+			ID:             payment.ID,
+			SubscriptionID: pgtype.UUID{Bytes: sub.ID.Bytes, Valid: true},
+		}); err != nil {
+			return err
+		}
 
-	if _, err = txQueries.UpdatePaymentSubscriptionID(ctx, gen.UpdatePaymentSubscriptionIDParams{
-		ID:             payment.ID,
-		SubscriptionID: pgtype.UUID{Bytes: sub.ID.Bytes, Valid: true},
-	}); err != nil {
-		return err
-	}
+		if err = service.RecordAudit(ctx, tx, models.AuditEntry{
+			Action:     "payment.paid",
+			TargetType: "payment",
+			TargetID:   uuid.UUID(payment.ID.Bytes).String(),
+			Before:     payment,
+			After:      map[string]any{"status": "paid", "subscription_id": sub.ID, "source": "stripe_checkout"},
+		}); err != nil {
+			return err
+		}
 
-	if err = tx.Commit(ctx); err != nil {
+		return service.QueueReceiptEmail(ctx, tx, payment, h.svc.Support.Email())
+	})
+	if err != nil {
 		return err
 	}
+	telemetry.Payments.WithLabelValues("paid").Inc()
+	logger := logging.FromContext(ctx).With("transaction_id", transactionIDStr)
 	// Send notification asynchronously
-	go func() {
-		// Use a fresh background context (don't use the request ctx – it may be cancelled)
-		bgCtx := context.Background()
-
+	// service.Go detaches from the request ctx, which is cancelled once we respond
+	service.Go(ctx, func(bgCtx context.Context) {
 		notifReq := models.SendNotificationRequest{
 			UserID:            payment.UserID.Bytes, // Adjust if needed
-			Type:              "subscription_created",
+			Type:              service.NotificationSubscriptionCreated,
 			NotificationTitle: fmt.Sprintf("Subscription to %s Activated!", plan.Name),
 			Message: fmt.Sprintf("Your subscription is active from %s to %s.",
 				start.Format("January 02, 2006"), end.Format("January 02, 2006")),
@@ -99,44 +110,44 @@ func processPaidCheckoutSession(ctx context.Context, checkoutSession *stripe.Che
 			ObjectTitle: plan.Name,
 		}
 
-		if err := service.NotificationService(bgCtx, notifReq); err != nil {
-			log.Printf("⚠️ [Webhook] Failed to send subscription notification: %v", err)
+		if err := h.svc.Notifications.Dispatch(bgCtx, notifReq); err != nil {
+			logger.Warn("failed to send subscription notification", "error", err)
 			// Optional: send to Sentry/Slack for monitoring
 		} else {
-			log.Printf("✅ [Webhook] Subscription notification sent for user %s", payment.UserID)
+			logger.Info("subscription notification sent", "user_id", uuid.UUID(payment.UserID.Bytes))
 		}
-	}()
-	log.Printf("✅ [SuccessHandler] Payment %s marked as PAID, subscription %s created", transactionIDStr, sub.ID)
+	})
+	logger.Info("payment marked as paid", "subscription_id", uuid.UUID(sub.ID.Bytes))
 	return nil
 }
-func StripeSuccessHandler(c *gin.Context) {
+func (h *Handler) StripeSuccessHandler(c *gin.Context) {
 	sessionID := c.Query("session_id")
 	if sessionID == "" {
-		c.String(http.StatusBadRequest, "Missing session_id")
+		c.Error(apierror.BadRequest("missing session_id"))
 		return
 	}
 
 	s, err := session.Get(sessionID, nil)
 	if err != nil {
-		log.Printf("❌ [Success] Failed to retrieve session %s: %v", sessionID, err)
-		c.String(http.StatusBadRequest, "Invalid session")
+		requestLogger(c).Error("failed to retrieve checkout session", "session_id", sessionID, "error", err)
+		c.Error(apierror.BadRequest("invalid session"))
 		return
 	}
 
 	if s.PaymentStatus != stripe.CheckoutSessionPaymentStatusPaid {
 		// Redirect to cancel page if somehow not paid
-		http.Redirect(c.Writer, c.Request, os.Getenv("PAYMENT_CANCEL_REDIRECT"), http.StatusSeeOther)
+		http.Redirect(c.Writer, c.Request, service.Stripe.CancelRedirect, http.StatusSeeOther)
 		return
 	}
 
-	if err := processPaidCheckoutSession(c.Request.Context(), s); err != nil {
-		log.Printf("❌ [Success] Failed to process paid session %s: %v", sessionID, err)
+	if err := h.processPaidCheckoutSession(c.Request.Context(), s); err != nil {
+		requestLogger(c).Error("failed to process paid checkout session", "session_id", sessionID, "error", err)
 		// You can show an error page or still redirect (user already paid)
 		// Most people just redirect to success anyway
 	}
 
 	tranID := s.Metadata["transaction_id"]
-	redirectURL := os.Getenv("PAYMENT_SUCCESS_REDIRECT")
+	redirectURL := service.Stripe.SuccessRedirect
 	if tranID != "" {
 		redirectURL += "?tran_id=" + tranID
 	}
@@ -144,7 +155,7 @@ func StripeSuccessHandler(c *gin.Context) {
 	http.Redirect(c.Writer, c.Request, redirectURL, http.StatusSeeOther)
 }
 
-func StripeCancelHandler(c *gin.Context) {
+func (h *Handler) StripeCancelHandler(c *gin.Context) {
 	// Optional but recommended: retrieve the session to confirm it was actually cancelled
 	// and mark the payment as "cancelled" in your DB (idempotently
 	sessionID := c.Query("session_id")
@@ -153,25 +164,26 @@ func StripeCancelHandler(c *gin.Context) {
 		s, err := session.Get(sessionID, nil)
 		if err != nil {
 			// If we can't retrieve the session, just redirect anyway – user already cancelled
-			log.Printf("⚠️ [Cancel] Could not retrieve session %s: %v", sessionID, err)
+			requestLogger(c).Warn("could not retrieve cancelled checkout session", "session_id", sessionID, "error", err)
 		} else if s.PaymentStatus != stripe.CheckoutSessionPaymentStatusPaid {
 			// Only mark as cancelled if it really wasn't paid
 			if tranID, ok := s.Metadata["transaction_id"]; ok && tranID != "" {
 				if uuid, err := uuid.Parse(tranID); err == nil {
-					if _, err := db.Q.UpdatePaymentStatusByTransactionID(c.Request.Context(), gen.UpdatePaymentStatusByTransactionIDParams{
+					if _, err := h.store.UpdatePaymentStatusByTransactionID(c.Request.Context(), gen.UpdatePaymentStatusByTransactionIDParams{
 						TransactionID: pgtype.UUID{Bytes: uuid, Valid: true},
 						Status:        "cancelled",
 					}); err != nil {
-						log.Printf("❌ [Cancel] Failed to mark payment as cancelled: %v", err)
+						requestLogger(c).Error("failed to mark payment as cancelled", "transaction_id", tranID, "error", err)
 					} else {
-						log.Printf("✅ [Cancel] Payment %s marked as cancelled", tranID)
+						telemetry.Payments.WithLabelValues("cancelled").Inc()
+						requestLogger(c).Info("payment marked as cancelled", "transaction_id", tranID)
 					}
 				}
 			}
 		}
 	}
 
-	redirectURL := os.Getenv("PAYMENT_CANCEL_REDIRECT")
+	redirectURL := service.Stripe.CancelRedirect
 	if redirectURL == "" {
 		redirectURL = "https://book-library-web.vercel.app/payment/cancelled" // fallback
 	}
@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
 	"github.com/THEGunDevil/GoForBackend/internal/db"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
@@ -14,17 +16,17 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-func CreateSubscriptionHandler(c *gin.Context) {
+func (h *Handler) CreateSubscriptionHandler(c *gin.Context) {
 	// Get userID from middleware (secure)
 	userIDVal, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apierror.Unauthorized("unauthorized"))
 		return
 	}
 
 	userUUID, ok := userIDVal.(uuid.UUID)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user id"})
+		c.Error(apierror.Internal("invalid user id", nil))
 		return
 	}
 
@@ -32,47 +34,47 @@ func CreateSubscriptionHandler(c *gin.Context) {
 	var req models.CreateSubscriptionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		// Use http.StatusBadRequest (400) constant
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}) 
+		c.Error(apierror.Invalid(err))
 		return
 	}
 
 	// Assuming models.CreateSubscriptionRequest uses uuid.UUID for PlanID
 	if req.PlanID == uuid.Nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "plan_id is required"})
+		c.Error(apierror.BadRequest("plan_id is required"))
 		return
 	}
 
 	planUUID := uuid.UUID(req.PlanID)
 
 	// Fetch the plan
-	plan, err := db.Q.GetSubscriptionPlanByID(c.Request.Context(), pgtype.UUID{
+	plan, err := h.store.GetSubscriptionPlanByID(c.Request.Context(), pgtype.UUID{
 		Bytes: planUUID,
 		Valid: true,
 	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "plan not found"})
+			c.Error(apierror.NotFound("plan not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch plan", "details": err.Error()})
+			c.Error(apierror.Internal("failed to fetch plan", err))
 		}
 		return
 	}
 
 	// Check if user already has an active subscription
-	_, err = db.Q.GetSubscriptionByUserID(c.Request.Context(), pgtype.UUID{
+	_, err = h.store.GetSubscriptionByUserID(c.Request.Context(), pgtype.UUID{
 		Bytes: userUUID,
 		Valid: true,
 	})
 
 	if err == nil {
 		// Subscription found (due to the previously recommended SQL fix)
-		c.JSON(http.StatusForbidden, gin.H{"error": "you already have an active subscription"})
+		c.Error(apierror.Forbidden("you already have an active subscription"))
 		return
 	}
 
 	if !errors.Is(err, pgx.ErrNoRows) {
 		// Handle unexpected database error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error checking subscription status", "details": err.Error()})
+		c.Error(apierror.Internal("database error checking subscription status", err))
 		return
 	}
 	
@@ -98,9 +100,9 @@ func CreateSubscriptionHandler(c *gin.Context) {
 		AutoRenew: req.AutoRenew,
 	}
 
-	sub, err := db.Q.CreateSubscription(c.Request.Context(), params)
+	sub, err := h.store.CreateSubscription(c.Request.Context(), params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create subscription", "details": err.Error()})
+		c.Error(apierror.Internal("failed to create subscription", err))
 		return
 	}
 
@@ -120,25 +122,25 @@ func CreateSubscriptionHandler(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"message": "subscription created", "subscription": response})
 }
 
-func GetSubscriptionByIDHandler(c *gin.Context) {
+func (h *Handler) GetSubscriptionByIDHandler(c *gin.Context) {
     idStr := c.Param("id")
 
     subID, err := uuid.Parse(idStr)
     if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription ID"})
+        c.Error(apierror.BadRequest("invalid subscription ID"))
         return
     }
 
-    sub, err := db.Q.GetSubscriptionByID(c.Request.Context(), pgtype.UUID{
+    sub, err := h.store.GetSubscriptionByID(c.Request.Context(), pgtype.UUID{
         Bytes: subID, Valid: true,
     })
 
     if err != nil {
         if errors.Is(err, pgx.ErrNoRows) {
-            c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+            c.Error(apierror.NotFound("subscription not found"))
             return
         }
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch subscription", "details": err.Error()})
+        c.Error(apierror.Internal("failed to fetch subscription", err))
         return
     }
 
@@ -157,24 +159,24 @@ func GetSubscriptionByIDHandler(c *gin.Context) {
 
     c.JSON(http.StatusOK, gin.H{"subscription": response}) // Return single object
 }
-func GetSubscriptionByUserIDHandler(c *gin.Context) {
+func (h *Handler) GetSubscriptionByUserIDHandler(c *gin.Context) {
 	idStr := c.Param("user_id")
 
 	userID, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription user ID"})
+		c.Error(apierror.BadRequest("invalid subscription user ID"))
 		return
 	}
 
-	sub, err := db.Q.GetSubscriptionByUserID(c.Request.Context(), pgtype.UUID{
+	sub, err := h.store.GetSubscriptionByUserID(c.Request.Context(), pgtype.UUID{
 		Bytes: userID,
 		Valid: true,
 	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"subscription": nil})
+			c.Error(apierror.NotFound("subscription not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch subscription", "details": err.Error()})
+			c.Error(apierror.Internal("failed to fetch subscription", err))
 		}
 		return
 	}
@@ -194,13 +196,10 @@ func GetSubscriptionByUserIDHandler(c *gin.Context) {
 	c.JSON(200, gin.H{"subscription": response})
 }
 
-func ListSubscriptionsHandler(c *gin.Context) {
-	subs, err := db.Q.ListSubscriptions(c.Request.Context())
+func (h *Handler) ListSubscriptionsHandler(c *gin.Context) {
+	subs, err := h.store.ListSubscriptions(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to fetch subscriptions",
-			"details": err.Error(),
-		})
+		c.Error(apierror.Internal("failed to fetch subscriptions", err))
 		return
 	}
 
@@ -224,21 +223,21 @@ func ListSubscriptionsHandler(c *gin.Context) {
 		"subscriptions": response, // fixed typo
 	})
 }
-func ListSubscriptionsByUserHandler(c *gin.Context) {
+func (h *Handler) ListSubscriptionsByUserHandler(c *gin.Context) {
 	userID := c.Param("user_id")
 
 	parsed, err := uuid.Parse(userID)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid user_id"})
+		c.Error(apierror.BadRequest("invalid user_id"))
 		return
 	}
 
-	subs, err := db.Q.ListSubscriptionsByUser(c.Request.Context(), pgtype.UUID{
+	subs, err := h.store.ListSubscriptionsByUser(c.Request.Context(), pgtype.UUID{
 		Bytes: parsed, Valid: true,
 	})
 
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to fetch user subscriptions", "details": err.Error()})
+		c.Error(apierror.Internal("failed to fetch user subscriptions", err))
 		return
 	}
 	var response []models.Subscription
@@ -258,19 +257,20 @@ func ListSubscriptionsByUserHandler(c *gin.Context) {
 	}
 	c.JSON(200, gin.H{"subscriptions": response})
 }
-func UpdateSubscriptionHandler(c *gin.Context) {
+func (h *Handler) UpdateSubscriptionHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	subID, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid subscription ID"})
+		c.Error(apierror.BadRequest("invalid subscription ID"))
 		return
 	}
 
 	var req models.UpdateSubscriptionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		c.Error(apierror.Invalid(err))
 		return
 	}
+	ctx := c.Request.Context()
 	start := time.Now().UTC()
 	end := start.Add(time.Duration(req.DurationDays) * 24 * time.Hour)
 	params := gen.UpdateSubscriptionParams{
@@ -281,11 +281,27 @@ func UpdateSubscriptionHandler(c *gin.Context) {
 		AutoRenew: *req.AutoRenew,
 	}
 
-updated, err := db.Q.UpdateSubscription(c.Request.Context(), params)
-    if err != nil {
-        c.JSON(500, gin.H{"error": "failed to update subscription", "details": err.Error()})
-        return
-    }
+	var updated gen.Subscription
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		before, err := tx.GetSubscriptionByID(ctx, params.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NotFound("subscription not found")
+		}
+		if err != nil {
+			return fmt.Errorf("fetch subscription: %w", err)
+		}
+		if updated, err = tx.UpdateSubscription(ctx, params); err != nil {
+			return fmt.Errorf("update subscription: %w", err)
+		}
+		if err := recordAudit(c, tx, "subscription.update", "subscription", subID, before, updated); err != nil {
+			return fmt.Errorf("audit subscription update: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(c, err, "failed to update subscription")
+		return
+	}
     
     // Response should be a single object, not a slice
     response := models.Subscription{
@@ -302,20 +318,34 @@ updated, err := db.Q.UpdateSubscription(c.Request.Context(), params)
 
     c.JSON(http.StatusOK, gin.H{"message": "subscription updated", "subscription": response}) // Return single object
 }
-func DeleteSubscriptionByIDHandler(c *gin.Context) {
+func (h *Handler) DeleteSubscriptionByIDHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	subID, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid subscription ID"})
+		c.Error(apierror.BadRequest("invalid subscription ID"))
 		return
 	}
 
-	err = db.Q.DeleteSubscription(c.Request.Context(), pgtype.UUID{
-		Bytes: subID, Valid: true,
+	ctx := c.Request.Context()
+	id := pgtype.UUID{Bytes: subID, Valid: true}
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		before, err := tx.GetSubscriptionByID(ctx, id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NotFound("subscription not found")
+		}
+		if err != nil {
+			return fmt.Errorf("fetch subscription: %w", err)
+		}
+		if err := tx.DeleteSubscription(ctx, id); err != nil {
+			return fmt.Errorf("delete subscription: %w", err)
+		}
+		if err := recordAudit(c, tx, "subscription.delete", "subscription", subID, before, nil); err != nil {
+			return fmt.Errorf("audit subscription deletion: %w", err)
+		}
+		return nil
 	})
-
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to delete subscription", "details": err.Error()})
+		writeTxError(c, err, "failed to delete subscription")
 		return
 	}
 
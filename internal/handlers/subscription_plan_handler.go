@@ -2,10 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 
-	"github.com/THEGunDevil/GoForBackend/internal/db"
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
 	"github.com/THEGunDevil/GoForBackend/internal/service"
@@ -24,7 +24,7 @@ func unmarshalFeatures(featuresStr string) map[string]interface{} {
 	}
 	
 	if err := json.Unmarshal([]byte(featuresStr), &features); err != nil {
-		log.Println("❌ Features unmarshal error:", err)
+		slog.Warn("failed to unmarshal subscription plan features", "error", err)
 		return make(map[string]interface{})
 	}
 	
@@ -48,39 +48,32 @@ func buildSubscriptionPlanResponse(sub gen.SubscriptionPlan) models.Subscription
 }
 
 // CreateSubscriptionPlanHandler creates a new subscription plan
-func CreateSubscriptionPlanHandler(c *gin.Context) {
-	log.Println("🔹 CreateSubscriptionPlanHandler called")
+func (h *Handler) CreateSubscriptionPlanHandler(c *gin.Context) {
 	var req models.SubscriptionPlan
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Println("❌ JSON bind error:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.Invalid(err))
 		return
 	}
-	log.Printf("🔹 Request received: Name=%s, Price=%f, Duration=%d\n", req.Name, req.Price, req.DurationDays)
 
 	// Validations
 	if len(req.Name) == 0 || len(req.Name) > 100 {
-		log.Println("❌ Validation failed: invalid name")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "name must be between 1 and 100 characters"})
+		c.Error(apierror.BadRequest("name must be between 1 and 100 characters"))
 		return
 	}
 
 	if req.Price <= 0 {
-		log.Println("❌ Validation failed: invalid price")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "price must be greater than 0"})
+		c.Error(apierror.BadRequest("price must be greater than 0"))
 		return
 	}
 
 	if req.DurationDays <= 0 {
-		log.Println("❌ Validation failed: invalid duration")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "duration days must be greater than 0"})
+		c.Error(apierror.BadRequest("duration days must be greater than 0"))
 		return
 	}
 
 	if len(req.Description) > 255 {
-		log.Println("❌ Validation failed: description too long")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "description must not exceed 255 characters"})
+		c.Error(apierror.BadRequest("description must not exceed 255 characters"))
 		return
 	}
 
@@ -91,13 +84,10 @@ func CreateSubscriptionPlanHandler(c *gin.Context) {
 
 	featuresBytes, err := json.Marshal(req.Features)
 	if err != nil {
-		log.Println("❌ Features marshal error:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid features format"})
+		c.Error(apierror.BadRequest("invalid features format"))
 		return
 	}
 
-	log.Printf("🔹 Features JSON: %s\n", string(featuresBytes))
-
 	params := gen.CreateSubscriptionPlanParams{
 		Name:         req.Name,
 		Price:        float64(req.Price),
@@ -105,20 +95,17 @@ func CreateSubscriptionPlanHandler(c *gin.Context) {
 		Description:  service.StringToPGText(req.Description),
 		Features:     pgtype.Text{String: string(featuresBytes), Valid: true},
 	}
-	log.Println("🔹 SQLC params prepared")
 
-	sub, err := db.Q.CreateSubscriptionPlan(c.Request.Context(), params)
+	sub, err := h.store.CreateSubscriptionPlan(c.Request.Context(), params)
 	if err != nil {
-		log.Println("❌ DB insert error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to create subscription plan",
-			"details": err.Error(),
-		})
+		requestLogger(c).Error("failed to create subscription plan", "error", err)
+		c.Error(apierror.Internal("failed to create subscription plan", nil))
 		return
 	}
-	log.Println("✅ Subscription plan created with ID:", sub.ID)
+	requestLogger(c).Info("subscription plan created", "plan_id", uuid.UUID(sub.ID.Bytes))
 
 	resp := buildSubscriptionPlanResponse(sub)
+	h.logAudit(c, "subscription_plan.create", "subscription_plan", resp.ID, nil, resp)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "subscription plan created successfully",
@@ -127,47 +114,38 @@ func CreateSubscriptionPlanHandler(c *gin.Context) {
 }
 
 // UpdateSubscriptionPlanHandler updates an existing subscription plan
-func UpdateSubscriptionPlanHandler(c *gin.Context) {
-	log.Println("🔹 UpdateSubscriptionPlanHandler called")
+func (h *Handler) UpdateSubscriptionPlanHandler(c *gin.Context) {
 	var req models.SubscriptionPlan
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Println("❌ JSON bind error:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.Invalid(err))
 		return
 	}
 
 	// Validate ID is provided
 	if req.ID == uuid.Nil {
-		log.Println("❌ Validation failed: ID is required")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "subscription plan ID is required"})
+		c.Error(apierror.BadRequest("subscription plan ID is required"))
 		return
 	}
 
-	log.Printf("🔹 Request received: ID=%s, Name=%s, Price=%f, Duration=%d\n", req.ID, req.Name, req.Price, req.DurationDays)
-
 	// Validations
 	if len(req.Name) == 0 || len(req.Name) > 100 {
-		log.Println("❌ Validation failed: invalid name")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "name must be between 1 and 100 characters"})
+		c.Error(apierror.BadRequest("name must be between 1 and 100 characters"))
 		return
 	}
 
 	if req.Price <= 0 {
-		log.Println("❌ Validation failed: invalid price")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "price must be greater than 0"})
+		c.Error(apierror.BadRequest("price must be greater than 0"))
 		return
 	}
 
 	if req.DurationDays <= 0 {
-		log.Println("❌ Validation failed: invalid duration")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "duration days must be greater than 0"})
+		c.Error(apierror.BadRequest("duration days must be greater than 0"))
 		return
 	}
 
 	if len(req.Description) > 255 {
-		log.Println("❌ Validation failed: description too long")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "description must not exceed 255 characters"})
+		c.Error(apierror.BadRequest("description must not exceed 255 characters"))
 		return
 	}
 
@@ -178,13 +156,10 @@ func UpdateSubscriptionPlanHandler(c *gin.Context) {
 
 	featuresBytes, err := json.Marshal(req.Features)
 	if err != nil {
-		log.Println("❌ Features marshal error:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid features format"})
+		c.Error(apierror.BadRequest("invalid features format"))
 		return
 	}
 
-	log.Printf("🔹 Features JSON: %s\n", string(featuresBytes))
-
 	params := gen.UpdateSubscriptionPlanParams{
 		ID:           service.UUIDToPGType(req.ID),
 		Name:         req.Name,
@@ -193,20 +168,23 @@ func UpdateSubscriptionPlanHandler(c *gin.Context) {
 		Description:  service.StringToPGText(req.Description),
 		Features:     pgtype.Text{String: string(featuresBytes), Valid: true},
 	}
-	log.Println("🔹 SQLC params prepared")
 
-	sub, err := db.Q.UpdateSubscriptionPlan(c.Request.Context(), params)
+	before, err := h.store.GetSubscriptionPlanByID(c.Request.Context(), params.ID)
+	if err != nil {
+		c.Error(apierror.NotFound("subscription plan not found"))
+		return
+	}
+
+	sub, err := h.store.UpdateSubscriptionPlan(c.Request.Context(), params)
 	if err != nil {
-		log.Println("❌ DB update error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to update subscription plan",
-			"details": err.Error(),
-		})
+		requestLogger(c).Error("failed to update subscription plan", "plan_id", req.ID, "error", err)
+		c.Error(apierror.Internal("failed to update subscription plan", nil))
 		return
 	}
-	log.Println("✅ Subscription plan updated with ID:", sub.ID)
+	requestLogger(c).Info("subscription plan updated", "plan_id", uuid.UUID(sub.ID.Bytes))
 
 	resp := buildSubscriptionPlanResponse(sub)
+	h.logAudit(c, "subscription_plan.update", "subscription_plan", resp.ID, buildSubscriptionPlanResponse(before), resp)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "subscription plan updated successfully",
@@ -215,19 +193,13 @@ func UpdateSubscriptionPlanHandler(c *gin.Context) {
 }
 
 // GetSubscriptionsPlanHandler retrieves all subscription plans
-func GetSubscriptionsPlanHandler(c *gin.Context) {
-	log.Println("🔹 GetSubscriptionsPlanHandler called")
-
-	sublists, err := db.Q.ListSubscriptionPlans(c.Request.Context())
+func (h *Handler) GetSubscriptionsPlanHandler(c *gin.Context) {
+	sublists, err := h.store.ListSubscriptionPlans(c.Request.Context())
 	if err != nil {
-		log.Println("❌ DB fetch error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to get subscription plans",
-			"details": err.Error(),
-		})
+		requestLogger(c).Error("failed to fetch subscription plans", "error", err)
+		c.Error(apierror.Internal("failed to get subscription plans", nil))
 		return
 	}
-	log.Printf("🔹 %d plans fetched\n", len(sublists))
 
 	var response []models.SubscriptionPlan
 	for _, r := range sublists {
@@ -241,29 +213,23 @@ func GetSubscriptionsPlanHandler(c *gin.Context) {
 }
 
 // GetSubscriptionPlanByIDHandler retrieves a single subscription plan by ID
-func GetSubscriptionPlanByIDHandler(c *gin.Context) {
-	log.Println("🔹 GetSubscriptionPlanByIDHandler called")
+func (h *Handler) GetSubscriptionPlanByIDHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	
 	parsedID, err := uuid.Parse(idStr)
 	if err != nil {
-		log.Println("❌ Invalid UUID:", idStr)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription plan ID format"})
+		c.Error(apierror.BadRequest("invalid subscription plan ID format"))
 		return
 	}
 
-	sub, err := db.Q.GetSubscriptionPlanByID(c.Request.Context(), pgtype.UUID{Bytes: parsedID, Valid: true})
+	sub, err := h.store.GetSubscriptionPlanByID(c.Request.Context(), pgtype.UUID{Bytes: parsedID, Valid: true})
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			log.Println("❌ Subscription plan not found:", parsedID)
-			c.JSON(http.StatusNotFound, gin.H{"error": "subscription plan not found"})
+			c.Error(apierror.NotFound("subscription plan not found"))
 			return
 		}
-		log.Println("❌ DB fetch error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to get subscription plan",
-			"details": err.Error(),
-		})
+		requestLogger(c).Error("failed to fetch subscription plans", "error", err)
+		c.Error(apierror.Internal("failed to get subscription plan", nil))
 		return
 	}
 
@@ -276,27 +242,29 @@ func GetSubscriptionPlanByIDHandler(c *gin.Context) {
 }
 
 // DeleteSubscriptionPlanByID deletes a subscription plan by ID
-func DeleteSubscriptionPlanByID(c *gin.Context) {
-	log.Println("🔹 DeleteSubscriptionPlanByID called")
+func (h *Handler) DeleteSubscriptionPlanByID(c *gin.Context) {
 	idStr := c.Param("id")
 
 	parsedID, err := uuid.Parse(idStr)
 	if err != nil {
-		log.Println("❌ Invalid UUID:", idStr)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription plan ID format"})
+		c.Error(apierror.BadRequest("invalid subscription plan ID format"))
+		return
+	}
+
+	before, err := h.store.GetSubscriptionPlanByID(c.Request.Context(), pgtype.UUID{Bytes: parsedID, Valid: true})
+	if err != nil {
+		c.Error(apierror.NotFound("subscription plan not found"))
 		return
 	}
 
-	err = db.Q.DeleteSubscriptionPlan(c.Request.Context(), pgtype.UUID{Bytes: parsedID, Valid: true})
+	err = h.store.DeleteSubscriptionPlan(c.Request.Context(), pgtype.UUID{Bytes: parsedID, Valid: true})
 	if err != nil {
-		log.Println("❌ DB delete error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to delete subscription plan",
-			"details": err.Error(),
-		})
+		requestLogger(c).Error("failed to delete subscription plan", "plan_id", parsedID, "error", err)
+		c.Error(apierror.Internal("failed to delete subscription plan", nil))
 		return
 	}
+	h.logAudit(c, "subscription_plan.delete", "subscription_plan", parsedID, buildSubscriptionPlanResponse(before), nil)
 
-	log.Println("✅ Subscription plan deleted:", parsedID)
+	requestLogger(c).Info("subscription plan deleted", "plan_id", parsedID)
 	c.JSON(http.StatusOK, gin.H{"message": "subscription plan deleted successfully"})
 }
\ No newline at end of file
@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	"github.com/THEGunDevil/GoForBackend/internal/db"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/logging"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/THEGunDevil/GoForBackend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ListMySupportTicketsHandler lists the caller's tickets, most recently
+// active first
+func (h *Handler) ListMySupportTicketsHandler(c *gin.Context) {
+	userID, ok := notificationUserID(c)
+	if !ok {
+		return
+	}
+	page, limit := parsePagination(c)
+	pgUserID := pgtype.UUID{Bytes: userID, Valid: true}
+
+	rows, err := h.store.ListSupportTicketsByUser(c.Request.Context(), gen.ListSupportTicketsByUserParams{
+		UserID: pgUserID,
+		Limit:  int32(limit),
+		Offset: int32((page - 1) * limit),
+	})
+	if err != nil {
+		requestLogger(c).Error("failed to list own support tickets", "error", err)
+		c.Error(apierror.Internal("failed to fetch support tickets", nil))
+		return
+	}
+	totalCount, err := h.store.CountSupportTicketsByUser(c.Request.Context(), pgUserID)
+	if err != nil {
+		c.Error(apierror.Internal("failed to fetch support tickets", err))
+		return
+	}
+	writeSupportTicketPage(c, rows, page, limit, totalCount)
+}
+
+// GetMySupportTicketHandler returns one of the caller's tickets with its thread
+func (h *Handler) GetMySupportTicketHandler(c *gin.Context) {
+	ticket, ok := h.findOwnSupportTicket(c)
+	if !ok {
+		return
+	}
+	h.writeSupportTicketDetail(c, http.StatusOK, ticket)
+}
+
+// ReplyToMySupportTicketHandler adds a member message to their ticket. A
+// resolved ticket is reopened; a closed one needs a new ticket.
+func (h *Handler) ReplyToMySupportTicketHandler(c *gin.Context) {
+	ticket, ok := h.findOwnSupportTicket(c)
+	if !ok {
+		return
+	}
+	if ticket.Status == "closed" {
+		c.Error(apierror.Conflict("this ticket is closed, please open a new one"))
+		return
+	}
+
+	var req models.SupportReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+	h.addSupportReply(c, ticket, req.Body, false, "open")
+}
+
+// ListSupportTicketsHandler is the staff queue (admin). Filters:
+// ?status, ?category, ?priority and ?assigned_to (a user ID or "me").
+func (h *Handler) ListSupportTicketsHandler(c *gin.Context) {
+	params := gen.ListSupportTicketsParams{
+		Status:   optionalQuery(c, "status"),
+		Category: optionalQuery(c, "category"),
+		Priority: optionalQuery(c, "priority"),
+	}
+	if v := c.Query("assigned_to"); v != "" {
+		if v == "me" {
+			adminID, ok := notificationUserID(c)
+			if !ok {
+				return
+			}
+			params.AssignedTo = pgtype.UUID{Bytes: adminID, Valid: true}
+		} else {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				c.Error(apierror.BadRequest("invalid assigned_to"))
+				return
+			}
+			params.AssignedTo = pgtype.UUID{Bytes: id, Valid: true}
+		}
+	}
+	page, limit := parsePagination(c)
+	params.RowLimit = int32(limit)
+	params.RowOffset = int32((page - 1) * limit)
+
+	rows, err := h.store.ListSupportTickets(c.Request.Context(), params)
+	if err != nil {
+		requestLogger(c).Error("failed to list support tickets", "error", err)
+		c.Error(apierror.Internal("failed to fetch support tickets", nil))
+		return
+	}
+	totalCount, err := h.store.CountSupportTickets(c.Request.Context(), gen.CountSupportTicketsParams{
+		Status:     params.Status,
+		Category:   params.Category,
+		Priority:   params.Priority,
+		AssignedTo: params.AssignedTo,
+	})
+	if err != nil {
+		c.Error(apierror.Internal("failed to fetch support tickets", err))
+		return
+	}
+	writeSupportTicketPage(c, rows, page, limit, totalCount)
+}
+
+// GetSupportTicketHandler returns any ticket with its thread (admin)
+func (h *Handler) GetSupportTicketHandler(c *gin.Context) {
+	ticket, ok := h.findSupportTicket(c)
+	if !ok {
+		return
+	}
+	h.writeSupportTicketDetail(c, http.StatusOK, ticket)
+}
+
+// UpdateSupportTicketHandler changes status, priority, category or the
+// assignee of a ticket (admin)
+func (h *Handler) UpdateSupportTicketHandler(c *gin.Context) {
+	current, ok := h.findSupportTicket(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateSupportTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+
+	params := gen.UpdateSupportTicketParams{
+		ID:         current.ID,
+		Status:     current.Status,
+		Priority:   current.Priority,
+		Category:   current.Category,
+		AssignedTo: current.AssignedTo,
+	}
+	if req.Status != nil {
+		params.Status = *req.Status
+	}
+	if req.Priority != nil {
+		params.Priority = *req.Priority
+	}
+	if req.Category != nil {
+		params.Category = *req.Category
+	}
+	if req.AssignedTo != nil {
+		if *req.AssignedTo == "" {
+			params.AssignedTo = pgtype.UUID{}
+		} else {
+			assignee, err := uuid.Parse(*req.AssignedTo)
+			if err != nil {
+				c.Error(apierror.BadRequest("invalid assigned_to"))
+				return
+			}
+			user, err := h.store.GetUserByID(c.Request.Context(), pgtype.UUID{Bytes: assignee, Valid: true})
+			if err != nil || user.DeletedAt.Valid || user.Role.String != "admin" {
+				c.Error(apierror.BadRequest("tickets can only be assigned to admins"))
+				return
+			}
+			params.AssignedTo = user.ID
+		}
+	}
+
+	updated, err := h.store.UpdateSupportTicket(c.Request.Context(), params)
+	if err != nil {
+		requestLogger(c).Error("failed to update support ticket", "ticket_id", uuid.UUID(current.ID.Bytes), "error", err)
+		c.Error(apierror.Internal("failed to update support ticket", nil))
+		return
+	}
+	response := toSupportTicketResponse(updated)
+	h.logAudit(c, "support_ticket.update", "support_ticket", response.ID, toSupportTicketResponse(current), response)
+	c.JSON(http.StatusOK, response)
+}
+
+// ReplyToSupportTicketHandler adds a staff reply (admin). The ticket moves
+// to pending unless the request sets another status.
+func (h *Handler) ReplyToSupportTicketHandler(c *gin.Context) {
+	ticket, ok := h.findSupportTicket(c)
+	if !ok {
+		return
+	}
+
+	var req models.SupportReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apierror.Invalid(err))
+		return
+	}
+	status := "pending"
+	if req.Status != nil {
+		status = *req.Status
+	}
+	h.addSupportReply(c, ticket, req.Body, true, status)
+}
+
+// addSupportReply stores the message, moves the ticket to status and
+// notifies the other side in the background
+func (h *Handler) addSupportReply(c *gin.Context, ticket gen.SupportTicket, body string, isStaff bool, status string) {
+	authorID, ok := notificationUserID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	var (
+		msg     gen.SupportTicketMessage
+		updated gen.SupportTicket
+	)
+	err := h.store.WithTx(ctx, func(tx db.Store) error {
+		var err error
+		msg, err = tx.CreateSupportTicketMessage(ctx, gen.CreateSupportTicketMessageParams{
+			TicketID: ticket.ID,
+			AuthorID: pgtype.UUID{Bytes: authorID, Valid: true},
+			IsStaff:  isStaff,
+			Body:     body,
+		})
+		if err != nil {
+			return fmt.Errorf("save ticket reply: %w", err)
+		}
+		updated, err = tx.RecordSupportTicketReply(ctx, gen.RecordSupportTicketReplyParams{ID: ticket.ID, Status: status})
+		if err != nil {
+			return fmt.Errorf("update ticket after reply: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(c, err, "failed to save reply")
+		return
+	}
+
+	service.Go(ctx, func(ctx context.Context) {
+		if err := h.svc.Support.NotifyReply(ctx, updated, msg); err != nil {
+			logging.FromContext(ctx).Warn("failed to notify about ticket reply", "ticket_id", uuid.UUID(updated.ID.Bytes), "error", err)
+		}
+	})
+
+	h.writeSupportTicketDetail(c, http.StatusCreated, updated)
+}
+
+func (h *Handler) findSupportTicket(c *gin.Context) (gen.SupportTicket, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("invalid ticket ID"))
+		return gen.SupportTicket{}, false
+	}
+	ticket, err := h.store.GetSupportTicketByID(c.Request.Context(), pgtype.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.Error(apierror.NotFound("ticket not found"))
+		return ticket, false
+	}
+	if err != nil {
+		c.Error(apierror.Internal("failed to fetch ticket", err))
+		return ticket, false
+	}
+	return ticket, true
+}
+
+// findOwnSupportTicket is findSupportTicket limited to the caller's tickets;
+// other users' tickets are reported as not found
+func (h *Handler) findOwnSupportTicket(c *gin.Context) (gen.SupportTicket, bool) {
+	userID, ok := notificationUserID(c)
+	if !ok {
+		return gen.SupportTicket{}, false
+	}
+	ticket, ok := h.findSupportTicket(c)
+	if !ok {
+		return ticket, false
+	}
+	if uuid.UUID(ticket.UserID.Bytes) != userID {
+		c.Error(apierror.NotFound("ticket not found"))
+		return ticket, false
+	}
+	return ticket, true
+}
+
+func writeSupportTicketPage(c *gin.Context, rows []gen.SupportTicket, page, limit int, totalCount int64) {
+	tickets := make([]models.SupportTicketResponse, 0, len(rows))
+	for _, t := range rows {
+		tickets = append(tickets, toSupportTicketResponse(t))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"page":        page,
+		"limit":       limit,
+		"count":       len(tickets),
+		"total_count": totalCount,
+		"total_pages": int(math.Ceil(float64(totalCount) / float64(limit))),
+		"tickets":     tickets,
+	})
+}
+
+func (h *Handler) writeSupportTicketDetail(c *gin.Context, status int, ticket gen.SupportTicket) {
+	messages, err := h.store.ListSupportTicketMessages(c.Request.Context(), ticket.ID)
+	if err != nil {
+		requestLogger(c).Error("failed to load ticket messages", "ticket_id", uuid.UUID(ticket.ID.Bytes), "error", err)
+		c.Error(apierror.Internal("failed to fetch ticket messages", nil))
+		return
+	}
+	response := toSupportTicketResponse(ticket)
+	response.Messages = make([]models.SupportTicketMessageResponse, 0, len(messages))
+	for _, m := range messages {
+		response.Messages = append(response.Messages, toSupportTicketMessageResponse(m))
+	}
+	c.JSON(status, response)
+}
+
+func toSupportTicketResponse(t gen.SupportTicket) models.SupportTicketResponse {
+	return models.SupportTicketResponse{
+		ID:            t.ID.Bytes,
+		UserID:        t.UserID.Bytes,
+		Name:          t.Name,
+		Email:         t.Email,
+		Subject:       t.Subject,
+		Category:      t.Category,
+		Status:        t.Status,
+		Priority:      t.Priority,
+		AssignedTo:    uuidPtr(t.AssignedTo),
+		LastMessageAt: t.LastMessageAt.Time,
+		ClosedAt:      timePtr(t.ClosedAt),
+		CreatedAt:     t.CreatedAt.Time,
+		UpdatedAt:     t.UpdatedAt.Time,
+	}
+}
+
+func toSupportTicketMessageResponse(m gen.SupportTicketMessage) models.SupportTicketMessageResponse {
+	return models.SupportTicketMessageResponse{
+		ID:        m.ID.Bytes,
+		AuthorID:  uuidPtr(m.AuthorID),
+		IsStaff:   m.IsStaff,
+		Body:      m.Body,
+		CreatedAt: m.CreatedAt.Time,
+	}
+}
@@ -1,24 +1,27 @@
 package handlers
 
 import (
-	"log"
+	"errors"
+	"fmt"
 	"math"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
 	"github.com/THEGunDevil/GoForBackend/internal/db"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
 	"github.com/THEGunDevil/GoForBackend/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // GetUserHandler fetches user by email
-func GetUsersHandler(c *gin.Context) {
+func (h *Handler) GetUsersHandler(c *gin.Context) {
 	page := 1
 	limit := 10
 
@@ -35,22 +38,24 @@ func GetUsersHandler(c *gin.Context) {
 
 	offset := (page - 1) * limit
 
+	withDeleted := includeDeleted(c)
 	params := gen.ListUsersPaginatedParams{
-		Limit:  int32(limit),
-		Offset: int32(offset),
+		Limit:   int32(limit),
+		Offset:  int32(offset),
+		Column3: withDeleted,
 	}
 
 	// 1️⃣ Fetch paginated users
-	users, err := db.Q.ListUsersPaginated(c.Request.Context(), params)
+	users, err := h.store.ListUsersPaginated(c.Request.Context(), params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 
 	// 2️⃣ Count total users
-	totalCount, err := db.Q.CountUsers(c.Request.Context())
+	totalCount, err := h.store.CountUsers(c.Request.Context(), withDeleted)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierror.Internal("something went wrong", err))
 		return
 	}
 
@@ -59,21 +64,21 @@ func GetUsersHandler(c *gin.Context) {
 	// 3️⃣ Build response
 	var response []models.UserResponse
 	for _, user := range users {
-		activeBorrowsCount, err := db.Q.CountActiveBorrowsByUserID(
+		activeBorrowsCount, err := h.store.CountActiveBorrowsByUserID(
 			c.Request.Context(),
 			pgtype.UUID{Bytes: user.ID.Bytes, Valid: true},
 		)
 		if err != nil {
-			log.Printf("Failed to count active borrows for user %v: %v", user.ID, err)
+			requestLogger(c).Warn("failed to count active borrows", "target_user_id", uuid.UUID(user.ID.Bytes), "error", err)
 			activeBorrowsCount = 0
 		}
 
-		allBorrowsCount, err := db.Q.CountBorrowedBooksByUserID(
+		allBorrowsCount, err := h.store.CountBorrowedBooksByUserID(
 			c.Request.Context(),
 			pgtype.UUID{Bytes: user.ID.Bytes, Valid: true},
 		)
 		if err != nil {
-			log.Printf("Failed to count all borrows for user %v: %v", user.ID, err)
+			requestLogger(c).Warn("failed to count borrows", "target_user_id", uuid.UUID(user.ID.Bytes), "error", err)
 			allBorrowsCount = 0
 		}
 
@@ -92,6 +97,7 @@ func GetUsersHandler(c *gin.Context) {
 			AllBorrowsCount:    int(allBorrowsCount),
 			ActiveBorrowsCount: int(activeBorrowsCount),
 			CreatedAt:          user.CreatedAt.Time,
+			DeletedAt:          timePtr(user.DeletedAt),
 
 			// 👈 optional: add field to struct
 		})
@@ -107,16 +113,16 @@ func GetUsersHandler(c *gin.Context) {
 	})
 }
 
-func GetUserByIDHandler(c *gin.Context) {
+func (h *Handler) GetUserByIDHandler(c *gin.Context) {
 	userIDVal, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "userID not found in context"})
+		c.Error(apierror.Unauthorized("userID not found in context"))
 		return
 	}
 
 	userUUID, ok := userIDVal.(uuid.UUID)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid userID type"})
+		c.Error(apierror.Unauthorized("invalid userID type"))
 		return
 	}
 
@@ -124,22 +130,22 @@ func GetUserByIDHandler(c *gin.Context) {
 	// bannedUserFlag, _ := c.Get("banned_user")
 
 	// 2️⃣ Fetch user from DB
-	user, err := db.Q.GetUserByID(c.Request.Context(), pgtype.UUID{Bytes: userUUID, Valid: true})
+	user, err := h.store.GetUserByID(c.Request.Context(), pgtype.UUID{Bytes: userUUID, Valid: true})
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		c.Error(apierror.NotFound("user not found"))
 		return
 	}
 
 	// 3️⃣ Count borrows
-	activeBorrowsCount, err := db.Q.CountActiveBorrowsByUserID(c.Request.Context(), pgtype.UUID{Bytes: user.ID.Bytes, Valid: true})
+	activeBorrowsCount, err := h.store.CountActiveBorrowsByUserID(c.Request.Context(), pgtype.UUID{Bytes: user.ID.Bytes, Valid: true})
 	if err != nil {
-		log.Printf("Failed to count active borrows for user %v: %v", user.ID, err)
+		requestLogger(c).Warn("failed to count active borrows", "target_user_id", uuid.UUID(user.ID.Bytes), "error", err)
 		activeBorrowsCount = 0
 	}
 
-	allBorrowsCount, err := db.Q.CountBorrowedBooksByUserID(c.Request.Context(), pgtype.UUID{Bytes: user.ID.Bytes, Valid: true})
+	allBorrowsCount, err := h.store.CountBorrowedBooksByUserID(c.Request.Context(), pgtype.UUID{Bytes: user.ID.Bytes, Valid: true})
 	if err != nil {
-		log.Printf("Failed to count all borrows for user %v: %v", user.ID, err)
+		requestLogger(c).Warn("failed to count borrows", "target_user_id", uuid.UUID(user.ID.Bytes), "error", err)
 		allBorrowsCount = 0
 	}
 
@@ -161,10 +167,10 @@ func GetUserByIDHandler(c *gin.Context) {
 		CreatedAt:          user.CreatedAt.Time,
 	}
 
-	log.Printf("👤 Returning user data for user %v (banned: %v)", user.ID, user.IsBanned.Bool)
+	requestLogger(c).Debug("returning user data", "target_user_id", uuid.UUID(user.ID.Bytes), "banned", user.IsBanned.Bool)
 	c.JSON(http.StatusOK, resp)
 }
-func SearchUsersPaginatedHandler(c *gin.Context) {
+func (h *Handler) SearchUsersPaginatedHandler(c *gin.Context) {
 	// Pagination
 	page := 1
 	limit := 10
@@ -189,7 +195,7 @@ func SearchUsersPaginatedHandler(c *gin.Context) {
 		query = "" // or "*" depending on your query
 	}
 
-	log.Printf("🔍 Searching users: email='%s', page=%d", query, page)
+	requestLogger(c).Debug("searching users", "page", page)
 
 	// SQLC params
 	params := gen.SearchUsersByEmailWithPaginationParams{
@@ -199,17 +205,17 @@ func SearchUsersPaginatedHandler(c *gin.Context) {
 	}
 
 	// Execute search query
-	rows, err := db.Q.SearchUsersByEmailWithPagination(c.Request.Context(), params)
+	rows, err := h.store.SearchUsersByEmailWithPagination(c.Request.Context(), params)
 	if err != nil {
-		log.Printf("❌ Search error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch users"})
+		requestLogger(c).Error("failed to search users", "error", err)
+		c.Error(apierror.Internal("failed to fetch users", nil))
 		return
 	}
 
 	// Count total matching users
-	totalCount, err := db.Q.CountUsersByEmail(c.Request.Context(), pgtype.Text{String: query, Valid: true})
+	totalCount, err := h.store.CountUsersByEmail(c.Request.Context(), pgtype.Text{String: query, Valid: true})
 	if err != nil {
-		log.Printf("❌ Count error: %v", err)
+		requestLogger(c).Warn("failed to count users", "error", err)
 		totalCount = 0
 	}
 
@@ -240,19 +246,19 @@ func SearchUsersPaginatedHandler(c *gin.Context) {
 }
 
 // UpdateUserByIDHandler updates user by ID
-func UpdateUserByIDHandler(c *gin.Context) {
+func (h *Handler) UpdateUserByIDHandler(c *gin.Context) {
 	// Parse UUID
 	idStr := c.Param("id")
 	parsedID, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(apierror.BadRequest("Invalid user ID"))
 		return
 	}
 
 	// Fetch current user (to get old public_id)
-	currentUser, err := db.Q.GetUserByID(c, pgtype.UUID{Bytes: parsedID, Valid: true})
+	currentUser, err := h.store.GetUserByID(c.Request.Context(), pgtype.UUID{Bytes: parsedID, Valid: true})
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		c.Error(apierror.NotFound("User not found"))
 		return
 	}
 
@@ -267,7 +273,7 @@ func UpdateUserByIDHandler(c *gin.Context) {
 	}
 
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.Invalid(err))
 		return
 	}
 
@@ -305,20 +311,20 @@ func UpdateUserByIDHandler(c *gin.Context) {
 	if req.ProfileImg != nil {
 		// 1) Delete old image if exists
 		if currentUser.ProfileImgPublicID.Valid {
-			_ = service.DeleteImageFromCloudinary(currentUser.ProfileImgPublicID.String)
+			_ = service.DeleteImageFromCloudinary(c.Request.Context(), currentUser.ProfileImgPublicID.String)
 		}
 
 		// 2) Upload new image
 		file, err := req.ProfileImg.Open()
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open image"})
+			c.Error(apierror.Internal("failed to open image", err))
 			return
 		}
 		defer file.Close()
 
-		newImageURL, newPublicID, err = service.UploadProfileImgToCloudinary(file, req.ProfileImg.Filename)
+		newImageURL, newPublicID, err = service.UploadProfileImgToCloudinary(c.Request.Context(), file, req.ProfileImg.Filename)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "image upload failed"})
+			c.Error(apierror.Internal("image upload failed", err))
 			return
 		}
 
@@ -328,9 +334,9 @@ func UpdateUserByIDHandler(c *gin.Context) {
 	}
 
 	// Save changes
-	updatedUser, err := db.Q.UpdateUserByID(c, params)
+	updatedUser, err := h.store.UpdateUserByID(c.Request.Context(), params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Update failed"})
+		c.Error(apierror.Internal("Update failed", err))
 		return
 	}
 
@@ -354,40 +360,40 @@ func UpdateUserByIDHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
-func DeleteProfileImage(c *gin.Context) {
+func (h *Handler) DeleteProfileImage(c *gin.Context) {
 	// Get userID from context (from auth middleware)
 	userIDVal, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "userID not found in context"})
+		c.Error(apierror.Unauthorized("userID not found in context"))
 		return
 	}
 
 	userUUID, ok := userIDVal.(uuid.UUID)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid userID type"})
+		c.Error(apierror.Unauthorized("invalid userID type"))
 		return
 	}
 
 	// Fetch user to check existing image
-	user, err := db.Q.GetUserByID(c, pgtype.UUID{
+	user, err := h.store.GetUserByID(c.Request.Context(), pgtype.UUID{
 		Bytes: userUUID,
 		Valid: true,
 	})
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		c.Error(apierror.NotFound("user not found"))
 		return
 	}
 
 	// If no profile image → return immediately
 	if !user.ProfileImgPublicID.Valid || user.ProfileImgPublicID.String == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "no profile image to delete"})
+		c.Error(apierror.BadRequest("no profile image to delete"))
 		return
 	}
 
 	// Delete from Cloudinary
-	err = service.DeleteImageFromCloudinary(user.ProfileImgPublicID.String)
+	err = service.DeleteImageFromCloudinary(c.Request.Context(), user.ProfileImgPublicID.String)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete from cloudinary"})
+		c.Error(apierror.Internal("failed to delete from cloudinary", err))
 		return
 	}
 
@@ -398,9 +404,9 @@ func DeleteProfileImage(c *gin.Context) {
 		ProfileImgPublicID: pgtype.Text{Valid: false}, // sets NULL
 	}
 
-	_, err = db.Q.UpdateUserByID(c, params)
+	_, err = h.store.UpdateUserByID(c.Request.Context(), params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user record"})
+		c.Error(apierror.Internal("failed to update user record", err))
 		return
 	}
 
@@ -410,19 +416,19 @@ func DeleteProfileImage(c *gin.Context) {
 }
 
 
-func BanUserByIDHandler(c *gin.Context) {
+func (h *Handler) BanUserByIDHandler(c *gin.Context) {
 	// Parse UUID
 	idStr := c.Param("id")
 	parsedID, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(apierror.BadRequest("Invalid user ID"))
 		return
 	}
 
 	// Bind request
 	var req models.BanRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apierror.Invalid(err))
 		return
 	}
 
@@ -438,6 +444,7 @@ func BanUserByIDHandler(c *gin.Context) {
 	}
 
 	// Update user ban
+	ctx := c.Request.Context()
 	params := gen.UpdateUserBanByUserIDParams{
 		ID:             pgtype.UUID{Bytes: parsedID, Valid: true},
 		IsBanned:       pgtype.Bool{Bool: req.IsBanned, Valid: true},
@@ -446,10 +453,25 @@ func BanUserByIDHandler(c *gin.Context) {
 		IsPermanentBan: pgtype.Bool{Bool: req.IsPermanentBan, Valid: true},
 	}
 
-	updatedUser, err := db.Q.UpdateUserBanByUserID(c.Request.Context(), params)
+	var updatedUser gen.User
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		before, err := tx.GetUserByID(ctx, params.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NotFound("user not found")
+		}
+		if err != nil {
+			return err
+		}
+		if updatedUser, err = tx.UpdateUserBanByUserID(ctx, params); err != nil {
+			return fmt.Errorf("update user ban: %w", err)
+		}
+		if err := recordAudit(c, tx, "user.ban", "user", parsedID, auditUser(before), auditUser(updatedUser)); err != nil {
+			return fmt.Errorf("audit user ban: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		log.Printf("BanUser error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user ban status"})
+		writeTxError(c, err, "failed to update user ban status")
 		return
 	}
 
@@ -479,3 +501,120 @@ func BanUserByIDHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, resp)
 }
+
+// DeleteUserByIDHandler soft-deletes an account (admin). Outstanding tokens
+// are revoked and open reservations cancelled; payments and borrow history
+// stay until the account is purged.
+func (h *Handler) DeleteUserByIDHandler(c *gin.Context) {
+	parsedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("Invalid user ID"))
+		return
+	}
+	if currentID, _ := c.Get("userID"); currentID == parsedID {
+		c.Error(apierror.BadRequest("you cannot delete your own account"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := pgtype.UUID{Bytes: parsedID, Valid: true}
+
+	var user gen.User
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		var err error
+		user, err = tx.SoftDeleteUserByID(ctx, userID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NotFound("user not found")
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.CancelOpenReservationsByUserID(ctx, userID); err != nil {
+			return fmt.Errorf("cancel reservations: %w", err)
+		}
+		if err := recordAudit(c, tx, "user.delete", "user", parsedID, nil, auditUser(user)); err != nil {
+			return fmt.Errorf("audit user deletion: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(c, err, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user deleted", "deleted_at": user.DeletedAt.Time})
+}
+
+// RestoreUserByIDHandler reactivates a soft-deleted account (admin). The
+// user has to log in again since their old tokens were revoked.
+func (h *Handler) RestoreUserByIDHandler(c *gin.Context) {
+	parsedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("Invalid user ID"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		user, err := tx.RestoreUserByID(ctx, pgtype.UUID{Bytes: parsedID, Valid: true})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NotFound("deleted user not found")
+		}
+		if err != nil {
+			return err
+		}
+		if err := recordAudit(c, tx, "user.restore", "user", parsedID, nil, auditUser(user)); err != nil {
+			return fmt.Errorf("audit user restore: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(c, err, "something went wrong")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user restored"})
+}
+
+// PurgeUserByIDHandler permanently removes a soft-deleted account together
+// with its payments, borrows and reviews (admin)
+func (h *Handler) PurgeUserByIDHandler(c *gin.Context) {
+	parsedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.BadRequest("Invalid user ID"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	var user gen.User
+	err = h.store.WithTx(ctx, func(tx db.Store) error {
+		var err error
+		user, err = tx.PurgeUserByID(ctx, pgtype.UUID{Bytes: parsedID, Valid: true})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apierror.NotFound("user not found or not deleted")
+		}
+		if isForeignKeyViolation(err) {
+			return apierror.Conflict("user has payments with issued receipts and cannot be purged")
+		}
+		if err != nil {
+			return fmt.Errorf("purge user: %w", err)
+		}
+		if err := recordAudit(c, tx, "user.purge", "user", parsedID, auditUser(user), nil); err != nil {
+			return fmt.Errorf("audit user purge: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeTxError(c, err, "something went wrong")
+		return
+	}
+
+	// The profile image is no longer referenced by anything
+	if user.ProfileImgPublicID.Valid && user.ProfileImgPublicID.String != "" {
+		if err := service.DeleteImageFromCloudinary(c.Request.Context(), user.ProfileImgPublicID.String); err != nil {
+			requestLogger(c).Warn("failed to delete profile image of purged user", "target_user_id", parsedID, "error", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user purged"})
+}
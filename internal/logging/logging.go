@@ -0,0 +1,126 @@
+// Package logging builds the service's slog logger, carries a request-scoped
+// logger and request ID through contexts, and redacts secrets from records.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Output formats for Config.Format
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Attribute keys for the IDs that tie log lines to a request and its trace
+const (
+	RequestIDKey = "request_id"
+	TraceIDKey   = "trace_id"
+)
+
+// Config picks the level and encoding of log records
+type Config struct {
+	Level  string // debug, info (the default), warn or error
+	Format string // json or text (the default)
+}
+
+// ParseLevel reads a level name; an empty name is info
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return level, fmt.Errorf("unknown log level %q", name)
+	}
+	return level, nil
+}
+
+// New returns a logger writing to w. Every record has secrets redacted and
+// carries the request and trace IDs of the context it was logged with.
+func New(w io.Writer, cfg Config) (*slog.Logger, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	case "", FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+	return slog.New(contextHandler{Handler: handler}), nil
+}
+
+type loggerKey struct{}
+type requestIDKey struct{}
+
+// WithLogger returns ctx carrying logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger ctx carries, or slog.Default()
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// WithRequestID returns ctx carrying the request's ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the ID of the request ctx belongs to, or ""
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds the request and trace IDs from the record's context,
+// unless the logger was already tagged with them through With
+type contextHandler struct {
+	slog.Handler
+	hasRequestID bool
+	hasTraceID   bool
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" && !h.hasRequestID {
+		r.AddAttrs(slog.String(RequestIDKey, id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() && !h.hasTraceID {
+		r.AddAttrs(slog.String(TraceIDKey, span.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := contextHandler{h.Handler.WithAttrs(attrs), h.hasRequestID, h.hasTraceID}
+	for _, a := range attrs {
+		switch a.Key {
+		case RequestIDKey:
+			next.hasRequestID = true
+		case TraceIDKey:
+			next.hasTraceID = true
+		}
+	}
+	return next
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name), h.hasRequestID, h.hasTraceID}
+}
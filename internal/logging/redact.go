@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces secrets in log output
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys whose values are never logged
+var sensitiveKeys = []string{
+	"password", "passwd", "secret", "token", "authorization", "cookie",
+	"api_key", "apikey", "signature", "private_key", "dburl", "db_url", "dsn",
+}
+
+var (
+	// user:password@ in connection strings and URLs
+	urlCredentials = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://[^:/@\s]+):[^@\s]+@`)
+	// key=value pairs in libpq connection strings and query strings
+	secretParams = regexp.MustCompile(`(?i)\b(password|passwd|secret|token|api_key|apikey|signature)=[^\s&;]+`)
+	bearerTokens = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`)
+	jwts         = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+	// Stripe secret/restricted keys and webhook secrets, Resend keys,
+	// Stripe signature headers
+	providerKeys = regexp.MustCompile(`\b(sk|rk)_(live|test)_[A-Za-z0-9]+|\bwhsec_[A-Za-z0-9]+|\bre_[A-Za-z0-9]{16,}|\bt=\d+,v1=[a-f0-9]+`)
+)
+
+// Redact masks credentials, tokens and keys inside s
+func Redact(s string) string {
+	s = urlCredentials.ReplaceAllString(s, "$1:"+Redacted+"@")
+	s = secretParams.ReplaceAllString(s, "$1="+Redacted)
+	s = bearerTokens.ReplaceAllString(s, "Bearer "+Redacted)
+	s = jwts.ReplaceAllString(s, Redacted)
+	return providerKeys.ReplaceAllString(s, Redacted)
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range sensitiveKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactAttr is the slog ReplaceAttr hook: sensitive keys lose their value
+// and every string, error and Stringer has secrets masked
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if a.Key != slog.MessageKey && isSensitiveKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	switch v := a.Value.Resolve(); v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(v.String()))
+	case slog.KindAny:
+		switch x := v.Any().(type) {
+		case error:
+			return slog.String(a.Key, Redact(x.Error()))
+		case fmt.Stringer:
+			return slog.String(a.Key, Redact(x.String()))
+		}
+	}
+	return a
+}
@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// File writes each message as an .eml file instead of sending it, so local
+// development can open the emails in a mail client.
+type File struct {
+	Dir string
+}
+
+// NewFile returns a file mailer; an empty dir means "tmp/mail".
+func NewFile(dir string) *File {
+	if dir == "" {
+		dir = filepath.Join("tmp", "mail")
+	}
+	return &File{Dir: dir}
+}
+
+func (f *File) Send(_ context.Context, msg Message) error {
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create mail dump dir: %v", err)
+	}
+	body, err := buildMIME(msg)
+	if err != nil {
+		return err
+	}
+
+	slug := strings.Trim(unsafeFileChars.ReplaceAllString(strings.ToLower(msg.Subject), "-"), "-")
+	if len(slug) > 50 {
+		slug = slug[:50]
+	}
+	name := filepath.Join(f.Dir, fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405.000000"), slug))
+	if err := os.WriteFile(name, body, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", name, err)
+	}
+	slog.Info("mail written to file", "file", name)
+	return nil
+}
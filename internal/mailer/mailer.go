@@ -0,0 +1,110 @@
+// Package mailer renders and sends transactional email through a pluggable
+// provider (Resend, SMTP, a file dump for local development, or the log).
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Message is a single outgoing email. Text is required; HTML is optional.
+type Message struct {
+	From    string
+	To      []string
+	ReplyTo string
+	Subject string
+	Text    string
+	HTML    string
+	// Template is the template the message was rendered from, if any
+	Template    string
+	Attachments []Attachment
+}
+
+// Attachment is a file sent with a message. The JSON form is what the
+// email outbox stores.
+type Attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// Mailer delivers a message or reports why it could not.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// DefaultFrom is used when neither the message nor the config sets a sender.
+const DefaultFrom = "Book Library <onboarding@resend.dev>"
+
+// Config selects and configures the provider.
+type Config struct {
+	Provider string // "resend", "smtp", "file" or "log"
+	From     string
+	ReplyTo  string
+
+	ResendAPIKey string
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	DumpDir string // where the file provider writes .eml files
+}
+
+// New builds the configured provider. From and ReplyTo from the config are
+// applied to messages that do not set their own.
+func New(cfg Config) (Mailer, error) {
+	var m Mailer
+	switch strings.ToLower(cfg.Provider) {
+	case "resend":
+		if cfg.ResendAPIKey == "" {
+			return nil, fmt.Errorf("mail provider resend needs RESEND_API_KEY")
+		}
+		m = NewResend(cfg.ResendAPIKey, cfg.From)
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("mail provider smtp needs SMTP_HOST")
+		}
+		m = NewSMTP(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword)
+	case "file":
+		m = NewFile(cfg.DumpDir)
+	case "log", "":
+		m = LogMailer{}
+	default:
+		return nil, fmt.Errorf("unknown mail provider %q (use resend, smtp, file or log)", cfg.Provider)
+	}
+
+	from := cfg.From
+	if from == "" {
+		from = DefaultFrom
+	}
+	return withDefaults{Mailer: m, from: from, replyTo: cfg.ReplyTo}, nil
+}
+
+// withDefaults fills in the sender and reply-to address
+type withDefaults struct {
+	Mailer
+	from    string
+	replyTo string
+}
+
+func (d withDefaults) Send(ctx context.Context, msg Message) error {
+	if msg.From == "" {
+		msg.From = d.from
+	}
+	if msg.ReplyTo == "" {
+		msg.ReplyTo = d.replyTo
+	}
+	return d.Mailer.Send(ctx, msg)
+}
+
+// LogMailer writes messages to the log instead of sending them.
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, msg Message) error {
+	slog.InfoContext(ctx, "mail not sent (log provider)", "to", strings.Join(msg.To, ","), "subject", msg.Subject)
+	return nil
+}
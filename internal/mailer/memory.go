@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory records messages instead of sending them. Use it in tests.
+type Memory struct {
+	mu   sync.Mutex
+	sent []Message
+	// Err, when set, is returned by Send and nothing is recorded.
+	Err error
+}
+
+// NewMemory returns an empty in-memory mailer.
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Send(_ context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return m.Err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// Sent returns a copy of every message recorded so far.
+func (m *Memory) Sent() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.sent...)
+}
+
+// Reset forgets recorded messages.
+func (m *Memory) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = nil
+}
@@ -0,0 +1,86 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/telemetry"
+)
+
+const resendEndpoint = "https://api.resend.com/emails"
+
+// Resend sends email through the Resend HTTP API.
+type Resend struct {
+	APIKey string
+	From   string
+	Client *http.Client
+}
+
+// NewResend returns a Resend mailer with a bounded HTTP client.
+func NewResend(apiKey, from string) *Resend {
+	return &Resend{
+		APIKey: apiKey,
+		From:   from,
+		Client: &http.Client{Timeout: 15 * time.Second, Transport: telemetry.Transport("resend", nil)},
+	}
+}
+
+func (r *Resend) Send(ctx context.Context, msg Message) error {
+	from := msg.From
+	if from == "" {
+		from = r.From
+	}
+
+	payload := map[string]interface{}{
+		"from":    from,
+		"to":      msg.To,
+		"subject": msg.Subject,
+		"text":    msg.Text,
+	}
+	if msg.HTML != "" {
+		payload["html"] = msg.HTML
+	}
+	if msg.ReplyTo != "" {
+		payload["reply_to"] = msg.ReplyTo
+	}
+	if len(msg.Attachments) > 0 {
+		attachments := make([]map[string]string, 0, len(msg.Attachments))
+		for _, a := range msg.Attachments {
+			attachments = append(attachments, map[string]string{
+				"filename": a.Filename,
+				"content":  base64.StdEncoding.EncodeToString(a.Data),
+			})
+		}
+		payload["attachments"] = attachments
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal json: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, resendEndpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+r.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return fmt.Errorf("resend API error %d: %v", resp.StatusCode, result)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Templates. Each has a <name>.txt defining "subject" and "text" and a
+// <name>.html defining "content", which is wrapped in layout.html.
+const (
+	TemplateWelcome       = "welcome"
+	TemplatePasswordReset = "password_reset"
+	TemplateDueSoon       = "due_soon"
+	TemplateOverdue       = "overdue"
+	TemplateHoldReady     = "hold_ready"
+	TemplateReceipt       = "receipt"
+	TemplateRefund        = "refund"
+	TemplateNotification  = "notification" // any other notification type
+	TemplateDigest        = "digest"
+	TemplateContact       = "contact"
+)
+
+//go:embed templates/*
+var templateFS embed.FS
+
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+var templates = mustParseTemplates(
+	TemplateWelcome,
+	TemplatePasswordReset,
+	TemplateDueSoon,
+	TemplateOverdue,
+	TemplateHoldReady,
+	TemplateReceipt,
+	TemplateRefund,
+	TemplateNotification,
+	TemplateDigest,
+	TemplateContact,
+)
+
+func mustParseTemplates(names ...string) map[string]emailTemplate {
+	out := make(map[string]emailTemplate, len(names))
+	for _, name := range names {
+		text := texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/"+name+".txt"))
+		html := htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html"))
+		out[name] = emailTemplate{text: text, html: html}
+	}
+	return out
+}
+
+// Render fills in the named template and returns a message with Subject,
+// Text and HTML set. The caller sets the recipients.
+func Render(name string, data any) (Message, error) {
+	t, ok := templates[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := t.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := t.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s text: %w", name, err)
+	}
+	if err := t.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s html: %w", name, err)
+	}
+
+	return Message{
+		Subject:  strings.TrimSpace(subject.String()),
+		Text:     strings.TrimSpace(text.String()),
+		HTML:     html.String(),
+		Template: name,
+	}, nil
+}
+
+// NotificationData feeds the notification, due_soon, overdue, hold_ready and
+// refund templates.
+type NotificationData struct {
+	Name    string
+	Title   string
+	Message string
+}
+
+// WelcomeData feeds the welcome template
+type WelcomeData struct {
+	Name string
+}
+
+// PasswordResetData feeds the password_reset template
+type PasswordResetData struct {
+	Name      string
+	ResetURL  string
+	ExpiresIn string // e.g. "1 hour"
+}
+
+// ReceiptData feeds the receipt template
+type ReceiptData struct {
+	Name          string
+	Number        string // receipt number, e.g. INV-000042
+	Plan          string
+	Amount        string
+	Currency      string
+	Date          string
+	TransactionID string
+}
+
+// DigestData feeds the digest template
+type DigestData struct {
+	Name  string
+	Items []DigestItem
+}
+
+// DigestItem is one line of a digest email
+type DigestItem struct {
+	Title   string
+	Date    string
+	Message string
+}
+
+// ContactData feeds the contact template
+type ContactData struct {
+	Name    string
+	Email   string
+	Subject string
+	Message string
+}
@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplates(t *testing.T) {
+	note := NotificationData{Name: "Ada", Title: "Heads up", Message: "'Dune' is due back on Jan 2, 2026."}
+	tests := []struct {
+		name string
+		data any
+		want string // must appear in the text part
+	}{
+		{TemplateWelcome, WelcomeData{Name: "Ada"}, "Welcome to Book Library"},
+		{TemplatePasswordReset, PasswordResetData{Name: "Ada", ResetURL: "https://example.com/reset?t=abc", ExpiresIn: "1 hour"}, "https://example.com/reset?t=abc"},
+		{TemplateDueSoon, note, note.Message},
+		{TemplateOverdue, note, note.Message},
+		{TemplateHoldReady, note, note.Message},
+		{TemplateRefund, note, note.Message},
+		{TemplateNotification, note, note.Message},
+		{TemplateReceipt, ReceiptData{Name: "Ada", Plan: "Gold", Amount: "9.99", Currency: "USD", TransactionID: "tx-1"}, "9.99 USD"},
+		{TemplateDigest, DigestData{Name: "Ada", Items: []DigestItem{{Title: "New", Date: "Jan 2", Message: "A new book"}}}, "A new book"},
+		{TemplateContact, ContactData{Name: "Ada", Email: "ada@example.com", Subject: "Hello", Message: "Hi there"}, "ada@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := Render(tt.name, tt.data)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if msg.Subject == "" || strings.Contains(msg.Subject, "\n") {
+				t.Errorf("Subject = %q", msg.Subject)
+			}
+			if !strings.Contains(msg.Text, tt.want) {
+				t.Errorf("Text = %q, want it to contain %q", msg.Text, tt.want)
+			}
+			if !strings.Contains(msg.HTML, "<html") {
+				t.Errorf("HTML is missing the layout")
+			}
+			if msg.Template != tt.name {
+				t.Errorf("Template = %q", msg.Template)
+			}
+		})
+	}
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	msg, err := Render(TemplateNotification, NotificationData{Name: "Ada", Title: "x", Message: "<script>alert(1)</script>"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if strings.Contains(msg.HTML, "<script>") {
+		t.Errorf("HTML part was not escaped: %s", msg.HTML)
+	}
+	if !strings.Contains(msg.Text, "<script>") {
+		t.Errorf("text part should be left as is: %q", msg.Text)
+	}
+}
@@ -0,0 +1,220 @@
+package marc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	subfieldDelimiter = 0x1F
+	fieldTerminator   = 0x1E
+	recordTerminator  = 0x1D
+
+	leaderLength   = 24
+	directoryEntry = 12
+	maxRecordLen   = 99999
+)
+
+// ErrRecordTooLong is returned when an encoded record would not fit the
+// five-digit record length in the leader.
+var ErrRecordTooLong = errors.New("marc: record exceeds 99999 bytes")
+
+// Reader decodes a stream of ISO 2709 records.
+type Reader struct {
+	br *bufio.Reader
+}
+
+// NewReader returns a Reader that consumes records from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{br: bufio.NewReader(r)}
+}
+
+// Read returns the next record, or io.EOF once the stream is exhausted.
+func (r *Reader) Read() (*Record, error) {
+	for {
+		raw, err := r.br.ReadBytes(recordTerminator)
+		if err == io.EOF {
+			// Tolerate trailing newlines/whitespace some exporters add.
+			if len(bytes.TrimSpace(raw)) == 0 {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("marc: truncated record (missing record terminator)")
+		}
+		if err != nil {
+			return nil, err
+		}
+		raw = bytes.TrimLeft(raw, "\r\n\t ")
+		if len(raw) <= 1 {
+			continue
+		}
+		return Unmarshal(raw)
+	}
+}
+
+// ReadAll decodes every record in r.
+func ReadAll(r io.Reader) ([]*Record, error) {
+	reader := NewReader(r)
+	var records []*Record
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, fmt.Errorf("record %d: %w", len(records)+1, err)
+		}
+		records = append(records, rec)
+	}
+}
+
+// Unmarshal decodes a single ISO 2709 record, including its record terminator.
+func Unmarshal(data []byte) (*Record, error) {
+	if len(data) < leaderLength+1 {
+		return nil, fmt.Errorf("marc: record too short (%d bytes)", len(data))
+	}
+	leader := string(data[:leaderLength])
+
+	baseAddr, err := strconv.Atoi(leader[12:17])
+	if err != nil || baseAddr <= leaderLength || baseAddr > len(data) {
+		return nil, fmt.Errorf("marc: invalid base address of data %q", leader[12:17])
+	}
+
+	// The directory runs from the end of the leader to the field terminator
+	// sitting just before the base address.
+	directory := data[leaderLength : baseAddr-1]
+	if len(directory)%directoryEntry != 0 {
+		return nil, fmt.Errorf("marc: malformed directory (%d bytes)", len(directory))
+	}
+
+	rec := &Record{Leader: leader}
+	for i := 0; i < len(directory); i += directoryEntry {
+		entry := directory[i : i+directoryEntry]
+		tag := string(entry[0:3])
+		length, err := strconv.Atoi(string(entry[3:7]))
+		if err != nil || length < 1 {
+			return nil, fmt.Errorf("marc: field %s has invalid length %q", tag, entry[3:7])
+		}
+		start, err := strconv.Atoi(string(entry[7:12]))
+		if err != nil || start < 0 {
+			return nil, fmt.Errorf("marc: field %s has invalid start %q", tag, entry[7:12])
+		}
+
+		from, to := baseAddr+start, baseAddr+start+length
+		if from < baseAddr || to < from || to > len(data) {
+			return nil, fmt.Errorf("marc: field %s points outside the record", tag)
+		}
+		field := bytes.TrimSuffix(data[from:to], []byte{fieldTerminator})
+
+		if isControlTag(tag) {
+			rec.ControlFields = append(rec.ControlFields, ControlField{Tag: tag, Value: string(field)})
+			continue
+		}
+		if len(field) < 2 {
+			return nil, fmt.Errorf("marc: data field %s is missing indicators", tag)
+		}
+
+		df := DataField{Tag: tag, Ind1: string(field[0]), Ind2: string(field[1])}
+		for _, part := range bytes.Split(field[2:], []byte{subfieldDelimiter}) {
+			if len(part) == 0 {
+				continue
+			}
+			df.Subfields = append(df.Subfields, Subfield{Code: string(part[0]), Value: string(part[1:])})
+		}
+		rec.DataFields = append(rec.DataFields, df)
+	}
+
+	return rec, nil
+}
+
+// Marshal encodes a record as ISO 2709. Leader positions that depend on the
+// record layout (length, base address, encoding and entry map) are recomputed.
+func Marshal(rec *Record) ([]byte, error) {
+	var (
+		dir    bytes.Buffer
+		fields bytes.Buffer
+	)
+
+	addField := func(tag string, body []byte) error {
+		if len(tag) != 3 {
+			return fmt.Errorf("marc: invalid tag %q", tag)
+		}
+		body = append(body, fieldTerminator)
+		if len(body) > 9999 {
+			return fmt.Errorf("marc: field %s exceeds 9999 bytes", tag)
+		}
+		fmt.Fprintf(&dir, "%s%04d%05d", tag, len(body), fields.Len())
+		fields.Write(body)
+		return nil
+	}
+
+	for _, cf := range rec.ControlFields {
+		if err := addField(cf.Tag, []byte(cf.Value)); err != nil {
+			return nil, err
+		}
+	}
+	for _, df := range rec.DataFields {
+		var body bytes.Buffer
+		body.WriteString(indicator(df.Ind1))
+		body.WriteString(indicator(df.Ind2))
+		for _, sf := range df.Subfields {
+			if sf.Code == "" {
+				continue
+			}
+			body.WriteByte(subfieldDelimiter)
+			body.WriteString(sf.Code[:1])
+			body.WriteString(sf.Value)
+		}
+		if err := addField(df.Tag, body.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	dir.WriteByte(fieldTerminator)
+
+	baseAddr := leaderLength + dir.Len()
+	total := baseAddr + fields.Len() + 1
+	if total > maxRecordLen {
+		return nil, ErrRecordTooLong
+	}
+
+	leader := []byte(rec.Leader)
+	if len(leader) != leaderLength {
+		leader = []byte(DefaultLeader)
+	}
+	copy(leader[0:5], fmt.Sprintf("%05d", total))
+	leader[9] = 'a' // UCS/Unicode: we always write UTF-8
+	leader[10] = '2'
+	leader[11] = '2'
+	copy(leader[12:17], fmt.Sprintf("%05d", baseAddr))
+	copy(leader[20:24], "4500")
+
+	out := make([]byte, 0, total)
+	out = append(out, leader...)
+	out = append(out, dir.Bytes()...)
+	out = append(out, fields.Bytes()...)
+	out = append(out, recordTerminator)
+	return out, nil
+}
+
+// Writer encodes records to an ISO 2709 stream.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a Writer that writes records to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write encodes a single record.
+func (w *Writer) Write(rec *Record) error {
+	data, err := Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(data)
+	return err
+}
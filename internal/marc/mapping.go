@@ -0,0 +1,221 @@
+package marc
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+)
+
+var (
+	isbnPattern = regexp.MustCompile(`[0-9Xx][0-9Xx\-]{8,16}[0-9Xx]`)
+	yearPattern = regexp.MustCompile(`\d{4}`)
+)
+
+// ToBook maps the catalog fields of a record onto a create request:
+//
+//	020 $a  ISBN
+//	100 $a  author, plus 700 $a co-authors ("; " separated)
+//	245 $a  title, $b subtitle
+//	264 $c  publication year (260 $c or 008/07-10 as fallbacks)
+//	520 $a  description
+//	650 $a  genres (", " separated; 655 $a as a fallback)
+//
+// TotalCopies is left at zero; the caller decides how many copies to stock.
+func ToBook(rec *Record) models.CreateBookRequest {
+	var req models.CreateBookRequest
+
+	for _, f := range rec.Fields("020") {
+		if isbn := normalizeISBN(f.Subfield("a")); isbn != "" {
+			req.Isbn = isbn
+			break
+		}
+	}
+
+	// Main entry first, then co-authors from added entries (700 without a
+	// relator, or with "author" as the relator).
+	var authors []string
+	if f := first(rec.Fields("100")); f != nil {
+		authors = append(authors, trimPunct(f.Subfield("a")))
+	} else if f := first(rec.Fields("110")); f != nil {
+		authors = append(authors, trimPunct(f.Subfield("a")))
+	}
+	for _, f := range rec.Fields("700") {
+		relator := strings.ToLower(trimPunct(f.Subfield("e")))
+		if relator == "" || relator == "author" {
+			authors = append(authors, trimPunct(f.Subfield("a")))
+		}
+	}
+	req.Author = joinUnique(authors, "; ")
+
+	if f := first(rec.Fields("245")); f != nil {
+		title := trimPunct(f.Subfield("a"))
+		if sub := trimPunct(f.Subfield("b")); sub != "" {
+			title = title + ": " + sub
+		}
+		req.Title = title
+	}
+
+	req.PublishedYear = publicationYear(rec)
+
+	if f := first(rec.Fields("520")); f != nil {
+		req.Description = strings.TrimSpace(f.Subfield("a"))
+	}
+
+	for _, tag := range []string{"650", "655"} {
+		var genres []string
+		for _, f := range rec.Fields(tag) {
+			genres = append(genres, trimPunct(f.Subfield("a")))
+		}
+		if req.Genre = joinUnique(genres, ", "); req.Genre != "" {
+			break
+		}
+	}
+
+	return req
+}
+
+// FromBook builds a MARC21 record for a catalog book.
+func FromBook(book models.BookResponse) *Record {
+	rec := NewRecord()
+
+	rec.AddControlField("001", book.ID.String())
+	updated := book.UpdatedAt
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	rec.AddControlField("005", updated.UTC().Format("20060102150405")+".0")
+	rec.AddControlField("008", fixedField(book))
+
+	authors := splitList(book.Author, ";&")
+	rec.AddDataField("020", " ", " ", Subfield{Code: "a", Value: book.Isbn})
+	if len(authors) > 0 {
+		rec.AddDataField("100", "1", " ", Subfield{Code: "a", Value: authors[0]})
+	}
+
+	ind1 := "0"
+	if book.Author != "" {
+		ind1 = "1"
+	}
+	rec.AddDataField("245", ind1, strconv.Itoa(nonFiling(book.Title)), Subfield{Code: "a", Value: book.Title})
+
+	if book.PublishedYear > 0 {
+		rec.AddDataField("264", " ", "1", Subfield{Code: "c", Value: strconv.Itoa(int(book.PublishedYear))})
+	}
+	rec.AddDataField("520", " ", " ", Subfield{Code: "a", Value: book.Description})
+	for _, name := range authors[min(1, len(authors)):] {
+		rec.AddDataField("700", "1", " ", Subfield{Code: "a", Value: name}, Subfield{Code: "e", Value: "author"})
+	}
+	for _, genre := range splitList(book.Genre, ",;/") {
+		rec.AddDataField("650", " ", "4", Subfield{Code: "a", Value: genre})
+	}
+
+	return rec
+}
+
+// fixedField builds the 40-character 008 field: date entered, a single
+// known publication date and otherwise blank/unknown positions.
+func fixedField(book models.BookResponse) string {
+	created := book.CreatedAt
+	if created.IsZero() {
+		created = time.Now()
+	}
+	year := "uuuu"
+	dateType := "n"
+	if book.PublishedYear > 0 {
+		year = fmt.Sprintf("%04d", book.PublishedYear)
+		dateType = "s"
+	}
+	// 00-05 entered, 06 type of date, 07-10 date 1, 11-14 date 2,
+	// 15-17 place, 18-34 material specific, 35-37 language, 38-39 modified/source.
+	f := created.UTC().Format("060102") + dateType + year + "    " + "xx " +
+		strings.Repeat(" ", 17) + "und" + " d"
+	return f
+}
+
+func publicationYear(rec *Record) int {
+	for _, tag := range []string{"264", "260"} {
+		for _, f := range rec.Fields(tag) {
+			if y := yearPattern.FindString(f.Subfield("c")); y != "" {
+				n, _ := strconv.Atoi(y)
+				return n
+			}
+		}
+	}
+	if f := rec.ControlField("008"); len(f) >= 11 {
+		if n, err := strconv.Atoi(f[7:11]); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// normalizeISBN pulls the ISBN out of a 020 $a value such as
+// "9780141439518 (pbk.)" and drops hyphens.
+func normalizeISBN(s string) string {
+	m := isbnPattern.FindString(s)
+	return strings.ToUpper(strings.ReplaceAll(m, "-", ""))
+}
+
+// trimPunct strips the ISBD punctuation cataloguers leave at the end of
+// subfields (" /", " :", ",", ".").
+func trimPunct(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimRight(s, " /:;,=")
+	// Keep a trailing period that belongs to an initial or abbreviation.
+	if body, ok := strings.CutSuffix(s, "."); ok && !strings.HasSuffix(body, ".") {
+		words := strings.Fields(body)
+		if len(words) == 0 || len(words[len(words)-1]) > 2 {
+			s = body
+		}
+	}
+	return strings.TrimSpace(s)
+}
+
+// nonFiling returns the 245 second indicator: how many leading characters
+// to skip when sorting (e.g. 4 for "The ").
+func nonFiling(title string) int {
+	lower := strings.ToLower(title)
+	for _, article := range []string{"the ", "an ", "a "} {
+		if strings.HasPrefix(lower, article) {
+			return len(article)
+		}
+	}
+	return 0
+}
+
+// splitList splits a display string on any of seps, dropping blanks.
+func splitList(s, seps string) []string {
+	var out []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return strings.ContainsRune(seps, r) }) {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// joinUnique joins the non-empty values, skipping case-insensitive duplicates.
+func joinUnique(values []string, sep string) string {
+	var out []string
+	seen := map[string]bool{}
+	for _, v := range values {
+		key := strings.ToLower(v)
+		if v == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, v)
+	}
+	return strings.Join(out, sep)
+}
+
+func first(fields []DataField) *DataField {
+	if len(fields) == 0 {
+		return nil
+	}
+	return &fields[0]
+}
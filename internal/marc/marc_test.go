@@ -0,0 +1,226 @@
+package marc
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/google/uuid"
+)
+
+func loadSample(t *testing.T) []*Record {
+	t.Helper()
+	f, err := os.Open("testdata/pride_and_prejudice.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	records, err := DecodeXML(f)
+	if err != nil {
+		t.Fatalf("DecodeXML: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	return records
+}
+
+func TestToBook(t *testing.T) {
+	records := loadSample(t)
+
+	tests := []struct {
+		name string
+		rec  *Record
+		want models.CreateBookRequest
+	}{
+		{
+			name: "260 imprint and 650 with subdivision",
+			rec:  records[0],
+			want: models.CreateBookRequest{
+				Title:         "Pride and prejudice",
+				Author:        "Austen, Jane",
+				PublishedYear: 2003,
+				Isbn:          "9780141439518",
+				Genre:         "Courtship",
+				Description:   "Elizabeth Bennet and Mr Darcy misjudge each other in a novel of manners set among the landed gentry of Regency England.",
+			},
+		},
+		{
+			name: "264 imprint, subtitle and hyphenated ISBN",
+			rec:  records[1],
+			want: models.CreateBookRequest{
+				Title:         "The Martian: a novel",
+				Author:        "Weir, Andy",
+				PublishedYear: 2014,
+				Isbn:          "9780804139021",
+				Genre:         "Science fiction",
+				Description:   "An astronaut stranded on Mars has to improvise his way to survival.",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToBook(tt.rec)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToBook() = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestISO2709RoundTrip(t *testing.T) {
+	records := loadSample(t)
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, rec := range records {
+		if err := w.Write(rec); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	got, err := ReadAll(&buf)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if len(got) != len(records) {
+		t.Fatalf("got %d records, want %d", len(got), len(records))
+	}
+	for i := range records {
+		if !reflect.DeepEqual(got[i].ControlFields, records[i].ControlFields) {
+			t.Errorf("record %d control fields = %+v, want %+v", i, got[i].ControlFields, records[i].ControlFields)
+		}
+		if !reflect.DeepEqual(got[i].DataFields, records[i].DataFields) {
+			t.Errorf("record %d data fields = %+v, want %+v", i, got[i].DataFields, records[i].DataFields)
+		}
+		if ToBook(got[i]) != ToBook(records[i]) {
+			t.Errorf("record %d maps differently after round trip", i)
+		}
+	}
+}
+
+func TestXMLRoundTrip(t *testing.T) {
+	records := loadSample(t)
+
+	var buf bytes.Buffer
+	if err := EncodeXML(&buf, records); err != nil {
+		t.Fatalf("EncodeXML: %v", err)
+	}
+	got, err := DecodeXML(&buf)
+	if err != nil {
+		t.Fatalf("DecodeXML: %v", err)
+	}
+	if !reflect.DeepEqual(got, records) {
+		t.Errorf("XML round trip changed records:\n got %+v\nwant %+v", got, records)
+	}
+}
+
+func TestFromBookRoundTrip(t *testing.T) {
+	book := models.BookResponse{
+		ID:            uuid.MustParse("6f1c2a8e-3b7d-4c2a-9a51-0d9b1f2e7c44"),
+		Title:         "The Left Hand of Darkness",
+		Author:        "Le Guin, Ursula K.",
+		PublishedYear: 1969,
+		Isbn:          "9780441478125",
+		Genre:         "Science fiction",
+		Description:   "An envoy visits a planet whose people have no fixed sex.",
+		CreatedAt:     time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		UpdatedAt:     time.Date(2024, 3, 2, 8, 30, 0, 0, time.UTC),
+	}
+
+	rec := FromBook(book)
+	if got := rec.Fields("245")[0].Ind2; got != "4" {
+		t.Errorf("245 nonfiling indicator = %q, want %q", got, "4")
+	}
+	if got := rec.ControlField("008"); len(got) != 40 {
+		t.Errorf("008 length = %d, want 40", len(got))
+	}
+
+	data, err := Marshal(rec)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	decoded, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	want := models.CreateBookRequest{
+		Title:         book.Title,
+		Author:        book.Author,
+		PublishedYear: int(book.PublishedYear),
+		Isbn:          book.Isbn,
+		Genre:         book.Genre,
+		Description:   book.Description,
+	}
+	if got := ToBook(decoded); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToBook(FromBook()) = %+v\nwant %+v", got, want)
+	}
+	if got := decoded.ControlField("001"); got != book.ID.String() {
+		t.Errorf("001 = %q, want %q", got, book.ID.String())
+	}
+}
+
+func TestUnmarshalRejectsGarbage(t *testing.T) {
+	if _, err := Unmarshal([]byte("not a marc record at all, sorry\x1d")); err == nil {
+		t.Fatal("expected an error for a malformed leader")
+	}
+}
+
+// Directory entries come from uploads; bad lengths and starts must be
+// errors, not slice panics
+func TestUnmarshalRejectsBadDirectory(t *testing.T) {
+	var buf bytes.Buffer
+	rec := &Record{Leader: "00000nam a2200000 a 4500", ControlFields: []ControlField{{Tag: "001", Value: "abc123"}}}
+	if err := NewWriter(&buf).Write(rec); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Unmarshal(buf.Bytes()); err != nil {
+		t.Fatalf("unmodified record: %v", err)
+	}
+
+	for _, tt := range []struct{ name, length, start string }{
+		{"negative length", "-001", "00000"},
+		{"zero length", "0000", "00000"},
+		{"negative start", "0007", "-0001"},
+		{"past the end", "0007", "99999"},
+	} {
+		data := bytes.Clone(buf.Bytes())
+		copy(data[leaderLength+3:], tt.length)
+		copy(data[leaderLength+7:], tt.start)
+		if _, err := Unmarshal(data); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestFromBookCoAuthorsAndGenres(t *testing.T) {
+	book := models.BookResponse{
+		ID:            uuid.MustParse("0b6d9f7a-2c31-4e8b-8f0e-5a4c3b2a1d10"),
+		Title:         "Good Omens",
+		Author:        "Pratchett, Terry; Gaiman, Neil",
+		PublishedYear: 1990,
+		Genre:         "Fantasy, Humor",
+	}
+
+	rec := FromBook(book)
+	if n := len(rec.Fields("700")); n != 1 {
+		t.Fatalf("got %d 700 fields, want 1", n)
+	}
+	if n := len(rec.Fields("650")); n != 2 {
+		t.Fatalf("got %d 650 fields, want 2", n)
+	}
+
+	got := ToBook(rec)
+	if got.Author != book.Author {
+		t.Errorf("Author = %q, want %q", got.Author, book.Author)
+	}
+	if got.Genre != book.Genre {
+		t.Errorf("Genre = %q, want %q", got.Genre, book.Genre)
+	}
+}
@@ -0,0 +1,120 @@
+package marc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Namespace is the MARCXML (MARC21 slim) schema namespace.
+const Namespace = "http://www.loc.gov/MARC21/slim"
+
+type xmlCollection struct {
+	XMLName xml.Name    `xml:"collection"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Records []xmlRecord `xml:"record"`
+}
+
+type xmlRecord struct {
+	XMLName       xml.Name          `xml:"record"`
+	Leader        string            `xml:"leader"`
+	ControlFields []xmlControlField `xml:"controlfield"`
+	DataFields    []xmlDataField    `xml:"datafield"`
+}
+
+type xmlControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlDataField struct {
+	Tag       string        `xml:"tag,attr"`
+	Ind1      string        `xml:"ind1,attr"`
+	Ind2      string        `xml:"ind2,attr"`
+	Subfields []xmlSubfield `xml:"subfield"`
+}
+
+type xmlSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// DecodeXML reads every <record> element from a MARCXML document. Both a
+// <collection> wrapper and a bare <record> root are accepted, with or without
+// a namespace prefix.
+func DecodeXML(r io.Reader) ([]*Record, error) {
+	dec := xml.NewDecoder(r)
+	var records []*Record
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return records, fmt.Errorf("marcxml: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+		var xr xmlRecord
+		if err := dec.DecodeElement(&xr, &start); err != nil {
+			return records, fmt.Errorf("marcxml: record %d: %w", len(records)+1, err)
+		}
+		records = append(records, xr.toRecord())
+	}
+	return records, nil
+}
+
+// EncodeXML writes records as a MARCXML <collection>.
+func EncodeXML(w io.Writer, records []*Record) error {
+	coll := xmlCollection{Xmlns: Namespace}
+	for _, rec := range records {
+		coll.Records = append(coll.Records, fromRecord(rec))
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(coll); err != nil {
+		return fmt.Errorf("marcxml: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func (xr xmlRecord) toRecord() *Record {
+	rec := &Record{Leader: strings.TrimRight(xr.Leader, "\r\n")}
+	for _, cf := range xr.ControlFields {
+		rec.ControlFields = append(rec.ControlFields, ControlField{Tag: cf.Tag, Value: cf.Value})
+	}
+	for _, df := range xr.DataFields {
+		field := DataField{Tag: df.Tag, Ind1: indicator(df.Ind1), Ind2: indicator(df.Ind2)}
+		for _, sf := range df.Subfields {
+			field.Subfields = append(field.Subfields, Subfield{Code: sf.Code, Value: sf.Value})
+		}
+		rec.DataFields = append(rec.DataFields, field)
+	}
+	return rec
+}
+
+func fromRecord(rec *Record) xmlRecord {
+	xr := xmlRecord{Leader: rec.Leader}
+	if len(xr.Leader) != leaderLength {
+		xr.Leader = DefaultLeader
+	}
+	for _, cf := range rec.ControlFields {
+		xr.ControlFields = append(xr.ControlFields, xmlControlField{Tag: cf.Tag, Value: cf.Value})
+	}
+	for _, df := range rec.DataFields {
+		field := xmlDataField{Tag: df.Tag, Ind1: indicator(df.Ind1), Ind2: indicator(df.Ind2)}
+		for _, sf := range df.Subfields {
+			field.Subfields = append(field.Subfields, xmlSubfield{Code: sf.Code, Value: sf.Value})
+		}
+		xr.DataFields = append(xr.DataFields, field)
+	}
+	return xr
+}
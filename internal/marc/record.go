@@ -0,0 +1,110 @@
+// Package marc reads and writes bibliographic records in MARC21 (ISO 2709)
+// and MARCXML, and maps the fields we care about onto catalog books.
+package marc
+
+import "strings"
+
+// DefaultLeader is used for records we build ourselves: a new ("n"),
+// language material ("a"), monograph ("m"), Unicode ("a") record.
+const DefaultLeader = "00000nam a2200000 i 4500"
+
+// Record is a single MARC21 bibliographic record.
+type Record struct {
+	Leader        string
+	ControlFields []ControlField
+	DataFields    []DataField
+}
+
+// ControlField is a 00X field, which carries data but no indicators or subfields.
+type ControlField struct {
+	Tag   string
+	Value string
+}
+
+// DataField is a variable data field (010-999) with two indicators and subfields.
+type DataField struct {
+	Tag       string
+	Ind1      string
+	Ind2      string
+	Subfields []Subfield
+}
+
+// Subfield is a single coded value inside a data field, e.g. $a.
+type Subfield struct {
+	Code  string
+	Value string
+}
+
+// NewRecord returns an empty record with the default leader.
+func NewRecord() *Record {
+	return &Record{Leader: DefaultLeader}
+}
+
+// isControlTag reports whether tag is a control field tag (001-009).
+func isControlTag(tag string) bool {
+	return strings.HasPrefix(tag, "00")
+}
+
+// ControlField returns the value of the first control field with the given tag.
+func (r *Record) ControlField(tag string) string {
+	for _, f := range r.ControlFields {
+		if f.Tag == tag {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// Fields returns every data field with the given tag, in record order.
+func (r *Record) Fields(tag string) []DataField {
+	var out []DataField
+	for _, f := range r.DataFields {
+		if f.Tag == tag {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// AddControlField appends a control field.
+func (r *Record) AddControlField(tag, value string) {
+	r.ControlFields = append(r.ControlFields, ControlField{Tag: tag, Value: value})
+}
+
+// AddDataField appends a data field. Empty subfields are dropped and the
+// field is skipped entirely when nothing is left.
+func (r *Record) AddDataField(tag, ind1, ind2 string, subfields ...Subfield) {
+	var kept []Subfield
+	for _, sf := range subfields {
+		if strings.TrimSpace(sf.Value) != "" {
+			kept = append(kept, sf)
+		}
+	}
+	if len(kept) == 0 {
+		return
+	}
+	r.DataFields = append(r.DataFields, DataField{
+		Tag:       tag,
+		Ind1:      indicator(ind1),
+		Ind2:      indicator(ind2),
+		Subfields: kept,
+	})
+}
+
+// Subfield returns the first value for the given subfield code.
+func (f DataField) Subfield(code string) string {
+	for _, sf := range f.Subfields {
+		if sf.Code == code {
+			return sf.Value
+		}
+	}
+	return ""
+}
+
+// indicator normalises an indicator to exactly one character (blank when unset).
+func indicator(s string) string {
+	if s == "" {
+		return " "
+	}
+	return s[:1]
+}
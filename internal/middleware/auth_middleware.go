@@ -1,14 +1,14 @@
 package middleware
 
 import (
-	// "log"
-	"log"
+	"context"
 	"net/http"
 	"strings"
 	// "time"
 
-	"github.com/THEGunDevil/GoForBackend/internal/db"
-	// gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/logging"
 	"github.com/THEGunDevil/GoForBackend/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
@@ -16,87 +16,95 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// UserLookup loads the account a token was issued to; db.Store satisfies it
+type UserLookup interface {
+	GetUserByID(ctx context.Context, id pgtype.UUID) (gen.User, error)
+}
+
 // AuthMiddleware validates JWT tokens and sets user context
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(users UserLookup) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		log.Println("🔹 AuthMiddleware started")
+		ctx := c.Request.Context()
+		logger := logging.FromContext(ctx)
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			log.Println("❌ Authorization header missing")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authorization header missing"})
+			abort(c, apierror.Unauthorized("authorization header missing"))
 			return
 		}
-		log.Println("✅ Authorization header found")
 
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-			log.Printf("❌ Invalid auth header format: %v\n", authHeader)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header format"})
+			logger.DebugContext(ctx, "auth rejected: malformed authorization header")
+			abort(c, apierror.Unauthorized("invalid authorization header format"))
 			return
 		}
 
 		tokenString := parts[1]
 		token, err := service.VerifyToken(tokenString, false)
 		if err != nil {
-			log.Printf("❌ Token verification failed: %v\n", err)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+			logger.DebugContext(ctx, "auth rejected: token verification failed", "error", err)
+			abort(c, apierror.Unauthorized("invalid or expired token"))
 			return
 		}
-		log.Println("✅ Token verified successfully")
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok || !token.Valid {
-			log.Println("❌ Invalid token claims")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+			logger.DebugContext(ctx, "auth rejected: invalid token claims")
+			abort(c, apierror.Unauthorized("invalid token claims"))
 			return
 		}
-		log.Printf("✅ Token claims: %+v\n", claims)
 
 		subStr, ok := claims["sub"].(string)
 		if !ok {
-			log.Println("❌ Missing sub claim")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing sub claim"})
+			logger.DebugContext(ctx, "auth rejected: missing sub claim")
+			abort(c, apierror.Unauthorized("missing sub claim"))
 			return
 		}
 
 		userUUID, err := uuid.Parse(subStr)
 		if err != nil {
-			log.Printf("❌ Invalid user UUID: %v\n", err)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+			logger.DebugContext(ctx, "auth rejected: invalid sub claim", "error", err)
+			abort(c, apierror.Unauthorized("invalid user ID"))
 			return
 		}
-		log.Printf("✅ User UUID: %v\n", userUUID)
 
-		user, err := db.Q.GetUserByID(c.Request.Context(), pgtype.UUID{Bytes: userUUID, Valid: true})
+		user, err := users.GetUserByID(ctx, pgtype.UUID{Bytes: userUUID, Valid: true})
 		if err != nil {
-			log.Printf("❌ User not found: %v\n", err)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+			logger.WarnContext(ctx, "auth rejected: user lookup failed", "user_id", userUUID, "error", err)
+			abort(c, apierror.Unauthorized("user not found"))
+			return
+		}
+
+		if user.DeletedAt.Valid {
+			logger.InfoContext(ctx, "auth rejected: account deleted", "user_id", userUUID)
+			abort(c, apierror.Unauthorized("account has been deleted"))
 			return
 		}
-		log.Printf("✅ User fetched: %s %s\n", user.FirstName, user.LastName)
 
 		tokenVersion, _ := claims["token_version"].(float64)
 		if int32(tokenVersion) != user.TokenVersion {
-			log.Printf("❌ Token version mismatch: token=%v, user=%v\n", int32(tokenVersion), user.TokenVersion)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+			logger.InfoContext(ctx, "auth rejected: token revoked", "user_id", userUUID)
+			abort(c, apierror.Unauthorized("token has been revoked"))
 			return
 		}
-		log.Println("✅ Token version validated")
+
+		// Everything logged from here on names the user
+		logger = logger.With("user_id", userUUID)
+		c.Request = c.Request.WithContext(logging.WithLogger(ctx, logger))
 
 		// Handle banned users
 		if user.IsBanned.Bool {
-			log.Println("⚠️ User is banned")
-
 			// Routes allowed for banned users
 			allowedPaths := []string{
 				"/users/user",   // fetch ban info
 				"/contact/send", // contact support
+				"/support",      // follow up on support tickets (ban appeals)
 			}
 
 			// Check if request path is allowed
 			for _, path := range allowedPaths {
 				if strings.HasPrefix(c.FullPath(), path) {
-					log.Printf("✅ Banned user accessing allowed route %s\n", path)
 					c.Set("banned_user", true)
 					c.Set("userID", userUUID)
 					c.Set("role", user.Role.String)
@@ -110,13 +118,11 @@ func AuthMiddleware() gin.HandlerFunc {
 			}
 
 			// Block all other routes
-			log.Printf("❌ Banned user tried to access: %s\n", c.FullPath())
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-				"error":            "your account is banned",
-				"reason":           user.BanReason.String,
-				"is_permanent_ban": user.IsPermanentBan.Bool,
-				"ban_until":        user.BanUntil.Time,
-			})
+			logger.InfoContext(ctx, "banned user blocked", "route", c.FullPath())
+			abort(c, apierror.New(http.StatusForbidden, "account_banned", "your account is banned").
+				With("reason", user.BanReason.String).
+				With("is_permanent_ban", user.IsPermanentBan.Bool).
+				With("ban_until", user.BanUntil.Time))
 			return
 		}
 
@@ -129,9 +135,48 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Set("banReason", user.BanReason.String)
 		c.Set("banUntil", user.BanUntil.Time)
 
-		log.Println("✅ Context set for downstream handlers")
 		c.Next()
-		log.Println("🔹 AuthMiddleware finished")
+	}
+}
+
+// OptionalAuth sets the user context when a valid bearer token is present and
+// otherwise lets the request through anonymously. Used on public routes that
+// show admins more (e.g. ?include_deleted=true).
+func OptionalAuth(users UserLookup) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+			c.Next()
+			return
+		}
+
+		token, err := service.VerifyToken(parts[1], false)
+		if err != nil {
+			c.Next()
+			return
+		}
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok || !token.Valid {
+			c.Next()
+			return
+		}
+		subStr, _ := claims["sub"].(string)
+		userUUID, err := uuid.Parse(subStr)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		user, err := users.GetUserByID(c.Request.Context(), pgtype.UUID{Bytes: userUUID, Valid: true})
+		tokenVersion, _ := claims["token_version"].(float64)
+		if err != nil || user.DeletedAt.Valid || int32(tokenVersion) != user.TokenVersion {
+			c.Next()
+			return
+		}
+
+		c.Set("userID", userUUID)
+		c.Set("role", user.Role.String)
+		c.Next()
 	}
 }
 
@@ -140,13 +185,32 @@ func AdminOnly() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, _ := c.Get("role")
 		if role != "admin" {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+			abort(c, apierror.Forbidden("admin access required"))
 			return
 		}
 		c.Next()
 	}
 }
 
+// RequireRole lets the request through when the user has one of roles
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := c.Get("role")
+		for _, r := range roles {
+			if role == r {
+				c.Next()
+				return
+			}
+		}
+		abort(c, apierror.Forbidden("insufficient role"))
+	}
+}
+
+// FinanceOrAdmin ensures the request is from finance staff or an admin
+func FinanceOrAdmin() gin.HandlerFunc {
+	return RequireRole("admin", "finance")
+}
+
 // CORSMiddleware configures CORS headers
 // func CORSMiddleware(allowedOrigins ...string) gin.HandlerFunc {
 // 	return func(c *gin.Context) {
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	"github.com/THEGunDevil/GoForBackend/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// Errors replies to requests that ended with an error recorded through
+// c.Error. The last error is mapped with apierror.From and sent as a
+// problem, unless the handler already wrote a response; server errors are
+// logged with their cause.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		last := c.Errors.Last()
+		if last == nil {
+			return
+		}
+		apiErr := apierror.From(last.Err)
+		if apiErr.Status >= http.StatusInternalServerError && apiErr.Err != nil {
+			ctx := c.Request.Context()
+			logging.FromContext(ctx).ErrorContext(ctx, apiErr.Detail, "error", apiErr.Err)
+		}
+		if c.Writer.Written() {
+			return
+		}
+		apierror.Write(c, apiErr)
+	}
+}
+
+// Recovery turns a panic into a 500 problem; Errors logs the panic value
+// and stack with the request's logger
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		c.Error(apierror.Internal("something went wrong", fmt.Errorf("panic: %v\n%s", recovered, debug.Stack())))
+		c.Abort()
+	})
+}
+
+// NotFound answers requests for routes that do not exist
+func NotFound(c *gin.Context) {
+	c.Error(apierror.NotFound("no such route"))
+}
+
+// MethodNotAllowed answers requests using a method a route does not serve
+func MethodNotAllowed(c *gin.Context) {
+	c.Error(apierror.New(http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed for this route"))
+}
+
+// abort stops the chain with err for Errors to send
+func abort(c *gin.Context, err *apierror.Error) {
+	c.Error(err)
+	c.Abort()
+}
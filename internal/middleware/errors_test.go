@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+func TestErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Errors(), Recovery())
+	r.NoRoute(NotFound)
+	r.GET("/conflict", func(c *gin.Context) { c.Error(apierror.Conflict("book has copies on loan")) })
+	r.GET("/db", func(c *gin.Context) { c.Error(errors.New(`relation "books" does not exist`)) })
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+	r.GET("/written", func(c *gin.Context) {
+		c.JSON(http.StatusAccepted, gin.H{"message": "queued"})
+		c.Error(errors.New("notification failed"))
+	})
+	r.GET("/banned", AdminOnly(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{"/conflict", http.StatusConflict, "conflict", "book has copies on loan"},
+		{"/db", http.StatusInternalServerError, "internal", "something went wrong"},
+		{"/panic", http.StatusInternalServerError, "internal", "something went wrong"},
+		{"/banned", http.StatusForbidden, "forbidden", "admin access required"},
+		{"/nowhere", http.StatusNotFound, "not_found", "no such route"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		var p apierror.Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+			t.Fatalf("%s: %v in %q", tt.path, err, w.Body.String())
+		}
+		if w.Code != tt.wantStatus || p.Status != tt.wantStatus || p.Code != tt.wantCode || p.Detail != tt.wantDetail || p.Instance != tt.path {
+			t.Errorf("%s = %d %+v", tt.path, w.Code, p)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != apierror.ContentType {
+			t.Errorf("%s: Content-Type %q", tt.path, ct)
+		}
+	}
+
+	// A response already on its way is left alone
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/written", nil))
+	if w.Code != http.StatusAccepted || w.Body.String() != `{"message":"queued"}` {
+		t.Errorf("/written = %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/THEGunDevil/GoForBackend/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestIDPropagates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID(slog.Default()))
+	var seen string
+	r.GET("/", func(c *gin.Context) {
+		seen = logging.RequestID(c.Request.Context())
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if seen != "abc-123" || w.Header().Get(RequestIDHeader) != "abc-123" {
+		t.Errorf("caller's ID not kept: context %q, header %q", seen, w.Header().Get(RequestIDHeader))
+	}
+
+	// Anything that could forge a log line is replaced
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "bad id\nlevel=ERROR")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if seen == "" || seen == "bad id\nlevel=ERROR" || w.Header().Get(RequestIDHeader) != seen {
+		t.Errorf("invalid ID not replaced: context %q, header %q", seen, w.Header().Get(RequestIDHeader))
+	}
+}
@@ -1,8 +1,8 @@
 package middleware
 
 import (
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
 	"github.com/gin-gonic/gin"
-	"net/http"
 	"sync"
 	"time"
 )
@@ -41,9 +41,7 @@ func RateLimiter() gin.HandlerFunc {
 		// Exceeded limit
 		if client.Requests >= maxReq {
 			mutex.Unlock()
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-				"error": "Too many requests. Please try again later.",
-			})
+			abort(c, apierror.TooManyRequests("Too many requests. Please try again later."))
 			return
 		}
 
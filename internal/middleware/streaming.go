@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// NoWriteTimeout lifts the server's write timeout for long-lived responses
+// such as Server-Sent Events and streamed exports, which would otherwise be
+// cut off mid-stream
+func NoWriteTimeout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			ctx := c.Request.Context()
+			logging.FromContext(ctx).WarnContext(ctx, "failed to lift write deadline", "error", err)
+		}
+		c.Next()
+	}
+}
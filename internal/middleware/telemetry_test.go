@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/THEGunDevil/GoForBackend/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestTelemetryLabelsByRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Telemetry())
+	r.GET("/books/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, path := range []string{"/books/1", "/books/2", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if n := testutil.CollectAndCount(telemetry.HTTPRequestDuration); n != 2 {
+		t.Errorf("got %d series, want one per route template and status (2)", n)
+	}
+	families, err := telemetry.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes := map[string]uint64{}
+	for _, family := range families {
+		if family.GetName() != "library_http_request_duration_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "route" {
+					routes[label.GetValue()] = m.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+	if routes["/books/:id"] != 2 || routes["unmatched"] != 1 {
+		t.Errorf("requests by route = %v", routes)
+	}
+}
+
+func TestMetricsAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Errors())
+	r.GET("/metrics", MetricsAuth("secret"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for header, want := range map[string]int{"": 401, "Bearer nope": 401, "Bearer secret": 200} {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("Authorization %q: status %d, want %d", header, w.Code, want)
+		}
+	}
+}
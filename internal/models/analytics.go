@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// AnalyticsPoint is one time bucket of a series. Values holds the metric's
+// figures, e.g. "borrows" and "returns".
+type AnalyticsPoint struct {
+	Start  time.Time          `json:"start"`
+	Values map[string]float64 `json:"values"`
+}
+
+// AnalyticsPeriod is a series over [From, To) with its totals
+type AnalyticsPeriod struct {
+	From   time.Time          `json:"from"`
+	To     time.Time          `json:"to"`
+	Series []AnalyticsPoint   `json:"series"`
+	Totals map[string]float64 `json:"totals"`
+}
+
+// AnalyticsResponse is a metric over the requested period and the period of
+// the same length just before it. Change is the relative change of each
+// total, null when the previous total was zero.
+type AnalyticsResponse struct {
+	Metric   string `json:"metric"`
+	Bucket   string `json:"bucket"`
+	Timezone string `json:"timezone"`
+	Currency string `json:"currency,omitempty"`
+	AnalyticsPeriod
+	Previous AnalyticsPeriod     `json:"previous"`
+	Change   map[string]*float64 `json:"change"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateAnnouncementRequest is the body of POST /admin/announcements.
+// Audience picks the recipients: "all", "role" (with Role), "active_plan",
+// "overdue" or "users" (with UserIDs). PublishAt defaults to now.
+type CreateAnnouncementRequest struct {
+	Title     string      `json:"title" binding:"required,max=200"`
+	Message   string      `json:"message" binding:"required"`
+	Audience  string      `json:"audience" binding:"required,oneof=all role active_plan overdue users"`
+	Role      string      `json:"role,omitempty"`
+	UserIDs   []uuid.UUID `json:"user_ids,omitempty"`
+	Priority  string      `json:"priority,omitempty" binding:"omitempty,oneof=low normal high urgent"`
+	PublishAt *time.Time  `json:"publish_at,omitempty"`
+	ExpiresAt *time.Time  `json:"expires_at,omitempty"`
+}
+
+// UpdateAnnouncementRequest edits a scheduled announcement; omitted fields are kept
+type UpdateAnnouncementRequest struct {
+	Title     *string      `json:"title" binding:"omitempty,max=200"`
+	Message   *string      `json:"message"`
+	Audience  *string      `json:"audience" binding:"omitempty,oneof=all role active_plan overdue users"`
+	Role      *string      `json:"role"`
+	UserIDs   *[]uuid.UUID `json:"user_ids"`
+	Priority  *string      `json:"priority" binding:"omitempty,oneof=low normal high urgent"`
+	PublishAt *time.Time   `json:"publish_at"`
+	ExpiresAt *time.Time   `json:"expires_at"`
+}
+
+// AnnouncementResponse is an announcement as returned to admins
+type AnnouncementResponse struct {
+	ID             uuid.UUID   `json:"id"`
+	Title          string      `json:"title"`
+	Message        string      `json:"message"`
+	Audience       string      `json:"audience"`
+	Role           string      `json:"role,omitempty"`
+	UserIDs        []uuid.UUID `json:"user_ids,omitempty"`
+	Priority       string      `json:"priority"`
+	PublishAt      time.Time   `json:"publish_at"`
+	ExpiresAt      *time.Time  `json:"expires_at,omitempty"`
+	Status         string      `json:"status"`
+	PublishedAt    *time.Time  `json:"published_at,omitempty"`
+	RecipientCount int32       `json:"recipient_count"`
+	CreatedBy      *uuid.UUID  `json:"created_by,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEntry describes one administrative or financial action to record.
+// Before and After are marshalled to JSON; leave them nil when not relevant
+// (e.g. no "before" on create, no "after" on purge).
+type AuditEntry struct {
+	ActorID    *uuid.UUID
+	Action     string // e.g. "user.ban", "payment.status_update"
+	TargetType string // e.g. "user", "book", "payment"
+	TargetID   string
+	Before     any
+	After      any
+	IP         string
+	RequestID  string
+}
+
+// AuditLogResponse is one row of GET /admin/audit
+type AuditLogResponse struct {
+	ID         uuid.UUID       `json:"id"`
+	ActorID    *uuid.UUID      `json:"actor_id"`
+	ActorEmail string          `json:"actor_email,omitempty"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	IP         string          `json:"ip,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
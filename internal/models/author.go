@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuthorResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	SortName  string    `json:"sort_name"`
+	Bio       string    `json:"bio"`
+	BookCount int64     `json:"book_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateAuthorRequest struct {
+	Name     string `json:"name" binding:"required"`
+	SortName string `json:"sort_name"`
+	Bio      string `json:"bio"`
+}
+
+type UpdateAuthorRequest struct {
+	Name     *string `json:"name"`
+	SortName *string `json:"sort_name"`
+	Bio      *string `json:"bio"`
+}
+
+// BookContributor is an author linked to a book, e.g. as author or translator.
+type BookContributor struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Role     string    `json:"role"`
+	Position int32     `json:"position"`
+}
+
+// SetBookAuthorsRequest replaces every contributor of a book. Each entry
+// refers to an existing author by ID or names one (created if missing).
+type SetBookAuthorsRequest struct {
+	Authors []BookAuthorInput `json:"authors" binding:"required"`
+}
+
+type BookAuthorInput struct {
+	AuthorID *uuid.UUID `json:"author_id"`
+	Name     string     `json:"name"`
+	Role     string     `json:"role"` // author (default), editor, translator, illustrator, contributor
+}
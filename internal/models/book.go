@@ -18,18 +18,24 @@ type CreateBookRequest struct {
     Image         *multipart.FileHeader `json:"image" form:"image"`                               // optional file
 }
 type BookResponse struct {
-	ID              uuid.UUID `json:"id"`
-	Title           string    `json:"title"`
-	Author          string    `json:"author"`
-	PublishedYear   int32     `json:"published_year"`
-	Isbn            string    `json:"isbn"`
-	AvailableCopies int32     `json:"available_copies"`
-	TotalCopies     int32     `json:"total_copies"`
-	Genre           string    `json:"genre"`       // new
-	Description     string    `json:"description"` // new
-	ImageURL        string    `json:"image_url"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID              uuid.UUID  `json:"id"`
+	Title           string     `json:"title"`
+	Author          string     `json:"author"`
+	PublishedYear   int32      `json:"published_year"`
+	Isbn            string     `json:"isbn"`
+	AvailableCopies int32      `json:"available_copies"`
+	TotalCopies     int32      `json:"total_copies"`
+	Genre           string     `json:"genre"`       // new
+	Description     string     `json:"description"` // new
+	ImageURL        string     `json:"image_url"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"` // set while the book is in the bin
+
+	// Populated on the single-book endpoint only.
+	Authors []BookContributor `json:"authors,omitempty"`
+	Genres  []GenreRef        `json:"genres,omitempty"`
+	Series  *BookSeriesRef    `json:"series,omitempty"`
 }
 
 type UpdateBookRequest struct {
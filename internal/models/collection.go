@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TitleTurnover is how hard a title's copies worked over a range: borrows
+// per copy owned
+type TitleTurnover struct {
+	BookID      uuid.UUID `json:"book_id"`
+	Title       string    `json:"title"`
+	Author      string    `json:"author"`
+	TotalCopies int       `json:"total_copies"`
+	Borrows     int       `json:"borrows"`
+	Turnover    float64   `json:"turnover"`
+}
+
+// GenreTurnover is TitleTurnover summed over a top-level genre
+type GenreTurnover struct {
+	Genre    string  `json:"genre"`
+	Titles   int     `json:"titles"`
+	Copies   int     `json:"copies"`
+	Borrows  int     `json:"borrows"`
+	Turnover float64 `json:"turnover"`
+}
+
+// DeadStockBook is a title nobody has borrowed for a while.
+// LastBorrowedAt is nil if it was never borrowed.
+type DeadStockBook struct {
+	BookID         uuid.UUID  `json:"book_id"`
+	Title          string     `json:"title"`
+	Author         string     `json:"author"`
+	TotalCopies    int        `json:"total_copies"`
+	AddedAt        time.Time  `json:"added_at"`
+	LastBorrowedAt *time.Time `json:"last_borrowed_at"`
+}
+
+// BookHoldStats is a title's hold queue over a range. QueueLength is the
+// queue now; AvgQueueLength is the average over the range and
+// AvgWaitHours how long readers waited for the book, nil if none got it.
+type BookHoldStats struct {
+	BookID         uuid.UUID `json:"book_id"`
+	Title          string    `json:"title"`
+	TotalCopies    int       `json:"total_copies"`
+	QueueLength    int       `json:"queue_length"`
+	Holds          int       `json:"holds"`
+	AvgQueueLength float64   `json:"avg_queue_length"`
+	AvgWaitHours   *float64  `json:"avg_wait_hours"`
+}
+
+// PurchaseRecommendation is a title with more readers waiting than copies.
+// SuggestedCopies would give every waiting reader a copy.
+type PurchaseRecommendation struct {
+	BookID          uuid.UUID `json:"book_id"`
+	Title           string    `json:"title"`
+	Author          string    `json:"author"`
+	TotalCopies     int       `json:"total_copies"`
+	AvailableCopies int       `json:"available_copies"`
+	QueueLength     int       `json:"queue_length"`
+	SuggestedCopies int       `json:"suggested_copies"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateExportRequest queues a report export. Filters are the report's query
+// parameters, e.g. {"status": "overdue"} for borrows.
+type CreateExportRequest struct {
+	Report  string            `json:"report" binding:"required"`
+	Format  string            `json:"format"` // csv (default), xlsx or pdf
+	Filters map[string]string `json:"filters"`
+}
+
+// ExportJobResponse is an export job. DownloadURL is set once the file is
+// ready and works without a login until DownloadURLExpiresAt.
+type ExportJobResponse struct {
+	ID                   uuid.UUID         `json:"id"`
+	Report               string            `json:"report"`
+	Format               string            `json:"format"`
+	Filters              map[string]string `json:"filters"`
+	Status               string            `json:"status"`
+	Filename             string            `json:"filename,omitempty"`
+	SizeBytes            *int64            `json:"size_bytes,omitempty"`
+	Error                string            `json:"error,omitempty"`
+	CreatedAt            time.Time         `json:"created_at"`
+	StartedAt            *time.Time        `json:"started_at,omitempty"`
+	FinishedAt           *time.Time        `json:"finished_at,omitempty"`
+	ExpiresAt            *time.Time        `json:"expires_at,omitempty"`
+	DownloadURL          string            `json:"download_url,omitempty"`
+	DownloadURLExpiresAt *time.Time        `json:"download_url_expires_at,omitempty"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "github.com/google/uuid"
+
+type GenreResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Slug        string     `json:"slug"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+	Description string     `json:"description"`
+	BookCount   int64      `json:"book_count"`
+}
+
+type CreateGenreRequest struct {
+	Name        string     `json:"name" binding:"required"`
+	Slug        string     `json:"slug"` // derived from name when empty
+	ParentID    *uuid.UUID `json:"parent_id"`
+	Description string     `json:"description"`
+}
+
+type UpdateGenreRequest struct {
+	Name        *string    `json:"name"`
+	Slug        *string    `json:"slug"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+	ClearParent bool       `json:"clear_parent"` // makes the genre top-level again
+	Description *string    `json:"description"`
+}
+
+// GenreRef is a genre attached to a book.
+type GenreRef struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	Slug string    `json:"slug"`
+}
+
+// SetBookGenresRequest replaces a book's genres with the given slugs.
+type SetBookGenresRequest struct {
+	Genres []string `json:"genres" binding:"required"`
+}
@@ -0,0 +1,16 @@
+package models
+
+// HealthCheckResult is the outcome of one readiness check
+type HealthCheckResult struct {
+	Status   string `json:"status"` // ok or failed
+	Optional bool   `json:"optional,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ReadinessResponse is the body of /readyz. Status is ready, not_ready or
+// draining; only required checks decide it.
+type ReadinessResponse struct {
+	Status string                       `json:"status"`
+	Checks map[string]HealthCheckResult `json:"checks"`
+}
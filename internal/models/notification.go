@@ -19,6 +19,7 @@ type Notification struct {
 	Message           string          `json:"message"`              // full message
 	Metadata          json.RawMessage `json:"metadata,omitempty"`   // optional JSONB data
 	IsRead            bool            `json:"is_read"`              // read/unread status
+	ReadAt            *time.Time      `json:"read_at,omitempty"`    // when it was read
 	CreatedAt         time.Time       `json:"created_at"`           // creation timestamp
 }
 
@@ -32,4 +33,32 @@ type SendNotificationRequest struct {
 	NotificationTitle string          `json:"notification_title" binding:"required"` // short title
 	Message           string          `json:"message" binding:"required"`            // full message
 	Metadata          json.RawMessage `json:"metadata,omitempty"`                    // optional extra info
+	ExpiresAt         *time.Time      `json:"expires_at,omitempty"`                  // hidden from the inbox afterwards
+}
+
+// NotificationChannels says where a notification type is delivered
+type NotificationChannels struct {
+	InApp  bool `json:"in_app"`
+	Email  bool `json:"email"`
+	Digest bool `json:"digest"`
+}
+
+// NotificationPreference is the effective setting for one notification type
+type NotificationPreference struct {
+	Type string `json:"type"`
+	NotificationChannels
+	IsDefault bool `json:"is_default"` // true when the user never changed this type
+}
+
+// NotificationPreferenceInput changes one type; omitted channels keep their current value
+type NotificationPreferenceInput struct {
+	Type   string `json:"type" binding:"required"`
+	InApp  *bool  `json:"in_app"`
+	Email  *bool  `json:"email"`
+	Digest *bool  `json:"digest"`
+}
+
+// UpdateNotificationPreferencesRequest is the body of PUT /notifications/preferences
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreferenceInput `json:"preferences" binding:"required,min=1,dive"`
 }
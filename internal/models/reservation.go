@@ -46,6 +46,9 @@ type CreateReservationParams struct {
 	UserID uuid.UUID
 	BookID uuid.UUID
 }
+type CreateReservationRequest struct {
+	BookID string `json:"book_id" binding:"required"`
+}
 type UpdateReservationStatusRequest struct {
-	Status string `json:"status" binding:"required,oneof=pending notified fulfilled cancelled"`
+	Status string `json:"status" binding:"required,oneof=pending notified fulfilled cancelled picked_up"`
 }
@@ -7,11 +7,12 @@ import (
 	"github.com/google/uuid"
 )
 
+// CreateReviewRequest is the body of POST /reviews/review. bookId is
+// camelCase, unlike the rest of the API; existing clients send it that way.
 type CreateReviewRequest struct {
-	UserID  uuid.UUID `json:"user_id"`
-	BookID  uuid.UUID `json:"book_id"`
-	Rating  int       `json:"rating"`
-	Comment string    `json:"comment"`
+	BookID  *uuid.UUID `json:"bookId,omitempty"`
+	Rating  *int       `json:"rating,omitempty"`
+	Comment *string    `json:"comment,omitempty"`
 }
 type Review struct {
 	ID        uuid.UUID `json:"id"`
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SeriesResponse struct {
+	ID          uuid.UUID         `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	BookCount   int64             `json:"book_count"`
+	Books       []SeriesBookEntry `json:"books,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// SeriesBookEntry is a book together with its place in the series.
+type SeriesBookEntry struct {
+	Position *float64     `json:"position"`
+	Book     BookResponse `json:"book"`
+}
+
+type CreateSeriesRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type UpdateSeriesRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+// BookSeriesRef is the series a book belongs to.
+type BookSeriesRef struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Position *float64  `json:"position"`
+}
+
+// SetBookSeriesRequest places a book in a series; a nil SeriesID removes it.
+type SetBookSeriesRequest struct {
+	SeriesID *uuid.UUID `json:"series_id"`
+	Position *float64   `json:"position"`
+}
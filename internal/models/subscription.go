@@ -90,3 +90,6 @@ type CreatePaymentRequest struct {
 	UserID uuid.UUID `json:"user_id"`
 	PlanID uuid.UUID `json:"plan_id"`
 }
+type UpdatePaymentStatusRequest struct {
+	Status string `json:"status"` // paid, failed, pending or cancelled
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SupportTicketResponse is a ticket with, on the detail endpoints, its
+// message thread oldest first
+type SupportTicketResponse struct {
+	ID            uuid.UUID                      `json:"id"`
+	UserID        uuid.UUID                      `json:"user_id"`
+	Name          string                         `json:"name"`
+	Email         string                         `json:"email"`
+	Subject       string                         `json:"subject"`
+	Category      string                         `json:"category"`
+	Status        string                         `json:"status"`
+	Priority      string                         `json:"priority"`
+	AssignedTo    *uuid.UUID                     `json:"assigned_to,omitempty"`
+	LastMessageAt time.Time                      `json:"last_message_at"`
+	ClosedAt      *time.Time                     `json:"closed_at,omitempty"`
+	CreatedAt     time.Time                      `json:"created_at"`
+	UpdatedAt     time.Time                      `json:"updated_at"`
+	Messages      []SupportTicketMessageResponse `json:"messages,omitempty"`
+}
+
+// SupportTicketMessageResponse is one message in a ticket thread
+type SupportTicketMessageResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	AuthorID  *uuid.UUID `json:"author_id,omitempty"`
+	IsStaff   bool       `json:"is_staff"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// SupportReplyRequest adds a message to a ticket. Status is only honored on
+// staff replies; by default a staff reply marks the ticket pending (waiting
+// on the member) and a member reply reopens it.
+type SupportReplyRequest struct {
+	Body   string  `json:"body" binding:"required,max=5000"`
+	Status *string `json:"status" binding:"omitempty,oneof=open pending resolved closed"`
+}
+
+// UpdateSupportTicketRequest is the admin triage update; omitted fields are
+// kept. AssignedTo takes an admin's user ID, or "" to unassign.
+type UpdateSupportTicketRequest struct {
+	Status     *string `json:"status" binding:"omitempty,oneof=open pending resolved closed"`
+	Priority   *string `json:"priority" binding:"omitempty,oneof=low normal high urgent"`
+	Category   *string `json:"category" binding:"omitempty,oneof=general ban_appeal billing catalog"`
+	AssignedTo *string `json:"assigned_to"`
+}
@@ -59,7 +59,13 @@ type UserResponse struct {
 	ReadingStreak      int        `json:"reading_streak"`
 	AllBorrowsCount    int        `json:"all_borrows_count"`    // true = permanent ban
 	ActiveBorrowsCount int        `json:"active_borrows_count"` // true = permanent ban
+	DeletedAt          *time.Time `json:"deleted_at,omitempty"` // set while the account is deleted
 }
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
 type BanRequest struct {
 	IsBanned       bool       `json:"is_banned"`
 	BanReason      string     `json:"ban_reason"`
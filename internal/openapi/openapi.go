@@ -0,0 +1,317 @@
+// Package openapi describes the API as an OpenAPI 3.1 document. The route
+// table in routes.go lists every endpoint; request and response schemas are
+// reflected from the models the handlers bind and return, so they cannot
+// drift from internal/models.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// Document is an OpenAPI 3.1 document, limited to the parts this API uses
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathItem maps a lower-case HTTP method to its operation
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags"`
+	Security    []map[string][]string `json:"security"`
+	Roles       []string              `json:"x-roles,omitempty"` // roles the route is limited to
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path, query or cookie
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Schema is a JSON Schema (2020-12) as OpenAPI 3.1 embeds it
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"` // a name, or [name, "null"] when nullable
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+}
+
+// Access says who may call a route
+type Access int
+
+const (
+	Public  Access = iota
+	Member         // any signed-in user
+	Admin          // admins only
+	Finance        // finance staff or admins
+)
+
+// Route is one entry in the route table
+type Route struct {
+	Method    string
+	Path      string // as registered with gin, e.g. /books/:id
+	Tag       string
+	Summary   string
+	Notes     string // longer description, when the summary is not enough
+	Access    Access
+	Query     []Parameter
+	Cookie    string // cookie the route reads instead of a bearer token
+	Body      any    // model or *Schema the handler binds
+	Encodings string // media types Body is accepted as; JSON when unset
+	Status    int    // success status; 200 when unset
+	Result    any    // model or *Schema returned; nil for no body
+	Type      string // content types of Result when it is not JSON
+}
+
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+// SpecPath turns a gin path into an OpenAPI one: /books/:id is /books/{id}
+func SpecPath(ginPath string) string {
+	return pathParam.ReplaceAllString(ginPath, "{$1}")
+}
+
+// Routes returns the route table
+func Routes() []Route { return routes }
+
+var (
+	buildOnce sync.Once
+	built     *Document
+)
+
+// Spec returns the document for the route table, built on first use
+func Spec() *Document {
+	buildOnce.Do(func() { built = Build(routes) })
+	return built
+}
+
+// Build assembles a document from a route table
+func Build(table []Route) *Document {
+	reg := newRegistry()
+	doc := &Document{
+		OpenAPI: "3.1.0",
+		Info: Info{
+			Title:   "Book Library API",
+			Version: "1.0.0",
+			Description: "Catalogue, loans, reservations, reviews, subscriptions and payments for the library. " +
+				"Errors are RFC 7807 application/problem+json documents: code is stable and meant for programs, " +
+				"detail is for people.",
+		},
+		Paths: map[string]PathItem{},
+		Components: Components{
+			SecuritySchemes: map[string]SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT",
+					Description: "Access token from /auth/login or /auth/refresh"},
+				"refreshCookie": {Type: "apiKey", In: "cookie", Name: "refresh_token",
+					Description: "HttpOnly refresh token cookie set by /auth/login"},
+			},
+		},
+	}
+	errorRef := reg.schema(apierror.Problem{})
+
+	tags := map[string]bool{}
+	for _, rt := range table {
+		op := &Operation{
+			OperationID: operationID(rt.Method, rt.Path),
+			Summary:     rt.Summary,
+			Description: rt.Notes,
+			Tags:        []string{rt.Tag},
+			Security:    []map[string][]string{},
+			Responses:   map[string]*Response{},
+		}
+		tags[rt.Tag] = true
+
+		switch rt.Access {
+		case Member:
+			op.Security = []map[string][]string{{"bearerAuth": {}}}
+		case Admin:
+			op.Security = []map[string][]string{{"bearerAuth": {}}}
+			op.Roles = []string{"admin"}
+		case Finance:
+			op.Security = []map[string][]string{{"bearerAuth": {}}}
+			op.Roles = []string{"finance", "admin"}
+		}
+		if rt.Cookie != "" {
+			op.Security = []map[string][]string{{"refreshCookie": {}}}
+		}
+
+		for _, name := range pathParam.FindAllStringSubmatch(rt.Path, -1) {
+			op.Parameters = append(op.Parameters, Parameter{Name: name[1], In: "path", Required: true, Schema: pathSchema(name[1])})
+		}
+		for _, p := range rt.Query {
+			p.In = "query"
+			op.Parameters = append(op.Parameters, p)
+		}
+
+		if rt.Body != nil {
+			op.RequestBody = &RequestBody{Required: true, Content: content(orDefault(rt.Encodings, "application/json"), reg.schema(rt.Body))}
+		}
+
+		status := rt.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		ok := &Response{Description: http.StatusText(status)}
+		if rt.Result != nil {
+			ok.Content = content(orDefault(rt.Type, "application/json"), reg.schema(rt.Result))
+		}
+		op.Responses[fmt.Sprint(status)] = ok
+
+		errorContent := map[string]MediaType{apierror.ContentType: {Schema: errorRef}}
+		if rt.Access != Public || rt.Cookie != "" {
+			op.Responses["401"] = &Response{Description: "Missing, invalid or revoked credentials", Content: errorContent}
+		}
+		if rt.Access == Admin || rt.Access == Finance {
+			op.Responses["403"] = &Response{Description: "The caller's role may not use this route", Content: errorContent}
+		}
+		op.Responses["default"] = &Response{Description: "Error", Content: errorContent}
+
+		path := SpecPath(rt.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = PathItem{}
+		}
+		doc.Paths[path][strings.ToLower(rt.Method)] = op
+	}
+
+	for name := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: name})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+	doc.Components.Schemas = reg.schemas
+	return doc
+}
+
+// content offers schema under each of the space-separated media types
+func content(mediaTypes string, schema *Schema) map[string]MediaType {
+	out := map[string]MediaType{}
+	for _, t := range strings.Fields(mediaTypes) {
+		out[t] = MediaType{Schema: schema}
+	}
+	return out
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// pathSchema types a path parameter; ids are UUIDs
+func pathSchema(name string) *Schema {
+	if name == "id" || strings.HasSuffix(name, "_id") {
+		return &Schema{Type: "string", Format: "uuid"}
+	}
+	return &Schema{Type: "string"}
+}
+
+// operationID names an operation after its method and path:
+// GET /books/:id/marc is getBooksByIdMarc
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, seg := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' }) {
+		if strings.HasPrefix(seg, ":") {
+			b.WriteString("By")
+			seg = seg[1:]
+		}
+		for _, word := range strings.FieldsFunc(seg, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	if path == "/" {
+		b.WriteString("Root")
+	}
+	return b.String()
+}
+
+// Handler serves the document as JSON
+func Handler(c *gin.Context) {
+	c.JSON(http.StatusOK, Spec())
+}
+
+// DocsHandler serves a Redoc page that renders /openapi.json
+func DocsHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
+
+const docsPage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Book Library API</title>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+  <redoc spec-url="/openapi.json"></redoc>
+  <script src="https://cdn.jsdelivr.net/npm/redoc@2/bundles/redoc.standalone.js"></script>
+</body>
+</html>
+`
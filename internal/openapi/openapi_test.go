@@ -0,0 +1,101 @@
+package openapi
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestOperationID(t *testing.T) {
+	tests := []struct{ method, path, want string }{
+		{"GET", "/", "getRoot"},
+		{"GET", "/books/", "getBooks"},
+		{"GET", "/books/:id/marc", "getBooksByIdMarc"},
+		{"GET", "/payments/:id/receipt.pdf", "getPaymentsByIdReceiptPdf"},
+		{"PATCH", "/users/user/ban/:id", "patchUsersUserBanById"},
+		{"GET", "/analytics/collection/dead-stock", "getAnalyticsCollectionDeadStock"},
+	}
+	for _, tt := range tests {
+		if got := operationID(tt.method, tt.path); got != tt.want {
+			t.Errorf("operationID(%s %s) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+
+	seen := map[string]string{}
+	for _, rt := range Routes() {
+		id := operationID(rt.Method, rt.Path)
+		if other, ok := seen[id]; ok {
+			t.Errorf("%s %s and %s share operationId %s", rt.Method, rt.Path, other, id)
+		}
+		seen[id] = rt.Method + " " + rt.Path
+	}
+}
+
+// Every $ref points at a component, and the document is valid JSON
+func TestSpecReferencesResolve(t *testing.T) {
+	spec := Spec()
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				name := strings.TrimPrefix(ref, "#/components/schemas/")
+				if spec.Components.Schemas[name] == nil {
+					t.Errorf("dangling reference %s", ref)
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+	walk(doc)
+}
+
+// Schemas come from the models' json and binding tags
+func TestSchemasFollowModels(t *testing.T) {
+	schemas := Spec().Components.Schemas
+
+	review := schemas["CreateReviewRequest"]
+	if review == nil || review.Properties["bookId"] == nil || review.Properties["book_id"] != nil {
+		t.Errorf("CreateReviewRequest = %+v, want the camelCase bookId the handler binds", review)
+	}
+
+	book := schemas["CreateBookRequest"]
+	if book == nil || !slices.Contains(book.Required, "title") || book.Properties["image"].Format != "binary" {
+		t.Errorf("CreateBookRequest = %+v", book)
+	}
+
+	status := schemas["UpdateReservationStatusRequest"]
+	if status == nil || !slices.Contains(status.Properties["status"].Enum, any("picked_up")) {
+		t.Errorf("UpdateReservationStatusRequest = %+v", status)
+	}
+
+	contact := schemas["handlers.ContactRequest"]
+	if contact == nil || contact.Properties["email"].Format != "email" || *contact.Properties["name"].MaxLength != 50 {
+		t.Errorf("handlers.ContactRequest = %+v", contact)
+	}
+
+	// pgtype columns and pointers may be null
+	payment := schemas["gen.Payment"]
+	if payment == nil || !slices.Equal(payment.Properties["payment_gateway"].Type.([]string), []string{"string", "null"}) {
+		t.Errorf("gen.Payment = %+v", payment)
+	}
+	user := schemas["UserResponse"]
+	if user == nil || user.Properties["deleted_at"].Format != "date-time" || !isType(user.Properties["deleted_at"], "string") {
+		t.Errorf("UserResponse.deleted_at = %+v", user.Properties["deleted_at"])
+	}
+}
@@ -0,0 +1,483 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/handlers"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+)
+
+// Request body encodings other than plain JSON
+const (
+	jsonOrForm = "application/json multipart/form-data"
+	formOnly   = "multipart/form-data"
+)
+
+// Response content types other than JSON
+const (
+	reportTypes = "text/csv application/vnd.openxmlformats-officedocument.spreadsheetml.sheet application/pdf"
+	marcTypes   = "application/marcxml+xml application/marc"
+)
+
+var (
+	str      = &Schema{Type: "string"}
+	integer  = &Schema{Type: "integer"}
+	boolean  = &Schema{Type: "boolean"}
+	number   = &Schema{Type: "number"}
+	dateTime = &Schema{Type: "string", Format: "date-time"}
+	uuidStr  = &Schema{Type: "string", Format: "uuid"}
+	binary   = &Schema{Type: "string", Format: "binary"}
+	anyValue = &Schema{}
+
+	message = object(prop("message", str))
+
+	pageQuery = []Parameter{
+		query("page", integer, "Page number, from 1"),
+		query("limit", integer, "Page size"),
+	}
+	rangeQuery = []Parameter{
+		query("from", str, "Start date, RFC3339 or YYYY-MM-DD"),
+		query("to", str, "End date, RFC3339 or YYYY-MM-DD"),
+		query("tz", str, "IANA time zone the dates are in"),
+	}
+	analyticsQuery = append([]Parameter{
+		query("bucket", enum("day", "week", "month"), "Width of each point in the series"),
+	}, rangeQuery...)
+	reportQuery = []Parameter{
+		query("format", enum("csv", "xlsx", "pdf"), "File format, csv by default"),
+		query("search", str, "Free-text filter"),
+		query("status", str, "Status filter"),
+		query("from", str, "Start date, RFC3339 or YYYY-MM-DD"),
+		query("to", str, "End date, RFC3339 or YYYY-MM-DD"),
+	}
+)
+
+// routes is every route server.NewRouter registers. The contract test in
+// internal/server fails when the two disagree.
+var routes = []Route{
+	// Health and docs
+	{Method: "GET", Path: "/", Tag: "Health", Summary: "Liveness check", Result: object(prop("status", str))},
+	{Method: "GET", Path: "/healthz", Tag: "Health", Summary: "Liveness check", Result: object(prop("status", str))},
+	{Method: "GET", Path: "/readyz", Tag: "Health", Summary: "Readiness check",
+		Notes: "Checks the database and other dependencies; 503 with the same body when one is down.", Result: models.ReadinessResponse{}},
+	{Method: "GET", Path: "/metrics", Tag: "Health", Summary: "Prometheus metrics",
+		Notes: "Requires METRICS_TOKEN as a bearer token when the server sets one.", Result: str, Type: "text/plain"},
+	{Method: "GET", Path: "/openapi.json", Tag: "Docs", Summary: "This document", Result: anyValue},
+	{Method: "GET", Path: "/docs", Tag: "Docs", Summary: "Rendered API documentation", Result: str, Type: "text/html"},
+
+	// Auth
+	{Method: "POST", Path: "/auth/register", Tag: "Auth", Summary: "Register an account", Body: models.User{},
+		Status: http.StatusCreated, Result: models.UserResponse{}},
+	{Method: "POST", Path: "/auth/login", Tag: "Auth", Summary: "Sign in", Body: models.LoginRequest{},
+		Notes:  "Sets the refresh_token cookie used by /auth/refresh.",
+		Result: object(prop("access_token", str), prop("role", str))},
+	{Method: "POST", Path: "/auth/refresh", Tag: "Auth", Summary: "Issue a new access token", Cookie: "refresh_token",
+		Notes: "Rotates the refresh_token cookie.", Result: object(prop("access_token", str))},
+	{Method: "POST", Path: "/auth/logout", Tag: "Auth", Summary: "Sign out", Notes: "Clears the refresh_token cookie.", Result: message},
+
+	// Users
+	{Method: "GET", Path: "/users/", Tag: "Users", Summary: "List users", Access: Admin, Query: pageQuery,
+		Result: page("users", models.UserResponse{})},
+	{Method: "GET", Path: "/banned-users/", Tag: "Users", Summary: "List users", Access: Admin, Query: pageQuery,
+		Result: page("users", models.UserResponse{})},
+	{Method: "GET", Path: "/users/user/email", Tag: "Users", Summary: "Search users by email", Access: Admin,
+		Query: append([]Parameter{query("email", str, "Email to search for")}, pageQuery...), Result: page("users", models.UserResponse{})},
+	{Method: "GET", Path: "/users/user/:id", Tag: "Users", Summary: "Get a user", Access: Member, Result: models.UserResponse{}},
+	{Method: "GET", Path: "/users/user/profile/:id", Tag: "Users", Summary: "Get a user's profile with their loans and reviews",
+		Access: Member, Result: models.Profile{}},
+	{Method: "PATCH", Path: "/users/user/:id", Tag: "Users", Summary: "Update a user", Access: Member,
+		Body: models.UpdateUserRequest{}, Encodings: formOnly, Result: models.UserResponse{}},
+	{Method: "PATCH", Path: "/users/user/ban/:id", Tag: "Users", Summary: "Ban or unban a user", Access: Admin,
+		Body: models.BanRequest{}, Result: models.UserResponse{}},
+	{Method: "DELETE", Path: "/users/user/:id", Tag: "Users", Summary: "Delete a user's profile image", Access: Member, Result: message},
+	{Method: "DELETE", Path: "/users/:id", Tag: "Users", Summary: "Soft-delete a user", Access: Admin,
+		Result: object(prop("message", str), prop("deleted_at", dateTime))},
+	{Method: "POST", Path: "/users/:id/restore", Tag: "Users", Summary: "Restore a deleted user", Access: Admin, Result: message},
+	{Method: "DELETE", Path: "/users/:id/purge", Tag: "Users", Summary: "Permanently remove a deleted user", Access: Admin, Result: message},
+
+	// Books
+	{Method: "GET", Path: "/books/", Tag: "Books", Summary: "List books",
+		Notes: "Admins may pass include_deleted=true.", Query: append(pageQuery, includeDeleted), Result: page("books", models.BookResponse{})},
+	{Method: "GET", Path: "/books/search", Tag: "Books", Summary: "Search books",
+		Query:  append([]Parameter{query("query", str, "Title or author"), query("genre", str, "Genre slug, or all")}, pageQuery...),
+		Result: page("books", models.BookResponse{})},
+	{Method: "GET", Path: "/books/genres", Tag: "Books", Summary: "List genre names in use", Result: []string{}},
+	{Method: "GET", Path: "/books/genre/:genre", Tag: "Books", Summary: "List books in a genre", Query: pageQuery,
+		Result: page("books", models.BookResponse{})},
+	{Method: "GET", Path: "/books/:id", Tag: "Books", Summary: "Get a book",
+		Notes: "Admins may pass include_deleted=true.", Query: []Parameter{includeDeleted}, Result: models.BookResponse{}},
+	{Method: "POST", Path: "/books/", Tag: "Books", Summary: "Add a book", Access: Admin,
+		Body: models.CreateBookRequest{}, Encodings: jsonOrForm, Status: http.StatusCreated, Result: models.BookResponse{}},
+	{Method: "PATCH", Path: "/books/:id", Tag: "Books", Summary: "Update a book", Access: Admin,
+		Body: models.UpdateBookRequest{}, Encodings: jsonOrForm, Result: models.BookResponse{}},
+	{Method: "DELETE", Path: "/books/:id", Tag: "Books", Summary: "Soft-delete a book", Access: Admin,
+		Result: object(prop("message", str), prop("book", models.BookResponse{}))},
+	{Method: "POST", Path: "/books/:id/restore", Tag: "Books", Summary: "Restore a deleted book", Access: Admin,
+		Result: object(prop("message", str), prop("book", models.BookResponse{}))},
+	{Method: "DELETE", Path: "/books/:id/purge", Tag: "Books", Summary: "Permanently remove a deleted book", Access: Admin, Result: message},
+	{Method: "GET", Path: "/books/marc", Tag: "Books", Summary: "Export the catalogue as MARC", Access: Admin,
+		Query: []Parameter{marcFormat}, Result: binary, Type: marcTypes},
+	{Method: "GET", Path: "/books/:id/marc", Tag: "Books", Summary: "Export a book as MARC", Access: Admin,
+		Query: []Parameter{marcFormat}, Result: binary, Type: marcTypes},
+	{Method: "POST", Path: "/books/marc", Tag: "Books", Summary: "Import one book from a MARC record", Access: Admin,
+		Notes: "The file may also be sent as the raw request body.",
+		Query: marcImportQuery, Body: object(prop("file", binary)), Encodings: formOnly,
+		Status: http.StatusCreated, Result: models.BookResponse{}},
+	{Method: "POST", Path: "/books/marc/batch", Tag: "Books", Summary: "Import every record in a MARC file", Access: Admin,
+		Notes: "The file may also be sent as the raw request body.",
+		Query: marcImportQuery, Body: object(prop("file", binary)), Encodings: formOnly,
+		Result: object(prop("total", integer), prop("created", integer), prop("skipped", integer), prop("failed", integer),
+			prop("results", []handlers.MARCImportResult{}))},
+	{Method: "PUT", Path: "/books/:id/authors", Tag: "Books", Summary: "Set a book's authors", Access: Admin,
+		Body: models.SetBookAuthorsRequest{}, Result: object(prop("book_id", uuidStr), prop("authors", []models.BookContributor{}))},
+	{Method: "PUT", Path: "/books/:id/genres", Tag: "Books", Summary: "Set a book's genres", Access: Admin,
+		Body: models.SetBookGenresRequest{}, Result: object(prop("book_id", uuidStr), prop("genres", []models.GenreRef{}))},
+	{Method: "PUT", Path: "/books/:id/series", Tag: "Books", Summary: "Set or clear a book's series", Access: Admin,
+		Body: models.SetBookSeriesRequest{}, Result: object(prop("book_id", uuidStr), prop("series", &models.BookSeriesRef{}))},
+
+	// Authors
+	{Method: "GET", Path: "/authors/", Tag: "Authors", Summary: "List authors",
+		Query: append([]Parameter{query("search", str, "Name filter")}, pageQuery...), Result: page("authors", models.AuthorResponse{})},
+	{Method: "GET", Path: "/authors/:id", Tag: "Authors", Summary: "Get an author", Result: models.AuthorResponse{}},
+	{Method: "GET", Path: "/authors/:id/books", Tag: "Authors", Summary: "List an author's books", Query: pageQuery,
+		Result: page("books", models.BookResponse{}, prop("author", models.AuthorResponse{}))},
+	{Method: "POST", Path: "/authors/", Tag: "Authors", Summary: "Add an author", Access: Admin,
+		Body: models.CreateAuthorRequest{}, Status: http.StatusCreated, Result: models.AuthorResponse{}},
+	{Method: "PATCH", Path: "/authors/:id", Tag: "Authors", Summary: "Update an author", Access: Admin,
+		Body: models.UpdateAuthorRequest{}, Result: models.AuthorResponse{}},
+
+	// Series
+	{Method: "GET", Path: "/series/", Tag: "Series", Summary: "List series",
+		Query: append([]Parameter{query("search", str, "Name filter")}, pageQuery...), Result: page("series", models.SeriesResponse{})},
+	{Method: "GET", Path: "/series/:id", Tag: "Series", Summary: "Get a series with its books in reading order", Result: models.SeriesResponse{}},
+	{Method: "POST", Path: "/series/", Tag: "Series", Summary: "Add a series", Access: Admin,
+		Body: models.CreateSeriesRequest{}, Status: http.StatusCreated, Result: models.SeriesResponse{}},
+	{Method: "PATCH", Path: "/series/:id", Tag: "Series", Summary: "Update a series", Access: Admin,
+		Body: models.UpdateSeriesRequest{}, Result: models.SeriesResponse{}},
+
+	// Genres
+	{Method: "GET", Path: "/genres/", Tag: "Genres", Summary: "List the genre taxonomy", Result: []models.GenreResponse{}},
+	{Method: "POST", Path: "/genres/", Tag: "Genres", Summary: "Add a genre", Access: Admin,
+		Body: models.CreateGenreRequest{}, Status: http.StatusCreated, Result: models.GenreResponse{}},
+	{Method: "PATCH", Path: "/genres/:id", Tag: "Genres", Summary: "Update a genre", Access: Admin,
+		Body: models.UpdateGenreRequest{}, Result: models.GenreResponse{}},
+
+	// Reservations
+	{Method: "GET", Path: "/reservations/", Tag: "Reservations", Summary: "List reservations", Access: Member,
+		Notes: "Admins see every reservation, members their own.", Query: pageQuery,
+		Result: object(prop("reservations", []models.ReservationResponse{}), prop("page", integer), prop("limit", integer), prop("count", integer))},
+	{Method: "GET", Path: "/reservations/book/:id", Tag: "Reservations", Summary: "List a book's reservations", Access: Member,
+		Result: []models.ReservationResponse{}},
+	{Method: "GET", Path: "/reservations/book/:id/user", Tag: "Reservations", Summary: "Get a user's reservation of a book", Access: Member,
+		Query: []Parameter{query("user_id", uuidStr, "User to look up")}, Result: models.ReservationResponse{}},
+	{Method: "GET", Path: "/reservations/reservation/:id", Tag: "Reservations", Summary: "Get a reservation", Access: Member,
+		Result: models.ReservationResponse{}},
+	{Method: "GET", Path: "/reservations/next/:id", Tag: "Reservations", Summary: "Get the next pending reservation for a book", Access: Admin,
+		Result: models.ReservationResponse{}},
+	{Method: "POST", Path: "/reservations/", Tag: "Reservations", Summary: "Reserve a book", Access: Member,
+		Body: models.CreateReservationRequest{}, Status: http.StatusCreated, Result: models.ReservationResponse{}},
+	{Method: "PATCH", Path: "/reservations/:id/status", Tag: "Reservations", Summary: "Change a reservation's status", Access: Admin,
+		Body: models.UpdateReservationStatusRequest{}, Result: models.ReservationResponse{}},
+
+	// Borrows
+	{Method: "GET", Path: "/borrows/", Tag: "Borrows", Summary: "List loans", Access: Admin, Query: pageQuery,
+		Result: page("borrows", models.BorrowResponse{})},
+	{Method: "GET", Path: "/borrows/user/:id", Tag: "Borrows", Summary: "List a user's loans", Access: Member, Result: []models.BorrowResponse{}},
+	{Method: "GET", Path: "/borrows/book/:id", Tag: "Borrows", Summary: "List a book's loans", Access: Member, Result: []models.BorrowResponse{}},
+	{Method: "GET", Path: "/borrows/borrow/book/:id", Tag: "Borrows", Summary: "Get the caller's loan of a book", Access: Member,
+		Result: []models.BorrowResponse{}},
+	{Method: "POST", Path: "/borrows/borrow", Tag: "Borrows", Summary: "Borrow a book", Access: Member,
+		Body: models.CreateBorrowRequest{}, Status: http.StatusCreated, Result: models.BorrowResponse{}},
+	{Method: "PATCH", Path: "/borrows/borrow/:id/return", Tag: "Borrows", Summary: "Return a book", Access: Member,
+		Body: models.ReturnBookRequest{}, Result: map[string]string{}},
+
+	// Reviews
+	{Method: "GET", Path: "/reviews/book/:id", Tag: "Reviews", Summary: "List a book's reviews", Access: Member, Result: []models.ReviewResponse{}},
+	{Method: "GET", Path: "/reviews/user/:id", Tag: "Reviews", Summary: "List a user's reviews", Access: Member, Result: []models.ReviewResponse{}},
+	{Method: "GET", Path: "/reviews/review/:id", Tag: "Reviews", Summary: "Get a review", Access: Member, Result: models.ReviewResponse{}},
+	{Method: "POST", Path: "/reviews/review", Tag: "Reviews", Summary: "Review a book", Access: Member,
+		Notes: "bookId is camelCase, unlike the rest of the API. A rating (1-5), a comment or both are required.",
+		Body:  models.CreateReviewRequest{}, Status: http.StatusCreated,
+		Result: object(prop("message", str), prop("review", gen.Review{}))},
+	{Method: "PATCH", Path: "/reviews/review/:id", Tag: "Reviews", Summary: "Update the caller's review", Access: Member,
+		Body: models.UpdateReviewRequest{}, Result: object(prop("message", str), prop("review", gen.Review{}))},
+	{Method: "DELETE", Path: "/reviews/review/:id", Tag: "Reviews", Summary: "Delete a review", Access: Member},
+
+	// Support
+	{Method: "POST", Path: "/contact/send", Tag: "Support", Summary: "Open a support ticket from the contact form", Access: Member,
+		Body: handlers.ContactRequest{}, Status: http.StatusCreated,
+		Result: object(prop("message", str), prop("ticket", models.SupportTicketResponse{}))},
+	{Method: "GET", Path: "/support/tickets", Tag: "Support", Summary: "List the caller's tickets", Access: Member, Query: pageQuery,
+		Result: page("tickets", models.SupportTicketResponse{})},
+	{Method: "POST", Path: "/support/tickets", Tag: "Support", Summary: "Open a support ticket", Access: Member,
+		Body: handlers.ContactRequest{}, Status: http.StatusCreated,
+		Result: object(prop("message", str), prop("ticket", models.SupportTicketResponse{}))},
+	{Method: "GET", Path: "/support/tickets/:id", Tag: "Support", Summary: "Get one of the caller's tickets", Access: Member,
+		Result: models.SupportTicketResponse{}},
+	{Method: "POST", Path: "/support/tickets/:id/messages", Tag: "Support", Summary: "Reply to one of the caller's tickets", Access: Member,
+		Body: models.SupportReplyRequest{}, Status: http.StatusCreated, Result: models.SupportTicketResponse{}},
+
+	// Notifications
+	{Method: "GET", Path: "/notifications", Tag: "Notifications", Summary: "List the caller's inbox", Access: Member,
+		Query: []Parameter{
+			query("limit", integer, "Page size"),
+			query("read", boolean, "Only read or only unread items"),
+			query("type", str, "Notification type"),
+			query("cursor", str, "next_cursor from the previous page"),
+		},
+		Result: object(prop("notifications", []models.Notification{}), prop("count", integer),
+			prop("has_more", boolean), prop("next_cursor", &Schema{Type: []string{"string", "null"}}))},
+	{Method: "GET", Path: "/notifications/unread-count", Tag: "Notifications", Summary: "Count unread notifications", Access: Member,
+		Result: object(prop("unread_count", integer))},
+	{Method: "GET", Path: "/notifications/get", Tag: "Notifications", Summary: "List the caller's notifications", Access: Member,
+		Query: pageQuery, Result: []models.Notification{}},
+	{Method: "GET", Path: "/notifications/stream", Tag: "Notifications", Summary: "Stream new notifications", Access: Member,
+		Notes: "Server-sent events, one notification per event. Resume with Last-Event-ID or last_event_id.",
+		Query: []Parameter{query("last_event_id", str, "Replay events after this one")}, Result: str, Type: "text/event-stream"},
+	{Method: "PATCH", Path: "/notifications/:id/read", Tag: "Notifications", Summary: "Mark a notification read", Access: Member,
+		Result: models.Notification{}},
+	{Method: "PATCH", Path: "/notifications/mark-read", Tag: "Notifications", Summary: "Mark every notification read", Access: Member, Result: message},
+	{Method: "DELETE", Path: "/notifications/:id", Tag: "Notifications", Summary: "Dismiss a notification", Access: Member, Result: message},
+	{Method: "GET", Path: "/notifications/preferences", Tag: "Notifications", Summary: "Get delivery preferences", Access: Member,
+		Result: object(prop("preferences", []models.NotificationPreference{}))},
+	{Method: "PUT", Path: "/notifications/preferences", Tag: "Notifications", Summary: "Update delivery preferences", Access: Member,
+		Body: models.UpdateNotificationPreferencesRequest{}, Result: object(prop("preferences", []models.NotificationPreference{}))},
+
+	// Subscriptions
+	{Method: "GET", Path: "/subscription-plan/", Tag: "Subscriptions", Summary: "List plans", Access: Member,
+		Result: object(prop("message", str), prop("plans", []models.SubscriptionPlan{}))},
+	{Method: "GET", Path: "/subscription-plan/:id", Tag: "Subscriptions", Summary: "Get a plan", Access: Member,
+		Result: object(prop("message", str), prop("plan", models.SubscriptionPlan{}))},
+	{Method: "POST", Path: "/subscription-plan/", Tag: "Subscriptions", Summary: "Add a plan", Access: Admin,
+		Body: models.SubscriptionPlan{}, Result: object(prop("message", str), prop("plan", models.SubscriptionPlan{}))},
+	{Method: "DELETE", Path: "/subscription-plan/:id", Tag: "Subscriptions", Summary: "Delete a plan", Access: Admin, Result: message},
+	{Method: "GET", Path: "/subscription/", Tag: "Subscriptions", Summary: "List subscriptions", Access: Admin,
+		Result: object(prop("subscriptions", []models.Subscription{}))},
+	{Method: "GET", Path: "/subscription/:user_id", Tag: "Subscriptions", Summary: "Get a user's current subscription", Access: Member,
+		Result: object(prop("subscription", models.Subscription{}))},
+	{Method: "GET", Path: "/subscription/user/:user_id", Tag: "Subscriptions", Summary: "List a user's subscriptions", Access: Admin,
+		Result: object(prop("subscriptions", []models.Subscription{}))},
+	{Method: "POST", Path: "/subscription/", Tag: "Subscriptions", Summary: "Subscribe to a plan", Access: Member,
+		Body: models.CreateSubscriptionRequest{}, Status: http.StatusCreated,
+		Result: object(prop("message", str), prop("subscription", models.Subscription{}))},
+	{Method: "DELETE", Path: "/subscription/:id", Tag: "Subscriptions", Summary: "Delete a subscription", Access: Admin, Result: message},
+
+	// Payments
+	{Method: "GET", Path: "/payments/search-payments", Tag: "Payments", Summary: "Search payments", Access: Finance,
+		Query: append([]Parameter{query("search", str, "Transaction or user"), query("email", str, "Payer email")}, pageQuery...),
+		Result: object(prop("payments", []models.DashboardPaymentResponse{}), prop("metadata", object(
+			prop("total", integer), prop("page", integer), prop("limit", integer), prop("totalPages", integer))))},
+	{Method: "GET", Path: "/payments/all-payments", Tag: "Payments", Summary: "List payments", Access: Finance, Query: pageQuery,
+		Result: object(prop("payments", []models.DashboardPaymentResponse{}), prop("metadata", object(
+			prop("total", integer), prop("page", integer), prop("limit", integer), prop("totalSales", number))))},
+	{Method: "GET", Path: "/payments/:id", Tag: "Payments", Summary: "Get a payment", Access: Member,
+		Result: object(prop("payment", gen.Payment{}))},
+	{Method: "GET", Path: "/payments/:id/receipt.pdf", Tag: "Payments", Summary: "Download a payment receipt", Access: Member,
+		Result: binary, Type: "application/pdf"},
+	{Method: "POST", Path: "/payments/payment", Tag: "Payments", Summary: "Start a Stripe checkout for a plan", Access: Member,
+		Body:   models.CreatePaymentRequest{},
+		Result: object(prop("message", str), prop("payment_id", uuidStr), prop("transaction_id", uuidStr), prop("redirect_url", str))},
+	{Method: "PATCH", Path: "/payments/payment/:id/status", Tag: "Payments", Summary: "Change a payment's status", Access: Admin,
+		Body: models.UpdatePaymentStatusRequest{}, Result: object(prop("payment", gen.Payment{}))},
+	{Method: "DELETE", Path: "/payments/payment/:id", Tag: "Payments", Summary: "Delete a payment", Access: Admin, Result: message},
+
+	// Stripe
+	{Method: "POST", Path: "/stripe/webhook", Tag: "Stripe", Summary: "Receive Stripe events",
+		Notes: "Verified with the Stripe-Signature header.", Body: anyValue},
+	{Method: "GET", Path: "/stripe/success", Tag: "Stripe", Summary: "Return from a paid checkout",
+		Notes: "Redirects to the frontend's success page.", Query: []Parameter{required(query("session_id", str, "Checkout session"))},
+		Status: http.StatusSeeOther},
+	{Method: "GET", Path: "/stripe/cancel", Tag: "Stripe", Summary: "Return from a cancelled checkout",
+		Notes: "Redirects to the frontend's cancel page.", Query: []Parameter{query("session_id", str, "Checkout session")},
+		Status: http.StatusSeeOther},
+
+	// Refunds
+	{Method: "GET", Path: "/refunds/:id", Tag: "Refunds", Summary: "Get a refund", Access: Member,
+		Result: object(prop("refund", models.Refund{}))},
+	{Method: "GET", Path: "/refunds/:id/credit-note.pdf", Tag: "Refunds", Summary: "Download a refund's credit note", Access: Member,
+		Result: binary, Type: "application/pdf"},
+	{Method: "GET", Path: "/refunds/payment/:payment_id", Tag: "Refunds", Summary: "List a payment's refunds", Access: Finance,
+		Result: object(prop("refunds", []gen.Refund{}))},
+	{Method: "GET", Path: "/refunds/status", Tag: "Refunds", Summary: "List refunds by status", Access: Finance,
+		Query:  []Parameter{required(query("status", enum("requested", "processed", "rejected"), ""))},
+		Result: object(prop("refunds", []gen.Refund{}))},
+	{Method: "POST", Path: "/refunds/", Tag: "Refunds", Summary: "Request a refund", Access: Member,
+		Body: models.CreateRefundRequest{}, Result: object(prop("message", str), prop("refund", models.Refund{}))},
+	{Method: "PATCH", Path: "/refunds/:id/status", Tag: "Refunds", Summary: "Process or reject a refund", Access: Admin,
+		Body: models.CreateRefundRequest{}, Result: object(prop("refund", models.Refund{}))},
+	{Method: "DELETE", Path: "/refunds/:id", Tag: "Refunds", Summary: "Delete a refund", Access: Admin, Result: message},
+
+	// Reports
+	{Method: "GET", Path: "/download/books", Tag: "Reports", Summary: "Download the catalogue", Access: Admin,
+		Query: append(reportQuery, query("genre", str, "Genre slug")), Result: binary, Type: reportTypes},
+	{Method: "GET", Path: "/download/users", Tag: "Reports", Summary: "Download users", Access: Admin,
+		Query:  append(reportQuery, query("role", str, "Role filter"), query("banned", boolean, "Only banned or only active users")),
+		Result: binary, Type: reportTypes},
+	{Method: "GET", Path: "/download/borrows", Tag: "Reports", Summary: "Download loans", Access: Admin,
+		Query: reportQuery, Result: binary, Type: reportTypes},
+	{Method: "GET", Path: "/download/reservations", Tag: "Reports", Summary: "Download reservations", Access: Admin,
+		Query: reportQuery, Result: binary, Type: reportTypes},
+	{Method: "GET", Path: "/download/audit-log", Tag: "Reports", Summary: "Download the audit log", Access: Admin,
+		Query: auditQuery(reportQuery[0]), Result: binary, Type: reportTypes},
+	{Method: "GET", Path: "/download/payments", Tag: "Reports", Summary: "Download payments", Access: Finance,
+		Query: reportQuery, Result: binary, Type: reportTypes},
+	{Method: "GET", Path: "/download/refunds", Tag: "Reports", Summary: "Download refunds", Access: Finance,
+		Query: reportQuery, Result: binary, Type: reportTypes},
+
+	// Exports
+	{Method: "POST", Path: "/exports", Tag: "Exports", Summary: "Queue a report export", Access: Finance,
+		Body: models.CreateExportRequest{}, Status: http.StatusAccepted, Result: models.ExportJobResponse{}},
+	{Method: "GET", Path: "/exports", Tag: "Exports", Summary: "List the caller's exports", Access: Finance, Query: pageQuery,
+		Result: page("exports", models.ExportJobResponse{})},
+	{Method: "GET", Path: "/exports/:id", Tag: "Exports", Summary: "Get an export", Access: Finance,
+		Notes: "Once the export has succeeded the response carries a fresh signed download link.", Result: models.ExportJobResponse{}},
+	{Method: "GET", Path: "/exports/:id/download", Tag: "Exports", Summary: "Download a finished export",
+		Notes:  "Authorised by the signature on the link, not a token.",
+		Query:  []Parameter{required(query("expires", str, "")), required(query("signature", str, ""))},
+		Result: binary, Type: reportTypes},
+
+	// Analytics
+	{Method: "GET", Path: "/overview", Tag: "Analytics", Summary: "Dashboard overview", Access: Admin, Result: models.OverviewResponse{}},
+	{Method: "GET", Path: "/analytics/borrows", Tag: "Analytics", Summary: "Loans per bucket", Access: Admin,
+		Query: analyticsQuery, Result: models.AnalyticsResponse{}},
+	{Method: "GET", Path: "/analytics/members", Tag: "Analytics", Summary: "New members per bucket", Access: Admin,
+		Query: analyticsQuery, Result: models.AnalyticsResponse{}},
+	{Method: "GET", Path: "/analytics/reservations", Tag: "Analytics", Summary: "Reservations per bucket", Access: Admin,
+		Query: analyticsQuery, Result: models.AnalyticsResponse{}},
+	{Method: "GET", Path: "/analytics/overdue", Tag: "Analytics", Summary: "Overdue loans per bucket of due date", Access: Admin,
+		Query: analyticsQuery, Result: models.AnalyticsResponse{}},
+	{Method: "GET", Path: "/analytics/revenue", Tag: "Analytics", Summary: "Revenue, refunds and net revenue per bucket", Access: Finance,
+		Query:  append(analyticsQuery, required(query("currency", str, "Currency to count, e.g. USD"))),
+		Result: models.AnalyticsResponse{}},
+	{Method: "GET", Path: "/analytics/collection/turnover", Tag: "Analytics", Summary: "Loans per copy by title or genre", Access: Admin,
+		Notes: "group=genre returns genres for the whole range; group=title pages through titles.",
+		Query: append(append([]Parameter{
+			query("group", enum("title", "genre"), "title by default"),
+			query("sort", enum("asc", "desc"), "Order titles by turnover"),
+		}, rangeQuery...), pageQuery...),
+		Result: object(prop("from", dateTime), prop("to", dateTime),
+			prop("genres", []models.GenreTurnover{}), prop("titles", []models.TitleTurnover{}),
+			prop("page", integer), prop("limit", integer), prop("count", integer), prop("total_count", integer), prop("total_pages", integer))},
+	{Method: "GET", Path: "/analytics/collection/dead-stock", Tag: "Analytics", Summary: "Titles not borrowed recently", Access: Admin,
+		Query:  append([]Parameter{query("months", integer, "Look-back window, 12 by default")}, pageQuery...),
+		Result: page("books", models.DeadStockBook{}, prop("months", integer), prop("cutoff", dateTime))},
+	{Method: "GET", Path: "/analytics/collection/holds", Tag: "Analytics", Summary: "Hold queue length and wait per title", Access: Admin,
+		Query:  append(append([]Parameter{}, rangeQuery...), pageQuery...),
+		Result: page("books", models.BookHoldStats{}, prop("from", dateTime), prop("to", dateTime))},
+	{Method: "GET", Path: "/analytics/collection/recommendations", Tag: "Analytics", Summary: "Titles whose hold queue outgrows their copies", Access: Admin,
+		Query:  []Parameter{query("limit", integer, "20 by default, at most 100")},
+		Result: object(prop("count", integer), prop("recommendations", []models.PurchaseRecommendation{}))},
+
+	// Admin
+	{Method: "GET", Path: "/list/data-paginated", Tag: "Admin", Summary: "List reservations or loans by status", Access: Admin,
+		Notes: "pending, notified, fulfilled and cancelled list reservations; borrowed_at, returned_at and not_returned list loans; " +
+			"user_name and book_title search loans for query.",
+		Query: append([]Parameter{
+			required(query("status", enum("pending", "notified", "fulfilled", "cancelled", "borrowed_at", "returned_at", "not_returned", "user_name", "book_title"), "")),
+			query("query", str, "Search term for user_name and book_title"),
+		}, pageQuery...),
+		Result: object(prop("reservations", []models.ReservationListResponse{}), prop("borrows", []models.BorrowResponse{}),
+			prop("page", integer), prop("limit", integer), prop("count", integer), prop("total_pages", integer))},
+	{Method: "GET", Path: "/admin/audit", Tag: "Admin", Summary: "List the audit log", Access: Admin,
+		Notes:  "With format set, downloads every matching row like /download/audit-log.",
+		Query:  append(auditQuery(query("format", enum("csv", "xlsx", "pdf"), "Download instead of paging")), pageQuery...),
+		Result: page("entries", models.AuditLogResponse{})},
+	{Method: "POST", Path: "/admin/announcements", Tag: "Admin", Summary: "Create an announcement", Access: Admin,
+		Body: models.CreateAnnouncementRequest{}, Status: http.StatusCreated, Result: models.AnnouncementResponse{}},
+	{Method: "GET", Path: "/admin/announcements", Tag: "Admin", Summary: "List announcements", Access: Admin,
+		Query:  append([]Parameter{query("status", enum("scheduled", "published", "cancelled", "expired"), "")}, pageQuery...),
+		Result: page("announcements", models.AnnouncementResponse{})},
+	{Method: "GET", Path: "/admin/announcements/:id", Tag: "Admin", Summary: "Get an announcement", Access: Admin,
+		Result: models.AnnouncementResponse{}},
+	{Method: "PATCH", Path: "/admin/announcements/:id", Tag: "Admin", Summary: "Update a scheduled announcement", Access: Admin,
+		Body: models.UpdateAnnouncementRequest{}, Result: models.AnnouncementResponse{}},
+	{Method: "POST", Path: "/admin/announcements/:id/cancel", Tag: "Admin", Summary: "Cancel a scheduled announcement", Access: Admin,
+		Result: models.AnnouncementResponse{}},
+	{Method: "GET", Path: "/admin/support/tickets", Tag: "Admin", Summary: "List support tickets", Access: Admin,
+		Query: append([]Parameter{
+			query("status", enum("open", "pending", "resolved", "closed"), ""),
+			query("category", enum("general", "ban_appeal", "billing", "catalog"), ""),
+			query("priority", enum("low", "normal", "high", "urgent"), ""),
+			query("assigned_to", uuidStr, "Staff member"),
+		}, pageQuery...),
+		Result: page("tickets", models.SupportTicketResponse{})},
+	{Method: "GET", Path: "/admin/support/tickets/:id", Tag: "Admin", Summary: "Get a support ticket", Access: Admin,
+		Result: models.SupportTicketResponse{}},
+	{Method: "PATCH", Path: "/admin/support/tickets/:id", Tag: "Admin", Summary: "Triage a support ticket", Access: Admin,
+		Body: models.UpdateSupportTicketRequest{}, Result: models.SupportTicketResponse{}},
+	{Method: "POST", Path: "/admin/support/tickets/:id/messages", Tag: "Admin", Summary: "Reply to a support ticket", Access: Admin,
+		Body: models.SupportReplyRequest{}, Status: http.StatusCreated, Result: models.SupportTicketResponse{}},
+}
+
+var (
+	includeDeleted  = query("include_deleted", boolean, "Include soft-deleted rows (admins only)")
+	marcFormat      = query("format", enum("marcxml", "marc"), "marcxml by default")
+	marcImportQuery = []Parameter{
+		query("format", enum("marcxml", "marc"), "Format of the upload; detected when unset"),
+		query("copies", integer, "Copies of each imported book, 1 by default"),
+	}
+)
+
+// auditQuery is the audit log's filters, after the given format parameter
+func auditQuery(format Parameter) []Parameter {
+	return []Parameter{
+		format,
+		query("actor_id", uuidStr, "Who made the change"),
+		query("action", str, "e.g. book.update"),
+		query("target_type", str, "e.g. book"),
+		query("target_id", str, ""),
+		query("from", str, "Start date, RFC3339 or YYYY-MM-DD"),
+		query("to", str, "End date, RFC3339 or YYYY-MM-DD"),
+	}
+}
+
+func query(name string, s *Schema, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: s}
+}
+
+func required(p Parameter) Parameter {
+	p.Required = true
+	return p
+}
+
+func enum(values ...any) *Schema {
+	return &Schema{Type: "string", Enum: values}
+}
+
+type property struct {
+	name   string
+	schema *Schema
+}
+
+// prop is an object property; v is a *Schema or a Go value to reflect
+func prop(name string, v any) property {
+	if s, ok := v.(*Schema); ok {
+		return property{name, s}
+	}
+	return property{name, &Schema{Type: goType{reflect.TypeOf(v)}}}
+}
+
+// object is a gin.H response with the given keys
+func object(props ...property) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, p := range props {
+		s.Properties[p.name] = p.schema
+	}
+	return s
+}
+
+// page is the envelope paginated list handlers return, with the items
+// under key
+func page(key string, item any, extra ...property) *Schema {
+	props := append([]property{
+		prop("page", integer),
+		prop("limit", integer),
+		prop("count", integer),
+		prop("total_count", integer),
+		prop("total_pages", integer),
+		prop(key, reflect.New(reflect.SliceOf(reflect.TypeOf(item))).Elem().Interface()),
+	}, extra...)
+	return object(props...)
+}
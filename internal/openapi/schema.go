@@ -0,0 +1,270 @@
+package openapi
+
+import (
+	"encoding/json"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// registry reflects Go types into schemas. Named structs become components
+// under #/components/schemas and are referenced from everywhere else.
+type registry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newRegistry() *registry {
+	return &registry{schemas: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	uuidType      = reflect.TypeOf(uuid.UUID{})
+	rawJSONType   = reflect.TypeOf(json.RawMessage{})
+	fileType      = reflect.TypeOf(multipart.FileHeader{})
+	schemaPtrType = reflect.TypeOf(&Schema{})
+)
+
+// pgtypes marshal as their value, or null when not valid
+var pgtypes = map[reflect.Type]Schema{
+	reflect.TypeOf(pgtype.UUID{}):        {Type: "string", Format: "uuid"},
+	reflect.TypeOf(pgtype.Text{}):        {Type: "string"},
+	reflect.TypeOf(pgtype.Timestamp{}):   {Type: "string", Format: "date-time"},
+	reflect.TypeOf(pgtype.Timestamptz{}): {Type: "string", Format: "date-time"},
+	reflect.TypeOf(pgtype.Date{}):        {Type: "string", Format: "date"},
+	reflect.TypeOf(pgtype.Int4{}):        {Type: "integer", Format: "int32"},
+	reflect.TypeOf(pgtype.Int8{}):        {Type: "integer", Format: "int64"},
+	reflect.TypeOf(pgtype.Float8{}):      {Type: "number", Format: "double"},
+	reflect.TypeOf(pgtype.Bool{}):        {Type: "boolean"},
+}
+
+// schema returns the schema for v: a *Schema is used as is (with any Go
+// values inside it reflected), anything else is reflected from its type
+func (r *registry) schema(v any) *Schema {
+	if s, ok := v.(*Schema); ok {
+		return r.resolve(s)
+	}
+	return r.typeSchema(reflect.TypeOf(v))
+}
+
+// resolve reflects the Go values placed in a hand-written schema by the
+// helpers in routes.go
+func (r *registry) resolve(s *Schema) *Schema {
+	if s == nil {
+		return nil
+	}
+	if goValue, ok := s.Type.(goType); ok {
+		return r.typeSchema(goValue.t)
+	}
+	out := *s
+	if s.Properties != nil {
+		out.Properties = make(map[string]*Schema, len(s.Properties))
+		for name, p := range s.Properties {
+			out.Properties[name] = r.resolve(p)
+		}
+	}
+	out.Items = r.resolve(s.Items)
+	out.AdditionalProperties = r.resolve(s.AdditionalProperties)
+	return &out
+}
+
+func (r *registry) typeSchema(t reflect.Type) *Schema {
+	if t == nil || t == schemaPtrType {
+		return &Schema{}
+	}
+	if t.Kind() == reflect.Pointer {
+		return nullable(r.typeSchema(t.Elem()))
+	}
+	if s, ok := pgtypes[t]; ok {
+		s.Type = []string{s.Type.(string), "null"}
+		return &s
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case rawJSONType:
+		return &Schema{}
+	case fileType:
+		return &Schema{Type: "string", Format: "binary"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.typeSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.typeSchema(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		return r.structRef(t)
+	}
+	return &Schema{}
+}
+
+// structRef registers a named struct as a component and refers to it
+func (r *registry) structRef(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return r.structSchema(t)
+	}
+	name, ok := r.names[t]
+	if !ok {
+		name = componentName(t)
+		r.names[t] = name
+		r.schemas[name] = &Schema{} // placeholder, for self-referencing types
+		r.schemas[name] = r.structSchema(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// componentName is the type name for models and the error model, and
+// pkg.Name for the rest, so models.Payment and gen.Payment do not collide
+func componentName(t reflect.Type) string {
+	pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+	if pkg == "models" || pkg == "apierror" {
+		return t.Name()
+	}
+	return pkg + "." + t.Name()
+}
+
+func (r *registry) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	r.addFields(s, t)
+	return s
+}
+
+func (r *registry) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, omitempty, ok := fieldName(f)
+		if !ok {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				r.addFields(s, ft)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := r.typeSchema(f.Type)
+		if applyBinding(prop, f.Tag.Get("binding")) && !omitempty {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+}
+
+// fieldName reads the JSON name, falling back to the form name for fields
+// that are only ever bound from multipart forms
+func fieldName(f reflect.StructField) (name string, omitempty, ok bool) {
+	tag, hasJSON := f.Tag.Lookup("json")
+	if !hasJSON {
+		tag = f.Tag.Get("form")
+	}
+	if tag == "-" {
+		return "", false, false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	return name, strings.Contains(opts, "omitempty"), true
+}
+
+// applyBinding copies gin's validation rules onto the schema and reports
+// whether the field is required
+func applyBinding(s *Schema, binding string) (required bool) {
+	target := s
+	if len(s.AnyOf) > 0 {
+		target = s.AnyOf[0]
+	}
+	for _, rule := range strings.Split(binding, ",") {
+		key, arg, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "email":
+			target.Format = "email"
+		case "oneof":
+			for _, v := range strings.Fields(arg) {
+				target.Enum = append(target.Enum, v)
+			}
+		case "min", "max":
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				continue
+			}
+			bound := float64(n)
+			switch {
+			case isType(target, "string") && key == "min":
+				target.MinLength = &n
+			case isType(target, "string"):
+				target.MaxLength = &n
+			case isType(target, "integer") || isType(target, "number"):
+				if key == "min" {
+					target.Minimum = &bound
+				} else {
+					target.Maximum = &bound
+				}
+			}
+		}
+	}
+	return required
+}
+
+func isType(s *Schema, name string) bool {
+	switch t := s.Type.(type) {
+	case string:
+		return t == name
+	case []string:
+		return len(t) > 0 && t[0] == name
+	}
+	return false
+}
+
+// nullable allows null as well as s
+func nullable(s *Schema) *Schema {
+	switch t := s.Type.(type) {
+	case string:
+		s.Type = []string{t, "null"}
+		return s
+	case []string:
+		return s
+	}
+	if s.Ref == "" && s.Type == nil {
+		return s // already accepts anything
+	}
+	return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+}
+
+// goType marks a place in a hand-written schema that is filled in by
+// reflecting a Go type
+type goType struct{ t reflect.Type }
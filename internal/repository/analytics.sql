@@ -0,0 +1,162 @@
+-- Every analytics query buckets rows with date_trunc in the caller's time
+-- zone. from_time and to_time are UTC, to_time exclusive; bucket is day, week
+-- or month. Buckets with no rows come back as zeroes.
+
+-- name: AnalyticsBorrows :many
+WITH buckets AS (
+    SELECT generate_series(
+        date_trunc(sqlc.arg(bucket)::text, (sqlc.arg(from_time)::timestamp AT TIME ZONE 'UTC') AT TIME ZONE sqlc.arg(tz)::text),
+        ((sqlc.arg(to_time)::timestamp AT TIME ZONE 'UTC') AT TIME ZONE sqlc.arg(tz)::text) - INTERVAL '1 microsecond',
+        ('1 ' || sqlc.arg(bucket)::text)::interval
+    ) AS bucket_start
+),
+borrowed AS (
+    SELECT date_trunc(sqlc.arg(bucket)::text, (borrowed_at AT TIME ZONE 'UTC') AT TIME ZONE sqlc.arg(tz)::text) AS bucket_start,
+           COUNT(*) AS n
+    FROM borrows
+    WHERE borrowed_at >= sqlc.arg(from_time)::timestamp AND borrowed_at < sqlc.arg(to_time)::timestamp
+    GROUP BY 1
+),
+returned AS (
+    SELECT date_trunc(sqlc.arg(bucket)::text, (returned_at AT TIME ZONE 'UTC') AT TIME ZONE sqlc.arg(tz)::text) AS bucket_start,
+           COUNT(*) AS n
+    FROM borrows
+    WHERE returned_at >= sqlc.arg(from_time)::timestamp AND returned_at < sqlc.arg(to_time)::timestamp
+    GROUP BY 1
+)
+SELECT b.bucket_start::timestamp AS bucket_start,
+       COALESCE(bo.n, 0)::bigint AS borrows,
+       COALESCE(r.n, 0)::bigint AS returns
+FROM buckets b
+LEFT JOIN borrowed bo ON bo.bucket_start = b.bucket_start
+LEFT JOIN returned r ON r.bucket_start = b.bucket_start
+ORDER BY b.bucket_start;
+
+-- name: AnalyticsNewMembers :many
+WITH buckets AS (
+    SELECT generate_series(
+        date_trunc(sqlc.arg(bucket)::text, (sqlc.arg(from_time)::timestamp AT TIME ZONE 'UTC') AT TIME ZONE sqlc.arg(tz)::text),
+        ((sqlc.arg(to_time)::timestamp AT TIME ZONE 'UTC') AT TIME ZONE sqlc.arg(tz)::text) - INTERVAL '1 microsecond',
+        ('1 ' || sqlc.arg(bucket)::text)::interval
+    ) AS bucket_start
+),
+joined AS (
+    SELECT date_trunc(sqlc.arg(bucket)::text, (created_at AT TIME ZONE 'UTC') AT TIME ZONE sqlc.arg(tz)::text) AS bucket_start,
+           COUNT(*) AS n
+    FROM users
+    WHERE role = 'member'
+      AND created_at >= sqlc.arg(from_time)::timestamp AND created_at < sqlc.arg(to_time)::timestamp
+    GROUP BY 1
+)
+SELECT b.bucket_start::timestamp AS bucket_start,
+       COALESCE(j.n, 0)::bigint AS new_members
+FROM buckets b
+LEFT JOIN joined j ON j.bucket_start = b.bucket_start
+ORDER BY b.bucket_start;
+
+-- name: AnalyticsOverdue :many
+-- Borrows are bucketed by due date. Only loans already due count; a loan is
+-- late if it came back after its due date or is still out.
+WITH buckets AS (
+    SELECT generate_series(
+        date_trunc(sqlc.arg(bucket)::text, (sqlc.arg(from_time)::timestamp AT TIME ZONE 'UTC') AT TIME ZONE sqlc.arg(tz)::text),
+        ((sqlc.arg(to_time)::timestamp AT TIME ZONE 'UTC') AT TIME ZONE sqlc.arg(tz)::text) - INTERVAL '1 microsecond',
+        ('1 ' || sqlc.arg(bucket)::text)::interval
+    ) AS bucket_start
+),
+due AS (
+    SELECT date_trunc(sqlc.arg(bucket)::text, (due_date AT TIME ZONE 'UTC') AT TIME ZONE sqlc.arg(tz)::text) AS bucket_start,
+           COUNT(*) AS due,
+           COUNT(*) FILTER (WHERE returned_at IS NULL OR returned_at > due_date) AS late
+    FROM borrows
+    WHERE due_date >= sqlc.arg(from_time)::timestamp AND due_date < sqlc.arg(to_time)::timestamp
+      AND due_date <= NOW()
+    GROUP BY 1
+)
+SELECT b.bucket_start::timestamp AS bucket_start,
+       COALESCE(d.due, 0)::bigint AS due,
+       COALESCE(d.late, 0)::bigint AS late
+FROM buckets b
+LEFT JOIN due d ON d.bucket_start = b.bucket_start
+ORDER BY b.bucket_start;
+
+-- name: AnalyticsReservations :many
+-- Reservation timestamps are timestamptz, so they convert to the zone directly.
+WITH buckets AS (
+    SELECT generate_series(
+        date_trunc(sqlc.arg(bucket)::text, (sqlc.arg(from_time)::timestamp AT TIME ZONE 'UTC') AT TIME ZONE sqlc.arg(tz)::text),
+        ((sqlc.arg(to_time)::timestamp AT TIME ZONE 'UTC') AT TIME ZONE sqlc.arg(tz)::text) - INTERVAL '1 microsecond',
+        ('1 ' || sqlc.arg(bucket)::text)::interval
+    ) AS bucket_start
+),
+created AS (
+    SELECT date_trunc(sqlc.arg(bucket)::text, created_at AT TIME ZONE sqlc.arg(tz)::text) AS bucket_start,
+           COUNT(*) AS n
+    FROM reservations
+    WHERE created_at >= (sqlc.arg(from_time)::timestamp AT TIME ZONE 'UTC')
+      AND created_at < (sqlc.arg(to_time)::timestamp AT TIME ZONE 'UTC')
+    GROUP BY 1
+),
+fulfilled AS (
+    SELECT date_trunc(sqlc.arg(bucket)::text, fulfilled_at AT TIME ZONE sqlc.arg(tz)::text) AS bucket_start,
+           COUNT(*) AS n
+    FROM reservations
+    WHERE fulfilled_at >= (sqlc.arg(from_time)::timestamp AT TIME ZONE 'UTC')
+      AND fulfilled_at < (sqlc.arg(to_time)::timestamp AT TIME ZONE 'UTC')
+    GROUP BY 1
+),
+cancelled AS (
+    SELECT date_trunc(sqlc.arg(bucket)::text, cancelled_at AT TIME ZONE sqlc.arg(tz)::text) AS bucket_start,
+           COUNT(*) AS n
+    FROM reservations
+    WHERE cancelled_at >= (sqlc.arg(from_time)::timestamp AT TIME ZONE 'UTC')
+      AND cancelled_at < (sqlc.arg(to_time)::timestamp AT TIME ZONE 'UTC')
+    GROUP BY 1
+)
+SELECT b.bucket_start::timestamp AS bucket_start,
+       COALESCE(c.n, 0)::bigint AS created,
+       COALESCE(f.n, 0)::bigint AS fulfilled,
+       COALESCE(x.n, 0)::bigint AS cancelled
+FROM buckets b
+LEFT JOIN created c ON c.bucket_start = b.bucket_start
+LEFT JOIN fulfilled f ON f.bucket_start = b.bucket_start
+LEFT JOIN cancelled x ON x.bucket_start = b.bucket_start
+ORDER BY b.bucket_start;
+
+-- name: AnalyticsRevenue :many
+-- Gross is paid payments by payment date, refunds are processed refunds by
+-- the date they were processed, both in the one currency given.
+WITH buckets AS (
+    SELECT generate_series(
+        date_trunc(sqlc.arg(bucket)::text, (sqlc.arg(from_time)::timestamp AT TIME ZONE 'UTC') AT TIME ZONE sqlc.arg(tz)::text),
+        ((sqlc.arg(to_time)::timestamp AT TIME ZONE 'UTC') AT TIME ZONE sqlc.arg(tz)::text) - INTERVAL '1 microsecond',
+        ('1 ' || sqlc.arg(bucket)::text)::interval
+    ) AS bucket_start
+),
+paid AS (
+    SELECT date_trunc(sqlc.arg(bucket)::text, (created_at AT TIME ZONE 'UTC') AT TIME ZONE sqlc.arg(tz)::text) AS bucket_start,
+           SUM(amount) AS amount
+    FROM payments
+    WHERE status = 'paid'
+      AND created_at >= sqlc.arg(from_time)::timestamp AND created_at < sqlc.arg(to_time)::timestamp
+      AND upper(currency) = upper(sqlc.arg(currency)::text)
+    GROUP BY 1
+),
+refunded AS (
+    SELECT date_trunc(sqlc.arg(bucket)::text, (r.processed_at AT TIME ZONE 'UTC') AT TIME ZONE sqlc.arg(tz)::text) AS bucket_start,
+           SUM(r.amount) AS amount
+    FROM refunds r
+    JOIN payments p ON p.id = r.payment_id
+    WHERE r.status = 'processed'
+      AND r.processed_at >= sqlc.arg(from_time)::timestamp AND r.processed_at < sqlc.arg(to_time)::timestamp
+      AND upper(p.currency) = upper(sqlc.arg(currency)::text)
+    GROUP BY 1
+)
+SELECT b.bucket_start::timestamp AS bucket_start,
+       COALESCE(p.amount, 0)::float8 AS gross,
+       COALESCE(r.amount, 0)::float8 AS refunds,
+       (COALESCE(p.amount, 0) - COALESCE(r.amount, 0))::float8 AS net
+FROM buckets b
+LEFT JOIN paid p ON p.bucket_start = b.bucket_start
+LEFT JOIN refunded r ON r.bucket_start = b.bucket_start
+ORDER BY b.bucket_start;
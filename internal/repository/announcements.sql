@@ -0,0 +1,106 @@
+-- name: CreateAnnouncement :one
+INSERT INTO announcements (
+    title,
+    message,
+    audience,
+    audience_role,
+    user_ids,
+    priority,
+    publish_at,
+    expires_at,
+    created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: GetAnnouncementByID :one
+SELECT * FROM announcements
+WHERE id = $1;
+
+-- name: ListAnnouncements :many
+SELECT * FROM announcements
+WHERE (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+ORDER BY publish_at DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountAnnouncements :one
+SELECT COUNT(*) FROM announcements
+WHERE ($1::text IS NULL OR status = $1);
+
+-- name: UpdateAnnouncement :one
+-- Only scheduled announcements can be edited.
+UPDATE announcements
+SET title = $2,
+    message = $3,
+    audience = $4,
+    audience_role = $5,
+    user_ids = $6,
+    priority = $7,
+    publish_at = $8,
+    expires_at = $9,
+    updated_at = NOW()
+WHERE id = $1 AND status = 'scheduled'
+RETURNING *;
+
+-- name: CancelAnnouncement :one
+UPDATE announcements
+SET status = 'cancelled', updated_at = NOW()
+WHERE id = $1 AND status = 'scheduled'
+RETURNING *;
+
+-- name: ClaimDueAnnouncement :one
+-- Marks the next due announcement not in skip published and returns it. Run
+-- it in the transaction that delivers the announcement, so a failed delivery
+-- undoes the claim. SKIP LOCKED lets several instances run the scheduler
+-- without publishing twice.
+UPDATE announcements
+SET status = 'published', published_at = NOW(), updated_at = NOW()
+WHERE id = (
+    SELECT id FROM announcements
+    WHERE status = 'scheduled'
+      AND publish_at <= NOW()
+      AND (expires_at IS NULL OR expires_at > NOW())
+      AND id <> ALL(COALESCE(sqlc.arg(skip)::uuid[], '{}'))
+    ORDER BY publish_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ExpireAnnouncements :execrows
+-- Scheduled announcements whose expiry passed before they could go out.
+UPDATE announcements
+SET status = 'expired', updated_at = NOW()
+WHERE status = 'scheduled'
+  AND expires_at IS NOT NULL
+  AND expires_at <= NOW();
+
+-- name: SetAnnouncementRecipientCount :exec
+UPDATE announcements
+SET recipient_count = $2
+WHERE id = $1;
+
+-- name: ListUserIDsByRole :many
+SELECT id FROM users
+WHERE role = $1 AND deleted_at IS NULL;
+
+-- name: ListUserIDsWithActivePlan :many
+SELECT DISTINCT s.user_id
+FROM subscriptions s
+JOIN users u ON u.id = s.user_id
+WHERE s.status = 'active'
+  AND s.end_date > NOW()
+  AND u.deleted_at IS NULL;
+
+-- name: ListUserIDsWithOverdueBorrows :many
+SELECT DISTINCT b.user_id
+FROM borrows b
+JOIN users u ON u.id = b.user_id
+WHERE b.returned_at IS NULL
+  AND b.due_date < NOW()
+  AND u.deleted_at IS NULL;
+
+-- name: ListExistingUserIDs :many
+SELECT id FROM users
+WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL;
@@ -0,0 +1,28 @@
+-- name: CreateAuditLog :exec
+INSERT INTO audit_log (actor_id, action, target_type, target_id, before, after, ip, request_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: ListAuditLog :many
+SELECT a.id, a.actor_id, a.action, a.target_type, a.target_id, a.before, a.after,
+       a.ip, a.request_id, a.created_at,
+       COALESCE(u.email, '')::text AS actor_email
+FROM audit_log a
+LEFT JOIN users u ON u.id = a.actor_id
+WHERE (sqlc.narg(actor_id)::uuid IS NULL OR a.actor_id = sqlc.narg(actor_id))
+  AND (sqlc.narg(action)::text IS NULL OR a.action = sqlc.narg(action))
+  AND (sqlc.narg(target_type)::text IS NULL OR a.target_type = sqlc.narg(target_type))
+  AND (sqlc.narg(target_id)::text IS NULL OR a.target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(from_time)::timestamp IS NULL OR a.created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamp IS NULL OR a.created_at < sqlc.narg(to_time))
+ORDER BY a.created_at DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountAuditLog :one
+SELECT COUNT(*)
+FROM audit_log a
+WHERE (sqlc.narg(actor_id)::uuid IS NULL OR a.actor_id = sqlc.narg(actor_id))
+  AND (sqlc.narg(action)::text IS NULL OR a.action = sqlc.narg(action))
+  AND (sqlc.narg(target_type)::text IS NULL OR a.target_type = sqlc.narg(target_type))
+  AND (sqlc.narg(target_id)::text IS NULL OR a.target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(from_time)::timestamp IS NULL OR a.created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamp IS NULL OR a.created_at < sqlc.narg(to_time));
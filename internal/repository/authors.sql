@@ -0,0 +1,91 @@
+-- name: ListAuthors :many
+SELECT
+    a.id,
+    a.name,
+    a.sort_name,
+    a.bio,
+    a.created_at,
+    a.updated_at,
+    (SELECT COUNT(*) FROM book_authors ba WHERE ba.author_id = a.id) AS book_count
+FROM authors a
+WHERE ($1::text = '' OR a.name ILIKE '%' || $1 || '%')
+ORDER BY COALESCE(NULLIF(a.sort_name, ''), a.name)
+LIMIT $2 OFFSET $3;
+
+-- name: CountAuthors :one
+SELECT COUNT(*) FROM authors
+WHERE ($1::text = '' OR name ILIKE '%' || $1 || '%');
+
+-- name: GetAuthorByID :one
+SELECT * FROM authors
+WHERE id = $1;
+
+-- name: CreateAuthor :one
+INSERT INTO authors (name, sort_name, bio)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: UpsertAuthorByName :one
+INSERT INTO authors (name, sort_name)
+VALUES ($1, $1)
+ON CONFLICT ((LOWER(name))) DO UPDATE SET name = authors.name
+RETURNING *;
+
+-- name: UpdateAuthorByID :one
+UPDATE authors
+SET
+    name = COALESCE(sqlc.narg('name'), name),
+    sort_name = COALESCE(sqlc.narg('sort_name'), sort_name),
+    bio = COALESCE(sqlc.narg('bio'), bio),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: ListBooksByAuthorID :many
+SELECT * FROM books
+WHERE id IN (SELECT book_id FROM book_authors WHERE author_id = $1)
+  AND deleted_at IS NULL
+ORDER BY published_year DESC NULLS LAST, title
+LIMIT $2 OFFSET $3;
+
+-- name: CountBooksByAuthorID :one
+SELECT COUNT(DISTINCT ba.book_id)
+FROM book_authors ba
+JOIN books b ON b.id = ba.book_id
+WHERE ba.author_id = $1 AND b.deleted_at IS NULL;
+
+-- name: ListAuthorsByBookID :many
+SELECT
+    a.id,
+    a.name,
+    ba.role,
+    ba.position
+FROM book_authors ba
+JOIN authors a ON a.id = ba.author_id
+WHERE ba.book_id = $1
+ORDER BY ba.position, a.name;
+
+-- name: AddBookAuthor :exec
+INSERT INTO book_authors (book_id, author_id, role, position)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (book_id, author_id, role) DO UPDATE SET position = EXCLUDED.position;
+
+-- name: DeleteBookAuthors :exec
+DELETE FROM book_authors
+WHERE book_id = $1;
+
+-- name: DeleteBookAuthorsByRole :exec
+DELETE FROM book_authors
+WHERE book_id = $1 AND role = $2;
+
+-- name: SyncBookAuthorColumn :exec
+UPDATE books
+SET author = LEFT(sub.names, 100),
+    updated_at = NOW()
+FROM (
+    SELECT string_agg(a.name, '; ' ORDER BY ba.position, a.name) AS names
+    FROM book_authors ba
+    JOIN authors a ON a.id = ba.author_id
+    WHERE ba.book_id = $1 AND ba.role = 'author'
+) sub
+WHERE books.id = $1 AND sub.names IS NOT NULL;
@@ -1,6 +1,7 @@
 -- name: ListBooksPaginated :many
 SELECT *
 FROM books
+WHERE ($3::boolean OR deleted_at IS NULL)
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2;
 
@@ -18,18 +19,57 @@ LIMIT $3 OFFSET $4;
 SELECT * FROM books
 WHERE id = $1;
 
+-- name: GetBookByIDForUpdate :one
+-- Locks a book that is not deleted until the transaction ends.
+SELECT * FROM books
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: GetBookByISBN :one
+SELECT * FROM books
+WHERE isbn = $1;
+
+-- name: ListAllBooks :many
+SELECT * FROM books
+WHERE deleted_at IS NULL
+ORDER BY created_at DESC;
+
 -- name: FilterBooksByGenre :many
+-- $1 is a genre slug; books filed under a child genre match its parent too.
 SELECT * FROM books
-WHERE ($1 = 'all' OR genre = $1)
+WHERE (
+    $1::text = 'all'
+    OR EXISTS (
+        SELECT 1
+        FROM book_genres bg
+        JOIN genres g ON g.id = bg.genre_id
+        LEFT JOIN genres p ON p.id = g.parent_id
+        WHERE bg.book_id = books.id
+          AND (g.slug = $1::text OR p.slug = $1::text)
+    )
+)
+AND deleted_at IS NULL
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3;
 
 -- name: CountBooksByGenre :one
 SELECT COUNT(*) FROM books
-WHERE ($1 = 'all' OR genre = $1);
+WHERE (
+    $1::text = 'all'
+    OR EXISTS (
+        SELECT 1
+        FROM book_genres bg
+        JOIN genres g ON g.id = bg.genre_id
+        LEFT JOIN genres p ON p.id = g.parent_id
+        WHERE bg.book_id = books.id
+          AND (g.slug = $1::text OR p.slug = $1::text)
+    )
+)
+AND deleted_at IS NULL;
 
 -- name: CountBooks :one
-SELECT COUNT(*) FROM books;
+SELECT COUNT(*) FROM books
+WHERE ($1::boolean OR deleted_at IS NULL);
 
 -- name: CreateBook :one
 INSERT INTO books (title, author, published_year, isbn, total_copies, image_url, genre, description)
@@ -57,7 +97,7 @@ RETURNING *;
 -- name: DecrementAvailableCopiesByID :one
 UPDATE books
 SET available_copies = available_copies - 1
-WHERE id = $1 AND available_copies > 0
+WHERE id = $1 AND available_copies > 0 AND deleted_at IS NULL
 RETURNING available_copies;
 
 -- name: IncrementAvailableCopiesByID :one
@@ -66,9 +106,24 @@ SET available_copies = available_copies + 1
 WHERE id = $1
 RETURNING available_copies;
 
--- name: DeleteBookByID :one
+-- name: SoftDeleteBookByID :one
+UPDATE books
+SET deleted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: RestoreBookByID :one
+UPDATE books
+SET deleted_at = NULL,
+    updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: PurgeBookByID :one
+-- Permanently removes a soft-deleted book together with its history.
 DELETE FROM books
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NOT NULL
 RETURNING *;
 -- name: SearchBooks :many
 SELECT
@@ -88,6 +143,7 @@ FROM books
 WHERE
     ($1::text IS NULL OR genre ILIKE '%' || $1 || '%')
     AND ($2::text IS NULL OR title ILIKE '%' || $2 || '%' OR author ILIKE '%' || $2 || '%')
+    AND deleted_at IS NULL
 ORDER BY title
 LIMIT $3
 OFFSET $4;
@@ -110,14 +166,33 @@ WHERE
     (
         $1::text = ''
         OR $1::text = 'all'
-        OR genre ILIKE '%' || $1 || '%'
+        OR EXISTS (
+            SELECT 1
+            FROM book_genres bg
+            JOIN genres g ON g.id = bg.genre_id
+            LEFT JOIN genres p ON p.id = g.parent_id
+            WHERE bg.book_id = books.id
+              AND (g.slug = $1::text OR p.slug = $1::text)
+        )
     )
     AND (
         $2::text = ''
         OR title ILIKE '%' || $2 || '%'
-        OR author ILIKE '%' || $2 || '%'
         OR description ILIKE '%' || $2 || '%'
+        OR EXISTS (
+            SELECT 1
+            FROM book_authors ba
+            JOIN authors a ON a.id = ba.author_id
+            WHERE ba.book_id = books.id AND a.name ILIKE '%' || $2 || '%'
+        )
+        OR EXISTS (
+            SELECT 1
+            FROM book_series bs
+            JOIN series s ON s.id = bs.series_id
+            WHERE bs.book_id = books.id AND s.name ILIKE '%' || $2 || '%'
+        )
     )
+    AND deleted_at IS NULL
 ORDER BY title
 LIMIT $3
 OFFSET $4;
@@ -128,17 +203,36 @@ WHERE
     (
         $1::text = ''
         OR $1::text = 'all'
-        OR genre ILIKE '%' || $1 || '%'
+        OR EXISTS (
+            SELECT 1
+            FROM book_genres bg
+            JOIN genres g ON g.id = bg.genre_id
+            LEFT JOIN genres p ON p.id = g.parent_id
+            WHERE bg.book_id = books.id
+              AND (g.slug = $1::text OR p.slug = $1::text)
+        )
     )
     AND (
         $2::text = ''
         OR title ILIKE '%' || $2 || '%'
-        OR author ILIKE '%' || $2 || '%'
         OR description ILIKE '%' || $2 || '%'
-    );
+        OR EXISTS (
+            SELECT 1
+            FROM book_authors ba
+            JOIN authors a ON a.id = ba.author_id
+            WHERE ba.book_id = books.id AND a.name ILIKE '%' || $2 || '%'
+        )
+        OR EXISTS (
+            SELECT 1
+            FROM book_series bs
+            JOIN series s ON s.id = bs.series_id
+            WHERE bs.book_id = books.id AND s.name ILIKE '%' || $2 || '%'
+        )
+    )
+    AND deleted_at IS NULL;
 -- name: ListGenres :many
-SELECT DISTINCT genre
-FROM books
-WHERE genre IS NOT NULL AND genre <> ''
-ORDER BY genre;
+SELECT g.name
+FROM genres g
+WHERE EXISTS (SELECT 1 FROM book_genres bg WHERE bg.genre_id = g.id)
+ORDER BY g.name;
 
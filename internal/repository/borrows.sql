@@ -112,6 +112,12 @@ FROM borrows
 WHERE user_id = $1
 AND returned_at IS NULL;
 
+-- name: CountActiveBorrowsByBookID :one
+SELECT COUNT(*)
+FROM borrows
+WHERE book_id = $1
+AND returned_at IS NULL;
+
 -- name: SearchBorrowsWithPagination :many
 SELECT 
     b.id, 
@@ -141,3 +147,52 @@ JOIN users u ON b.user_id = u.id
 WHERE 
     ($1 = 'user_name' AND LOWER(u.first_name || ' ' || u.last_name) LIKE LOWER('%' || $2 || '%'))
     OR ($1 = 'book_title' AND LOWER(bk.title) LIKE LOWER('%' || $2 || '%'));
+
+-- name: ListBorrowsDueSoon :many
+-- Open borrows due before the cutoff that have not been reminded yet.
+SELECT
+    b.id,
+    b.user_id,
+    b.book_id,
+    b.due_date,
+    bk.title
+FROM borrows b
+JOIN books bk ON bk.id = b.book_id
+LEFT JOIN borrow_due_reminders r ON r.borrow_id = b.id
+WHERE b.returned_at IS NULL
+  AND b.due_date IS NOT NULL
+  AND b.due_date > NOW()
+  AND b.due_date <= sqlc.arg(due_before)::timestamp
+  AND r.borrow_id IS NULL
+ORDER BY b.due_date;
+
+-- name: ClaimBorrowDueReminder :one
+-- Records the reminder for a borrow; no row comes back when it already has one.
+INSERT INTO borrow_due_reminders (borrow_id)
+VALUES ($1)
+ON CONFLICT (borrow_id) DO NOTHING
+RETURNING borrow_id;
+
+-- name: ListOverdueBorrowsToNotify :many
+-- Open borrows past their due date that have not had an overdue notice yet.
+SELECT
+    b.id,
+    b.user_id,
+    b.book_id,
+    b.due_date,
+    bk.title
+FROM borrows b
+JOIN books bk ON bk.id = b.book_id
+LEFT JOIN borrow_overdue_notices n ON n.borrow_id = b.id
+WHERE b.returned_at IS NULL
+  AND b.due_date IS NOT NULL
+  AND b.due_date <= NOW()
+  AND n.borrow_id IS NULL
+ORDER BY b.due_date;
+
+-- name: ClaimBorrowOverdueNotice :one
+-- Records the overdue notice for a borrow; no row comes back when it already has one.
+INSERT INTO borrow_overdue_notices (borrow_id)
+VALUES ($1)
+ON CONFLICT (borrow_id) DO NOTHING
+RETURNING borrow_id;
@@ -0,0 +1,142 @@
+-- name: CollectionTurnoverByTitle :many
+-- Turnover is borrows in the range per copy owned. ascending lists the
+-- slowest titles first.
+SELECT b.id AS book_id,
+       b.title,
+       b.author,
+       b.total_copies,
+       COUNT(br.id) AS borrows,
+       COALESCE(COUNT(br.id)::float8 / NULLIF(b.total_copies, 0), 0)::float8 AS turnover
+FROM books b
+LEFT JOIN borrows br ON br.book_id = b.id
+    AND br.borrowed_at >= sqlc.arg(from_time)::timestamp
+    AND br.borrowed_at < sqlc.arg(to_time)::timestamp
+WHERE b.deleted_at IS NULL
+GROUP BY b.id
+ORDER BY
+    CASE WHEN sqlc.arg(ascending)::bool THEN COALESCE(COUNT(br.id)::float8 / NULLIF(b.total_copies, 0), 0) END ASC,
+    CASE WHEN NOT sqlc.arg(ascending)::bool THEN COALESCE(COUNT(br.id)::float8 / NULLIF(b.total_copies, 0), 0) END DESC,
+    b.title, b.id
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountCollectionTitles :one
+SELECT COUNT(*) FROM books WHERE deleted_at IS NULL;
+
+-- name: CollectionTurnoverByGenre :many
+-- Rolled up to the top-level genre like GetCategoryData. A book in two
+-- genres counts toward both.
+WITH book_borrows AS (
+    SELECT b.id, b.total_copies, COUNT(br.id) AS borrows
+    FROM books b
+    LEFT JOIN borrows br ON br.book_id = b.id
+        AND br.borrowed_at >= sqlc.arg(from_time)::timestamp
+        AND br.borrowed_at < sqlc.arg(to_time)::timestamp
+    WHERE b.deleted_at IS NULL
+    GROUP BY b.id
+),
+book_top_genres AS (
+    SELECT DISTINCT bg.book_id, COALESCE(p.name, g.name)::text AS genre
+    FROM book_genres bg
+    JOIN genres g ON g.id = bg.genre_id
+    LEFT JOIN genres p ON p.id = g.parent_id
+)
+SELECT t.genre::text AS genre,
+       COUNT(*) AS titles,
+       COALESCE(SUM(bb.total_copies), 0)::bigint AS copies,
+       COALESCE(SUM(bb.borrows), 0)::bigint AS borrows,
+       COALESCE(SUM(bb.borrows)::float8 / NULLIF(SUM(bb.total_copies), 0), 0)::float8 AS turnover
+FROM book_top_genres t
+JOIN book_borrows bb ON bb.id = t.book_id
+GROUP BY t.genre
+ORDER BY turnover DESC, t.genre;
+
+-- name: ListDeadStock :many
+-- Titles on the shelf since before the cutoff and not borrowed since.
+SELECT b.id AS book_id,
+       b.title,
+       b.author,
+       b.total_copies,
+       b.created_at,
+       MAX(br.borrowed_at)::timestamp AS last_borrowed_at
+FROM books b
+LEFT JOIN borrows br ON br.book_id = b.id
+WHERE b.deleted_at IS NULL
+  AND b.created_at < sqlc.arg(cutoff)::timestamp
+GROUP BY b.id
+HAVING MAX(br.borrowed_at) IS NULL OR MAX(br.borrowed_at) < sqlc.arg(cutoff)::timestamp
+ORDER BY MAX(br.borrowed_at) ASC NULLS FIRST, b.title
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountDeadStock :one
+SELECT COUNT(*) FROM (
+    SELECT b.id
+    FROM books b
+    LEFT JOIN borrows br ON br.book_id = b.id
+    WHERE b.deleted_at IS NULL
+      AND b.created_at < sqlc.arg(cutoff)::timestamp
+    GROUP BY b.id
+    HAVING MAX(br.borrowed_at) IS NULL OR MAX(br.borrowed_at) < sqlc.arg(cutoff)::timestamp
+) dead;
+
+-- name: CollectionHoldStats :many
+-- A hold waits in the queue from creation until the reader is notified (or
+-- it is fulfilled or cancelled). avg_queue_length is the time spent waiting
+-- inside the range divided by its length; avg_wait_seconds covers holds
+-- that reached the reader in the range.
+WITH bounds AS (
+    SELECT sqlc.arg(from_time)::timestamp AT TIME ZONE 'UTC' AS from_at,
+           sqlc.arg(to_time)::timestamp AT TIME ZONE 'UTC' AS to_at
+),
+holds AS (
+    SELECT r.book_id,
+           COUNT(*) FILTER (WHERE r.created_at >= bo.from_at AND r.created_at < bo.to_at) AS holds,
+           SUM(GREATEST(0, EXTRACT(EPOCH FROM
+               LEAST(COALESCE(r.notified_at, r.fulfilled_at, r.cancelled_at, NOW()), bo.to_at)
+               - GREATEST(r.created_at, bo.from_at)
+           ))) / EXTRACT(EPOCH FROM bo.to_at - bo.from_at) AS avg_queue_length,
+           AVG(EXTRACT(EPOCH FROM COALESCE(r.notified_at, r.fulfilled_at) - r.created_at))
+               FILTER (WHERE COALESCE(r.notified_at, r.fulfilled_at) >= bo.from_at
+                         AND COALESCE(r.notified_at, r.fulfilled_at) < bo.to_at) AS avg_wait_seconds
+    FROM reservations r
+    CROSS JOIN bounds bo
+    WHERE r.created_at < bo.to_at
+      AND COALESCE(r.notified_at, r.fulfilled_at, r.cancelled_at, NOW()) > bo.from_at
+    GROUP BY r.book_id, bo.from_at, bo.to_at
+)
+SELECT b.id AS book_id,
+       b.title,
+       b.total_copies,
+       (SELECT COUNT(*) FROM reservations q WHERE q.book_id = b.id AND q.status = 'pending') AS queue_length,
+       h.holds,
+       h.avg_queue_length::float8 AS avg_queue_length,
+       h.avg_wait_seconds::float8 AS avg_wait_seconds
+FROM holds h
+JOIN books b ON b.id = h.book_id
+WHERE b.deleted_at IS NULL
+ORDER BY h.avg_queue_length DESC, b.title
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountCollectionHoldStats :one
+SELECT COUNT(DISTINCT r.book_id)
+FROM reservations r
+JOIN books b ON b.id = r.book_id
+WHERE b.deleted_at IS NULL
+  AND r.created_at < (sqlc.arg(to_time)::timestamp AT TIME ZONE 'UTC')
+  AND COALESCE(r.notified_at, r.fulfilled_at, r.cancelled_at, NOW()) > (sqlc.arg(from_time)::timestamp AT TIME ZONE 'UTC');
+
+-- name: ListPurchaseRecommendations :many
+-- Titles with more readers waiting than copies owned, the widest gap first.
+SELECT b.id AS book_id,
+       b.title,
+       b.author,
+       b.total_copies,
+       b.available_copies,
+       COUNT(r.id) AS queue_length,
+       (COUNT(r.id) - b.total_copies)::bigint AS shortfall
+FROM books b
+JOIN reservations r ON r.book_id = b.id AND r.status = 'pending'
+WHERE b.deleted_at IS NULL
+GROUP BY b.id
+HAVING COUNT(r.id) > b.total_copies
+ORDER BY shortfall DESC, queue_length DESC, b.title
+LIMIT sqlc.arg(row_limit);
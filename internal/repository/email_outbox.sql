@@ -0,0 +1,43 @@
+-- name: EnqueueEmail :exec
+INSERT INTO email_outbox (template, to_addresses, reply_to, subject, text_body, html_body, attachments)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ClaimDueEmails :many
+-- Locks up to row_limit due emails for sending. Rows stuck in 'sending'
+-- (a worker died mid-send) are picked up again after stale_after if they
+-- have attempts left; FailStuckEmails gives up on the rest.
+UPDATE email_outbox
+SET status = 'sending', locked_at = NOW(), attempts = attempts + 1
+WHERE id IN (
+    SELECT id FROM email_outbox
+    WHERE (status = 'pending' AND next_attempt_at <= NOW())
+       OR (status = 'sending'
+           AND locked_at < NOW() - sqlc.arg(stale_after)::interval
+           AND attempts < max_attempts)
+    ORDER BY next_attempt_at
+    LIMIT sqlc.arg(row_limit)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: FailStuckEmails :execrows
+-- Gives up on emails stuck in 'sending' past stale_after on their last attempt.
+UPDATE email_outbox
+SET status = 'failed', locked_at = NULL, last_error = 'delivery was interrupted on the last attempt'
+WHERE status = 'sending'
+  AND locked_at < NOW() - sqlc.arg(stale_after)::interval
+  AND attempts >= max_attempts;
+
+-- name: MarkEmailSent :exec
+UPDATE email_outbox
+SET status = 'sent', sent_at = NOW(), locked_at = NULL, last_error = NULL
+WHERE id = $1;
+
+-- name: MarkEmailRetry :exec
+-- Puts a failed email back in the queue, or gives up once max_attempts is reached.
+UPDATE email_outbox
+SET status = CASE WHEN attempts >= max_attempts THEN 'failed' ELSE 'pending' END,
+    next_attempt_at = sqlc.arg(next_attempt_at),
+    locked_at = NULL,
+    last_error = sqlc.arg(last_error)
+WHERE id = sqlc.arg(id);
@@ -0,0 +1,72 @@
+-- name: CreateExportJob :one
+INSERT INTO export_jobs (user_id, report, format, filters)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetExportJobByID :one
+SELECT * FROM export_jobs
+WHERE id = $1;
+
+-- name: ListExportJobsByUser :many
+SELECT * FROM export_jobs
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountExportJobsByUser :one
+SELECT COUNT(*) FROM export_jobs
+WHERE user_id = $1;
+
+-- name: ClaimExportJob :one
+-- Takes the oldest queued job for this worker.
+UPDATE export_jobs
+SET status = 'running', started_at = NOW(), heartbeat_at = NOW()
+WHERE id = (
+    SELECT id FROM export_jobs
+    WHERE status = 'queued'
+    ORDER BY created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: TouchExportJob :execrows
+-- Records that the job's worker is still on it. No row is updated once the
+-- job has stopped running, e.g. after it was failed as stale.
+UPDATE export_jobs
+SET heartbeat_at = NOW()
+WHERE id = $1 AND status = 'running';
+
+-- name: CompleteExportJob :one
+-- Only a running job can complete; no row comes back for one that was
+-- failed in the meantime.
+UPDATE export_jobs
+SET status = 'succeeded',
+    blob_key = sqlc.arg(blob_key),
+    filename = sqlc.arg(filename),
+    size_bytes = sqlc.arg(size_bytes),
+    finished_at = NOW(),
+    expires_at = sqlc.arg(expires_at)
+WHERE id = sqlc.arg(id) AND status = 'running'
+RETURNING *;
+
+-- name: FailExportJob :exec
+UPDATE export_jobs
+SET status = 'failed', error = sqlc.arg(error), finished_at = NOW()
+WHERE id = sqlc.arg(id) AND status = 'running';
+
+-- name: FailStaleExportJobs :execrows
+-- Jobs whose worker stopped sending heartbeats are failed so their owners
+-- can start them again.
+UPDATE export_jobs
+SET status = 'failed', error = 'export was interrupted', finished_at = NOW()
+WHERE status = 'running'
+  AND COALESCE(heartbeat_at, started_at) < NOW() - sqlc.arg(stale_after)::interval;
+
+-- name: ExpireExportJobs :many
+-- Marks finished exports past expires_at as expired and returns the blobs
+-- to delete.
+UPDATE export_jobs
+SET status = 'expired'
+WHERE status = 'succeeded' AND expires_at <= NOW()
+RETURNING id, blob_key;
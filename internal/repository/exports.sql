@@ -0,0 +1,198 @@
+-- Report exports read whole tables in keyset pages: each query returns up
+-- to row_limit rows after the cursor taken from the previous page's last row.
+-- The first page passes a NULL after_id.
+
+-- name: ExportBooks :many
+SELECT
+    b.id,
+    b.title,
+    b.author,
+    b.genre,
+    b.published_year,
+    b.isbn,
+    b.available_copies,
+    b.total_copies,
+    b.created_at
+FROM books b
+WHERE b.deleted_at IS NULL
+  AND (
+        sqlc.arg(genre)::text IN ('', 'all')
+        OR EXISTS (
+            SELECT 1
+            FROM book_genres bg
+            JOIN genres g ON g.id = bg.genre_id
+            LEFT JOIN genres p ON p.id = g.parent_id
+            WHERE bg.book_id = b.id
+              AND (g.slug = sqlc.arg(genre) OR p.slug = sqlc.arg(genre))
+        )
+  )
+  AND (
+        sqlc.arg(search)::text = ''
+        OR b.title ILIKE '%' || sqlc.arg(search) || '%'
+        OR b.description ILIKE '%' || sqlc.arg(search) || '%'
+        OR EXISTS (
+            SELECT 1
+            FROM book_authors ba
+            JOIN authors a ON a.id = ba.author_id
+            WHERE ba.book_id = b.id AND a.name ILIKE '%' || sqlc.arg(search) || '%'
+        )
+        OR EXISTS (
+            SELECT 1
+            FROM book_series bs
+            JOIN series s ON s.id = bs.series_id
+            WHERE bs.book_id = b.id AND s.name ILIKE '%' || sqlc.arg(search) || '%'
+        )
+  )
+  AND (sqlc.narg(after_id)::uuid IS NULL OR (b.title, b.id) > (sqlc.arg(after_title)::text, sqlc.narg(after_id)))
+ORDER BY b.title, b.id
+LIMIT sqlc.arg(row_limit);
+
+-- name: ExportBorrows :many
+SELECT
+    b.id,
+    COALESCE(b.borrowed_at, 'epoch'::timestamp)::timestamp AS borrowed_at,
+    b.due_date,
+    b.returned_at,
+    bk.title AS book_title,
+    (u.first_name || ' ' || u.last_name)::text AS user_name,
+    u.email AS user_email
+FROM borrows b
+JOIN books bk ON bk.id = b.book_id
+JOIN users u ON u.id = b.user_id
+WHERE (
+        sqlc.arg(search)::text = ''
+        OR (u.first_name || ' ' || u.last_name) ILIKE '%' || sqlc.arg(search) || '%'
+        OR u.email ILIKE '%' || sqlc.arg(search) || '%'
+        OR bk.title ILIKE '%' || sqlc.arg(search) || '%'
+  )
+  AND (
+        sqlc.narg(status)::text IS NULL
+        OR (sqlc.narg(status) = 'active' AND b.returned_at IS NULL)
+        OR (sqlc.narg(status) = 'overdue' AND b.returned_at IS NULL AND b.due_date < NOW())
+        OR (sqlc.narg(status) = 'returned' AND b.returned_at IS NOT NULL)
+  )
+  AND (sqlc.narg(from_time)::timestamp IS NULL OR b.borrowed_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamp IS NULL OR b.borrowed_at < sqlc.narg(to_time))
+  AND (sqlc.narg(after_id)::uuid IS NULL
+       OR (COALESCE(b.borrowed_at, 'epoch'::timestamp), b.id) > (sqlc.arg(after_time)::timestamp, sqlc.narg(after_id)))
+ORDER BY COALESCE(b.borrowed_at, 'epoch'::timestamp), b.id
+LIMIT sqlc.arg(row_limit);
+
+-- name: ExportUsers :many
+-- Emails are unique, so the last email on a page is the whole cursor.
+SELECT
+    id,
+    first_name,
+    last_name,
+    email,
+    phone_number,
+    role,
+    is_banned,
+    created_at
+FROM users
+WHERE deleted_at IS NULL
+  AND (
+        sqlc.arg(search)::text = ''
+        OR email ILIKE '%' || sqlc.arg(search) || '%'
+        OR (first_name || ' ' || last_name) ILIKE '%' || sqlc.arg(search) || '%'
+  )
+  AND (sqlc.narg(role)::text IS NULL OR role = sqlc.narg(role))
+  AND (sqlc.narg(banned)::boolean IS NULL OR COALESCE(is_banned, FALSE) = sqlc.narg(banned))
+  AND email > sqlc.arg(after_email)::text
+ORDER BY email
+LIMIT sqlc.arg(row_limit);
+
+-- name: ExportPayments :many
+SELECT
+    p.id,
+    p.created_at,
+    p.email,
+    (u.first_name || ' ' || u.last_name)::text AS user_name,
+    sp.name AS plan_name,
+    p.amount,
+    p.currency,
+    p.payment_gateway,
+    p.transaction_id,
+    p.status,
+    COALESCE(i.number, '')::text AS receipt_number
+FROM payments p
+JOIN users u ON u.id = p.user_id
+JOIN subscription_plans sp ON sp.id = p.plan_id
+LEFT JOIN invoices i ON i.payment_id = p.id AND i.kind = 'receipt'
+WHERE (sqlc.narg(status)::text IS NULL OR p.status = sqlc.narg(status))
+  AND (sqlc.arg(search)::text = '' OR p.email ILIKE '%' || sqlc.arg(search) || '%')
+  AND (sqlc.narg(from_time)::timestamp IS NULL OR p.created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamp IS NULL OR p.created_at < sqlc.narg(to_time))
+  AND (sqlc.narg(after_id)::uuid IS NULL OR (p.created_at, p.id) > (sqlc.arg(after_time)::timestamp, sqlc.narg(after_id)))
+ORDER BY p.created_at, p.id
+LIMIT sqlc.arg(row_limit);
+
+-- name: ExportRefunds :many
+SELECT
+    r.id,
+    COALESCE(r.requested_at, 'epoch'::timestamp)::timestamp AS requested_at,
+    r.payment_id,
+    p.email,
+    r.amount,
+    p.currency,
+    r.reason,
+    r.status,
+    r.processed_at,
+    COALESCE(i.number, '')::text AS credit_note_number
+FROM refunds r
+JOIN payments p ON p.id = r.payment_id
+LEFT JOIN invoices i ON i.refund_id = r.id AND i.kind = 'credit_note'
+WHERE (sqlc.narg(status)::text IS NULL OR r.status = sqlc.narg(status))
+  AND (sqlc.narg(from_time)::timestamp IS NULL OR r.requested_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamp IS NULL OR r.requested_at < sqlc.narg(to_time))
+  AND (sqlc.narg(after_id)::uuid IS NULL
+       OR (COALESCE(r.requested_at, 'epoch'::timestamp), r.id) > (sqlc.arg(after_time)::timestamp, sqlc.narg(after_id)))
+ORDER BY COALESCE(r.requested_at, 'epoch'::timestamp), r.id
+LIMIT sqlc.arg(row_limit);
+
+-- name: ExportReservations :many
+SELECT
+    r.id,
+    r.created_at,
+    r.status,
+    bk.title AS book_title,
+    (u.first_name || ' ' || u.last_name)::text AS user_name,
+    u.email AS user_email,
+    r.notified_at,
+    r.fulfilled_at,
+    r.cancelled_at,
+    r.picked_up
+FROM reservations r
+JOIN books bk ON bk.id = r.book_id
+JOIN users u ON u.id = r.user_id
+WHERE (sqlc.narg(status)::text IS NULL OR r.status = sqlc.narg(status))
+  AND (sqlc.narg(from_time)::timestamptz IS NULL OR r.created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR r.created_at < sqlc.narg(to_time))
+  AND (sqlc.narg(after_id)::uuid IS NULL OR (r.created_at, r.id) > (sqlc.arg(after_time)::timestamptz, sqlc.narg(after_id)))
+ORDER BY r.created_at, r.id
+LIMIT sqlc.arg(row_limit);
+
+-- name: ExportAuditLog :many
+SELECT
+    a.id,
+    a.created_at,
+    a.actor_id,
+    COALESCE(u.email, '')::text AS actor_email,
+    a.action,
+    a.target_type,
+    a.target_id,
+    a.before,
+    a.after,
+    a.ip,
+    a.request_id
+FROM audit_log a
+LEFT JOIN users u ON u.id = a.actor_id
+WHERE (sqlc.narg(actor_id)::uuid IS NULL OR a.actor_id = sqlc.narg(actor_id))
+  AND (sqlc.narg(action)::text IS NULL OR a.action = sqlc.narg(action))
+  AND (sqlc.narg(target_type)::text IS NULL OR a.target_type = sqlc.narg(target_type))
+  AND (sqlc.narg(target_id)::text IS NULL OR a.target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(from_time)::timestamp IS NULL OR a.created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamp IS NULL OR a.created_at < sqlc.narg(to_time))
+  AND (sqlc.narg(after_id)::uuid IS NULL OR (a.created_at, a.id) > (sqlc.arg(after_time)::timestamp, sqlc.narg(after_id)))
+ORDER BY a.created_at, a.id
+LIMIT sqlc.arg(row_limit);
@@ -0,0 +1,82 @@
+-- name: ListGenreTaxonomy :many
+SELECT
+    g.id,
+    g.name,
+    g.slug,
+    g.parent_id,
+    g.description,
+    (SELECT COUNT(*) FROM book_genres bg WHERE bg.genre_id = g.id) AS book_count
+FROM genres g
+ORDER BY g.name;
+
+-- name: GetGenreByID :one
+SELECT * FROM genres
+WHERE id = $1;
+
+-- name: GetGenreBySlug :one
+SELECT * FROM genres
+WHERE slug = $1;
+
+-- name: LockGenres :exec
+-- Locks the genres in id order, so transactions locking overlapping sets
+-- queue up instead of deadlocking.
+SELECT id FROM genres
+WHERE id = ANY(sqlc.arg(ids)::uuid[])
+ORDER BY id
+FOR UPDATE;
+
+-- name: CountChildGenres :one
+SELECT COUNT(*) FROM genres
+WHERE parent_id = $1;
+
+-- name: CreateGenre :one
+INSERT INTO genres (name, slug, parent_id, description)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: UpsertGenreBySlug :one
+INSERT INTO genres (name, slug)
+VALUES ($1, $2)
+ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug
+RETURNING *;
+
+-- name: UpdateGenreByID :one
+UPDATE genres
+SET
+    name = COALESCE(sqlc.narg('name'), name),
+    slug = COALESCE(sqlc.narg('slug'), slug),
+    parent_id = CASE
+        WHEN sqlc.arg('clear_parent')::bool THEN NULL
+        ELSE COALESCE(sqlc.narg('parent_id'), parent_id)
+    END,
+    description = COALESCE(sqlc.narg('description'), description),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: ListGenresByBookID :many
+SELECT g.id, g.name, g.slug
+FROM book_genres bg
+JOIN genres g ON g.id = bg.genre_id
+WHERE bg.book_id = $1
+ORDER BY g.name;
+
+-- name: AddBookGenre :exec
+INSERT INTO book_genres (book_id, genre_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBookGenres :exec
+DELETE FROM book_genres
+WHERE book_id = $1;
+
+-- name: SyncBookGenreColumn :exec
+UPDATE books
+SET genre = COALESCE((
+        SELECT string_agg(g.name, ', ' ORDER BY g.name)
+        FROM book_genres bg
+        JOIN genres g ON g.id = bg.genre_id
+        WHERE bg.book_id = $1
+    ), ''),
+    updated_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,30 @@
+-- name: GetReceiptByPaymentID :one
+SELECT * FROM invoices
+WHERE payment_id = $1 AND kind = 'receipt';
+
+-- name: NextInvoiceNumber :one
+-- Takes the next number in kind's series. The counter row stays locked
+-- until the transaction ends and a rollback hands the number back, so the
+-- series has no gaps.
+UPDATE invoice_counters
+SET last_number = last_number + 1
+WHERE kind = $1
+RETURNING last_number;
+
+-- name: CreateReceipt :one
+-- Returns no rows if the payment already has a receipt.
+INSERT INTO invoices (kind, number, payment_id)
+VALUES ('receipt', $1, $2)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: GetCreditNoteByRefundID :one
+SELECT * FROM invoices
+WHERE refund_id = $1 AND kind = 'credit_note';
+
+-- name: CreateCreditNote :one
+-- Returns no rows if the refund already has a credit note.
+INSERT INTO invoices (kind, number, payment_id, refund_id)
+VALUES ('credit_note', $1, $2, $3)
+ON CONFLICT DO NOTHING
+RETURNING *;
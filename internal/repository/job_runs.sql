@@ -0,0 +1,9 @@
+-- name: ClaimJobRun :one
+-- Records a run of the named job unless it already ran within the interval,
+-- in which case no row comes back. Concurrent callers wait on the row lock,
+-- so only one of them claims each period.
+INSERT INTO job_runs (name, last_run_at)
+VALUES (sqlc.arg(name), NOW())
+ON CONFLICT (name) DO UPDATE SET last_run_at = NOW()
+WHERE job_runs.last_run_at <= NOW() - sqlc.arg(every)::interval
+RETURNING last_run_at;
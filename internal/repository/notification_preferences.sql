@@ -0,0 +1,43 @@
+-- name: ListNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE user_id = $1
+ORDER BY type;
+
+-- name: GetNotificationPreference :one
+SELECT * FROM notification_preferences
+WHERE user_id = $1 AND type = $2;
+
+-- name: UpsertNotificationPreference :one
+INSERT INTO notification_preferences (user_id, type, in_app, email, digest, updated_at)
+VALUES ($1, $2, $3, $4, $5, NOW())
+ON CONFLICT (user_id, type)
+DO UPDATE SET in_app = EXCLUDED.in_app, email = EXCLUDED.email, digest = EXCLUDED.digest, updated_at = NOW()
+RETURNING *;
+
+-- name: CreateDigestItem :exec
+INSERT INTO notification_digest_items (user_id, type, title, message)
+VALUES ($1, $2, $3, $4);
+
+-- name: ListPendingDigestItems :many
+-- Unsent digest items for active users, grouped by user for the digest job.
+-- The rows stay locked until the caller's transaction ends.
+SELECT
+    d.id,
+    d.user_id,
+    d.type,
+    d.title,
+    d.message,
+    d.created_at,
+    u.email,
+    u.first_name
+FROM notification_digest_items d
+JOIN users u ON u.id = d.user_id
+WHERE d.sent_at IS NULL
+  AND u.deleted_at IS NULL
+ORDER BY d.user_id, d.created_at
+FOR UPDATE OF d SKIP LOCKED;
+
+-- name: MarkDigestItemsSent :exec
+UPDATE notification_digest_items
+SET sent_at = NOW()
+WHERE id = ANY($1::uuid[]);
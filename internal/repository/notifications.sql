@@ -6,12 +6,18 @@ INSERT INTO events (
     title,
     message,
     metadata,
+    user_id,
+    expires_at,
     created_at
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, NOW()
+    $1, $2, $3, $4, $5, $6, $7, $8, NOW()
 )
 RETURNING *;
 
+-- name: GetEventByID :one
+SELECT * FROM events
+WHERE id = $1;
+
 -- name: AssignNotificationToUser :exec
 -- Use this for TARGETED notifications (Direct Messages). It forces is_read = false.
 INSERT INTO user_notification_status (
@@ -45,11 +51,108 @@ JOIN users u ON u.id = $1
 LEFT JOIN user_notification_status uns 
     ON e.id = uns.event_id AND uns.user_id = $1
 WHERE 
-    e.created_at >= u.created_at
-    OR uns.user_id IS NOT NULL
+    (e.user_id = $1 OR (e.user_id IS NULL AND e.created_at >= u.created_at))
+    AND uns.dismissed_at IS NULL
+    AND (e.expires_at IS NULL OR e.expires_at > NOW())
 ORDER BY e.created_at DESC
 LIMIT $2 OFFSET $3;
 
+-- name: ListUserNotifications :many
+-- Inbox page, newest first. Pass the last row's created_at and event_id as the cursor.
+SELECT 
+    e.id AS event_id,
+    e.object_id,
+    e.object_title,
+    e.type,
+    e.title AS notification_title,
+    e.message,
+    e.metadata,
+    e.created_at,
+    COALESCE(uns.is_read, false) AS is_read,
+    uns.read_at
+FROM events e
+JOIN users u ON u.id = sqlc.arg(user_id)
+LEFT JOIN user_notification_status uns 
+    ON e.id = uns.event_id AND uns.user_id = sqlc.arg(user_id)
+WHERE 
+    (e.user_id = sqlc.arg(user_id) OR (e.user_id IS NULL AND e.created_at >= u.created_at))
+    AND uns.dismissed_at IS NULL
+    AND (e.expires_at IS NULL OR e.expires_at > NOW())
+    AND (sqlc.narg(type)::text IS NULL OR e.type = sqlc.narg(type))
+    AND (sqlc.narg(is_read)::boolean IS NULL OR COALESCE(uns.is_read, false) = sqlc.narg(is_read))
+    AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+        OR (e.created_at, e.id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY e.created_at DESC, e.id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetUserNotification :one
+-- A single inbox item, if the event is visible to the user and not dismissed.
+SELECT 
+    e.id AS event_id,
+    e.object_id,
+    e.object_title,
+    e.type,
+    e.title AS notification_title,
+    e.message,
+    e.metadata,
+    e.created_at,
+    COALESCE(uns.is_read, false) AS is_read,
+    uns.read_at
+FROM events e
+JOIN users u ON u.id = sqlc.arg(user_id)
+LEFT JOIN user_notification_status uns 
+    ON e.id = uns.event_id AND uns.user_id = sqlc.arg(user_id)
+WHERE 
+    (e.user_id = sqlc.arg(user_id) OR (e.user_id IS NULL AND e.created_at >= u.created_at))
+    AND uns.dismissed_at IS NULL
+    AND (e.expires_at IS NULL OR e.expires_at > NOW())
+    AND e.id = sqlc.arg(event_id);
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*)
+FROM events e
+JOIN users u ON u.id = $1
+LEFT JOIN user_notification_status uns 
+    ON e.id = uns.event_id AND uns.user_id = $1
+WHERE 
+    (e.user_id = $1 OR (e.user_id IS NULL AND e.created_at >= u.created_at))
+    AND uns.dismissed_at IS NULL
+    AND (e.expires_at IS NULL OR e.expires_at > NOW())
+    AND COALESCE(uns.is_read, false) = false;
+
+-- name: DismissNotification :exec
+-- Hides an item for one user; creates the status row for broadcasts.
+INSERT INTO user_notification_status (user_id, event_id, is_read, dismissed_at, created_at)
+VALUES ($1, $2, false, NOW(), NOW())
+ON CONFLICT (user_id, event_id)
+DO UPDATE SET dismissed_at = NOW();
+
+-- name: ListUserNotificationsAfter :many
+-- Replays what a user missed after the given event (SSE Last-Event-ID), oldest first.
+SELECT 
+    e.id AS event_id,
+    e.object_id,
+    e.object_title,
+    e.type,
+    e.title AS notification_title,
+    e.message,
+    e.metadata,
+    e.created_at,
+    COALESCE(uns.is_read, false) AS is_read,
+    uns.read_at
+FROM events e
+JOIN users u ON u.id = sqlc.arg(user_id)
+JOIN events last ON last.id = sqlc.arg(after_event_id)
+LEFT JOIN user_notification_status uns 
+    ON e.id = uns.event_id AND uns.user_id = sqlc.arg(user_id)
+WHERE 
+    (e.user_id = sqlc.arg(user_id) OR (e.user_id IS NULL AND e.created_at >= u.created_at))
+    AND uns.dismissed_at IS NULL
+    AND (e.expires_at IS NULL OR e.expires_at > NOW())
+    AND (e.created_at, e.id) > (last.created_at, last.id)
+ORDER BY e.created_at ASC, e.id ASC
+LIMIT sqlc.arg(row_limit);
+
 -- name: MarkAllNotificationsAsRead :exec
 INSERT INTO user_notification_status (user_id, event_id, is_read, read_at, created_at)
 SELECT 
@@ -58,7 +161,7 @@ FROM events e
 JOIN users u ON u.id = $1
 LEFT JOIN user_notification_status uns ON e.id = uns.event_id AND uns.user_id = $1
 WHERE 
-    (e.created_at >= u.created_at OR uns.user_id IS NOT NULL)
+    (e.user_id = $1 OR (e.user_id IS NULL AND e.created_at >= u.created_at))
     AND COALESCE(uns.is_read, false) = false
 ON CONFLICT (user_id, event_id) 
 DO UPDATE SET is_read = true, read_at = NOW();
\ No newline at end of file
@@ -1,7 +1,7 @@
 -- name: GetStats :one
 SELECT
-    (SELECT COUNT(*) FROM books) AS total_books,
-    (SELECT COUNT(*) FROM users WHERE role='member') AS active_users,
+    (SELECT COUNT(*) FROM books WHERE deleted_at IS NULL) AS total_books,
+    (SELECT COUNT(*) FROM users WHERE role='member' AND deleted_at IS NULL) AS active_users,
     (SELECT COUNT(*) FROM subscriptions WHERE status='active') AS total_subscriptions,
     (SELECT COALESCE(SUM(p.amount),0)
      FROM payments p
@@ -21,17 +21,24 @@ GROUP BY month
 ORDER BY MIN(created_at);
 
 -- name: GetCategoryData :many
-SELECT genre AS name,
-       COUNT(*) AS value
-FROM books
-GROUP BY genre;
+-- Grouped on the genre taxonomy (rolled up to the top-level genre) so
+-- spelling variants of the same genre land in one bucket.
+SELECT COALESCE(p.name, g.name)::text AS name,
+       COUNT(DISTINCT bg.book_id) AS value
+FROM book_genres bg
+JOIN books b ON b.id = bg.book_id
+JOIN genres g ON g.id = bg.genre_id
+LEFT JOIN genres p ON p.id = g.parent_id
+WHERE b.deleted_at IS NULL
+GROUP BY COALESCE(p.name, g.name)
+ORDER BY value DESC;
 
 -- name: GetTopBorrowedBooks :many
 SELECT b.title AS name,
        COUNT(*) AS count
 FROM borrows br
 JOIN books b ON br.book_id = b.id
-GROUP BY b.title
+GROUP BY b.id, b.title
 ORDER BY count DESC
 LIMIT 5;
 
@@ -14,6 +14,20 @@ SET status = $2,
 WHERE id = $1
 RETURNING id, user_id, book_id, status, created_at, notified_at, fulfilled_at, cancelled_at, picked_up, updated_at;
 
+-- name: CancelOpenReservationsByBookID :exec
+UPDATE reservations
+SET status = 'cancelled',
+    cancelled_at = now(),
+    updated_at = now()
+WHERE book_id = $1 AND status IN ('pending', 'notified');
+
+-- name: CancelOpenReservationsByUserID :exec
+UPDATE reservations
+SET status = 'cancelled',
+    cancelled_at = now(),
+    updated_at = now()
+WHERE user_id = $1 AND status IN ('pending', 'notified');
+
 -- name: GetNextReservationForBook :one
 SELECT 
     r.id, 
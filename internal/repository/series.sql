@@ -0,0 +1,73 @@
+-- name: ListSeries :many
+SELECT
+    s.id,
+    s.name,
+    s.description,
+    s.created_at,
+    s.updated_at,
+    (SELECT COUNT(*) FROM book_series bs WHERE bs.series_id = s.id) AS book_count
+FROM series s
+WHERE ($1::text = '' OR s.name ILIKE '%' || $1 || '%')
+ORDER BY s.name
+LIMIT $2 OFFSET $3;
+
+-- name: CountSeries :one
+SELECT COUNT(*) FROM series
+WHERE ($1::text = '' OR name ILIKE '%' || $1 || '%');
+
+-- name: GetSeriesByID :one
+SELECT * FROM series
+WHERE id = $1;
+
+-- name: CreateSeries :one
+INSERT INTO series (name, description)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: UpdateSeriesByID :one
+UPDATE series
+SET
+    name = COALESCE(sqlc.narg('name'), name),
+    description = COALESCE(sqlc.narg('description'), description),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: ListBooksInSeries :many
+SELECT
+    b.id,
+    b.title,
+    b.author,
+    b.description,
+    b.genre,
+    b.published_year,
+    b.isbn,
+    b.total_copies,
+    b.available_copies,
+    b.created_at,
+    b.updated_at,
+    b.image_url,
+    bs.position
+FROM book_series bs
+JOIN books b ON b.id = bs.book_id
+WHERE bs.series_id = $1 AND b.deleted_at IS NULL
+ORDER BY bs.position NULLS LAST, b.published_year NULLS LAST, b.title;
+
+-- name: GetSeriesByBookID :one
+SELECT
+    s.id,
+    s.name,
+    bs.position
+FROM book_series bs
+JOIN series s ON s.id = bs.series_id
+WHERE bs.book_id = $1;
+
+-- name: SetBookSeries :exec
+INSERT INTO book_series (book_id, series_id, position)
+VALUES ($1, $2, $3)
+ON CONFLICT (book_id) DO UPDATE
+SET series_id = EXCLUDED.series_id, position = EXCLUDED.position;
+
+-- name: RemoveBookSeries :exec
+DELETE FROM book_series
+WHERE book_id = $1;
@@ -0,0 +1,67 @@
+-- name: CreateSupportTicket :one
+INSERT INTO support_tickets (user_id, name, email, subject, category, priority)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetSupportTicketByID :one
+SELECT * FROM support_tickets
+WHERE id = $1;
+
+-- name: ListSupportTicketsByUser :many
+SELECT * FROM support_tickets
+WHERE user_id = $1
+ORDER BY last_message_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountSupportTicketsByUser :one
+SELECT COUNT(*) FROM support_tickets
+WHERE user_id = $1;
+
+-- name: ListSupportTickets :many
+-- Staff queue. Every filter is optional.
+SELECT * FROM support_tickets
+WHERE (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(category)::text IS NULL OR category = sqlc.narg(category))
+  AND (sqlc.narg(priority)::text IS NULL OR priority = sqlc.narg(priority))
+  AND (sqlc.narg(assigned_to)::uuid IS NULL OR assigned_to = sqlc.narg(assigned_to))
+ORDER BY last_message_at DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountSupportTickets :one
+SELECT COUNT(*) FROM support_tickets
+WHERE (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(category)::text IS NULL OR category = sqlc.narg(category))
+  AND (sqlc.narg(priority)::text IS NULL OR priority = sqlc.narg(priority))
+  AND (sqlc.narg(assigned_to)::uuid IS NULL OR assigned_to = sqlc.narg(assigned_to));
+
+-- name: UpdateSupportTicket :one
+-- closed_at is set when the ticket is resolved or closed and cleared on reopen.
+UPDATE support_tickets
+SET status = $2,
+    priority = $3,
+    category = $4,
+    assigned_to = $5,
+    closed_at = CASE WHEN $2 IN ('resolved', 'closed') THEN COALESCE(closed_at, NOW()) ELSE NULL END,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: RecordSupportTicketReply :one
+-- Bumps the ticket after a new message and moves it to the given status.
+UPDATE support_tickets
+SET status = $2,
+    last_message_at = NOW(),
+    closed_at = CASE WHEN $2 IN ('resolved', 'closed') THEN COALESCE(closed_at, NOW()) ELSE NULL END,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateSupportTicketMessage :one
+INSERT INTO support_ticket_messages (ticket_id, author_id, is_staff, body)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListSupportTicketMessages :many
+SELECT * FROM support_ticket_messages
+WHERE ticket_id = $1
+ORDER BY created_at, id;
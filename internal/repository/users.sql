@@ -12,7 +12,8 @@ SELECT * FROM users
 WHERE id = $1;
 
 -- name: GetAllUser :many
-SELECT * FROM users;
+SELECT * FROM users
+WHERE deleted_at IS NULL;
 
 -- name: GetUserProfileStats :one
 SELECT
@@ -67,17 +68,41 @@ SET is_banned = COALESCE(sqlc.narg('is_banned'), is_banned),
 WHERE id = sqlc.arg('id')
 RETURNING *;
 
+-- name: SoftDeleteUserByID :one
+-- Also bumps token_version so every outstanding token is revoked.
+UPDATE users
+SET deleted_at = NOW(),
+    token_version = token_version + 1,
+    updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: RestoreUserByID :one
+UPDATE users
+SET deleted_at = NULL,
+    updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: PurgeUserByID :one
+-- Permanently removes a soft-deleted user together with their history.
+DELETE FROM users
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING *;
+
 -- name: IncrementTokenVersion :exec
 UPDATE users
 SET token_version = token_version + 1
 WHERE id = $1;
 -- name: ListUsersPaginated :many
 SELECT * FROM users
+WHERE ($3::boolean OR deleted_at IS NULL)
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2;
 
 -- name: CountUsers :one
-SELECT COUNT(*) FROM users;
+SELECT COUNT(*) FROM users
+WHERE ($1::boolean OR deleted_at IS NULL);
 
 -- name: SearchUsersByEmailWithPagination :many
 SELECT
@@ -94,6 +119,7 @@ WHERE
         WHEN $1 = '' THEN TRUE
         ELSE email ILIKE '%' || $1 || '%'
     END)
+    AND deleted_at IS NULL
 ORDER BY email
 LIMIT $2
 OFFSET $3;
@@ -104,4 +130,5 @@ WHERE
     (CASE 
         WHEN $1 = '' THEN TRUE
         ELSE email ILIKE '%' || $1 || '%'
-    END);
+    END)
+    AND deleted_at IS NULL;
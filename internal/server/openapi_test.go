@@ -0,0 +1,91 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/THEGunDevil/GoForBackend/internal/handlers"
+	"github.com/THEGunDevil/GoForBackend/internal/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+func testRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	return NewRouter(Deps{
+		Handler: handlers.New(nil, handlers.Services{}),
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+}
+
+// The contract: every registered route is documented, and nothing that is
+// documented is missing from the router
+func TestOpenAPICoversEveryRoute(t *testing.T) {
+	spec := openapi.Spec()
+	registered := map[string]bool{}
+	for _, route := range testRouter().Routes() {
+		path := openapi.SpecPath(route.Path)
+		registered[route.Method+" "+path] = true
+		if spec.Paths[path][strings.ToLower(route.Method)] == nil {
+			t.Errorf("%s %s (%s) is not in the OpenAPI document; add it to internal/openapi/routes.go",
+				route.Method, route.Path, route.Handler)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, route := range openapi.Routes() {
+		key := route.Method + " " + openapi.SpecPath(route.Path)
+		if seen[key] {
+			t.Errorf("%s is documented twice", key)
+		}
+		seen[key] = true
+		if !registered[key] {
+			t.Errorf("%s is documented but not registered", key)
+		}
+	}
+}
+
+// Routes documented as needing a token turn away requests without one
+func TestOpenAPISecurityMatchesRouter(t *testing.T) {
+	r := testRouter()
+	for _, route := range openapi.Routes() {
+		if route.Access == openapi.Public && route.Cookie == "" {
+			continue
+		}
+		path := strings.NewReplacer(":id", "00000000-0000-0000-0000-000000000001",
+			":user_id", "00000000-0000-0000-0000-000000000001",
+			":payment_id", "00000000-0000-0000-0000-000000000001").Replace(route.Path)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(route.Method, path, nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s without credentials = %d, want 401", route.Method, route.Path, w.Code)
+		}
+	}
+}
+
+func TestOpenAPIServed(t *testing.T) {
+	r := testRouter()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var doc struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); w.Code != http.StatusOK || err != nil {
+		t.Fatalf("GET /openapi.json = %d, %v", w.Code, err)
+	}
+	if doc.OpenAPI != "3.1.0" || doc.Paths["/books/{id}"] == nil {
+		t.Errorf("document is version %q with %d paths", doc.OpenAPI, len(doc.Paths))
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `spec-url="/openapi.json"`) {
+		t.Errorf("GET /docs = %d, %s", w.Code, w.Body.String())
+	}
+}
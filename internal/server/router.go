@@ -0,0 +1,336 @@
+// Package server builds the API's HTTP router. cmd/api serves it; tests
+// drive it with httptest.
+package server
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/handlers"
+	"github.com/THEGunDevil/GoForBackend/internal/middleware"
+	"github.com/THEGunDevil/GoForBackend/internal/openapi"
+	"github.com/THEGunDevil/GoForBackend/internal/telemetry"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// Deps is what the routes are wired to
+type Deps struct {
+	Handler      *handlers.Handler
+	Users        middleware.UserLookup // checks bearer tokens against the users table
+	Logger       *slog.Logger
+	CORSOrigins  []string // origins browsers may call from; none disables CORS
+	MetricsToken string   // bearer token for /metrics; open when empty
+}
+
+// NewRouter registers every route on a new gin engine
+func NewRouter(deps Deps) *gin.Engine {
+	h := deps.Handler
+	auth := middleware.AuthMiddleware(deps.Users)
+	optionalAuth := middleware.OptionalAuth(deps.Users)
+
+	r := gin.New() // instead of gin.Default() if you want full control
+	// Browsers on other origins; without any only same-origin calls work
+	if len(deps.CORSOrigins) > 0 {
+		r.Use(cors.New(cors.Config{
+			AllowOrigins:     deps.CORSOrigins,
+			AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.RequestIDHeader},
+			ExposeHeaders:    []string{"Content-Length", middleware.RequestIDHeader},
+			AllowCredentials: true,
+			MaxAge:           12 * time.Hour,
+		}))
+	}
+	r.Use(middleware.RequestID(deps.Logger))
+	r.Use(middleware.Telemetry())
+	r.Use(middleware.AccessLog())
+	// Failures are recorded with c.Error and sent as problem+json here
+	r.Use(middleware.Errors())
+	r.Use(middleware.Recovery())
+	r.HandleMethodNotAllowed = true
+	r.NoRoute(middleware.NotFound)
+	r.NoMethod(middleware.MethodNotAllowed)
+	// r.Use(middleware.RateLimiter())
+
+	// Health checks: / and /healthz are liveness, /readyz checks dependencies
+	r.GET("/", h.HealthzHandler)
+	r.GET("/healthz", h.HealthzHandler)
+	r.GET("/readyz", h.ReadyzHandler)
+
+	// OpenAPI document and the docs page that renders it
+	r.GET("/openapi.json", openapi.Handler)
+	r.GET("/docs", openapi.DocsHandler)
+
+	// Prometheus scrape target; set METRICS_TOKEN to require a bearer token
+	r.GET("/metrics", middleware.MetricsAuth(deps.MetricsToken), gin.WrapH(telemetry.Handler()))
+
+	// Report exports: ?format=csv|xlsx|pdf, every matching row
+	downloadGroup := r.Group("/download", middleware.NoWriteTimeout(), auth)
+	{
+		downloadGroup.GET("/books", middleware.AdminOnly(), h.DownloadSearchBooksHandler)
+		downloadGroup.GET("/users", middleware.AdminOnly(), h.DownloadUsersHandler)
+		downloadGroup.GET("/borrows", middleware.AdminOnly(), h.DownloadBorrowsHandler)
+		downloadGroup.GET("/reservations", middleware.AdminOnly(), h.DownloadReservationsHandler)
+		downloadGroup.GET("/audit-log", middleware.AdminOnly(), h.DownloadAuditLogHandler)
+		downloadGroup.GET("/payments", middleware.FinanceOrAdmin(), h.DownloadPaymentsHandler)
+		downloadGroup.GET("/refunds", middleware.FinanceOrAdmin(), h.DownloadRefundsHandler)
+	}
+
+	// Background exports for reports too large to download in one request.
+	// The download link is signed, so it skips auth.
+	r.GET("/exports/:id/download", middleware.NoWriteTimeout(), h.DownloadExportHandler)
+	exportGroup := r.Group("/exports")
+	exportGroup.Use(auth, middleware.FinanceOrAdmin())
+	{
+		exportGroup.POST("", h.CreateExportHandler)
+		exportGroup.GET("", h.ListExportsHandler)
+		exportGroup.GET("/:id", h.GetExportHandler)
+	}
+	r.GET("/overview", auth, middleware.AdminOnly(), h.OverviewHandler)
+
+	// Dashboard series over a chosen range: ?from=&to=&bucket=day|week|month&tz=
+	analyticsGroup := r.Group("/analytics", auth)
+	{
+		analyticsGroup.GET("/borrows", middleware.AdminOnly(), h.AnalyticsBorrowsHandler)
+		analyticsGroup.GET("/members", middleware.AdminOnly(), h.AnalyticsMembersHandler)
+		analyticsGroup.GET("/reservations", middleware.AdminOnly(), h.AnalyticsReservationsHandler)
+		analyticsGroup.GET("/overdue", middleware.AdminOnly(), h.AnalyticsOverdueHandler)
+		analyticsGroup.GET("/revenue", middleware.FinanceOrAdmin(), h.AnalyticsRevenueHandler)
+		analyticsGroup.GET("/collection/turnover", middleware.AdminOnly(), h.CollectionTurnoverHandler)
+		analyticsGroup.GET("/collection/dead-stock", middleware.AdminOnly(), h.DeadStockHandler)
+		analyticsGroup.GET("/collection/holds", middleware.AdminOnly(), h.HoldStatsHandler)
+		analyticsGroup.GET("/collection/recommendations", middleware.AdminOnly(), h.PurchaseRecommendationsHandler)
+	}
+	r.POST("/stripe/webhook", h.StripeWebhookHandler)
+	r.GET("/stripe/success", h.StripeSuccessHandler)
+	r.GET("/stripe/cancel", h.StripeCancelHandler)
+
+	// Auth routes (public)
+	authGroup := r.Group("/auth")
+	{
+		authGroup.POST("/register", h.RegisterHandler)
+		authGroup.POST("/login", h.LoginHandler)
+		authGroup.POST("/refresh", h.RefreshHandler) // ✅ add this
+		authGroup.POST("/logout", h.LogoutHandler)
+	}
+
+	// User routes (protected)
+	userGroup := r.Group("/users")
+	userGroup.Use(auth)
+	{
+		userGroup.GET("/", middleware.SkipRateLimit(), middleware.AdminOnly(), h.GetUsersHandler)
+		userGroup.GET("/user/email", middleware.AdminOnly(), middleware.SkipRateLimit(), h.SearchUsersPaginatedHandler)
+		userGroup.GET("/user/:id", h.GetUserByIDHandler)
+		userGroup.GET("/user/profile/:id", h.GetProfileDataByIDHandler)
+		userGroup.PATCH("/user/:id", h.UpdateUserByIDHandler)
+		userGroup.PATCH("/user/ban/:id", middleware.AdminOnly(), h.BanUserByIDHandler)
+		userGroup.DELETE("/user/:id", h.DeleteProfileImage)
+
+		// Soft delete, restore and purge (admin)
+		userGroup.DELETE("/:id", middleware.AdminOnly(), h.DeleteUserByIDHandler)
+		userGroup.POST("/:id/restore", middleware.AdminOnly(), h.RestoreUserByIDHandler)
+		userGroup.DELETE("/:id/purge", middleware.AdminOnly(), h.PurgeUserByIDHandler)
+	}
+	bannedUserGroup := r.Group("/banned-users")
+	bannedUserGroup.Use(auth)
+	{
+		bannedUserGroup.GET("/", middleware.AdminOnly(), h.GetUsersHandler)
+	}
+	// Book routes
+	bookGroup := r.Group("/books")
+	{
+		// Public
+		bookGroup.GET("/", middleware.SkipRateLimit(), optionalAuth, h.GetBooksHandler)
+		bookGroup.GET("/search", middleware.SkipRateLimit(), h.SearchBooksPaginatedHandler)
+		bookGroup.GET("/genres", h.ListGenresHandler)
+		bookGroup.GET("/genre/:genre", h.ListBooksByGenreHandler)
+		bookGroup.GET("/:id", optionalAuth, h.GetBookByIDHandler)
+
+		bookGroup.POST("/", auth, middleware.AdminOnly(), h.CreateBookHandler)
+		bookGroup.PATCH("/:id", auth, middleware.AdminOnly(), h.UpdateBookByIDHandler)
+		bookGroup.DELETE("/:id", auth, middleware.AdminOnly(), h.DeleteBookHandler)
+		bookGroup.POST("/:id/restore", auth, middleware.AdminOnly(), h.RestoreBookHandler)
+		bookGroup.DELETE("/:id/purge", auth, middleware.AdminOnly(), h.PurgeBookHandler)
+
+		// MARC21 / MARCXML import & export (admin)
+		bookGroup.GET("/marc", auth, middleware.AdminOnly(), h.ExportBooksMARCHandler)
+		bookGroup.GET("/:id/marc", auth, middleware.AdminOnly(), h.ExportBookMARCHandler)
+		bookGroup.POST("/marc", auth, middleware.AdminOnly(), h.ImportBookMARCHandler)
+		bookGroup.POST("/marc/batch", auth, middleware.AdminOnly(), h.ImportBooksMARCHandler)
+
+		// Classification (admin)
+		bookGroup.PUT("/:id/authors", auth, middleware.AdminOnly(), h.SetBookAuthorsHandler)
+		bookGroup.PUT("/:id/genres", auth, middleware.AdminOnly(), h.SetBookGenresHandler)
+		bookGroup.PUT("/:id/series", auth, middleware.AdminOnly(), h.SetBookSeriesHandler)
+	}
+
+	// Author routes
+	authorGroup := r.Group("/authors")
+	{
+		authorGroup.GET("/", middleware.SkipRateLimit(), h.ListAuthorsHandler)
+		authorGroup.GET("/:id", h.GetAuthorByIDHandler)
+		authorGroup.GET("/:id/books", h.ListBooksByAuthorHandler)
+		authorGroup.POST("/", auth, middleware.AdminOnly(), h.CreateAuthorHandler)
+		authorGroup.PATCH("/:id", auth, middleware.AdminOnly(), h.UpdateAuthorHandler)
+	}
+
+	// Series routes
+	seriesGroup := r.Group("/series")
+	{
+		seriesGroup.GET("/", middleware.SkipRateLimit(), h.ListSeriesHandler)
+		seriesGroup.GET("/:id", h.GetSeriesByIDHandler)
+		seriesGroup.POST("/", auth, middleware.AdminOnly(), h.CreateSeriesHandler)
+		seriesGroup.PATCH("/:id", auth, middleware.AdminOnly(), h.UpdateSeriesHandler)
+	}
+
+	// Genre taxonomy routes
+	genreGroup := r.Group("/genres")
+	{
+		genreGroup.GET("/", h.ListGenreTaxonomyHandler)
+		genreGroup.POST("/", auth, middleware.AdminOnly(), h.CreateGenreHandler)
+		genreGroup.PATCH("/:id", auth, middleware.AdminOnly(), h.UpdateGenreHandler)
+	}
+
+	// Reservation routes (protected)
+	reservationGroup := r.Group("/reservations")
+	reservationGroup.Use(auth)
+	{
+		reservationGroup.GET("/", h.GetReservationsHandler)
+		reservationGroup.GET("/book/:id", h.GetReservationsByBookIDHandler)
+		reservationGroup.GET("/book/:id/user", h.GetReservationsByBookIDAndUserIDHandler)
+		reservationGroup.GET("/reservation/:id", h.GetReservationsByReservationID)
+		reservationGroup.GET("/next/:id", middleware.AdminOnly(), h.GetNextReservationHandler)
+		reservationGroup.POST("/", h.CreateReservationHandler)
+		reservationGroup.PATCH("/:id/status", middleware.AdminOnly(), h.UpdateReservationStatusHandler)
+	}
+
+	// Borrow routes (protected)
+	borrowGroup := r.Group("/borrows")
+	borrowGroup.Use(auth)
+	{
+		borrowGroup.GET("/", middleware.AdminOnly(), middleware.SkipRateLimit(), h.GetAllBorrowsHandlers)
+		borrowGroup.GET("/user/:id", h.GetBorrowsByUserIDHandler)
+		borrowGroup.GET("/book/:id", h.GetBorrowsByBookIDHandler)
+		borrowGroup.GET("/borrow/book/:id", h.GetBorrowByBookAndUserIDHandler)
+		borrowGroup.POST("/borrow", h.BorrowBookHandler)
+		borrowGroup.PATCH("/borrow/:id/return", h.ReturnBookHandler)
+	}
+
+	// Review routes (protected)
+	reviewGroup := r.Group("/reviews")
+	reviewGroup.Use(auth)
+	{
+		reviewGroup.GET("/book/:id", h.GetReviewsByBookIDHandler)
+		reviewGroup.GET("/user/:id", h.GetReviewsByUserIDHandler)
+		reviewGroup.GET("/review/:id", h.GetReviewsByReviewIDHandler)
+		reviewGroup.POST("/review", h.CreateReviewHandler)
+		reviewGroup.PATCH("/review/:id", h.UpdateReviewByIDHandler)
+		reviewGroup.DELETE("/review/:id", h.DeleteReviewsByIDHandler)
+	}
+
+	// Contact routes (protected)
+	contactGroup := r.Group("/contact")
+	contactGroup.Use(auth)
+	{
+		contactGroup.POST("/send", h.ContactHandler)
+	}
+
+	// Support ticket routes (protected, reachable by banned users)
+	supportGroup := r.Group("/support")
+	supportGroup.Use(auth)
+	{
+		supportGroup.GET("/tickets", h.ListMySupportTicketsHandler)
+		supportGroup.POST("/tickets", h.ContactHandler)
+		supportGroup.GET("/tickets/:id", h.GetMySupportTicketHandler)
+		supportGroup.POST("/tickets/:id/messages", h.ReplyToMySupportTicketHandler)
+	}
+
+	// Notification routes (protected)
+	notificationGroup := r.Group("/notifications")
+	notificationGroup.Use(auth)
+	{
+		notificationGroup.GET("", h.ListNotificationsHandler)
+		notificationGroup.GET("/unread-count", middleware.SkipRateLimit(), h.UnreadNotificationCountHandler)
+		notificationGroup.PATCH("/:id/read", h.MarkNotificationReadHandler)
+		notificationGroup.DELETE("/:id", h.DismissNotificationHandler)
+		notificationGroup.GET("/get", h.GetUserNotificationsByUserIDHandler)
+		notificationGroup.PATCH("/mark-read", h.MarkAllNotificationsAsReadHandler)
+		notificationGroup.GET("/stream", middleware.SkipRateLimit(), middleware.NoWriteTimeout(), h.StreamNotificationsHandler)
+		notificationGroup.GET("/preferences", h.GetNotificationPreferencesHandler)
+		notificationGroup.PUT("/preferences", h.UpdateNotificationPreferencesHandler)
+	}
+
+	// Subscription Plan routes (protected)
+	subscriptionPlanGroup := r.Group("/subscription-plan")
+	subscriptionPlanGroup.Use(auth)
+	{
+		subscriptionPlanGroup.GET("/", h.GetSubscriptionsPlanHandler)
+		subscriptionPlanGroup.GET("/:id", h.GetSubscriptionPlanByIDHandler)
+		subscriptionPlanGroup.POST("/", middleware.AdminOnly(), h.CreateSubscriptionPlanHandler)
+		subscriptionPlanGroup.DELETE("/:id", middleware.AdminOnly(), h.DeleteSubscriptionPlanByID)
+	}
+
+	// Subscription routes (protected)
+	subscriptionGroup := r.Group("/subscription")
+	subscriptionGroup.Use(auth)
+	{
+		subscriptionGroup.GET("/", middleware.AdminOnly(), h.ListSubscriptionsHandler)
+		subscriptionGroup.GET("/:user_id", h.GetSubscriptionByUserIDHandler)
+		subscriptionGroup.GET("/user/:user_id", middleware.AdminOnly(), h.ListSubscriptionsByUserHandler)
+		subscriptionGroup.POST("/", h.CreateSubscriptionHandler)
+		subscriptionGroup.DELETE("/:id", middleware.AdminOnly(), h.DeleteSubscriptionByIDHandler)
+	}
+
+	// Payment routes (protected)
+	paymentGroup := r.Group("/payments")
+	paymentGroup.Use(auth)
+	{
+		paymentGroup.GET("/search-payments", middleware.FinanceOrAdmin(), h.SearchPaymentsPaginatedHandler)
+		paymentGroup.GET("/all-payments", middleware.FinanceOrAdmin(), h.ListAllPaymentsHandler)
+		paymentGroup.GET("/:id", h.GetPaymentHandler)
+		paymentGroup.GET("/:id/receipt.pdf", h.DownloadPaymentReceiptHandler)
+		paymentGroup.POST("/payment", h.CreatePaymentHandler)
+		paymentGroup.PATCH("/payment/:id/status", middleware.AdminOnly(), h.UpdatePaymentStatusHandler)
+		paymentGroup.DELETE("/payment/:id", middleware.AdminOnly(), h.DeletePaymentByIDHandler)
+	}
+
+	// Refund routes (protected)
+	refundGroup := r.Group("/refunds")
+	refundGroup.Use(auth)
+	{
+		refundGroup.GET("/:id", h.GetRefundHandler)
+		refundGroup.GET("/:id/credit-note.pdf", h.DownloadRefundCreditNoteHandler)
+		refundGroup.GET("/payment/:payment_id", middleware.FinanceOrAdmin(), h.ListRefundsByPaymentHandler)
+		refundGroup.GET("/status", middleware.FinanceOrAdmin(), h.ListRefundsByStatusHandler)
+		refundGroup.POST("/", h.CreateRefundHandler)
+		refundGroup.PATCH("/:id/status", middleware.AdminOnly(), h.UpdateRefundStatusHandler)
+		refundGroup.DELETE("/:id", middleware.AdminOnly(), h.DeleteRefundHandler)
+	}
+
+	// List routes (protected, admin only)
+	listGroup := r.Group("/list")
+	listGroup.Use(auth, middleware.AdminOnly()) // ← Auth MUST come before Admin
+	{
+		listGroup.GET("/data-paginated", h.ListDataByStatusHandler)
+	}
+
+	// Admin routes (protected, admin only)
+	adminGroup := r.Group("/admin")
+	adminGroup.Use(auth, middleware.AdminOnly())
+	{
+		adminGroup.GET("/audit", middleware.SkipRateLimit(), h.ListAuditLogHandler)
+
+		adminGroup.POST("/announcements", h.CreateAnnouncementHandler)
+		adminGroup.GET("/announcements", h.ListAnnouncementsHandler)
+		adminGroup.GET("/announcements/:id", h.GetAnnouncementHandler)
+		adminGroup.PATCH("/announcements/:id", h.UpdateAnnouncementHandler)
+		adminGroup.POST("/announcements/:id/cancel", h.CancelAnnouncementHandler)
+
+		adminGroup.GET("/support/tickets", h.ListSupportTicketsHandler)
+		adminGroup.GET("/support/tickets/:id", h.GetSupportTicketHandler)
+		adminGroup.PATCH("/support/tickets/:id", h.UpdateSupportTicketHandler)
+		adminGroup.POST("/support/tickets/:id/messages", h.ReplyToSupportTicketHandler)
+	}
+
+	return r
+}
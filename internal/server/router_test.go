@@ -0,0 +1,40 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/THEGunDevil/GoForBackend/internal/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// Requests that are turned away before any handler runs need no database
+func TestNewRouterGuardsRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := NewRouter(Deps{
+		Handler:      handlers.New(nil, handlers.Services{}),
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		MetricsToken: "scrape",
+	})
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodPost, "/borrows/borrow", http.StatusUnauthorized},
+		{http.MethodGet, "/admin/audit", http.StatusUnauthorized},
+		{http.MethodGet, "/metrics", http.StatusUnauthorized},
+		{http.MethodGet, "/healthz", http.StatusOK},
+		{http.MethodGet, "/no-such-route", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+		}
+	}
+}
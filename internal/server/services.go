@@ -0,0 +1,35 @@
+package server
+
+import (
+	"github.com/THEGunDevil/GoForBackend/internal/db"
+	"github.com/THEGunDevil/GoForBackend/internal/handlers"
+	"github.com/THEGunDevil/GoForBackend/internal/mailer"
+	"github.com/THEGunDevil/GoForBackend/internal/service"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ServiceConfig is what the services take from config. The zero value logs
+// email instead of sending it and keeps exports in a local directory.
+type ServiceConfig struct {
+	Mail         mailer.Mailer // provider the outbox delivers through
+	SupportEmail string        // support inbox, also printed on receipts
+	Exports      service.ExportConfig
+}
+
+// NewServices builds the application services on one store. The
+// notification dispatcher is shared, so support replies honour the same
+// preferences as everything else.
+func NewServices(pool *pgxpool.Pool, store db.Store, cfg ServiceConfig) handlers.Services {
+	notifications := service.NewNotificationDispatcher(store)
+	exports := service.NewExports(store, cfg.Exports)
+	return handlers.Services{
+		Notifications: notifications,
+		Announcements: service.NewAnnouncements(store),
+		Books:         service.NewBooks(store),
+		Borrows:       service.NewBorrows(store),
+		Exports:       exports,
+		Support:       service.NewSupport(store, notifications, cfg.SupportEmail),
+		EmailOutbox:   service.NewEmailOutbox(store, cfg.Mail),
+		Health:        service.NewHealth(pool, exports.Blobs()),
+	}
+}
@@ -1,28 +1,39 @@
 package service
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
 	"mime/multipart"
+	"os"
 
-	"github.com/THEGunDevil/GoForBackend/internal/db"
+	"github.com/THEGunDevil/GoForBackend/internal/telemetry"
 	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
 )
 
 var cld *cloudinary.Cloudinary
 
+// errCloudinaryDisabled is returned when no Cloudinary account is configured
+var errCloudinaryDisabled = errors.New("image uploads are not configured")
+
 // InitCloudinary initializes Cloudinary once from the env URL.
 func InitCloudinary(cloudURL string) {
 	var err error
 	cld, err = cloudinary.NewFromURL(cloudURL)
 	if err != nil {
-		log.Fatalf("Cloudinary init error: %v", err)
+		slog.Error("cloudinary init failed", "error", err)
+		os.Exit(1)
 	}
+	cld.Upload.Client.Transport = telemetry.Transport("cloudinary", cld.Upload.Client.Transport)
 }
 
 // UploadImageToCloudinary uploads a file and returns the secure URL.
-func UploadImageToCloudinary(file multipart.File, filename string) (string, error) {
-	uploadResp, err := cld.Upload.Upload(db.Ctx, file, uploader.UploadParams{
+func UploadImageToCloudinary(ctx context.Context, file multipart.File, filename string) (string, error) {
+	if cld == nil {
+		return "", errCloudinaryDisabled
+	}
+	uploadResp, err := cld.Upload.Upload(ctx, file, uploader.UploadParams{
 		Folder:   "books",     // optional folder in Cloudinary
 		PublicID: filename,    // use filename as Cloudinary public_id
 	})
@@ -32,8 +43,11 @@ func UploadImageToCloudinary(file multipart.File, filename string) (string, erro
 
 	return uploadResp.SecureURL, nil
 }
-func UploadProfileImgToCloudinary(file multipart.File, filename string) (string, string, error) {
-	uploadResp, err := cld.Upload.Upload(db.Ctx, file, uploader.UploadParams{
+func UploadProfileImgToCloudinary(ctx context.Context, file multipart.File, filename string) (string, string, error) {
+	if cld == nil {
+		return "", "", errCloudinaryDisabled
+	}
+	uploadResp, err := cld.Upload.Upload(ctx, file, uploader.UploadParams{
 		Folder:   "profile_pictures",
 		PublicID: filename,
 	})
@@ -43,9 +57,12 @@ func UploadProfileImgToCloudinary(file multipart.File, filename string) (string,
 
 	return uploadResp.SecureURL, uploadResp.PublicID, nil
 }
-func DeleteImageFromCloudinary(publicID string) error {
+func DeleteImageFromCloudinary(ctx context.Context, publicID string) error {
+	if cld == nil {
+		return errCloudinaryDisabled
+	}
 	// Must call Destroy (Upload API) to delete a single asset
-	_, err := cld.Upload.Destroy(db.Ctx, uploader.DestroyParams{
+	_, err := cld.Upload.Destroy(ctx, uploader.DestroyParams{
 		PublicID: publicID,
 	})
 
@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	_ "time/tzdata" // time zones must resolve on hosts without a zoneinfo database
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Analytics bucket sizes
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week"
+	BucketMonth = "month"
+)
+
+// Analytics metrics, as used in /analytics/:metric
+const (
+	MetricBorrows      = "borrows"
+	MetricMembers      = "members"
+	MetricRevenue      = "revenue"
+	MetricReservations = "reservations"
+	MetricOverdue      = "overdue"
+)
+
+// MaxAnalyticsBuckets caps how many points a series may have
+const MaxAnalyticsBuckets = 400
+
+// ErrUnknownMetric is returned by Analytics for a metric it does not serve
+var ErrUnknownMetric = apierror.New(http.StatusNotFound, "unknown_metric", "unknown metric")
+
+// ErrInvalidRange wraps a bad from, to, bucket or tz; the message is safe to
+// show to the client
+var ErrInvalidRange = apierror.New(http.StatusBadRequest, "invalid_range", "invalid analytics range")
+
+// AnalyticsQuery is a bucketed date range in a time zone. From and To are in
+// Location and To is exclusive.
+type AnalyticsQuery struct {
+	From     time.Time
+	To       time.Time
+	Bucket   string
+	Location *time.Location
+	// Currency is the currency revenue is counted in. Amounts in different
+	// currencies can't be added, so revenue requires it.
+	Currency string
+}
+
+// ParseAnalyticsQuery reads from, to, bucket, tz and currency. Dates are
+// YYYY-MM-DD in tz with to inclusive, or RFC3339 with to exclusive. Without
+// from and to it covers the 30 days, 12 weeks or 12 months up to today.
+func ParseAnalyticsQuery(values url.Values, now time.Time) (AnalyticsQuery, error) {
+	q, err := parseAnalyticsQuery(values, now)
+	if err != nil {
+		return q, fmt.Errorf("%w: %v", ErrInvalidRange, err)
+	}
+	return q, nil
+}
+
+func parseAnalyticsQuery(values url.Values, now time.Time) (AnalyticsQuery, error) {
+	q := AnalyticsQuery{
+		Bucket:   strings.ToLower(strings.TrimSpace(values.Get("bucket"))),
+		Currency: strings.ToUpper(strings.TrimSpace(values.Get("currency"))),
+		Location: time.UTC,
+	}
+	switch q.Bucket {
+	case "":
+		q.Bucket = BucketDay
+	case BucketDay, BucketWeek, BucketMonth:
+	default:
+		return q, fmt.Errorf("bucket must be %s, %s or %s", BucketDay, BucketWeek, BucketMonth)
+	}
+	loc, err := parseLocation(values)
+	if err != nil {
+		return q, err
+	}
+	q.Location = loc
+	q.From, q.To, err = parseDateRange(values, loc, now, func(to time.Time) time.Time {
+		switch q.Bucket {
+		case BucketWeek:
+			return to.AddDate(0, 0, -7*12)
+		case BucketMonth:
+			return to.AddDate(0, -12, 0)
+		default:
+			return to.AddDate(0, 0, -30)
+		}
+	})
+	if err != nil {
+		return q, err
+	}
+	if n := q.buckets(); n > MaxAnalyticsBuckets {
+		return q, fmt.Errorf("range has %d %s buckets, at most %d are allowed; use a larger bucket", n, q.Bucket, MaxAnalyticsBuckets)
+	}
+	return q, nil
+}
+
+// ParseDateRange reads from, to and tz the way ParseAnalyticsQuery does,
+// defaulting to the 12 months up to today. It allows any length of range.
+func ParseDateRange(values url.Values, now time.Time) (time.Time, time.Time, error) {
+	loc, err := parseLocation(values)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %v", ErrInvalidRange, err)
+	}
+	from, to, err := parseDateRange(values, loc, now, func(to time.Time) time.Time {
+		return to.AddDate(0, -12, 0)
+	})
+	if err != nil {
+		return from, to, fmt.Errorf("%w: %v", ErrInvalidRange, err)
+	}
+	return from, to, nil
+}
+
+func parseLocation(values url.Values) (*time.Location, error) {
+	tz := strings.TrimSpace(values.Get("tz"))
+	if tz == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", tz)
+	}
+	return loc, nil
+}
+
+// parseDateRange reads from and to in loc. to defaults to the end of today
+// and from to back(to).
+func parseDateRange(values url.Values, loc *time.Location, now time.Time, back func(to time.Time) time.Time) (time.Time, time.Time, error) {
+	to := startOfDay(now.In(loc)).AddDate(0, 0, 1)
+	if raw := strings.TrimSpace(values.Get("to")); raw != "" {
+		t, isDate, err := parseAnalyticsTime(raw, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("to: %v", err)
+		}
+		if isDate {
+			t = t.AddDate(0, 0, 1)
+		}
+		to = t
+	}
+	from := back(to)
+	if raw := strings.TrimSpace(values.Get("from")); raw != "" {
+		t, _, err := parseAnalyticsTime(raw, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("from: %v", err)
+		}
+		from = t
+	}
+	if !from.Before(to) {
+		return from, to, fmt.Errorf("from must be before to")
+	}
+	return from, to, nil
+}
+
+// parseAnalyticsTime reads a date or an RFC3339 time and reports which it was
+func parseAnalyticsTime(raw string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", raw, loc); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return t, false, fmt.Errorf("%q is not YYYY-MM-DD or RFC3339", raw)
+	}
+	return t.In(loc), false, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// bucketStart truncates t to its bucket the way date_trunc does: weeks start
+// on Monday
+func (q AnalyticsQuery) bucketStart(t time.Time) time.Time {
+	t = startOfDay(t.In(q.Location))
+	switch q.Bucket {
+	case BucketWeek:
+		return t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+	case BucketMonth:
+		return t.AddDate(0, 0, 1-t.Day())
+	default:
+		return t
+	}
+}
+
+func (q AnalyticsQuery) nextBucket(t time.Time) time.Time {
+	switch q.Bucket {
+	case BucketWeek:
+		return t.AddDate(0, 0, 7)
+	case BucketMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// buckets counts the buckets the range touches, stopping past the cap
+func (q AnalyticsQuery) buckets() int {
+	n := 0
+	for t := q.bucketStart(q.From); t.Before(q.To) && n <= MaxAnalyticsBuckets; t = q.nextBucket(t) {
+		n++
+	}
+	return n
+}
+
+// Previous is the period of the same length ending where q starts. Whole
+// months and whole days shift by the calendar so the buckets line up.
+func (q AnalyticsQuery) Previous() AnalyticsQuery {
+	prev := q
+	prev.To = q.From
+	from, to := q.From.In(q.Location), q.To.In(q.Location)
+	switch {
+	case from.Equal(startOfDay(from)) && to.Equal(startOfDay(to)) && from.Day() == 1 && to.Day() == 1:
+		months := (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+		prev.From = from.AddDate(0, -months, 0)
+	case from.Equal(startOfDay(from)) && to.Equal(startOfDay(to)):
+		fy, fm, fd := from.Date()
+		ty, tm, td := to.Date()
+		days := int(time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC).Sub(time.Date(fy, fm, fd, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+		prev.From = from.AddDate(0, 0, -days)
+	default:
+		prev.From = from.Add(-to.Sub(from))
+	}
+	return prev
+}
+
+// analyticsMetric fetches a metric's series. ratios are figures computed
+// from two others, as name, numerator and denominator, which are derived
+// again for the totals instead of being summed.
+type analyticsMetric struct {
+	fetch  func(ctx context.Context, store gen.Querier, q AnalyticsQuery) ([]models.AnalyticsPoint, error)
+	ratios [][3]string
+}
+
+var analyticsMetrics = map[string]analyticsMetric{
+	MetricBorrows:      {fetch: borrowSeries},
+	MetricMembers:      {fetch: memberSeries},
+	MetricRevenue:      {fetch: revenueSeries},
+	MetricReservations: {fetch: reservationSeries},
+	MetricOverdue:      {fetch: overdueSeries, ratios: [][3]string{{"rate", "late", "due"}}},
+}
+
+// Analytics returns the metric over q and over the period before it, read
+// through store
+func Analytics(ctx context.Context, store gen.Querier, metric string, q AnalyticsQuery) (models.AnalyticsResponse, error) {
+	m, ok := analyticsMetrics[metric]
+	if !ok {
+		return models.AnalyticsResponse{}, ErrUnknownMetric
+	}
+	if metric == MetricRevenue && q.Currency == "" {
+		return models.AnalyticsResponse{}, fmt.Errorf("%w: currency is required for revenue", ErrInvalidRange)
+	}
+	current, err := m.period(ctx, store, q)
+	if err != nil {
+		return models.AnalyticsResponse{}, err
+	}
+	previous, err := m.period(ctx, store, q.Previous())
+	if err != nil {
+		return models.AnalyticsResponse{}, err
+	}
+	response := models.AnalyticsResponse{
+		Metric:          metric,
+		Bucket:          q.Bucket,
+		Timezone:        q.Location.String(),
+		AnalyticsPeriod: current,
+		Previous:        previous,
+		Change:          analyticsChange(current.Totals, previous.Totals),
+	}
+	if metric == MetricRevenue {
+		response.Currency = q.Currency
+	}
+	return response, nil
+}
+
+func (m analyticsMetric) period(ctx context.Context, store gen.Querier, q AnalyticsQuery) (models.AnalyticsPeriod, error) {
+	series, err := m.fetch(ctx, store, q)
+	if err != nil {
+		return models.AnalyticsPeriod{}, err
+	}
+	totals := map[string]float64{}
+	for i, point := range series {
+		for name, v := range point.Values {
+			totals[name] += v
+		}
+		for _, r := range m.ratios {
+			series[i].Values[r[0]] = ratio(point.Values[r[1]], point.Values[r[2]])
+		}
+	}
+	for _, r := range m.ratios {
+		totals[r[0]] = ratio(totals[r[1]], totals[r[2]])
+	}
+	if series == nil {
+		series = []models.AnalyticsPoint{}
+	}
+	return models.AnalyticsPeriod{
+		From:   q.From,
+		To:     q.To,
+		Series: series,
+		Totals: totals,
+	}, nil
+}
+
+// ratio is n/d, or 0 when there is nothing to divide by
+func ratio(n, d float64) float64 {
+	if d == 0 {
+		return 0
+	}
+	return n / d
+}
+
+func analyticsChange(current, previous map[string]float64) map[string]*float64 {
+	change := make(map[string]*float64, len(current))
+	for name, v := range current {
+		if previous[name] == 0 {
+			change[name] = nil
+			continue
+		}
+		c := (v - previous[name]) / previous[name]
+		change[name] = &c
+	}
+	return change
+}
+
+// analyticsParams are the arguments every analytics query shares
+func analyticsParams(q AnalyticsQuery) (string, pgtype.Timestamp, string, pgtype.Timestamp) {
+	return q.Bucket,
+		pgtype.Timestamp{Time: q.From.UTC(), Valid: true},
+		q.Location.String(),
+		pgtype.Timestamp{Time: q.To.UTC(), Valid: true}
+}
+
+// bucketTime turns a bucket start, a wall-clock time in q's zone as the
+// database returns it, back into an instant
+func (q AnalyticsQuery) bucketTime(ts pgtype.Timestamp) time.Time {
+	t := ts.Time
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), q.Location)
+}
+
+func borrowSeries(ctx context.Context, store gen.Querier, q AnalyticsQuery) ([]models.AnalyticsPoint, error) {
+	bucket, from, tz, to := analyticsParams(q)
+	rows, err := store.AnalyticsBorrows(ctx, gen.AnalyticsBorrowsParams{Bucket: bucket, FromTime: from, Tz: tz, ToTime: to})
+	if err != nil {
+		return nil, err
+	}
+	series := make([]models.AnalyticsPoint, 0, len(rows))
+	for _, r := range rows {
+		series = append(series, models.AnalyticsPoint{
+			Start:  q.bucketTime(r.BucketStart),
+			Values: map[string]float64{"borrows": float64(r.Borrows), "returns": float64(r.Returns)},
+		})
+	}
+	return series, nil
+}
+
+func memberSeries(ctx context.Context, store gen.Querier, q AnalyticsQuery) ([]models.AnalyticsPoint, error) {
+	bucket, from, tz, to := analyticsParams(q)
+	rows, err := store.AnalyticsNewMembers(ctx, gen.AnalyticsNewMembersParams{Bucket: bucket, FromTime: from, Tz: tz, ToTime: to})
+	if err != nil {
+		return nil, err
+	}
+	series := make([]models.AnalyticsPoint, 0, len(rows))
+	for _, r := range rows {
+		series = append(series, models.AnalyticsPoint{
+			Start:  q.bucketTime(r.BucketStart),
+			Values: map[string]float64{"new_members": float64(r.NewMembers)},
+		})
+	}
+	return series, nil
+}
+
+func revenueSeries(ctx context.Context, store gen.Querier, q AnalyticsQuery) ([]models.AnalyticsPoint, error) {
+	bucket, from, tz, to := analyticsParams(q)
+	rows, err := store.AnalyticsRevenue(ctx, gen.AnalyticsRevenueParams{
+		Bucket:   bucket,
+		FromTime: from,
+		Tz:       tz,
+		ToTime:   to,
+		Currency: q.Currency,
+	})
+	if err != nil {
+		return nil, err
+	}
+	series := make([]models.AnalyticsPoint, 0, len(rows))
+	for _, r := range rows {
+		series = append(series, models.AnalyticsPoint{
+			Start:  q.bucketTime(r.BucketStart),
+			Values: map[string]float64{"gross": r.Gross, "refunds": r.Refunds, "net": r.Net},
+		})
+	}
+	return series, nil
+}
+
+func reservationSeries(ctx context.Context, store gen.Querier, q AnalyticsQuery) ([]models.AnalyticsPoint, error) {
+	bucket, from, tz, to := analyticsParams(q)
+	rows, err := store.AnalyticsReservations(ctx, gen.AnalyticsReservationsParams{Bucket: bucket, FromTime: from, Tz: tz, ToTime: to})
+	if err != nil {
+		return nil, err
+	}
+	series := make([]models.AnalyticsPoint, 0, len(rows))
+	for _, r := range rows {
+		series = append(series, models.AnalyticsPoint{
+			Start: q.bucketTime(r.BucketStart),
+			Values: map[string]float64{
+				"created":   float64(r.Created),
+				"fulfilled": float64(r.Fulfilled),
+				"cancelled": float64(r.Cancelled),
+			},
+		})
+	}
+	return series, nil
+}
+
+func overdueSeries(ctx context.Context, store gen.Querier, q AnalyticsQuery) ([]models.AnalyticsPoint, error) {
+	bucket, from, tz, to := analyticsParams(q)
+	rows, err := store.AnalyticsOverdue(ctx, gen.AnalyticsOverdueParams{Bucket: bucket, FromTime: from, Tz: tz, ToTime: to})
+	if err != nil {
+		return nil, err
+	}
+	series := make([]models.AnalyticsPoint, 0, len(rows))
+	for _, r := range rows {
+		series = append(series, models.AnalyticsPoint{
+			Start:  q.bucketTime(r.BucketStart),
+			Values: map[string]float64{"due": float64(r.Due), "late": float64(r.Late)},
+		})
+	}
+	return series, nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+)
+
+func TestParseAnalyticsQuery(t *testing.T) {
+	dhaka, _ := time.LoadLocation("Asia/Dhaka")
+	now := time.Date(2025, 3, 10, 20, 0, 0, 0, time.UTC) // already the 11th in Dhaka
+
+	q, err := ParseAnalyticsQuery(url.Values{"tz": {"Asia/Dhaka"}}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2025, 3, 12, 0, 0, 0, 0, dhaka); !q.To.Equal(want) || q.Bucket != BucketDay {
+		t.Errorf("default to = %v bucket %s, want %v day", q.To, q.Bucket, want)
+	}
+	if want := time.Date(2025, 2, 10, 0, 0, 0, 0, dhaka); !q.From.Equal(want) {
+		t.Errorf("default from = %v, want %v", q.From, want)
+	}
+
+	q, err = ParseAnalyticsQuery(url.Values{"from": {"2025-01-01"}, "to": {"2025-01-31"}, "bucket": {"week"}}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC); !q.To.Equal(want) {
+		t.Errorf("date to = %v, want inclusive end %v", q.To, want)
+	}
+	if n := q.buckets(); n != 5 {
+		t.Errorf("weeks touched = %d, want 5", n)
+	}
+
+	for _, values := range []url.Values{
+		{"bucket": {"hour"}},
+		{"tz": {"Mars/Olympus"}},
+		{"from": {"2025-02-01"}, "to": {"2025-01-01"}},
+		{"from": {"yesterday"}},
+		{"from": {"2020-01-01"}, "to": {"2025-01-01"}},
+	} {
+		if _, err := ParseAnalyticsQuery(values, now); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("%v: err = %v, want ErrInvalidRange", values, err)
+		}
+	}
+}
+
+func TestAnalyticsQueryPrevious(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	tests := []struct {
+		from, to, want time.Time
+	}{
+		// Whole months: March is preceded by February, not 31 days
+		{time.Date(2025, 3, 1, 0, 0, 0, 0, loc), time.Date(2025, 4, 1, 0, 0, 0, 0, loc), time.Date(2025, 2, 1, 0, 0, 0, 0, loc)},
+		// Whole days across the clocks going forward
+		{time.Date(2025, 3, 30, 0, 0, 0, 0, loc), time.Date(2025, 4, 6, 0, 0, 0, 0, loc), time.Date(2025, 3, 23, 0, 0, 0, 0, loc)},
+		{time.Date(2025, 3, 1, 12, 0, 0, 0, loc), time.Date(2025, 3, 1, 18, 0, 0, 0, loc), time.Date(2025, 3, 1, 6, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		prev := AnalyticsQuery{From: tt.from, To: tt.to, Bucket: BucketDay, Location: loc}.Previous()
+		if !prev.From.Equal(tt.want) || !prev.To.Equal(tt.from) {
+			t.Errorf("Previous(%v, %v) = %v, %v; want %v, %v", tt.from, tt.to, prev.From, prev.To, tt.want, tt.from)
+		}
+	}
+}
+
+func TestAnalyticsPeriodTotals(t *testing.T) {
+	m := analyticsMetric{
+		fetch: func(context.Context, gen.Querier, AnalyticsQuery) ([]models.AnalyticsPoint, error) {
+			return []models.AnalyticsPoint{
+				{Values: map[string]float64{"due": 4, "late": 1}},
+				{Values: map[string]float64{"due": 0, "late": 0}},
+				{Values: map[string]float64{"due": 6, "late": 4}},
+			}, nil
+		},
+		ratios: [][3]string{{"rate", "late", "due"}},
+	}
+	period, err := m.period(context.Background(), nil, AnalyticsQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := period.Series[0].Values["rate"]; got != 0.25 {
+		t.Errorf("first rate = %v, want 0.25", got)
+	}
+	if got := period.Series[1].Values["rate"]; got != 0 {
+		t.Errorf("empty bucket rate = %v, want 0", got)
+	}
+	if period.Totals["due"] != 10 || period.Totals["late"] != 5 || period.Totals["rate"] != 0.5 {
+		t.Errorf("totals = %v", period.Totals)
+	}
+
+	change := analyticsChange(map[string]float64{"a": 15, "b": 3}, map[string]float64{"a": 10})
+	if change["a"] == nil || *change["a"] != 0.5 || change["b"] != nil {
+		t.Errorf("change = %v", change)
+	}
+}
+
+func TestAnalyticsRevenueNeedsCurrency(t *testing.T) {
+	q, err := ParseAnalyticsQuery(url.Values{}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Analytics(context.Background(), nil, MetricRevenue, q); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("revenue without a currency: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/THEGunDevil/GoForBackend/internal/db"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/logging"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Announcements publishes admin announcements to their audience
+type Announcements struct {
+	store db.Store
+}
+
+// NewAnnouncements returns an Announcements publishing through store
+func NewAnnouncements(store db.Store) *Announcements {
+	return &Announcements{store: store}
+}
+
+// PublishDue marks scheduled announcements whose expiry has passed expired
+// and delivers every other one whose publish time has passed. Each is
+// claimed and delivered in one transaction, so one that fails to go out stays
+// scheduled for the next run. Safe to run on several instances at once.
+func (s *Announcements) PublishDue(ctx context.Context) error {
+	expired, err := s.store.ExpireAnnouncements(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to expire announcements: %w", err)
+	}
+	if expired > 0 {
+		logging.FromContext(ctx).Info("announcements expired before publishing", "count", expired)
+	}
+
+	// failed keeps this run from claiming an announcement it could not deliver again
+	failed := []pgtype.UUID{}
+	for {
+		var (
+			a     gen.Announcement
+			count int
+			done  bool
+		)
+		err := s.store.WithTx(ctx, func(tx db.Store) error {
+			var err error
+			a, err = tx.ClaimDueAnnouncement(ctx, failed)
+			if errors.Is(err, pgx.ErrNoRows) {
+				done = true
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to claim due announcement: %w", err)
+			}
+			if count, err = deliver(ctx, tx, a); err != nil {
+				return err
+			}
+			return tx.SetAnnouncementRecipientCount(ctx, gen.SetAnnouncementRecipientCountParams{
+				ID:             a.ID,
+				RecipientCount: int32(count),
+			})
+		})
+		switch {
+		case done:
+			return nil
+		case err != nil && !a.ID.Valid:
+			return err
+		case err != nil:
+			logging.FromContext(ctx).Warn("announcement not delivered, will retry", "announcement_id", uuid.UUID(a.ID.Bytes), "error", err)
+			failed = append(failed, a.ID)
+		default:
+			logging.FromContext(ctx).Info("announcement published", "announcement_id", uuid.UUID(a.ID.Bytes), "recipients", count)
+		}
+	}
+}
+
+// deliver turns an announcement into inbox events through a dispatcher on
+// tx: one broadcast event for "all", otherwise one targeted event per
+// recipient (which also honours their channel preferences).
+func deliver(ctx context.Context, tx db.Store, a gen.Announcement) (int, error) {
+	notifications := NewNotificationDispatcher(tx)
+	announcementID := uuid.UUID(a.ID.Bytes)
+	metadata, _ := json.Marshal(map[string]any{
+		"announcement_id": announcementID,
+		"priority":        a.Priority,
+	})
+	req := models.SendNotificationRequest{
+		ObjectID:          &announcementID,
+		ObjectTitle:       a.Title,
+		Type:              NotificationAnnouncement,
+		NotificationTitle: a.Title,
+		Message:           a.Message,
+		Metadata:          metadata,
+	}
+	if a.ExpiresAt.Valid {
+		req.ExpiresAt = &a.ExpiresAt.Time
+	}
+
+	if a.Audience == "all" {
+		if err := notifications.Dispatch(ctx, req); err != nil {
+			return 0, err
+		}
+		count, err := tx.CountUsers(ctx, false)
+		return int(count), err
+	}
+
+	recipients, err := AnnouncementRecipients(ctx, tx, a.Audience, a.AudienceRole.String, a.UserIds)
+	if err != nil {
+		return 0, err
+	}
+
+	// Any failure has aborted the transaction, so stop at the first one
+	for _, userID := range recipients {
+		req.UserID = userID
+		if err := notifications.Dispatch(ctx, req); err != nil {
+			return 0, fmt.Errorf("user %v: %w", userID, err)
+		}
+	}
+	return len(recipients), nil
+}
+
+// AnnouncementRecipients resolves a targeted audience to active user IDs
+func AnnouncementRecipients(ctx context.Context, q gen.Querier, audience, role string, userIDs []pgtype.UUID) ([]uuid.UUID, error) {
+	var (
+		ids []pgtype.UUID
+		err error
+	)
+	switch audience {
+	case "role":
+		ids, err = q.ListUserIDsByRole(ctx, StringToPGText(role))
+	case "active_plan":
+		ids, err = q.ListUserIDsWithActivePlan(ctx)
+	case "overdue":
+		ids, err = q.ListUserIDsWithOverdueBorrows(ctx)
+	case "users":
+		ids, err = q.ListExistingUserIDs(ctx, userIDs)
+	default:
+		return nil, fmt.Errorf("unknown audience %q", audience)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s audience: %w", audience, err)
+	}
+
+	out := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, id.Bytes)
+	}
+	return out, nil
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// RecordAudit appends an entry to audit_log. Pass a transaction-bound q to
+// make the entry commit or roll back together with the change it describes.
+func RecordAudit(ctx context.Context, q gen.Querier, entry models.AuditEntry) error {
+	before, err := auditJSON(entry.Before)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit before-state: %w", err)
+	}
+	after, err := auditJSON(entry.After)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit after-state: %w", err)
+	}
+
+	var actorID pgtype.UUID
+	if entry.ActorID != nil {
+		actorID = UUIDToPGType(*entry.ActorID)
+	}
+
+	return q.CreateAuditLog(ctx, gen.CreateAuditLogParams{
+		ActorID:    actorID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Before:     before,
+		After:      after,
+		Ip:         StringToPGText(entry.IP),
+		RequestID:  StringToPGText(entry.RequestID),
+	})
+}
+
+// auditJSON marshals a snapshot, keeping nil as SQL NULL
+func auditJSON(v any) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
@@ -8,9 +8,9 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-// Keep secrets in environment variables in production
-var JwtAccessSecret = []byte("super-secret-access-key")
-var JwtRefreshSecret = []byte("super-secret-refresh-key")
+// Token signing keys, set from config at startup
+var JwtAccessSecret []byte
+var JwtRefreshSecret []byte
 
 // Hash and verify passwords
 func HashPassword(password string) (string, error) {
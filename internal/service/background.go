@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// background tracks work that outlives the request that started it, such as
+// sending notifications after a response has gone out
+var background sync.WaitGroup
+
+// Go runs fn in its own goroutine. fn's context keeps ctx's values (logger,
+// request ID, trace) but is not cancelled when the request ends. Shutdown
+// waits for it through WaitBackground.
+func Go(ctx context.Context, fn func(ctx context.Context)) {
+	detached := context.WithoutCancel(ctx)
+	background.Go(func() { fn(detached) })
+}
+
+// StartWorker runs a long-lived worker such as EmailOutbox.Run in its own
+// goroutine. Unlike Go it keeps ctx's cancellation, which is how the worker
+// is told to stop; WaitBackground waits for it to return.
+func StartWorker(ctx context.Context, worker func(ctx context.Context)) {
+	background.Go(func() { worker(ctx) })
+}
+
+// WaitBackground blocks until everything started with Go or StartWorker has
+// returned, or ctx is done. Call it once no new requests can start and the
+// workers' context is cancelled.
+func WaitBackground(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
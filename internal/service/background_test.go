@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/logging"
+)
+
+func TestGoDetachesFromRequest(t *testing.T) {
+	reqCtx, cancel := context.WithCancel(logging.WithRequestID(context.Background(), "req-1"))
+	release := make(chan struct{})
+	var gotID string
+	var gotErr error
+	Go(reqCtx, func(ctx context.Context) {
+		<-release
+		gotID = logging.RequestID(ctx)
+		gotErr = ctx.Err()
+	})
+	cancel() // the response has gone out
+
+	short, stop := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer stop()
+	if err := WaitBackground(short); err == nil {
+		t.Fatal("WaitBackground returned before the work finished")
+	}
+
+	close(release)
+	if err := WaitBackground(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if gotID != "req-1" || gotErr != nil {
+		t.Errorf("background ctx: request ID %q, err %v; want req-1 and no cancellation", gotID, gotErr)
+	}
+}
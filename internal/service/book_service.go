@@ -1,30 +1,55 @@
 package service
 
 import (
+	"context"
+
 	"github.com/THEGunDevil/GoForBackend/internal/db"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-func AddBook(req models.CreateBookRequest, imageURL string) (models.BookResponse, error) {
-	book, err := db.Q.CreateBook(db.Ctx, gen.CreateBookParams{
-		Title:  req.Title,
-		Author: req.Author,
-		PublishedYear: pgtype.Int4{
-			Int32: int32(req.PublishedYear),
-			Valid: req.PublishedYear != 0,
-		},
-		Isbn: pgtype.Text{
-			String: req.Isbn,
-			Valid:  len(req.Isbn) > 0,
-		},
-		TotalCopies: int32(req.TotalCopies),
-		ImageUrl:    imageURL,
-		Genre:       req.Genre,
-		Description: req.Description,
-	})
+// Books manages the catalogue
+type Books struct {
+	store db.Store
+}
 
+// NewBooks returns a Books writing through store
+func NewBooks(store db.Store) *Books {
+	return &Books{store: store}
+}
+
+// AddBook creates a book and links its authors and genres in the same
+// transaction.
+func (s *Books) AddBook(ctx context.Context, req models.CreateBookRequest, imageURL string) (models.BookResponse, error) {
+	var book gen.Book
+	err := s.store.WithTx(ctx, func(tx db.Store) error {
+		var err error
+		book, err = tx.CreateBook(ctx, gen.CreateBookParams{
+			Title:  req.Title,
+			Author: req.Author,
+			PublishedYear: pgtype.Int4{
+				Int32: int32(req.PublishedYear),
+				Valid: req.PublishedYear != 0,
+			},
+			Isbn: pgtype.Text{
+				String: req.Isbn,
+				Valid:  len(req.Isbn) > 0,
+			},
+			TotalCopies: int32(req.TotalCopies),
+			ImageUrl:    imageURL,
+			Genre:       req.Genre,
+			Description: req.Description,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := LinkBookAuthors(ctx, tx, book.ID, SplitAuthors(req.Author)); err != nil {
+			return err
+		}
+		return LinkBookGenres(ctx, tx, book.ID, SplitGenres(req.Genre))
+	})
 	if err != nil {
 		return models.BookResponse{}, err
 	}
@@ -1,9 +1,12 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"net/http"
 	"time"
 
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
 	"github.com/THEGunDevil/GoForBackend/internal/db"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
@@ -12,49 +15,81 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-func Borrow(userUUID uuid.UUID, req models.CreateBorrowRequest) (models.BorrowResponse, error) {
-	bookUUID := req.BookID // Already uuid.UUID
+// Reasons Borrow refuses a loan
+var (
+	ErrAlreadyBorrowed   = apierror.New(http.StatusConflict, "already_borrowed", "you have already borrowed this book")
+	ErrBookNotFound      = apierror.New(http.StatusNotFound, "book_not_found", "book not found")
+	ErrNoCopiesAvailable = apierror.New(http.StatusConflict, "no_copies_available", "no copies available")
+	ErrInvalidDueDate    = apierror.New(http.StatusBadRequest, "invalid_due_date", "due_date must be an RFC3339 timestamp")
+)
+
+// Borrows checks books out and back in
+type Borrows struct {
+	store db.Store
+}
+
+// NewBorrows returns a Borrows writing through store
+func NewBorrows(store db.Store) *Borrows {
+	return &Borrows{store: store}
+}
+
+// Borrow checks a copy of the book out to the user. The book row is locked
+// and its copies decremented before the borrow is written, all in one
+// transaction, so a concurrent delete either sees the loan or makes this
+// fail with ErrBookNotFound.
+func (s *Borrows) Borrow(ctx context.Context, userUUID uuid.UUID, req models.CreateBorrowRequest) (models.BorrowResponse, error) {
+	bookID := pgtype.UUID{Bytes: req.BookID, Valid: true}
+	userID := pgtype.UUID{Bytes: userUUID, Valid: true}
 
-	// Check if this user already borrowed this book
-	_, err := db.Q.FilterBorrowByUserAndBookID(db.Ctx, gen.FilterBorrowByUserAndBookIDParams{
-		BookID: pgtype.UUID{Bytes: bookUUID, Valid: true},
-		UserID: pgtype.UUID{Bytes: userUUID, Valid: true},
-	})
-	if err == nil {
-		return models.BorrowResponse{}, errors.New("you have already borrowed this book")
-	}
-	if !errors.Is(err, pgx.ErrNoRows) {
-		return models.BorrowResponse{}, err
-	}
 	// Parse due date
 	dueDate, err := time.Parse(time.RFC3339, req.DueDate)
 	if err != nil {
-		return models.BorrowResponse{}, err
+		return models.BorrowResponse{}, ErrInvalidDueDate.Wrap(err)
 	}
 
-	// Get book info
-	book, err := db.Q.GetBookByID(db.Ctx, pgtype.UUID{Bytes: bookUUID, Valid: true})
-	if err != nil {
-		return models.BorrowResponse{}, err
-	}
+	var (
+		book   gen.Book
+		borrow gen.Borrow
+	)
+	err = s.store.WithTx(ctx, func(tx db.Store) error {
+		// Lock the book so a delete can't slip in before the loan exists
+		var err error
+		book, err = tx.GetBookByIDForUpdate(ctx, bookID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrBookNotFound
+		}
+		if err != nil {
+			return err
+		}
 
-	// Create borrow entry
-	borrow, err := db.Q.CreateBorrow(db.Ctx, gen.CreateBorrowParams{
-		UserID:     pgtype.UUID{Bytes: userUUID, Valid: true},
-		BookID:     pgtype.UUID{Bytes: bookUUID, Valid: true},
-		DueDate:    pgtype.Timestamp{Time: dueDate, Valid: true},
-		ReturnedAt: pgtype.Timestamp{Valid: false},
-	})
-	if err != nil {
-		return models.BorrowResponse{}, err
-	}
+		// Check if this user already borrowed this book
+		_, err = tx.FilterBorrowByUserAndBookID(ctx, gen.FilterBorrowByUserAndBookIDParams{
+			BookID: bookID,
+			UserID: userID,
+		})
+		if err == nil {
+			return ErrAlreadyBorrowed
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
 
-	// Decrement available copies
-	_, err = db.Q.DecrementAvailableCopiesByID(db.Ctx, pgtype.UUID{Bytes: bookUUID, Valid: true})
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return models.BorrowResponse{}, errors.New("no copies available")
+		if _, err := tx.DecrementAvailableCopiesByID(ctx, bookID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNoCopiesAvailable
+			}
+			return err
 		}
+
+		borrow, err = tx.CreateBorrow(ctx, gen.CreateBorrowParams{
+			UserID:     userID,
+			BookID:     bookID,
+			DueDate:    pgtype.Timestamp{Time: dueDate, Valid: true},
+			ReturnedAt: pgtype.Timestamp{Valid: false},
+		})
+		return err
+	})
+	if err != nil {
 		return models.BorrowResponse{}, err
 	}
 
@@ -74,7 +109,7 @@ func Borrow(userUUID uuid.UUID, req models.CreateBorrowRequest) (models.BorrowRe
 	}, nil
 }
 
-func Return(borrowUUID uuid.UUID ,req models.ReturnBookRequest) (map[string]string, error) {
+func (s *Borrows) Return(ctx context.Context, borrowUUID uuid.UUID ,req models.ReturnBookRequest) (map[string]string, error) {
 	// Parse book_id for incrementing copies
 	bookUUID, err := uuid.Parse(req.BookID.String())
 	if err != nil {
@@ -82,13 +117,13 @@ func Return(borrowUUID uuid.UUID ,req models.ReturnBookRequest) (map[string]stri
 	}
 
 	// Update returned_at in borrows table
-	err = db.Q.UpdateBorrowReturnedAtByID(db.Ctx, pgtype.UUID{Bytes: borrowUUID, Valid: true})
+	err = s.store.UpdateBorrowReturnedAtByID(ctx, pgtype.UUID{Bytes: borrowUUID, Valid: true})
 	if err != nil {
 		return nil, err
 	}
 
 	// Increment available copies of the book
-	_, err = db.Q.IncrementAvailableCopiesByID(db.Ctx, pgtype.UUID{Bytes: bookUUID, Valid: true})
+	_, err = s.store.IncrementAvailableCopiesByID(ctx, pgtype.UUID{Bytes: bookUUID, Valid: true})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/db"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// fakeBorrowStore holds one book and records the calls Borrow makes. A
+// deleted book is invisible to the locking read, as a committed soft delete
+// is to SELECT ... FOR UPDATE.
+type fakeBorrowStore struct {
+	db.Store
+	book       gen.Book
+	deleted    bool
+	calls      []string
+	rolledBack bool
+}
+
+func (s *fakeBorrowStore) WithTx(_ context.Context, fn func(tx db.Store) error) error {
+	err := fn(s)
+	s.rolledBack = err != nil
+	return err
+}
+
+func (s *fakeBorrowStore) GetBookByIDForUpdate(_ context.Context, _ pgtype.UUID) (gen.Book, error) {
+	s.calls = append(s.calls, "lock")
+	if s.deleted {
+		return gen.Book{}, pgx.ErrNoRows
+	}
+	return s.book, nil
+}
+
+func (s *fakeBorrowStore) FilterBorrowByUserAndBookID(_ context.Context, _ gen.FilterBorrowByUserAndBookIDParams) (gen.FilterBorrowByUserAndBookIDRow, error) {
+	s.calls = append(s.calls, "filter")
+	return gen.FilterBorrowByUserAndBookIDRow{}, pgx.ErrNoRows
+}
+
+func (s *fakeBorrowStore) DecrementAvailableCopiesByID(_ context.Context, _ pgtype.UUID) (pgtype.Int4, error) {
+	s.calls = append(s.calls, "decrement")
+	if s.book.AvailableCopies.Int32 == 0 {
+		return pgtype.Int4{}, pgx.ErrNoRows
+	}
+	s.book.AvailableCopies.Int32--
+	return s.book.AvailableCopies, nil
+}
+
+func (s *fakeBorrowStore) CreateBorrow(_ context.Context, arg gen.CreateBorrowParams) (gen.Borrow, error) {
+	s.calls = append(s.calls, "create")
+	return gen.Borrow{UserID: arg.UserID, BookID: arg.BookID, DueDate: arg.DueDate}, nil
+}
+
+func TestBorrow(t *testing.T) {
+	req := models.CreateBorrowRequest{BookID: uuid.New(), DueDate: time.Now().Add(7 * 24 * time.Hour).Format(time.RFC3339)}
+	cases := []struct {
+		name      string
+		copies    int32
+		deleted   bool
+		wantErr   error
+		wantCalls []string
+	}{
+		{"available", 1, false, nil, []string{"lock", "filter", "decrement", "create"}},
+		{"deleted while borrowing", 1, true, ErrBookNotFound, []string{"lock"}},
+		{"no copies", 0, false, ErrNoCopiesAvailable, []string{"lock", "filter", "decrement"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &fakeBorrowStore{
+				book:    gen.Book{Title: "Dune", AvailableCopies: pgtype.Int4{Int32: tc.copies, Valid: true}},
+				deleted: tc.deleted,
+			}
+			_, err := NewBorrows(store).Borrow(context.Background(), uuid.New(), req)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+			if store.rolledBack != (tc.wantErr != nil) {
+				t.Errorf("rolled back = %v", store.rolledBack)
+			}
+			if got := len(store.calls); got != len(tc.wantCalls) {
+				t.Fatalf("calls = %v, want %v", store.calls, tc.wantCalls)
+			}
+			for i, call := range tc.wantCalls {
+				if store.calls[i] != call {
+					t.Errorf("calls = %v, want %v", store.calls, tc.wantCalls)
+					break
+				}
+			}
+		})
+	}
+}
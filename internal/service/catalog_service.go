@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// BookAuthorRoles are the contributor roles accepted by book_authors.
+var BookAuthorRoles = map[string]bool{
+	"author":      true,
+	"editor":      true,
+	"translator":  true,
+	"illustrator": true,
+	"contributor": true,
+}
+
+var (
+	authorSeparator = regexp.MustCompile(`\s*[;&]\s*`)
+	genreSeparator  = regexp.MustCompile(`\s*[,;/]\s*`)
+	slugInvalid     = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// Slugify turns a genre name into its taxonomy slug ("Sci-Fi" -> "sci-fi").
+// It must stay in sync with the expression used by the 0002 backfill.
+func Slugify(s string) string {
+	s = slugInvalid.ReplaceAllString(strings.ToLower(strings.TrimSpace(s)), "-")
+	return strings.Trim(s, "-")
+}
+
+// SplitAuthors splits a display author string such as "Neil Gaiman & Terry
+// Pratchett" into individual names.
+func SplitAuthors(s string) []string {
+	return splitNames(authorSeparator, s)
+}
+
+// SplitGenres splits a free-text genre string such as "Fantasy, Adventure".
+func SplitGenres(s string) []string {
+	return splitNames(genreSeparator, s)
+}
+
+func splitNames(sep *regexp.Regexp, s string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, part := range sep.Split(s, -1) {
+		part = strings.TrimSpace(part)
+		key := strings.ToLower(part)
+		if part == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, part)
+	}
+	return out
+}
+
+// LinkBookAuthors replaces the "author" role links of a book with the given
+// names, creating authors that don't exist yet. Other roles are untouched.
+func LinkBookAuthors(ctx context.Context, q gen.Querier, bookID pgtype.UUID, names []string) error {
+	if err := q.DeleteBookAuthorsByRole(ctx, gen.DeleteBookAuthorsByRoleParams{BookID: bookID, Role: "author"}); err != nil {
+		return fmt.Errorf("clear book authors: %w", err)
+	}
+	for i, name := range names {
+		author, err := q.UpsertAuthorByName(ctx, name)
+		if err != nil {
+			return fmt.Errorf("upsert author %q: %w", name, err)
+		}
+		if err := q.AddBookAuthor(ctx, gen.AddBookAuthorParams{
+			BookID:   bookID,
+			AuthorID: author.ID,
+			Role:     "author",
+			Position: int32(i),
+		}); err != nil {
+			return fmt.Errorf("link author %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// LinkBookGenres replaces a book's genres. Names are matched on their slug,
+// so "sci-fi" and "Sci-Fi" resolve to the same genre; unknown genres are
+// added to the taxonomy.
+func LinkBookGenres(ctx context.Context, q gen.Querier, bookID pgtype.UUID, names []string) error {
+	if err := q.DeleteBookGenres(ctx, bookID); err != nil {
+		return fmt.Errorf("clear book genres: %w", err)
+	}
+	for _, name := range names {
+		slug := Slugify(name)
+		if slug == "" {
+			continue
+		}
+		genre, err := q.UpsertGenreBySlug(ctx, gen.UpsertGenreBySlugParams{Name: name, Slug: slug})
+		if err != nil {
+			return fmt.Errorf("upsert genre %q: %w", name, err)
+		}
+		if err := q.AddBookGenre(ctx, gen.AddBookGenreParams{BookID: bookID, GenreID: genre.ID}); err != nil {
+			return fmt.Errorf("link genre %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// GetBookClassification loads the authors, genres and series of a book.
+func GetBookClassification(ctx context.Context, q gen.Querier, bookID pgtype.UUID) ([]models.BookContributor, []models.GenreRef, *models.BookSeriesRef, error) {
+	authorRows, err := q.ListAuthorsByBookID(ctx, bookID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	authors := make([]models.BookContributor, 0, len(authorRows))
+	for _, a := range authorRows {
+		authors = append(authors, models.BookContributor{
+			ID:       a.ID.Bytes,
+			Name:     a.Name,
+			Role:     a.Role,
+			Position: a.Position,
+		})
+	}
+
+	genreRows, err := q.ListGenresByBookID(ctx, bookID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	genres := make([]models.GenreRef, 0, len(genreRows))
+	for _, g := range genreRows {
+		genres = append(genres, models.GenreRef{ID: g.ID.Bytes, Name: g.Name, Slug: g.Slug})
+	}
+
+	var series *models.BookSeriesRef
+	s, err := q.GetSeriesByBookID(ctx, bookID)
+	if err == nil {
+		series = &models.BookSeriesRef{ID: s.ID.Bytes, Name: s.Name, Position: Float8Ptr(s.Position)}
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, nil, err
+	}
+
+	return authors, genres, series, nil
+}
+
+// Float8Ptr converts a nullable float to a pointer (nil when NULL).
+func Float8Ptr(f pgtype.Float8) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	v := f.Float64
+	return &v
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/db"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/documents"
+	"github.com/THEGunDevil/GoForBackend/internal/logging"
+	"github.com/THEGunDevil/GoForBackend/internal/mailer"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	emailPollInterval = 10 * time.Second
+	emailBatchSize    = 20
+	// emailStaleAfter is how long an email may sit in 'sending' before
+	// another worker assumes the first one died and retries it
+	emailStaleAfter = 10 * time.Minute
+	emailRetryBase  = 30 * time.Second
+	emailRetryMax   = 6 * time.Hour
+)
+
+// OutboxMailer is a mailer.Mailer that writes to the email outbox instead of
+// sending, so a provider outage delays email rather than losing it.
+type OutboxMailer struct {
+	// Queries is the store or transaction the email is written through
+	Queries gen.Querier
+}
+
+func (m OutboxMailer) Send(ctx context.Context, msg mailer.Message) error {
+	template := msg.Template
+	if template == "" {
+		template = "custom"
+	}
+	var attachments []byte
+	if len(msg.Attachments) > 0 {
+		var err error
+		if attachments, err = json.Marshal(msg.Attachments); err != nil {
+			return fmt.Errorf("failed to encode attachments: %w", err)
+		}
+	}
+	return m.Queries.EnqueueEmail(ctx, gen.EnqueueEmailParams{
+		Template:    template,
+		ToAddresses: msg.To,
+		ReplyTo:     StringToPGText(msg.ReplyTo),
+		Subject:     msg.Subject,
+		TextBody:    msg.Text,
+		HtmlBody:    StringToPGText(msg.HTML),
+		Attachments: attachments,
+	})
+}
+
+// QueueEmail renders template with data and adds it to the outbox through q.
+// Pass the transaction's queries so the email is only sent if it commits.
+func QueueEmail(ctx context.Context, q gen.Querier, template string, to []string, replyTo string, data any) error {
+	msg, err := mailer.Render(template, data)
+	if err != nil {
+		return err
+	}
+	msg.To = to
+	msg.ReplyTo = replyTo
+	if err := (OutboxMailer{Queries: q}).Send(ctx, msg); err != nil {
+		return fmt.Errorf("failed to queue %s email: %w", template, err)
+	}
+	return nil
+}
+
+// QueueReceiptEmail adds the receipt for a paid payment to the outbox
+// through q, with the PDF attached. supportEmail is printed on the receipt.
+func QueueReceiptEmail(ctx context.Context, q db.Store, payment gen.Payment, supportEmail string) error {
+	receipt, err := BuildReceipt(ctx, q, payment, supportEmail)
+	if err != nil {
+		return err
+	}
+	pdf, err := documents.ReceiptPDF(receipt)
+	if err != nil {
+		return err
+	}
+
+	msg, err := mailer.Render(mailer.TemplateReceipt, mailer.ReceiptData{
+		Name:          receipt.CustomerName,
+		Number:        receipt.Number,
+		Plan:          receipt.Plan,
+		Amount:        fmt.Sprintf("%.2f", receipt.Amount),
+		Currency:      strings.ToUpper(receipt.Currency),
+		Date:          receipt.PaidAt.Format("January 02, 2006"),
+		TransactionID: receipt.TransactionID,
+	})
+	if err != nil {
+		return err
+	}
+	msg.To = []string{payment.Email}
+	msg.Attachments = []mailer.Attachment{{
+		Filename:    ReceiptFilename(receipt.Number),
+		ContentType: "application/pdf",
+		Data:        pdf,
+	}}
+	if err := (OutboxMailer{Queries: q}).Send(ctx, msg); err != nil {
+		return fmt.Errorf("failed to queue receipt email: %w", err)
+	}
+	return nil
+}
+
+// EmailOutbox delivers the emails queued through OutboxMailer
+type EmailOutbox struct {
+	store db.Store
+	mail  mailer.Mailer
+}
+
+// NewEmailOutbox returns an EmailOutbox that reads the queue from store and
+// sends through mail, or only logs the emails when mail is nil
+func NewEmailOutbox(store db.Store, mail mailer.Mailer) *EmailOutbox {
+	if mail == nil {
+		mail = mailer.LogMailer{}
+	}
+	return &EmailOutbox{store: store, mail: mail}
+}
+
+// Run drains the email outbox through its mailer until ctx is cancelled
+func (o *EmailOutbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(emailPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := o.DeliverQueued(ctx); err != nil {
+			logging.FromContext(ctx).Error("email outbox delivery failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverQueued sends every email that is due. Failures are rescheduled
+// with exponential backoff until max_attempts is reached, and emails whose
+// last attempt was interrupted are failed.
+func (o *EmailOutbox) DeliverQueued(ctx context.Context) error {
+	staleAfter := pgtype.Interval{Microseconds: emailStaleAfter.Microseconds(), Valid: true}
+	stuck, err := o.store.FailStuckEmails(ctx, staleAfter)
+	if err != nil {
+		return fmt.Errorf("failed to fail stuck emails: %w", err)
+	}
+	if stuck > 0 {
+		logging.FromContext(ctx).Error("giving up on emails interrupted on their last attempt", "count", stuck)
+	}
+
+	for {
+		emails, err := o.store.ClaimDueEmails(ctx, gen.ClaimDueEmailsParams{
+			StaleAfter: staleAfter,
+			RowLimit:   emailBatchSize,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to claim emails: %w", err)
+		}
+
+		for _, e := range emails {
+			msg := mailer.Message{
+				To:       e.ToAddresses,
+				ReplyTo:  e.ReplyTo.String,
+				Subject:  e.Subject,
+				Text:     e.TextBody,
+				HTML:     e.HtmlBody.String,
+				Template: e.Template,
+			}
+			err := json.Unmarshal(orNull(e.Attachments), &msg.Attachments)
+			if err == nil {
+				err = o.mail.Send(ctx, msg)
+			}
+			if err == nil {
+				if err := o.store.MarkEmailSent(ctx, e.ID); err != nil {
+					return fmt.Errorf("failed to mark email %v sent: %w", e.ID, err)
+				}
+				continue
+			}
+
+			logger := logging.FromContext(ctx).With("email_id", uuid.UUID(e.ID.Bytes), "template", e.Template)
+			logger.Warn("email delivery failed", "attempt", e.Attempts, "max_attempts", e.MaxAttempts, "error", err)
+			if e.Attempts >= e.MaxAttempts {
+				logger.Error("giving up on email")
+			}
+			err = o.store.MarkEmailRetry(ctx, gen.MarkEmailRetryParams{
+				NextAttemptAt: pgtype.Timestamp{Time: time.Now().UTC().Add(EmailRetryDelay(int(e.Attempts))), Valid: true},
+				LastError:     StringToPGText(err.Error()),
+				ID:            e.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to reschedule email %v: %w", e.ID, err)
+			}
+		}
+
+		if len(emails) < emailBatchSize {
+			return nil
+		}
+	}
+}
+
+// orNull lets an absent JSONB column unmarshal as null
+func orNull(b []byte) []byte {
+	if len(b) == 0 {
+		return []byte("null")
+	}
+	return b
+}
+
+// EmailRetryDelay is the wait after the given (1-based) failed attempt:
+// 30s, 1m, 2m, 4m, ... capped at 6h.
+func EmailRetryDelay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := emailRetryBase
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= emailRetryMax {
+			return emailRetryMax
+		}
+	}
+	return delay
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	"github.com/THEGunDevil/GoForBackend/internal/blob"
+	"github.com/THEGunDevil/GoForBackend/internal/db"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/export"
+	"github.com/THEGunDevil/GoForBackend/internal/logging"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	exportPollInterval    = 5 * time.Second
+	exportCleanupInterval = time.Hour
+	// exportHeartbeatInterval is how often a running job checks in, and
+	// exportStaleAfter how long it may go without doing so before it is
+	// assumed to have died with its worker
+	exportHeartbeatInterval = time.Minute
+	exportStaleAfter        = 5 * time.Minute
+	// ExportURLTTL is how long a signed download link works
+	ExportURLTTL = 15 * time.Minute
+)
+
+// ExportConfig is where export files are kept and how links to them are
+// signed. Zero fields get defaults suited to a single local instance.
+type ExportConfig struct {
+	// Blobs holds the files; a tmp/exports directory when nil
+	Blobs blob.Store
+	// Signer signs download links; a random key when it has none, so links
+	// stop working on restart
+	Signer blob.Signer
+	// Retention is how long a finished export is kept; 7 days when zero
+	Retention time.Duration
+	// PublicURL prefixes download links, e.g. https://api.example.com.
+	// Links are relative when it is empty.
+	PublicURL string
+}
+
+func randomKey() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+// CanExport reports whether a user with role may run the report. Finance
+// staff only get the money reports.
+func CanExport(role, report string) bool {
+	switch role {
+	case "admin":
+		return true
+	case "finance":
+		return report == ReportPayments || report == ReportRefunds
+	default:
+		return false
+	}
+}
+
+// Exports queues report exports and runs them in the background
+type Exports struct {
+	store db.Store
+	cfg   ExportConfig
+}
+
+// NewExports returns an Exports keeping its jobs in store and their files
+// where cfg says
+func NewExports(store db.Store, cfg ExportConfig) *Exports {
+	if cfg.Blobs == nil {
+		cfg.Blobs = blob.NewLocal("tmp/exports")
+	}
+	if len(cfg.Signer.Key) == 0 {
+		cfg.Signer = blob.Signer{Key: randomKey()}
+	}
+	if cfg.Retention == 0 {
+		cfg.Retention = 7 * 24 * time.Hour
+	}
+	return &Exports{store: store, cfg: cfg}
+}
+
+// Blobs is where the export files are kept
+func (s *Exports) Blobs() blob.Store {
+	return s.cfg.Blobs
+}
+
+// Queue checks the report and filters and queues an export job
+func (s *Exports) Queue(ctx context.Context, userID uuid.UUID, report string, format export.Format, filters map[string]string) (gen.ExportJob, error) {
+	if _, err := NewReport(s.store, report, reportFilters(filters)); err != nil {
+		return gen.ExportJob{}, err
+	}
+	if filters == nil {
+		filters = map[string]string{}
+	}
+	encoded, err := json.Marshal(filters)
+	if err != nil {
+		return gen.ExportJob{}, err
+	}
+	return s.store.CreateExportJob(ctx, gen.CreateExportJobParams{
+		UserID:  UUIDToPGType(userID),
+		Report:  report,
+		Format:  string(format),
+		Filters: encoded,
+	})
+}
+
+func reportFilters(filters map[string]string) url.Values {
+	values := url.Values{}
+	for k, v := range filters {
+		values.Set(k, v)
+	}
+	return values
+}
+
+// Run runs queued exports and removes expired ones until ctx is cancelled
+func (s *Exports) Run(ctx context.Context) {
+	ticker := time.NewTicker(exportPollInterval)
+	defer ticker.Stop()
+	lastCleanup := time.Time{}
+
+	for {
+		if time.Since(lastCleanup) >= exportCleanupInterval {
+			if err := s.Cleanup(ctx); err != nil {
+				logging.FromContext(ctx).Error("export cleanup failed", "error", err)
+			}
+			lastCleanup = time.Now()
+		}
+		if err := s.RunQueued(ctx); err != nil {
+			logging.FromContext(ctx).Error("export worker failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunQueued runs export jobs one at a time until the queue is empty
+func (s *Exports) RunQueued(ctx context.Context) error {
+	for ctx.Err() == nil {
+		job, err := s.store.ClaimExportJob(ctx)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to claim export job: %w", err)
+		}
+
+		if err := s.run(ctx, job); err != nil {
+			logging.FromContext(ctx).Warn("export failed", "export_id", uuid.UUID(job.ID.Bytes), "report", job.Report, "error", err)
+			failErr := s.store.FailExportJob(ctx, gen.FailExportJobParams{
+				Error: StringToPGText(exportFailure(err)),
+				ID:    job.ID,
+			})
+			if failErr != nil {
+				return fmt.Errorf("failed to mark export %v failed: %w", job.ID, failErr)
+			}
+			continue
+		}
+		logging.FromContext(ctx).Info("export finished", "export_id", uuid.UUID(job.ID.Bytes), "report", job.Report)
+	}
+	return ctx.Err()
+}
+
+// exportFailure is the reason a failed job shows its owner. Client errors,
+// such as a filter the report rejects, say what was wrong; anything else is
+// only in the log.
+func exportFailure(err error) string {
+	var apiErr *apierror.Error
+	if errors.As(err, &apiErr) && apiErr.Status < http.StatusInternalServerError {
+		return apierror.From(err).Detail
+	}
+	return "the export could not be generated; try again later"
+}
+
+func (s *Exports) run(ctx context.Context, job gen.ExportJob) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.heartbeat(ctx, cancel, job.ID)
+
+	var filters map[string]string
+	if err := json.Unmarshal(orNull(job.Filters), &filters); err != nil {
+		return fmt.Errorf("invalid filters: %w", err)
+	}
+	report, err := NewReport(s.store, job.Report, reportFilters(filters))
+	if err != nil {
+		return err
+	}
+	format, err := export.ParseFormat(job.Format)
+	if err != nil {
+		return err
+	}
+
+	// Render straight into the store instead of buffering the file
+	filename := report.Filename(format)
+	key := fmt.Sprintf("exports/%s/%s", uuid.UUID(job.ID.Bytes), filename)
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(report.Render(ctx, pw, format))
+	}()
+	size, err := s.cfg.Blobs.Put(ctx, key, pr)
+	pr.Close()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.store.CompleteExportJob(ctx, gen.CompleteExportJobParams{
+		BlobKey:   StringToPGText(key),
+		Filename:  StringToPGText(filename),
+		SizeBytes: pgtype.Int8{Int64: size, Valid: true},
+		ExpiresAt: pgtype.Timestamp{Time: time.Now().UTC().Add(s.cfg.Retention), Valid: true},
+		ID:        job.ID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		s.cfg.Blobs.Delete(ctx, key)
+		return errors.New("export was failed as stale before it finished")
+	}
+	if err != nil {
+		s.cfg.Blobs.Delete(ctx, key)
+		return fmt.Errorf("failed to record export: %w", err)
+	}
+	return nil
+}
+
+// heartbeat keeps a running job from being failed as stale until ctx is
+// done. If the job was failed anyway, e.g. after the database was
+// unreachable for a while, it stops the run with cancel.
+func (s *Exports) heartbeat(ctx context.Context, cancel context.CancelFunc, id pgtype.UUID) {
+	ticker := time.NewTicker(exportHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		running, err := s.store.TouchExportJob(ctx, id)
+		if err != nil {
+			logging.FromContext(ctx).Warn("export heartbeat failed", "export_id", uuid.UUID(id.Bytes), "error", err)
+			continue
+		}
+		if running == 0 {
+			logging.FromContext(ctx).Warn("export no longer running, stopping it", "export_id", uuid.UUID(id.Bytes))
+			cancel()
+			return
+		}
+	}
+}
+
+// Cleanup deletes the files of expired exports and fails jobs whose worker
+// stopped mid-run
+func (s *Exports) Cleanup(ctx context.Context) error {
+	stale, err := s.store.FailStaleExportJobs(ctx, pgtype.Interval{Microseconds: exportStaleAfter.Microseconds(), Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to fail stale exports: %w", err)
+	}
+	if stale > 0 {
+		logging.FromContext(ctx).Warn("marked interrupted exports as failed", "count", stale)
+	}
+
+	expired, err := s.store.ExpireExportJobs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to expire exports: %w", err)
+	}
+	for _, job := range expired {
+		if !job.BlobKey.Valid {
+			continue
+		}
+		if err := s.cfg.Blobs.Delete(ctx, job.BlobKey.String); err != nil {
+			logging.FromContext(ctx).Error("failed to delete export file", "key", job.BlobKey.String, "error", err)
+		}
+	}
+	if len(expired) > 0 {
+		logging.FromContext(ctx).Info("removed expired exports", "count", len(expired))
+	}
+	return nil
+}
+
+// exportResource is what a download link signs
+func exportResource(jobID uuid.UUID) string {
+	return "exports/" + jobID.String()
+}
+
+// DownloadURL returns a signed link to a finished export's file and when it
+// stops working. The link never outlives the file.
+func (s *Exports) DownloadURL(job gen.ExportJob, now time.Time) (string, time.Time) {
+	expires := now.Add(ExportURLTTL).Truncate(time.Second)
+	if job.ExpiresAt.Valid && job.ExpiresAt.Time.Before(expires) {
+		expires = job.ExpiresAt.Time.Truncate(time.Second)
+	}
+	id := uuid.UUID(job.ID.Bytes)
+	query := url.Values{
+		"expires":   {strconv.FormatInt(expires.Unix(), 10)},
+		"signature": {s.cfg.Signer.Sign(exportResource(id), expires)},
+	}
+	return fmt.Sprintf("%s/exports/%s/download?%s", s.cfg.PublicURL, id, query.Encode()), expires
+}
+
+// VerifyDownload checks a download link's expires and signature parameters
+func (s *Exports) VerifyDownload(jobID uuid.UUID, expires, signature string) error {
+	return s.cfg.Signer.Verify(exportResource(jobID), expires, signature, time.Now())
+}
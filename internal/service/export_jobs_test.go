@@ -0,0 +1,81 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/blob"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestExportDownloadURL(t *testing.T) {
+	exports := NewExports(nil, ExportConfig{Signer: blob.Signer{Key: []byte("test-key")}})
+	id := uuid.New()
+	job := gen.ExportJob{
+		ID:        pgtype.UUID{Bytes: id, Valid: true},
+		ExpiresAt: pgtype.Timestamp{Time: time.Now().Add(24 * time.Hour), Valid: true},
+	}
+
+	link, expires := exports.DownloadURL(job, time.Now())
+	if !strings.HasPrefix(link, "/exports/"+id.String()+"/download?") {
+		t.Fatalf("unexpected link %s", link)
+	}
+	if until := time.Until(expires); until > ExportURLTTL || until < ExportURLTTL-time.Minute {
+		t.Errorf("link expires in %v, want about %v", until, ExportURLTTL)
+	}
+	u, _ := url.Parse(link)
+	q := u.Query()
+	if err := exports.VerifyDownload(id, q.Get("expires"), q.Get("signature")); err != nil {
+		t.Errorf("fresh link rejected: %v", err)
+	}
+	if err := exports.VerifyDownload(uuid.New(), q.Get("expires"), q.Get("signature")); !errors.Is(err, blob.ErrBadSignature) {
+		t.Errorf("link for another export: %v", err)
+	}
+
+	// The link never outlives the file
+	job.ExpiresAt.Time = time.Now().Add(time.Minute)
+	if _, expires := exports.DownloadURL(job, time.Now()); expires.After(job.ExpiresAt.Time) {
+		t.Errorf("link expires %v, after the file at %v", expires, job.ExpiresAt.Time)
+	}
+}
+
+func TestCanExport(t *testing.T) {
+	cases := []struct {
+		role, report string
+		want         bool
+	}{
+		{"admin", ReportAuditLog, true},
+		{"finance", ReportPayments, true},
+		{"finance", ReportRefunds, true},
+		{"finance", ReportUsers, false},
+		{"member", ReportBooks, false},
+	}
+	for _, tc := range cases {
+		if got := CanExport(tc.role, tc.report); got != tc.want {
+			t.Errorf("CanExport(%q, %q) = %v, want %v", tc.role, tc.report, got, tc.want)
+		}
+	}
+}
+
+func TestExportFailure(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("%w: from must be a date", ErrInvalidFilter), "invalid report filter: from must be a date"},
+		{ErrUnknownReport, "unknown report"},
+		{errors.New("open /srv/exports/tmp: permission denied"), "the export could not be generated; try again later"},
+		{fmt.Errorf("failed to record export: %w", errors.New("connection reset")), "the export could not be generated; try again later"},
+	}
+	for _, tc := range cases {
+		if got := exportFailure(tc.err); got != tc.want {
+			t.Errorf("exportFailure(%v) = %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
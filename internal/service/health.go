@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/blob"
+	"github.com/THEGunDevil/GoForBackend/internal/db"
+	"github.com/THEGunDevil/GoForBackend/internal/logging"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// healthCheckTimeout bounds each readiness check so a hung dependency fails
+// the probe instead of stalling it
+const healthCheckTimeout = 2 * time.Second
+
+// HealthCheck is one dependency /readyz looks at. A failing optional check is
+// reported but leaves the instance ready. Check returns a short detail for
+// the response; its error is only logged, since /readyz is public.
+type HealthCheck struct {
+	Name     string
+	Optional bool
+	Check    func(ctx context.Context) (string, error)
+}
+
+// Health answers readiness probes
+type Health struct {
+	// Checks run on every readiness probe
+	Checks []HealthCheck
+}
+
+// NewHealth checks the database and migrations through pool, plus the
+// notification listener and the export blob store
+func NewHealth(pool *pgxpool.Pool, blobs blob.Store) *Health {
+	return &Health{Checks: []HealthCheck{
+		{Name: "database", Check: func(ctx context.Context) (string, error) { return "", pool.Ping(ctx) }},
+		{Name: "migrations", Check: func(ctx context.Context) (string, error) { return checkMigrations(ctx, pool) }},
+		{Name: "notifications", Optional: true, Check: checkNotificationListener},
+		{Name: "blob_store", Optional: true, Check: func(ctx context.Context) (string, error) { return checkBlobStore(ctx, blobs) }},
+	}}
+}
+
+var draining atomic.Bool
+
+// StartDraining makes readiness fail from now on so load balancers stop
+// sending traffic while in-flight requests finish
+func StartDraining() {
+	draining.Store(true)
+}
+
+// Readiness runs every check concurrently. ready is false when a required
+// check failed or the instance is shutting down.
+func (h *Health) Readiness(ctx context.Context) (models.ReadinessResponse, bool) {
+	results := make([]models.HealthCheckResult, len(h.Checks))
+	var wg sync.WaitGroup
+	for i, hc := range h.Checks {
+		wg.Go(func() {
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+			detail, err := hc.Check(checkCtx)
+			result := models.HealthCheckResult{Status: "ok", Optional: hc.Optional, Detail: detail}
+			if err != nil {
+				logging.FromContext(ctx).Warn("readiness check failed", "check", hc.Name, "error", err)
+				result.Status = "failed"
+				result.Error = checkFailure(checkCtx, err)
+			}
+			results[i] = result
+		})
+	}
+	wg.Wait()
+
+	response := models.ReadinessResponse{Status: "ready", Checks: map[string]models.HealthCheckResult{}}
+	ready := true
+	for i, hc := range h.Checks {
+		response.Checks[hc.Name] = results[i]
+		if results[i].Status != "ok" && !hc.Optional {
+			ready = false
+		}
+	}
+	if !ready {
+		response.Status = "not_ready"
+	}
+	if draining.Load() {
+		ready = false
+		response.Status = "draining"
+	}
+	return response, ready
+}
+
+// checkFailure is the reason a check failed as shown on /readyz
+func checkFailure(ctx context.Context, err error) string {
+	if errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+		return "timed out"
+	}
+	return "unavailable"
+}
+
+func checkMigrations(ctx context.Context, pool *pgxpool.Pool) (string, error) {
+	version, latest, err := db.CheckSchema(ctx, pool)
+	if errors.Is(err, db.ErrSchemaUnversioned) {
+		return "", err
+	}
+	return fmt.Sprintf("version %d of %d", version, latest), err
+}
+
+func checkNotificationListener(context.Context) (string, error) {
+	if !NotificationListenerUp() {
+		return "", errors.New("not listening; streams miss new notifications")
+	}
+	return "", nil
+}
+
+func checkBlobStore(ctx context.Context, blobs blob.Store) (string, error) {
+	checker, ok := blobs.(blob.Checker)
+	if !ok {
+		return "no check available", nil
+	}
+	return "", checker.Check(ctx)
+}
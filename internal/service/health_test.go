@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestReadiness(t *testing.T) {
+	t.Cleanup(func() { draining.Store(false) })
+	ok := func(context.Context) (string, error) { return "fine", nil }
+	fail := func(context.Context) (string, error) {
+		return "", errors.New("dial tcp 10.0.0.5:5432: connection refused")
+	}
+
+	h := &Health{Checks: []HealthCheck{
+		{Name: "database", Check: ok},
+		{Name: "blob_store", Optional: true, Check: fail},
+	}}
+	response, ready := h.Readiness(context.Background())
+	if !ready || response.Status != "ready" {
+		t.Errorf("optional failure made the instance unready: %+v", response)
+	}
+	if got := response.Checks["blob_store"]; got.Status != "failed" || got.Error != "unavailable" || !got.Optional {
+		t.Errorf("blob_store = %+v", got)
+	}
+	if got := response.Checks["database"]; got.Status != "ok" || got.Detail != "fine" {
+		t.Errorf("database = %+v", got)
+	}
+
+	h.Checks = append(h.Checks, HealthCheck{Name: "migrations", Check: fail})
+	if response, ready := h.Readiness(context.Background()); ready || response.Status != "not_ready" {
+		t.Errorf("required failure left the instance ready: %+v", response)
+	}
+
+	h.Checks = h.Checks[:1]
+	StartDraining()
+	if response, ready := h.Readiness(context.Background()); ready || response.Status != "draining" {
+		t.Errorf("draining instance reported %+v", response)
+	}
+}
@@ -5,13 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	// "strings"
 	"time"
 
+	"github.com/THEGunDevil/GoForBackend/internal/apierror"
+	"github.com/THEGunDevil/GoForBackend/internal/config"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/telemetry"
 	"github.com/stripe/stripe-go/v74"
 	"github.com/stripe/stripe-go/v74/checkout/session"
 )
@@ -42,7 +44,7 @@ func InitializeSSLCommerzPayment(payment *gen.Payment) (string, error) {
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := &http.Client{Timeout: 15 * time.Second, Transport: telemetry.Transport("sslcommerz", nil)}
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("gateway request failed: %w", err)
@@ -50,7 +52,7 @@ func InitializeSSLCommerzPayment(payment *gen.Payment) (string, error) {
 	defer resp.Body.Close()
 
 	body, _ := io.ReadAll(resp.Body)
-	log.Println("SSLCommerz response:", string(body))
+	slog.Debug("sslcommerz response", "body", string(body))
 
 	var respData struct {
 		GatewayPageURL string `json:"GatewayPageURL"`
@@ -67,34 +69,44 @@ func InitializeSSLCommerzPayment(payment *gen.Payment) (string, error) {
 	return respData.GatewayPageURL, nil
 }
 
+// Stripe is the checkout configuration, set by ConfigureStripe
+var Stripe config.StripeConfig
+
+// ConfigureStripe sets the API key and points stripe-go at cfg.APIURL, or
+// at Stripe itself when that is empty, calling it through client
+func ConfigureStripe(cfg config.StripeConfig, client *http.Client) {
+	Stripe = cfg
+	stripe.Key = cfg.SecretKey
+	stripe.SetHTTPClient(client)
+
+	backend := &stripe.BackendConfig{HTTPClient: client}
+	if cfg.APIURL != "" {
+		backend.URL = stripe.String(cfg.APIURL)
+	}
+	stripe.SetBackend(stripe.APIBackend, stripe.GetBackendWithConfig(stripe.APIBackend, backend))
+}
+
+// ErrPaymentsDisabled is returned when no Stripe key is configured
+var ErrPaymentsDisabled = apierror.New(http.StatusServiceUnavailable, "payments_disabled", "online payments are not configured")
+
 func InitializeStripePayment(payment *gen.Payment) (string, error) {
-	stripe.Key = os.Getenv("STRIPE_SECRET_KEY")
-	if stripe.Key == "" {
-		log.Fatal("STRIPE_SECRET_KEY is required")
+	if !Stripe.Enabled() {
+		return "", ErrPaymentsDisabled
 	}
-	log.Printf("Initializing Stripe payment: payment_id=%s, transaction_id=%s, amount=%f, currency=%s",
-		payment.ID, payment.TransactionID.String(), payment.Amount, payment.Currency)
+	logger := slog.With("payment_id", payment.ID.String(), "transaction_id", payment.TransactionID.String())
+	logger.Info("initializing stripe payment", "amount", payment.Amount, "currency", payment.Currency)
 
 	// Basic validations
 	if payment.Amount <= 0 {
 		err := fmt.Errorf("payment amount must be greater than 0")
-		log.Println("Error:", err)
+		logger.Warn("invalid stripe payment", "error", err)
 		return "", err
 	}
 	if payment.Currency == "" {
 		err := fmt.Errorf("payment currency is required")
-		log.Println("Error:", err)
+		logger.Warn("invalid stripe payment", "error", err)
 		return "", err
 	}
-	successURLBase := os.Getenv("PAYMENT_SUCCESS_URL")
-	cancelURL := os.Getenv("PAYMENT_CANCEL_URL")
-	successRedirect := os.Getenv("PAYMENT_SUCCESS_REDIRECT")
-	cancelRedirect := os.Getenv("PAYMENT_CANCEL_REDIRECT")
-
-	if successURLBase == "" || cancelURL == "" || successRedirect == "" || cancelRedirect == "" {
-		return "", fmt.Errorf("missing Stripe URL env vars")
-	}
-
 	params := &stripe.CheckoutSessionParams{
 		PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
 		LineItems: []*stripe.CheckoutSessionLineItemParams{
@@ -111,19 +123,19 @@ func InitializeStripePayment(payment *gen.Payment) (string, error) {
 		},
 		Mode: stripe.String(string(stripe.CheckoutSessionModePayment)),
 		// Stripe will replace {CHECKOUT_SESSION_ID} automatically
-		SuccessURL: stripe.String(successURLBase + "?session_id={CHECKOUT_SESSION_ID}"),
-		CancelURL:  stripe.String(cancelURL + "?session_id={CHECKOUT_SESSION_ID}"),
+		SuccessURL: stripe.String(Stripe.SuccessURL + "?session_id={CHECKOUT_SESSION_ID}"),
+		CancelURL:  stripe.String(Stripe.CancelURL + "?session_id={CHECKOUT_SESSION_ID}"),
 	}
 	params.AddMetadata("transaction_id", payment.TransactionID.String())
 	params.AddMetadata("payment_id", payment.ID.String())
 	params.AddMetadata("user_id", payment.UserID.String()) // safe way for pgtype.UUID
 	s, err := session.New(params)
 	if err != nil {
-		log.Printf("[ERROR] Failed to create Stripe session: %v", err)
+		logger.Error("failed to create stripe checkout session", "error", err)
 		return "", fmt.Errorf("failed to create Stripe session: %w", err)
 	}
 
-	log.Printf("Stripe Checkout session created – ID: %s, URL: %s", s.ID, s.URL)
+	logger.Info("stripe checkout session created", "session_id", s.ID)
 	return s.URL, nil
 }
 
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/THEGunDevil/GoForBackend/internal/db"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/documents"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// errInvoiceIssued rolls back the number taken for a document that was
+// issued concurrently
+var errInvoiceIssued = errors.New("invoice already issued")
+
+// IssueReceipt returns the payment's receipt, numbering it on first use.
+// Pass the transaction's store when issuing as part of a payment update.
+func IssueReceipt(ctx context.Context, store db.Store, payment gen.Payment) (gen.Invoice, error) {
+	invoice, err := store.GetReceiptByPaymentID(ctx, payment.ID)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return invoice, err
+	}
+	err = store.WithTx(ctx, func(tx db.Store) error {
+		n, err := tx.NextInvoiceNumber(ctx, "receipt")
+		if err != nil {
+			return err
+		}
+		invoice, err = tx.CreateReceipt(ctx, gen.CreateReceiptParams{
+			Number:    fmt.Sprintf("INV-%06d", n),
+			PaymentID: payment.ID,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errInvoiceIssued
+		}
+		return err
+	})
+	if errors.Is(err, errInvoiceIssued) {
+		// Issued concurrently; use that one
+		return store.GetReceiptByPaymentID(ctx, payment.ID)
+	}
+	return invoice, err
+}
+
+// IssueCreditNote returns the refund's credit note, numbering it on first use
+func IssueCreditNote(ctx context.Context, store db.Store, refund gen.Refund) (gen.Invoice, error) {
+	invoice, err := store.GetCreditNoteByRefundID(ctx, refund.ID)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return invoice, err
+	}
+	err = store.WithTx(ctx, func(tx db.Store) error {
+		n, err := tx.NextInvoiceNumber(ctx, "credit_note")
+		if err != nil {
+			return err
+		}
+		invoice, err = tx.CreateCreditNote(ctx, gen.CreateCreditNoteParams{
+			Number:    fmt.Sprintf("CN-%06d", n),
+			PaymentID: refund.PaymentID,
+			RefundID:  refund.ID,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errInvoiceIssued
+		}
+		return err
+	})
+	if errors.Is(err, errInvoiceIssued) {
+		return store.GetCreditNoteByRefundID(ctx, refund.ID)
+	}
+	return invoice, err
+}
+
+// BuildReceipt gathers what goes on a paid payment's receipt, issuing its
+// number if needed. supportEmail is the contact printed on it.
+func BuildReceipt(ctx context.Context, q db.Store, payment gen.Payment, supportEmail string) (documents.Receipt, error) {
+	invoice, err := IssueReceipt(ctx, q, payment)
+	if err != nil {
+		return documents.Receipt{}, fmt.Errorf("failed to issue receipt number: %w", err)
+	}
+	plan, err := q.GetSubscriptionPlanByID(ctx, payment.PlanID)
+	if err != nil {
+		return documents.Receipt{}, fmt.Errorf("failed to load plan: %w", err)
+	}
+
+	// While the payment is being marked paid the row still says pending;
+	// the receipt is issued at that moment
+	paidAt := invoice.IssuedAt.Time
+	if payment.Status == "paid" && payment.UpdatedAt.Valid {
+		paidAt = payment.UpdatedAt.Time
+	}
+
+	return documents.Receipt{
+		Number:        invoice.Number,
+		IssuedAt:      invoice.IssuedAt.Time,
+		CustomerName:  customerName(ctx, q, payment),
+		CustomerEmail: payment.Email,
+		Plan:          plan.Name,
+		DurationDays:  plan.DurationDays,
+		Amount:        payment.Amount,
+		Currency:      payment.Currency,
+		Gateway:       payment.PaymentGateway.String,
+		TransactionID: uuid.UUID(payment.TransactionID.Bytes).String(),
+		PaidAt:        paidAt,
+		SupportEmail:  supportEmail,
+	}, nil
+}
+
+// BuildCreditNote gathers what goes on a processed refund's credit note,
+// issuing its number (and the original receipt's) if needed
+func BuildCreditNote(ctx context.Context, q db.Store, refund gen.Refund, payment gen.Payment, supportEmail string) (documents.CreditNote, error) {
+	invoice, err := IssueCreditNote(ctx, q, refund)
+	if err != nil {
+		return documents.CreditNote{}, fmt.Errorf("failed to issue credit note number: %w", err)
+	}
+	receipt, err := IssueReceipt(ctx, q, payment)
+	if err != nil {
+		return documents.CreditNote{}, fmt.Errorf("failed to issue receipt number: %w", err)
+	}
+	plan, err := q.GetSubscriptionPlanByID(ctx, payment.PlanID)
+	if err != nil {
+		return documents.CreditNote{}, fmt.Errorf("failed to load plan: %w", err)
+	}
+
+	return documents.CreditNote{
+		Number:        invoice.Number,
+		ReceiptNumber: receipt.Number,
+		IssuedAt:      invoice.IssuedAt.Time,
+		CustomerName:  customerName(ctx, q, payment),
+		CustomerEmail: payment.Email,
+		Plan:          plan.Name,
+		Amount:        refund.Amount,
+		Currency:      payment.Currency,
+		Reason:        refund.Reason.String,
+		Gateway:       payment.PaymentGateway.String,
+		TransactionID: uuid.UUID(payment.TransactionID.Bytes).String(),
+		RequestedAt:   refund.RequestedAt.Time,
+		ProcessedAt:   refund.ProcessedAt.Time,
+		SupportEmail:  supportEmail,
+	}, nil
+}
+
+// ReceiptFilename is the download and attachment name of a receipt
+func ReceiptFilename(number string) string {
+	return fmt.Sprintf("receipt-%s.pdf", number)
+}
+
+// CreditNoteFilename is the download name of a credit note
+func CreditNoteFilename(number string) string {
+	return fmt.Sprintf("credit-note-%s.pdf", number)
+}
+
+func customerName(ctx context.Context, q gen.Querier, payment gen.Payment) string {
+	user, err := q.GetUserByID(ctx, payment.UserID)
+	if err != nil {
+		return ""
+	}
+	return user.FirstName + " " + user.LastName
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/db"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/logging"
+	"github.com/THEGunDevil/GoForBackend/internal/mailer"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// DueSoonWindow is how far ahead of the due date the reminder goes out
+	DueSoonWindow        = 48 * time.Hour
+	dueSoonCheckInterval = time.Hour
+	// announcementCheckInterval is how often scheduled announcements are published
+	announcementCheckInterval = time.Minute
+	// DigestInterval is how often pending digest items are emailed
+	DigestInterval = 24 * time.Hour
+	// digestCheckInterval is how often the job checks whether a digest is
+	// due; the last run is kept in job_runs so restarts do not reset it
+	digestCheckInterval = 5 * time.Minute
+	digestJobName       = "notification_digest"
+)
+
+// NotificationJobs sends the scheduled notifications: announcements,
+// reminders and digests
+type NotificationJobs struct {
+	store         db.Store
+	notifications *NotificationDispatcher
+	announcements *Announcements
+}
+
+// NewNotificationJobs returns the jobs, sending through notifications
+func NewNotificationJobs(store db.Store, notifications *NotificationDispatcher, announcements *Announcements) *NotificationJobs {
+	return &NotificationJobs{store: store, notifications: notifications, announcements: announcements}
+}
+
+// Run publishes scheduled announcements every minute, sends due-soon and
+// overdue reminders hourly and digest emails once per DigestInterval until
+// ctx is cancelled.
+func (j *NotificationJobs) Run(ctx context.Context) {
+	dueSoon := time.NewTicker(dueSoonCheckInterval)
+	defer dueSoon.Stop()
+	digest := time.NewTicker(digestCheckInterval)
+	defer digest.Stop()
+	announcements := time.NewTicker(announcementCheckInterval)
+	defer announcements.Stop()
+
+	runDueSoon := func() {
+		if err := j.SendDueSoonReminders(ctx); err != nil {
+			logging.FromContext(ctx).Error("due-soon reminders failed", "error", err)
+		}
+		if err := j.SendOverdueNotices(ctx); err != nil {
+			logging.FromContext(ctx).Error("overdue notices failed", "error", err)
+		}
+	}
+	runDueSoon()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-announcements.C:
+			if err := j.announcements.PublishDue(ctx); err != nil {
+				logging.FromContext(ctx).Error("failed to publish announcements", "error", err)
+			}
+		case <-dueSoon.C:
+			runDueSoon()
+		case <-digest.C:
+			if err := j.SendNotificationDigests(ctx); err != nil {
+				logging.FromContext(ctx).Error("notification digests failed", "error", err)
+			}
+		}
+	}
+}
+
+// SendDueSoonReminders notifies borrowers whose books are due within
+// DueSoonWindow. Each borrow is reminded once: the reminder is recorded
+// before it is sent, and a borrow another instance already recorded is
+// skipped.
+func (j *NotificationJobs) SendDueSoonReminders(ctx context.Context) error {
+	cutoff := pgtype.Timestamp{Time: time.Now().Add(DueSoonWindow), Valid: true}
+	borrows, err := j.store.ListBorrowsDueSoon(ctx, cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to list borrows due soon: %w", err)
+	}
+
+	for _, b := range borrows {
+		if _, err := j.store.ClaimBorrowDueReminder(ctx, b.ID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			return fmt.Errorf("failed to record reminder for borrow %v: %w", b.ID, err)
+		}
+		bookID := uuid.UUID(b.BookID.Bytes)
+		metadata, _ := json.Marshal(map[string]any{
+			"borrow_id": b.ID.String(),
+			"due_date":  b.DueDate.Time,
+		})
+		err := j.notifications.Dispatch(ctx, models.SendNotificationRequest{
+			UserID:            b.UserID.Bytes,
+			ObjectID:          &bookID,
+			ObjectTitle:       b.Title,
+			Type:              NotificationDueSoon,
+			NotificationTitle: "Your borrowed book is due soon",
+			Message:           fmt.Sprintf("'%s' is due back on %s.", b.Title, b.DueDate.Time.Format("Jan 2, 2006")),
+			Metadata:          metadata,
+		})
+		if err != nil {
+			logging.FromContext(ctx).Warn("due-soon reminder failed", "borrow_id", uuid.UUID(b.ID.Bytes), "error", err)
+		}
+	}
+	return nil
+}
+
+// SendOverdueNotices tells borrowers once that a book is past its due date.
+// Like reminders, the notice is recorded before it is sent.
+func (j *NotificationJobs) SendOverdueNotices(ctx context.Context) error {
+	borrows, err := j.store.ListOverdueBorrowsToNotify(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list overdue borrows: %w", err)
+	}
+
+	for _, b := range borrows {
+		if _, err := j.store.ClaimBorrowOverdueNotice(ctx, b.ID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			return fmt.Errorf("failed to record overdue notice for borrow %v: %w", b.ID, err)
+		}
+		bookID := uuid.UUID(b.BookID.Bytes)
+		metadata, _ := json.Marshal(map[string]any{
+			"borrow_id": b.ID.String(),
+			"due_date":  b.DueDate.Time,
+		})
+		err := j.notifications.Dispatch(ctx, models.SendNotificationRequest{
+			UserID:            b.UserID.Bytes,
+			ObjectID:          &bookID,
+			ObjectTitle:       b.Title,
+			Type:              NotificationOverdue,
+			NotificationTitle: "Your borrowed book is overdue",
+			Message:           fmt.Sprintf("'%s' was due back on %s.", b.Title, b.DueDate.Time.Format("Jan 2, 2006")),
+			Metadata:          metadata,
+		})
+		if err != nil {
+			logging.FromContext(ctx).Warn("overdue notice failed", "borrow_id", uuid.UUID(b.ID.Bytes), "error", err)
+		}
+	}
+	return nil
+}
+
+// SendNotificationDigests emails each user one summary of their pending
+// digest items and marks them sent, at most once per DigestInterval across
+// all instances. The run is claimed, the emails queued and the items marked
+// in one transaction, so a failure leaves everything for the next check.
+// Items that fail to render stay pending.
+func (j *NotificationJobs) SendNotificationDigests(ctx context.Context) error {
+	return j.store.WithTx(ctx, func(tx db.Store) error {
+		_, err := tx.ClaimJobRun(ctx, gen.ClaimJobRunParams{
+			Name:  digestJobName,
+			Every: pgtype.Interval{Microseconds: DigestInterval.Microseconds(), Valid: true},
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil // not due yet
+		}
+		if err != nil {
+			return fmt.Errorf("failed to claim digest run: %w", err)
+		}
+
+		items, err := tx.ListPendingDigestItems(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list digest items: %w", err)
+		}
+
+		for start := 0; start < len(items); {
+			end := start
+			for end < len(items) && items[end].UserID == items[start].UserID {
+				end++
+			}
+			batch := items[start:end]
+			start = end
+
+			data := mailer.DigestData{Name: batch[0].FirstName}
+			ids := make([]pgtype.UUID, 0, len(batch))
+			for _, item := range batch {
+				data.Items = append(data.Items, mailer.DigestItem{
+					Title:   item.Title,
+					Date:    item.CreatedAt.Time.Format("Jan 2"),
+					Message: item.Message,
+				})
+				ids = append(ids, item.ID)
+			}
+
+			msg, err := mailer.Render(mailer.TemplateDigest, data)
+			if err != nil {
+				logging.FromContext(ctx).Warn("notification digest failed", "user_id", uuid.UUID(batch[0].UserID.Bytes), "error", err)
+				continue
+			}
+			msg.To = []string{batch[0].Email}
+			if err := (OutboxMailer{Queries: tx}).Send(ctx, msg); err != nil {
+				return fmt.Errorf("failed to queue digest email: %w", err)
+			}
+			if err := tx.MarkDigestItemsSent(ctx, ids); err != nil {
+				return fmt.Errorf("failed to mark digest items sent: %w", err)
+			}
+		}
+		return nil
+	})
+}
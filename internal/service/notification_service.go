@@ -2,21 +2,204 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 
-	"github.com/THEGunDevil/GoForBackend/internal/db"
 	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/logging"
+	"github.com/THEGunDevil/GoForBackend/internal/mailer"
 	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/THEGunDevil/GoForBackend/internal/telemetry"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-// NotificationService handles creating event-based notifications
-func NotificationService(ctx context.Context, req models.SendNotificationRequest) error {
-	log.Printf("🔔 [DEBUG] NotificationService called for Type=%s | Title=%s",
-		req.Type, req.NotificationTitle)
+// Notification types users can set preferences for
+const (
+	NotificationBookAvailable       = "BOOK_AVAILABLE"
+	NotificationNewArrival          = "NEW_ARRIVAL"
+	NotificationDueSoon             = "DUE_SOON"
+	NotificationOverdue             = "OVERDUE"
+	NotificationSubscriptionCreated = "subscription_created"
+	NotificationRefundProcessed     = "refund_processed"
+	NotificationAnnouncement        = "ANNOUNCEMENT"
+	NotificationSupportReply        = "SUPPORT_REPLY"
+)
+
+// NotificationTypes lists the configurable types in display order
+var NotificationTypes = []string{
+	NotificationBookAvailable,
+	NotificationDueSoon,
+	NotificationOverdue,
+	NotificationNewArrival,
+	NotificationSubscriptionCreated,
+	NotificationRefundProcessed,
+	NotificationAnnouncement,
+	NotificationSupportReply,
+}
+
+// DefaultChannels returns the channels used until a user sets a preference.
+// Unknown types are in-app only.
+func DefaultChannels(notificationType string) models.NotificationChannels {
+	switch notificationType {
+	case NotificationBookAvailable, NotificationDueSoon, NotificationOverdue, NotificationSubscriptionCreated, NotificationRefundProcessed, NotificationSupportReply:
+		return models.NotificationChannels{InApp: true, Email: true}
+	default:
+		return models.NotificationChannels{InApp: true}
+	}
+}
+
+// IsNotificationType reports whether t is one of NotificationTypes
+func IsNotificationType(t string) bool {
+	for _, known := range NotificationTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationStore is the persistence the dispatcher needs
+type NotificationStore interface {
+	Channels(ctx context.Context, userID uuid.UUID, notificationType string) (models.NotificationChannels, error)
+	Recipient(ctx context.Context, userID uuid.UUID) (email, name string, err error)
+	CreateInApp(ctx context.Context, req models.SendNotificationRequest) error
+	QueueDigest(ctx context.Context, req models.SendNotificationRequest) error
+}
+
+// NotificationDispatcher routes each notification to the channels the
+// recipient enabled for its type.
+type NotificationDispatcher struct {
+	Store  NotificationStore
+	Mailer mailer.Mailer
+}
+
+// NewNotificationDispatcher returns a dispatcher that writes through q and
+// sends its email through the outbox. Pass a transaction's store to make the
+// notification part of it.
+func NewNotificationDispatcher(q gen.Querier) *NotificationDispatcher {
+	return &NotificationDispatcher{
+		Store:  dbNotificationStore{q: q},
+		Mailer: OutboxMailer{Queries: q},
+	}
+}
+
+// Dispatch delivers req on every enabled channel. Broadcasts (no UserID) are
+// in-app only. A failing channel does not stop the others; all errors are
+// returned joined.
+func (d *NotificationDispatcher) Dispatch(ctx context.Context, req models.SendNotificationRequest) error {
+	if req.UserID == uuid.Nil {
+		err := d.Store.CreateInApp(ctx, req)
+		telemetry.Notifications.WithLabelValues("broadcast", telemetry.Result(err)).Inc()
+		return err
+	}
+
+	channels, err := d.Store.Channels(ctx, req.UserID, req.Type)
+	if err != nil {
+		return fmt.Errorf("failed to load notification preferences: %w", err)
+	}
 
+	var errs []error
+	if channels.InApp {
+		err := d.Store.CreateInApp(ctx, req)
+		telemetry.Notifications.WithLabelValues("in_app", telemetry.Result(err)).Inc()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if channels.Email {
+		err := d.sendEmail(ctx, req)
+		telemetry.Notifications.WithLabelValues("email", telemetry.Result(err)).Inc()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to email notification: %w", err))
+		}
+	}
+	if channels.Digest {
+		err := d.Store.QueueDigest(ctx, req)
+		telemetry.Notifications.WithLabelValues("digest", telemetry.Result(err)).Inc()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to queue digest item: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (d *NotificationDispatcher) sendEmail(ctx context.Context, req models.SendNotificationRequest) error {
+	email, name, err := d.Store.Recipient(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+	msg, err := mailer.Render(emailTemplateFor(req.Type), mailer.NotificationData{
+		Name:    name,
+		Title:   req.NotificationTitle,
+		Message: req.Message,
+	})
+	if err != nil {
+		return err
+	}
+	msg.To = []string{email}
+	return d.Mailer.Send(ctx, msg)
+}
+
+// emailTemplateFor picks the email template for a notification type
+func emailTemplateFor(notificationType string) string {
+	switch notificationType {
+	case NotificationBookAvailable:
+		return mailer.TemplateHoldReady
+	case NotificationDueSoon:
+		return mailer.TemplateDueSoon
+	case NotificationOverdue:
+		return mailer.TemplateOverdue
+	case NotificationRefundProcessed:
+		return mailer.TemplateRefund
+	default:
+		return mailer.TemplateNotification
+	}
+}
+
+// dbNotificationStore is the Postgres-backed NotificationStore
+type dbNotificationStore struct {
+	q gen.Querier
+}
+
+func (s dbNotificationStore) Channels(ctx context.Context, userID uuid.UUID, notificationType string) (models.NotificationChannels, error) {
+	pref, err := s.q.GetNotificationPreference(ctx, gen.GetNotificationPreferenceParams{
+		UserID: UUIDToPGType(userID),
+		Type:   notificationType,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return DefaultChannels(notificationType), nil
+	}
+	if err != nil {
+		return models.NotificationChannels{}, err
+	}
+	return models.NotificationChannels{InApp: pref.InApp, Email: pref.Email, Digest: pref.Digest}, nil
+}
+
+func (s dbNotificationStore) Recipient(ctx context.Context, userID uuid.UUID) (string, string, error) {
+	user, err := s.q.GetUserByID(ctx, UUIDToPGType(userID))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load recipient: %w", err)
+	}
+	if user.DeletedAt.Valid {
+		return "", "", fmt.Errorf("recipient %v has been deleted", userID)
+	}
+	return user.Email, user.FirstName, nil
+}
+
+func (s dbNotificationStore) QueueDigest(ctx context.Context, req models.SendNotificationRequest) error {
+	return s.q.CreateDigestItem(ctx, gen.CreateDigestItemParams{
+		UserID:  UUIDToPGType(req.UserID),
+		Type:    req.Type,
+		Title:   req.NotificationTitle,
+		Message: req.Message,
+	})
+}
+
+// CreateInApp writes the event (and the unread assignment for targeted
+// notifications) that the inbox and the SSE stream read.
+func (s dbNotificationStore) CreateInApp(ctx context.Context, req models.SendNotificationRequest) error {
 	// Validate optional ObjectID
 	var pgObjectID pgtype.UUID
 	if req.ObjectID != nil {
@@ -25,7 +208,11 @@ func NotificationService(ctx context.Context, req models.SendNotificationRequest
 		pgObjectID = pgtype.UUID{Valid: false} // NULL in DB
 	}
 
-	// Prepare params for CreateEvent
+	// Prepare params for CreateEvent (a NULL user_id marks a broadcast)
+	var pgUserID pgtype.UUID
+	if req.UserID != uuid.Nil {
+		pgUserID = UUIDToPGType(req.UserID)
+	}
 	eventArg := gen.CreateEventParams{
 		ObjectID:    pgObjectID,
 		ObjectTitle: StringToPGText(req.ObjectTitle),
@@ -33,15 +220,18 @@ func NotificationService(ctx context.Context, req models.SendNotificationRequest
 		Title:       req.NotificationTitle,
 		Message:     req.Message,
 		Metadata:    req.Metadata,
+		UserID:      pgUserID,
+	}
+	if req.ExpiresAt != nil {
+		eventArg.ExpiresAt = pgtype.Timestamp{Time: req.ExpiresAt.UTC(), Valid: true}
 	}
 
 	// Insert event into events table
-	event, err := db.Q.CreateEvent(ctx, eventArg)
+	event, err := s.q.CreateEvent(ctx, eventArg)
 	if err != nil {
-		log.Printf("❌ [DEBUG] Failed to create event: %v", err)
 		return fmt.Errorf("failed to create event: %w", err)
 	}
-	log.Printf("✅ [DEBUG] Event created successfully: ID=%v", event.ID)
+	logging.FromContext(ctx).Debug("notification event created", "event_id", uuid.UUID(event.ID.Bytes), "type", req.Type)
 
 	// LOGIC CHANGE:
 	// Only assign to user_notification_status if we are targeting a SPECIFIC user.
@@ -53,15 +243,11 @@ func NotificationService(ctx context.Context, req models.SendNotificationRequest
 		}
 
 		// Use the new Assign function (sets is_read = FALSE)
-		err := db.Q.AssignNotificationToUser(ctx, assignArg)
+		err := s.q.AssignNotificationToUser(ctx, assignArg)
 		if err != nil {
-			log.Printf("❌ Failed to assign notification to user: %v", err)
 			return fmt.Errorf("failed to assign notification: %w", err)
 		}
-		log.Printf("✅ User notification status created (Unread) for UserID=%v", req.UserID)
-	} else {
-		log.Printf("📢 [DEBUG] Broadcast notification created (No specific assignment needed)")
 	}
 
 	return nil
-}
\ No newline at end of file
+}
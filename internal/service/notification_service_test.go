@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/THEGunDevil/GoForBackend/internal/mailer"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/google/uuid"
+)
+
+// fakeNotificationStore keeps preferences in a map and records what the
+// dispatcher wrote.
+type fakeNotificationStore struct {
+	prefs  map[string]models.NotificationChannels
+	inApp  []models.SendNotificationRequest
+	digest []models.SendNotificationRequest
+}
+
+func (s *fakeNotificationStore) Channels(_ context.Context, _ uuid.UUID, t string) (models.NotificationChannels, error) {
+	if ch, ok := s.prefs[t]; ok {
+		return ch, nil
+	}
+	return DefaultChannels(t), nil
+}
+
+func (s *fakeNotificationStore) Recipient(_ context.Context, _ uuid.UUID) (string, string, error) {
+	return "reader@example.com", "Ada", nil
+}
+
+func (s *fakeNotificationStore) CreateInApp(_ context.Context, req models.SendNotificationRequest) error {
+	s.inApp = append(s.inApp, req)
+	return nil
+}
+
+func (s *fakeNotificationStore) QueueDigest(_ context.Context, req models.SendNotificationRequest) error {
+	s.digest = append(s.digest, req)
+	return nil
+}
+
+func TestDispatchRoutesToEnabledChannels(t *testing.T) {
+	user := uuid.New()
+	tests := []struct {
+		name      string
+		prefs     map[string]models.NotificationChannels
+		req       models.SendNotificationRequest
+		wantInApp int
+		wantMail  int
+		wantQueue int
+	}{
+		{
+			name:      "defaults for BOOK_AVAILABLE are in-app and email",
+			req:       models.SendNotificationRequest{UserID: user, Type: NotificationBookAvailable, NotificationTitle: "Ready", Message: "Your book is ready"},
+			wantInApp: 1,
+			wantMail:  1,
+		},
+		{
+			name:      "defaults for NEW_ARRIVAL are in-app only",
+			req:       models.SendNotificationRequest{UserID: user, Type: NotificationNewArrival, NotificationTitle: "New", Message: "New book"},
+			wantInApp: 1,
+		},
+		{
+			name:      "email turned off, digest turned on",
+			prefs:     map[string]models.NotificationChannels{NotificationDueSoon: {InApp: true, Digest: true}},
+			req:       models.SendNotificationRequest{UserID: user, Type: NotificationDueSoon, NotificationTitle: "Due", Message: "Due soon"},
+			wantInApp: 1,
+			wantQueue: 1,
+		},
+		{
+			name:     "email only",
+			prefs:    map[string]models.NotificationChannels{NotificationRefundProcessed: {Email: true}},
+			req:      models.SendNotificationRequest{UserID: user, Type: NotificationRefundProcessed, NotificationTitle: "Refund", Message: "Refunded"},
+			wantMail: 1,
+		},
+		{
+			name:      "broadcasts ignore preferences",
+			prefs:     map[string]models.NotificationChannels{NotificationNewArrival: {Email: true}},
+			req:       models.SendNotificationRequest{Type: NotificationNewArrival, NotificationTitle: "New", Message: "New book"},
+			wantInApp: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeNotificationStore{prefs: tt.prefs}
+			mail := mailer.NewMemory()
+			d := &NotificationDispatcher{Store: store, Mailer: mail}
+
+			if err := d.Dispatch(context.Background(), tt.req); err != nil {
+				t.Fatalf("Dispatch: %v", err)
+			}
+			if len(store.inApp) != tt.wantInApp {
+				t.Errorf("in-app = %d, want %d", len(store.inApp), tt.wantInApp)
+			}
+			if got := len(mail.Sent()); got != tt.wantMail {
+				t.Errorf("emails = %d, want %d", got, tt.wantMail)
+			}
+			if len(store.digest) != tt.wantQueue {
+				t.Errorf("digest items = %d, want %d", len(store.digest), tt.wantQueue)
+			}
+		})
+	}
+}
+
+func TestDispatchEmailContent(t *testing.T) {
+	store := &fakeNotificationStore{}
+	mail := mailer.NewMemory()
+	d := &NotificationDispatcher{Store: store, Mailer: mail}
+
+	err := d.Dispatch(context.Background(), models.SendNotificationRequest{
+		UserID:            uuid.New(),
+		Type:              NotificationSubscriptionCreated,
+		NotificationTitle: "Subscription active",
+		Message:           "Your Gold plan is active.",
+	})
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+
+	sent := mail.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("got %d emails, want 1", len(sent))
+	}
+	msg := sent[0]
+	if len(msg.To) != 1 || msg.To[0] != "reader@example.com" {
+		t.Errorf("To = %v", msg.To)
+	}
+	if msg.Subject != "Subscription active" {
+		t.Errorf("Subject = %q", msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "Hi Ada") || !strings.Contains(msg.Text, "Your Gold plan is active.") {
+		t.Errorf("Text = %q", msg.Text)
+	}
+}
+
+func TestDispatchKeepsInAppWhenEmailFails(t *testing.T) {
+	store := &fakeNotificationStore{}
+	mail := mailer.NewMemory()
+	mail.Err = errors.New("provider down")
+	d := &NotificationDispatcher{Store: store, Mailer: mail}
+
+	err := d.Dispatch(context.Background(), models.SendNotificationRequest{
+		UserID:            uuid.New(),
+		Type:              NotificationBookAvailable,
+		NotificationTitle: "Ready",
+		Message:           "Your book is ready",
+	})
+	if err == nil || !strings.Contains(err.Error(), "provider down") {
+		t.Fatalf("err = %v, want the mailer error", err)
+	}
+	if len(store.inApp) != 1 {
+		t.Errorf("in-app = %d, want 1", len(store.inApp))
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/THEGunDevil/GoForBackend/internal/db"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/logging"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// notificationChannel is the Postgres channel the events_notify_created
+// trigger publishes on.
+const notificationChannel = "notifications"
+
+// subscriberBuffer is how many notifications a slow stream may fall behind
+// before new ones are dropped for it. The client catches up on reconnect via
+// Last-Event-ID.
+const subscriberBuffer = 16
+
+// eventSignal is the NOTIFY payload; a nil UserID is a broadcast.
+type eventSignal struct {
+	ID     uuid.UUID  `json:"id"`
+	UserID *uuid.UUID `json:"user_id"`
+}
+
+type notificationSubscriber struct {
+	userID uuid.UUID
+	ch     chan models.Notification
+	once   sync.Once
+}
+
+// close ends the subscriber's stream; callers hold subscribersMu
+func (s *notificationSubscriber) close() {
+	s.once.Do(func() { close(s.ch) })
+}
+
+var (
+	subscribersMu sync.RWMutex
+	subscribers   = map[*notificationSubscriber]struct{}{}
+)
+
+// SubscribeNotifications registers a stream for userID. Call the returned
+// function to unsubscribe; the channel is closed afterwards.
+func SubscribeNotifications(userID uuid.UUID) (<-chan models.Notification, func()) {
+	sub := &notificationSubscriber{userID: userID, ch: make(chan models.Notification, subscriberBuffer)}
+
+	subscribersMu.Lock()
+	subscribers[sub] = struct{}{}
+	subscribersMu.Unlock()
+
+	return sub.ch, func() {
+		subscribersMu.Lock()
+		delete(subscribers, sub)
+		sub.close()
+		subscribersMu.Unlock()
+	}
+}
+
+// CloseNotificationStreams ends every open stream so shutdown does not wait
+// on clients that would otherwise stay connected forever. They reconnect to
+// another instance and catch up through Last-Event-ID.
+func CloseNotificationStreams() {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	for sub := range subscribers {
+		delete(subscribers, sub)
+		sub.close()
+	}
+}
+
+// notificationsListening is whether the LISTEN connection is up
+var notificationsListening atomic.Bool
+
+// NotificationListenerUp reports whether new notifications reach this
+// instance's streams
+func NotificationListenerUp() bool {
+	return notificationsListening.Load()
+}
+
+// NotificationListener fans new events out to local subscribers. Every API
+// instance runs one, so a notification created on any instance reaches
+// streams on all of them.
+type NotificationListener struct {
+	pool  *pgxpool.Pool
+	store db.Store
+}
+
+// NewNotificationListener returns a listener that takes its LISTEN
+// connection from pool and loads events through store
+func NewNotificationListener(pool *pgxpool.Pool, store db.Store) *NotificationListener {
+	return &NotificationListener{pool: pool, store: store}
+}
+
+// Run holds a dedicated connection in LISTEN mode, reconnecting with backoff
+// until ctx is cancelled
+func (l *NotificationListener) Run(ctx context.Context) {
+	backoff := time.Second
+	for {
+		err := l.listen(ctx, func() { backoff = time.Second })
+		if ctx.Err() != nil {
+			return
+		}
+		logging.FromContext(ctx).Warn("notification listener stopped", "error", err, "retry_in", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+func (l *NotificationListener) listen(ctx context.Context, connected func()) error {
+	poolConn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	// Take the connection out of the pool so it never goes back in LISTEN mode.
+	conn := poolConn.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+notificationChannel); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	connected()
+	notificationsListening.Store(true)
+	defer notificationsListening.Store(false)
+	logging.FromContext(ctx).Info("listening for notifications", "channel", notificationChannel)
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var sig eventSignal
+		if err := json.Unmarshal([]byte(n.Payload), &sig); err != nil {
+			logging.FromContext(ctx).Error("bad notification payload", "payload", n.Payload, "error", err)
+			continue
+		}
+		l.dispatch(ctx, sig)
+	}
+}
+
+// dispatch loads the event once and hands it to every matching subscriber:
+// the recipient for targeted events, everyone for broadcasts.
+func (l *NotificationListener) dispatch(ctx context.Context, sig eventSignal) {
+	subscribersMu.RLock()
+	idle := len(subscribers) == 0
+	subscribersMu.RUnlock()
+	if idle {
+		return
+	}
+
+	event, err := l.store.GetEventByID(ctx, UUIDToPGType(sig.ID))
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to load event for streaming", "event_id", sig.ID, "error", err)
+		return
+	}
+
+	subscribersMu.RLock()
+	defer subscribersMu.RUnlock()
+	for sub := range subscribers {
+		if sig.UserID != nil && *sig.UserID != sub.userID {
+			continue
+		}
+		n := EventToNotification(event, sub.userID)
+		select {
+		case sub.ch <- n:
+		default:
+			logging.FromContext(ctx).Warn("notification stream full, dropping event", "user_id", sub.userID, "event_id", sig.ID)
+		}
+	}
+}
+
+// EventToNotification builds the unread notification a user sees for a new event
+func EventToNotification(e gen.Event, userID uuid.UUID) models.Notification {
+	return models.Notification{
+		ID:                e.ID.Bytes,
+		UserID:            userID,
+		ObjectID:          pgUUIDPtr(e.ObjectID),
+		ObjectTitle:       e.ObjectTitle.String,
+		Type:              e.Type,
+		NotificationTitle: e.Title,
+		Message:           e.Message,
+		Metadata:          e.Metadata,
+		CreatedAt:         e.CreatedAt.Time,
+	}
+}
+
+func pgUUIDPtr(id pgtype.UUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	u := uuid.UUID(id.Bytes)
+	return &u
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/THEGunDevil/GoForBackend/internal/db"
+	gen "github.com/THEGunDevil/GoForBackend/internal/db/gen"
+	"github.com/THEGunDevil/GoForBackend/internal/mailer"
+	"github.com/THEGunDevil/GoForBackend/internal/models"
+	"github.com/google/uuid"
+)
+
+// supportPreviewLength is how much of a reply goes into the notification
+const supportPreviewLength = 200
+
+// Support notifies the people on a support ticket
+type Support struct {
+	store         db.Store
+	notifications *NotificationDispatcher
+	email         string
+}
+
+// NewSupport returns a Support delivering through notifications. email is
+// the support inbox; with none, unassigned tickets send no email.
+func NewSupport(store db.Store, notifications *NotificationDispatcher, email string) *Support {
+	return &Support{store: store, notifications: notifications, email: email}
+}
+
+// Email is the support inbox contact messages go to, and the address
+// printed on receipts and credit notes
+func (s *Support) Email() string {
+	return s.email
+}
+
+// NotifyReply tells the other side of a ticket about a new message. Staff
+// replies go to the member through their notification preferences; member
+// replies go to the assigned admin, or to the support inbox while the ticket is
+// unassigned.
+func (s *Support) NotifyReply(ctx context.Context, ticket gen.SupportTicket, msg gen.SupportTicketMessage) error {
+	ticketID := uuid.UUID(ticket.ID.Bytes)
+	preview := msg.Body
+	if r := []rune(preview); len(r) > supportPreviewLength {
+		preview = strings.TrimSpace(string(r[:supportPreviewLength])) + "…"
+	}
+
+	if msg.IsStaff {
+		return s.notifications.Dispatch(ctx, models.SendNotificationRequest{
+			UserID:            ticket.UserID.Bytes,
+			ObjectID:          &ticketID,
+			ObjectTitle:       ticket.Subject,
+			Type:              NotificationSupportReply,
+			NotificationTitle: fmt.Sprintf("New reply on your support ticket: %s", ticket.Subject),
+			Message:           preview,
+		})
+	}
+
+	if ticket.AssignedTo.Valid {
+		return s.notifications.Dispatch(ctx, models.SendNotificationRequest{
+			UserID:            ticket.AssignedTo.Bytes,
+			ObjectID:          &ticketID,
+			ObjectTitle:       ticket.Subject,
+			Type:              NotificationSupportReply,
+			NotificationTitle: fmt.Sprintf("%s replied on ticket: %s", ticket.Name, ticket.Subject),
+			Message:           preview,
+		})
+	}
+	if s.email == "" {
+		return nil
+	}
+	return QueueEmail(ctx, s.store, mailer.TemplateContact, []string{s.email}, ticket.Email, mailer.ContactData{
+		Name:    ticket.Name,
+		Email:   ticket.Email,
+		Subject: fmt.Sprintf("Re: %s (ticket %s)", ticket.Subject, ticketID),
+		Message: msg.Body,
+	})
+}
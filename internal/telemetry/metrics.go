@@ -0,0 +1,89 @@
+// Package telemetry holds the service's Prometheus metrics and OpenTelemetry
+// tracing.
+package telemetry
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "library"
+
+// Registry holds every metric served on /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequestDuration is request latency by method, route template
+	// (e.g. /books/:id) and status code
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route template and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// Borrows counts books checked out
+	Borrows = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "borrows_total",
+		Help:      "Books borrowed.",
+	})
+
+	// Returns counts books brought back
+	Returns = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "returns_total",
+		Help:      "Books returned.",
+	})
+
+	// Payments counts payments created or moved to a status
+	Payments = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payments_total",
+		Help:      "Payments created or moved to a status, by that status.",
+	}, []string{"status"})
+
+	// WebhookFailures counts webhook deliveries answered with an error
+	WebhookFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_failures_total",
+		Help:      "Webhook deliveries rejected or failed, by provider and response status.",
+	}, []string{"provider", "status"})
+
+	// Notifications counts notification deliveries per channel
+	Notifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Notification deliveries by channel (in_app, email, digest, broadcast) and result.",
+	}, []string{"channel", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		Borrows,
+		Returns,
+		Payments,
+		WebhookFailures,
+		Notifications,
+	)
+}
+
+// Result is the result label for an outcome: "ok" or "error"
+func Result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+// Handler serves Registry in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,86 @@
+package telemetry
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector reads pgxpool statistics at scrape time
+type poolCollector struct {
+	stat func() *pgxpool.Stat
+
+	acquiredConns        *prometheus.Desc
+	idleConns            *prometheus.Desc
+	constructingConns    *prometheus.Desc
+	totalConns           *prometheus.Desc
+	maxConns             *prometheus.Desc
+	acquireCount         *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+	newConnsCount        *prometheus.Desc
+	maxLifetimeDestroys  *prometheus.Desc
+	maxIdleDestroys      *prometheus.Desc
+}
+
+// RegisterPool exposes the stats of a connection pool, usually the API's
+// pool.Stat, on Registry
+func RegisterPool(stat func() *pgxpool.Stat) error {
+	return Registry.Register(newPoolCollector(stat))
+}
+
+func newPoolCollector(stat func() *pgxpool.Stat) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return &poolCollector{
+		stat:                 stat,
+		acquiredConns:        desc("acquired_conns", "Connections currently in use."),
+		idleConns:            desc("idle_conns", "Idle connections."),
+		constructingConns:    desc("constructing_conns", "Connections being opened."),
+		totalConns:           desc("total_conns", "Open connections."),
+		maxConns:             desc("max_conns", "Maximum pool size."),
+		acquireCount:         desc("acquires_total", "Successful connection acquires."),
+		acquireDuration:      desc("acquire_duration_seconds_total", "Time spent acquiring connections."),
+		emptyAcquireCount:    desc("empty_acquires_total", "Acquires that had to wait for a connection."),
+		canceledAcquireCount: desc("canceled_acquires_total", "Acquires cancelled by their context."),
+		newConnsCount:        desc("new_conns_total", "Connections opened."),
+		maxLifetimeDestroys:  desc("max_lifetime_destroys_total", "Connections closed for reaching their maximum lifetime."),
+		maxIdleDestroys:      desc("max_idle_destroys_total", "Connections closed for idling too long."),
+	}
+}
+
+func (p *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		p.acquiredConns, p.idleConns, p.constructingConns, p.totalConns, p.maxConns,
+		p.acquireCount, p.acquireDuration, p.emptyAcquireCount, p.canceledAcquireCount,
+		p.newConnsCount, p.maxLifetimeDestroys, p.maxIdleDestroys,
+	} {
+		ch <- d
+	}
+}
+
+func (p *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := p.stat()
+	if s == nil {
+		return
+	}
+	gauge := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v)
+	}
+	counter := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v)
+	}
+	gauge(p.acquiredConns, float64(s.AcquiredConns()))
+	gauge(p.idleConns, float64(s.IdleConns()))
+	gauge(p.constructingConns, float64(s.ConstructingConns()))
+	gauge(p.totalConns, float64(s.TotalConns()))
+	gauge(p.maxConns, float64(s.MaxConns()))
+	counter(p.acquireCount, float64(s.AcquireCount()))
+	counter(p.acquireDuration, s.AcquireDuration().Seconds())
+	counter(p.emptyAcquireCount, float64(s.EmptyAcquireCount()))
+	counter(p.canceledAcquireCount, float64(s.CanceledAcquireCount()))
+	counter(p.newConnsCount, float64(s.NewConnsCount()))
+	counter(p.maxLifetimeDestroys, float64(s.MaxLifetimeDestroyCount()))
+	counter(p.maxIdleDestroys, float64(s.MaxIdleDestroyCount()))
+}
@@ -0,0 +1,69 @@
+package telemetry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQueryName(t *testing.T) {
+	tests := map[string]string{
+		"-- name: GetBookByID :one\nSELECT 1":         "GetBookByID",
+		"-- name: ListExportJobsByUser :many\nSELECT": "ListExportJobsByUser",
+		"SELECT 1":             "query",
+		"LISTEN notifications": "query",
+	}
+	for sql, want := range tests {
+		if got := QueryName(sql); got != want {
+			t.Errorf("QueryName(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
+
+func TestTransportRecordsClientSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: Transport("resend", nil)}
+	resp, err := client.Post(server.URL+"/emails", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "resend POST" {
+		t.Errorf("span name = %q", span.Name())
+	}
+	found := false
+	for _, attr := range span.Attributes() {
+		if attr == attribute.Int("http.response.status_code", http.StatusBadGateway) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("status code missing from %v", span.Attributes())
+	}
+	if traceparent != "" {
+		t.Errorf("trace context leaked to the third party: %q", traceparent)
+	}
+}